@@ -9,14 +9,20 @@ godebug default=go1.25
 require (
 	github.com/spf13/cobra v1.10.2
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/net v0.47.0
+	golang.org/x/sync v0.18.0
+	k8s.io/api v0.0.0-20251126203939-39e2e26f9bf7
 	k8s.io/apimachinery v0.0.0-20251126203613-2e9c2280ae35
 	k8s.io/apiserver v0.0.0-20251126210647-6e94bf6afede
 	k8s.io/client-go v0.0.0-20251126204431-46360b527ebc
 	k8s.io/code-generator v0.0.0-20251126205444-6c03715c63e0
 	k8s.io/component-base v0.0.0-20251126205700-dffb9dfaf9c7
+	k8s.io/klog/v2 v2.130.1
+	k8s.io/kms v0.0.0-20251126210012-3215d77feb60
 	k8s.io/kube-openapi v0.0.0-20251125145642-4e65d59e963e
 	k8s.io/utils v0.0.0-20251002143259-bc988d571ff4
 	sigs.k8s.io/structured-merge-diff/v6 v6.3.1
+	sigs.k8s.io/yaml v1.6.0
 )
 
 require (
@@ -96,9 +102,7 @@ require (
 	golang.org/x/crypto v0.45.0 // indirect
 	golang.org/x/exp v0.0.0-20251125195548-87e1e737ad39 // indirect
 	golang.org/x/mod v0.30.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
 	golang.org/x/oauth2 v0.33.0 // indirect
-	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/term v0.37.0 // indirect
 	golang.org/x/text v0.31.0 // indirect
@@ -112,12 +116,8 @@ require (
 	gopkg.in/inf.v0 v0.9.1 // indirect
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
-	k8s.io/api v0.0.0-20251126203939-39e2e26f9bf7 // indirect
 	k8s.io/gengo/v2 v2.0.0-20250922181213-ec3ebc5fd46b // indirect
-	k8s.io/klog/v2 v2.130.1 // indirect
-	k8s.io/kms v0.0.0-20251126210012-3215d77feb60 // indirect
 	sigs.k8s.io/apiserver-network-proxy/konnectivity-client v0.34.0 // indirect
 	sigs.k8s.io/json v0.0.0-20250730193827-2d320260d730 // indirect
 	sigs.k8s.io/randfill v1.0.0 // indirect
-	sigs.k8s.io/yaml v1.6.0 // indirect
 )
@@ -50,6 +50,17 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&File{},
 		&FileList{},
 		&FileContent{},
+		&FileArchive{},
+		&FileArchiveOptions{},
+		&FileArchiveManifest{},
+		&FileCopyOptions{},
+		&FileStat{},
+		&FileSignedURL{},
+		&Site{},
+		&SiteList{},
+		&SiteServeOptions{},
+		&Purge{},
+		&PurgeList{},
 	)
 	return nil
 }
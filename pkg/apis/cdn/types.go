@@ -46,6 +46,27 @@ type FileStatus struct {
 	Uploaded bool
 	// Error is an error message if the file upload failed.
 	Error string
+	// Checksum is the hex-encoded SHA-256 digest of the uploaded content.
+	Checksum string
+	// ContentGeneration is incremented whenever the content changes or is
+	// purged. It is part of the content's ETag.
+	ContentGeneration int64
+	// LastUpload records who last uploaded the content.
+	LastUpload *FileUpload
+}
+
+// FileUpload records the request that uploaded the content of a File.
+type FileUpload struct {
+	// User is the name of the authenticated user.
+	User string
+	// Groups are the groups of the authenticated user.
+	Groups []string
+	// SourceIP is the client address of the request.
+	SourceIP string
+	// UserAgent is the User-Agent of the request.
+	UserAgent string
+	// Time is when the content was uploaded.
+	Time metav1.Time
 }
 
 // +genclient
@@ -67,3 +88,292 @@ type FileContent struct {
 	metav1.TypeMeta
 	Status metav1.Status
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FileArchiveOptions is the query options for the archive subresource of a File
+type FileArchiveOptions struct {
+	metav1.TypeMeta
+
+	// Prune deletes Files of the archive that are not part of the upload.
+	Prune bool
+
+	// LabelSelector selects the Files of a downloaded archive.
+	LabelSelector string
+	// FieldSelector selects the Files of a downloaded archive.
+	FieldSelector string
+	// Format is the format of a downloaded archive, "tar.gz" or "zip".
+	Format string
+}
+
+const (
+	// FileArchiveFormatTarGz is a gzip-compressed tar archive.
+	FileArchiveFormatTarGz = "tar.gz"
+	// FileArchiveFormatZip is a zip archive.
+	FileArchiveFormatZip = "zip"
+)
+
+// FileArchiveEntryResult is the outcome of writing one archive entry.
+type FileArchiveEntryResult string
+
+const (
+	// FileArchiveEntryCreated means a new File was created for the entry.
+	FileArchiveEntryCreated FileArchiveEntryResult = "Created"
+	// FileArchiveEntryUpdated means an existing File was updated.
+	FileArchiveEntryUpdated FileArchiveEntryResult = "Updated"
+	// FileArchiveEntryUnchanged means the File already had the entry's content.
+	FileArchiveEntryUnchanged FileArchiveEntryResult = "Unchanged"
+	// FileArchiveEntryPruned means the File was deleted because the archive no longer contains it.
+	FileArchiveEntryPruned FileArchiveEntryResult = "Pruned"
+	// FileArchiveEntryFailed means the entry could not be written.
+	FileArchiveEntryFailed FileArchiveEntryResult = "Failed"
+)
+
+// FileArchiveEntry is the result for a single entry of an uploaded archive.
+type FileArchiveEntry struct {
+	// Path is the path of the entry in the archive.
+	Path string
+	// File is the name of the File the entry was written to.
+	File string
+	// Size is the size of the entry in bytes.
+	Size int64
+	// ContentType is the detected MIME type of the entry.
+	ContentType string
+	// Result is the outcome for the entry.
+	Result FileArchiveEntryResult
+	// Error is set if Result is Failed.
+	Error string
+	// Checksum is the hex-encoded SHA-256 digest of the entry.
+	Checksum string
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FileArchive is the response of the archive subresource of a File
+type FileArchive struct {
+	metav1.TypeMeta
+
+	Status  metav1.Status
+	Entries []FileArchiveEntry
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FileArchiveManifest describes the Files of a downloaded archive
+type FileArchiveManifest struct {
+	metav1.TypeMeta
+
+	// Entries maps the archive entries to their Files.
+	Entries []FileArchiveEntry
+	// Files are the selected Files, including those without content.
+	Files []File
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FileCopyOptions is the query options for the copy subresource of a File
+type FileCopyOptions struct {
+	metav1.TypeMeta
+
+	// Destination is the name of the File the content is copied to.
+	Destination string
+	// DestinationNamespace is the namespace of the destination File.
+	DestinationNamespace string
+	// Move deletes the copied File once the destination File is committed.
+	Move bool
+	// Check checks the copy without changing any File.
+	Check bool
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FileStat is the stat subresource of a File. It describes the content
+// stored for the File without the content itself.
+type FileStat struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	// Stored is false if no content is stored for the File.
+	Stored bool
+	// Size is the size of the content in bytes, excluding any encryption
+	// overhead.
+	Size int64
+	// ContentType is the content type stored with the content.
+	ContentType string
+	// Checksum is the hex-encoded SHA-256 digest of the stored content.
+	Checksum string
+	// Versions counts the contents stored for the File by this replica since
+	// it started.
+	Versions int64
+	// Modified is when the current content was stored.
+	Modified *metav1.Time
+	// LastAccess is when the content was last read, if it was.
+	LastAccess *metav1.Time
+	// Cached is true if the content is in the replica's content cache.
+	Cached bool
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FileSignedURL is the signedurl subresource of a File. Creating it signs a
+// URL that serves the content of the File without credentials until it
+// expires.
+type FileSignedURL struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	Spec   FileSignedURLSpec
+	Status FileSignedURLStatus
+}
+
+// FileSignedURLSpec is the requested validity of a signed URL.
+type FileSignedURLSpec struct {
+	// ExpirationSeconds is how long the URL is valid for.
+	ExpirationSeconds *int64
+}
+
+// FileSignedURLStatus is the signed URL.
+type FileSignedURLStatus struct {
+	// URL serves the content of the File.
+	URL string
+	// ExpirationTimestamp is when the URL stops working.
+	ExpirationTimestamp metav1.Time
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SiteList is a list of Site objects.
+type SiteList struct {
+	metav1.TypeMeta
+	metav1.ListMeta
+
+	Items []Site
+}
+
+// SiteRoute maps a URL path to a File.
+type SiteRoute struct {
+	// Path is the absolute URL path, e.g. "/docs/getting-started".
+	Path string
+	// File is the name of the File in the Site's namespace.
+	File string
+}
+
+// SiteHeader is an HTTP header added to responses.
+type SiteHeader struct {
+	// Name is the header name.
+	Name string
+	// Value is the header value.
+	Value string
+}
+
+// SiteHeaderRule adds headers to responses for paths under a prefix.
+type SiteHeaderRule struct {
+	// PathPrefix selects the paths the headers apply to.
+	PathPrefix string
+	// Headers are set on every response below PathPrefix.
+	Headers []SiteHeader
+}
+
+// SiteSpec is the specification of a Site.
+type SiteSpec struct {
+	// Selector selects the Files that make up the site. A selected File is
+	// served at the path in its cdn.k8s.toms.place/path annotation, or at
+	// "/<name>" if it has none.
+	Selector *metav1.LabelSelector
+	// Routes maps paths to Files explicitly. Routes take precedence over
+	// Files matched by Selector.
+	Routes []SiteRoute
+	// IndexDocument is appended to directory paths, e.g. "index.html".
+	IndexDocument string
+	// NotFoundDocument is the path served with status 404 when no route matches.
+	NotFoundDocument string
+	// SPAFallback serves the root index document for unmatched paths
+	// instead of NotFoundDocument.
+	SPAFallback bool
+	// Headers are added to responses by path prefix.
+	Headers []SiteHeaderRule
+}
+
+// SiteStatus is the status of a Site.
+type SiteStatus struct {
+	// ObservedGeneration is the generation the status was computed for.
+	ObservedGeneration int64
+	// Files is the number of Files served by the site.
+	Files int32
+	// TotalSize is the combined size in bytes of all Files served by the site.
+	TotalSize int64
+	// MissingFiles lists referenced Files that do not exist or have no content.
+	MissingFiles []string
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Site bundles Files into a routable static website.
+type Site struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	Spec   SiteSpec
+	Status SiteStatus
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SiteServeOptions is the query options for the serve subresource of a Site
+type SiteServeOptions struct {
+	metav1.TypeMeta
+
+	// Path is the URL path to resolve through the Site.
+	Path string
+}
+
+// PurgeSpec selects the Files whose cached content is purged. A File is
+// purged if it matches any of Names, Prefixes or Selector.
+type PurgeSpec struct {
+	// Names are exact File names.
+	Names []string
+	// Prefixes match File names by prefix.
+	Prefixes []string
+	// Selector matches Files by label.
+	Selector *metav1.LabelSelector
+}
+
+// PurgeWebhookStatus is the result of notifying one downstream purge webhook.
+type PurgeWebhookStatus struct {
+	URL   string
+	Code  int32
+	Error string
+}
+
+// PurgeStatus is the status of a Purge.
+type PurgeStatus struct {
+	// Files are the names of the purged Files. Until CompletionTime is set, they are the Files purged so far.
+	Files []string
+	// Webhooks are the results of notifying the downstream purge webhooks.
+	Webhooks []PurgeWebhookStatus
+	// CompletionTime is when the purge completed.
+	CompletionTime *metav1.Time
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Purge invalidates the cached content of Files.
+type Purge struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	Spec   PurgeSpec
+	Status PurgeStatus
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PurgeList is a list of Purge objects.
+type PurgeList struct {
+	metav1.TypeMeta
+	metav1.ListMeta
+
+	Items []Purge
+}
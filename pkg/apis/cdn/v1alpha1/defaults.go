@@ -20,6 +20,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DefaultSignedURLExpirationSeconds is the validity of signed URLs that do not
+// set one
+const DefaultSignedURLExpirationSeconds = 60 * 60
+
 func addDefaultingFuncs(scheme *runtime.Scheme) error {
 	return RegisterDefaults(scheme)
 }
@@ -28,3 +32,18 @@ func addDefaultingFuncs(scheme *runtime.Scheme) error {
 func SetDefaults_FileSpec(obj *FileSpec) {
 
 }
+
+// SetDefaults_FileSignedURLSpec sets defaults for FileSignedURL spec
+func SetDefaults_FileSignedURLSpec(obj *FileSignedURLSpec) {
+	if obj.ExpirationSeconds == nil {
+		expirationSeconds := int64(DefaultSignedURLExpirationSeconds)
+		obj.ExpirationSeconds = &expirationSeconds
+	}
+}
+
+// SetDefaults_SiteSpec sets defaults for Site spec
+func SetDefaults_SiteSpec(obj *SiteSpec) {
+	if obj.IndexDocument == "" {
+		obj.IndexDocument = "index.html"
+	}
+}
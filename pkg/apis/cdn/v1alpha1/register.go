@@ -42,7 +42,7 @@ func init() {
 	// We only register manually written functions here. The registration of the
 	// generated functions takes place in the generated files. The separation
 	// makes the code compile even when the generated files are missing.
-	localSchemeBuilder.Register(addKnownTypes, addDefaultingFuncs)
+	localSchemeBuilder.Register(addKnownTypes, addDefaultingFuncs, addFieldLabelConversionFuncs)
 }
 
 // Adds the list of known types to the given scheme.
@@ -51,6 +51,17 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&File{},
 		&FileList{},
 		&FileContent{},
+		&FileArchive{},
+		&FileArchiveOptions{},
+		&FileArchiveManifest{},
+		&FileCopyOptions{},
+		&FileStat{},
+		&FileSignedURL{},
+		&Site{},
+		&SiteList{},
+		&SiteServeOptions{},
+		&Purge{},
+		&PurgeList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
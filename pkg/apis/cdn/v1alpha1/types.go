@@ -36,10 +36,31 @@ type FileStatus struct {
 	Uploaded bool `json:"uploaded,omitempty" protobuf:"varint,1,opt,name=uploaded"`
 	// Error is an error message if the file upload failed.
 	Error string `json:"error,omitempty" protobuf:"bytes,2,opt,name=error"`
+	// Checksum is the hex-encoded SHA-256 digest of the uploaded content.
+	Checksum string `json:"checksum,omitempty" protobuf:"bytes,3,opt,name=checksum"`
+	// ContentGeneration is incremented whenever the content changes or is
+	// purged. It is part of the content's ETag.
+	ContentGeneration int64 `json:"contentGeneration,omitempty" protobuf:"varint,4,opt,name=contentGeneration"`
+	// LastUpload records who last uploaded the content.
+	LastUpload *FileUpload `json:"lastUpload,omitempty" protobuf:"bytes,5,opt,name=lastUpload"`
+}
+
+// FileUpload records the request that uploaded the content of a File.
+type FileUpload struct {
+	// User is the name of the authenticated user.
+	User string `json:"user,omitempty" protobuf:"bytes,1,opt,name=user"`
+	// Groups are the groups of the authenticated user.
+	Groups []string `json:"groups,omitempty" protobuf:"bytes,2,rep,name=groups"`
+	// SourceIP is the client address of the request.
+	SourceIP string `json:"sourceIP,omitempty" protobuf:"bytes,3,opt,name=sourceIP"`
+	// UserAgent is the User-Agent of the request.
+	UserAgent string `json:"userAgent,omitempty" protobuf:"bytes,4,opt,name=userAgent"`
+	// Time is when the content was uploaded.
+	Time metav1.Time `json:"time" protobuf:"bytes,5,opt,name=time"`
 }
 
 // +genclient
-// +genclient:nonNamespaced
+// +genclient:method=CreateSignedURL,verb=create,subresource=signedurl,input=FileSignedURL,result=FileSignedURL
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:prerelease-lifecycle-gen:introduced=1.0
 // +k8s:prerelease-lifecycle-gen:removed=1.10
@@ -51,7 +72,6 @@ type File struct {
 	Status            FileStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
 }
 
-// +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:prerelease-lifecycle-gen:introduced=1.0
 // +k8s:prerelease-lifecycle-gen:removed=1.10
@@ -73,3 +93,341 @@ type FileContent struct {
 	metav1.TypeMeta `json:",inline"`
 	Status          metav1.Status `json:"status,omitempty" protobuf:"bytes,1,opt,name=status"`
 }
+
+// +k8s:conversion-gen:explicit-from=net/url.Values
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// FileArchiveOptions is the query options for the archive subresource of a File
+type FileArchiveOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Prune deletes Files of the archive that are not part of the upload.
+	Prune bool `json:"prune,omitempty" protobuf:"varint,1,opt,name=prune"`
+
+	// LabelSelector selects the Files of a downloaded archive.
+	// Defaults to all Files in the namespace.
+	LabelSelector string `json:"labelSelector,omitempty" protobuf:"bytes,2,opt,name=labelSelector"`
+	// FieldSelector selects the Files of a downloaded archive.
+	FieldSelector string `json:"fieldSelector,omitempty" protobuf:"bytes,3,opt,name=fieldSelector"`
+	// Format is the format of a downloaded archive, "tar.gz" or "zip".
+	// Defaults to "tar.gz".
+	Format string `json:"format,omitempty" protobuf:"bytes,4,opt,name=format"`
+}
+
+const (
+	// FileArchiveFormatTarGz is a gzip-compressed tar archive.
+	FileArchiveFormatTarGz = "tar.gz"
+	// FileArchiveFormatZip is a zip archive.
+	FileArchiveFormatZip = "zip"
+)
+
+// FileArchiveEntryResult is the outcome of writing one archive entry.
+type FileArchiveEntryResult string
+
+const (
+	// FileArchiveEntryCreated means a new File was created for the entry.
+	FileArchiveEntryCreated FileArchiveEntryResult = "Created"
+	// FileArchiveEntryUpdated means an existing File was updated.
+	FileArchiveEntryUpdated FileArchiveEntryResult = "Updated"
+	// FileArchiveEntryUnchanged means the File already had the entry's content.
+	FileArchiveEntryUnchanged FileArchiveEntryResult = "Unchanged"
+	// FileArchiveEntryPruned means the File was deleted because the archive no longer contains it.
+	FileArchiveEntryPruned FileArchiveEntryResult = "Pruned"
+	// FileArchiveEntryFailed means the entry could not be written.
+	FileArchiveEntryFailed FileArchiveEntryResult = "Failed"
+)
+
+// FileArchiveEntry is the result for a single entry of an uploaded archive.
+type FileArchiveEntry struct {
+	// Path is the path of the entry in the archive.
+	Path string `json:"path" protobuf:"bytes,1,opt,name=path"`
+	// File is the name of the File the entry was written to.
+	File string `json:"file,omitempty" protobuf:"bytes,2,opt,name=file"`
+	// Size is the size of the entry in bytes.
+	Size int64 `json:"size,omitempty" protobuf:"varint,3,opt,name=size"`
+	// ContentType is the detected MIME type of the entry.
+	ContentType string `json:"contentType,omitempty" protobuf:"bytes,4,opt,name=contentType"`
+	// Result is the outcome for the entry.
+	Result FileArchiveEntryResult `json:"result,omitempty" protobuf:"bytes,5,opt,name=result,casttype=FileArchiveEntryResult"`
+	// Error is set if Result is Failed.
+	Error string `json:"error,omitempty" protobuf:"bytes,6,opt,name=error"`
+	// Checksum is the hex-encoded SHA-256 digest of the entry.
+	Checksum string `json:"checksum,omitempty" protobuf:"bytes,7,opt,name=checksum"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// FileArchive is the response of the archive subresource of a File
+type FileArchive struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Status  metav1.Status      `json:"status,omitempty" protobuf:"bytes,1,opt,name=status"`
+	Entries []FileArchiveEntry `json:"entries,omitempty" protobuf:"bytes,2,rep,name=entries"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// FileArchiveManifest describes the Files of a downloaded archive. It is
+// stored as the last entry of the archive.
+type FileArchiveManifest struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Entries maps the archive entries to their Files.
+	Entries []FileArchiveEntry `json:"entries,omitempty" protobuf:"bytes,1,rep,name=entries"`
+	// Files are the selected Files, including those without content.
+	Files []File `json:"files,omitempty" protobuf:"bytes,2,rep,name=files"`
+}
+
+// +k8s:conversion-gen:explicit-from=net/url.Values
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// FileCopyOptions is the query options for the copy subresource of a File
+type FileCopyOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Destination is the name of the File the content is copied to.
+	Destination string `json:"destination,omitempty" protobuf:"bytes,1,opt,name=destination"`
+	// DestinationNamespace is the namespace of the destination File.
+	// Defaults to the namespace of the copied File.
+	DestinationNamespace string `json:"destinationNamespace,omitempty" protobuf:"bytes,2,opt,name=destinationNamespace"`
+	// Move deletes the copied File once the destination File is committed.
+	Move bool `json:"move,omitempty" protobuf:"varint,3,opt,name=move"`
+	// Check checks the copy, including its authorization, without changing
+	// any File. The API server reserves the dryRun parameter of connect
+	// requests, so copies use their own.
+	Check bool `json:"check,omitempty" protobuf:"varint,4,opt,name=check"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// FileStat is the stat subresource of a File. It describes the content
+// stored for the File without the content itself.
+type FileStat struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Stored is false if no content is stored for the File.
+	Stored bool `json:"stored" protobuf:"varint,2,opt,name=stored"`
+	// Size is the size of the content in bytes, excluding any encryption
+	// overhead.
+	Size int64 `json:"size,omitempty" protobuf:"varint,3,opt,name=size"`
+	// ContentType is the content type stored with the content.
+	ContentType string `json:"contentType,omitempty" protobuf:"bytes,4,opt,name=contentType"`
+	// Checksum is the hex-encoded SHA-256 digest of the stored content.
+	Checksum string `json:"checksum,omitempty" protobuf:"bytes,5,opt,name=checksum"`
+	// Versions counts the contents stored for the File by this replica since
+	// it started.
+	Versions int64 `json:"versions,omitempty" protobuf:"varint,6,opt,name=versions"`
+	// Modified is when the current content was stored.
+	// +optional
+	Modified *metav1.Time `json:"modified,omitempty" protobuf:"bytes,7,opt,name=modified"`
+	// LastAccess is when the content was last read, if it was.
+	// +optional
+	LastAccess *metav1.Time `json:"lastAccess,omitempty" protobuf:"bytes,8,opt,name=lastAccess"`
+	// Cached is true if the content is in the replica's content cache.
+	Cached bool `json:"cached,omitempty" protobuf:"varint,9,opt,name=cached"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// FileSignedURL is the signedurl subresource of a File. Creating it signs a
+// URL that serves the content of the File without credentials until it
+// expires.
+type FileSignedURL struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	Spec FileSignedURLSpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+	// +optional
+	Status FileSignedURLStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// Bounds of FileSignedURLSpec.ExpirationSeconds
+const (
+	MinSignedURLExpirationSeconds = 60
+	MaxSignedURLExpirationSeconds = 7 * 24 * 60 * 60
+)
+
+// FileSignedURLSpec is the requested validity of a signed URL.
+type FileSignedURLSpec struct {
+	// ExpirationSeconds is how long the URL is valid for, between 60 seconds
+	// and 7 days. Defaults to one hour.
+	// +optional
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty" protobuf:"varint,1,opt,name=expirationSeconds"`
+}
+
+// FileSignedURLStatus is the signed URL.
+type FileSignedURLStatus struct {
+	// URL serves the content of the File. It is signed for the File's UID, so
+	// it stops working if the File is deleted, even if it is recreated.
+	URL string `json:"url" protobuf:"bytes,1,opt,name=url"`
+	// ExpirationTimestamp is when the URL stops working.
+	ExpirationTimestamp metav1.Time `json:"expirationTimestamp" protobuf:"bytes,2,opt,name=expirationTimestamp"`
+}
+
+// SiteRoute maps a URL path to a File.
+type SiteRoute struct {
+	// Path is the absolute URL path, e.g. "/docs/getting-started".
+	Path string `json:"path" protobuf:"bytes,1,opt,name=path"`
+	// File is the name of the File in the Site's namespace.
+	File string `json:"file" protobuf:"bytes,2,opt,name=file"`
+}
+
+// SiteHeader is an HTTP header added to responses.
+type SiteHeader struct {
+	// Name is the header name.
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+	// Value is the header value.
+	Value string `json:"value" protobuf:"bytes,2,opt,name=value"`
+}
+
+// SiteHeaderRule adds headers to responses for paths under a prefix.
+type SiteHeaderRule struct {
+	// PathPrefix selects the paths the headers apply to.
+	PathPrefix string `json:"pathPrefix" protobuf:"bytes,1,opt,name=pathPrefix"`
+	// Headers are set on every response below PathPrefix.
+	Headers []SiteHeader `json:"headers,omitempty" protobuf:"bytes,2,rep,name=headers"`
+}
+
+// SiteSpec is the specification of a Site.
+type SiteSpec struct {
+	// Selector selects the Files that make up the site. A selected File is
+	// served at the path in its cdn.k8s.toms.place/path annotation, or at
+	// "/<name>" if it has none.
+	Selector *metav1.LabelSelector `json:"selector,omitempty" protobuf:"bytes,1,opt,name=selector"`
+	// Routes maps paths to Files explicitly. Routes take precedence over
+	// Files matched by Selector.
+	Routes []SiteRoute `json:"routes,omitempty" protobuf:"bytes,2,rep,name=routes"`
+	// IndexDocument is appended to directory paths. Defaults to "index.html".
+	IndexDocument string `json:"indexDocument,omitempty" protobuf:"bytes,3,opt,name=indexDocument"`
+	// NotFoundDocument is the path served with status 404 when no route matches.
+	NotFoundDocument string `json:"notFoundDocument,omitempty" protobuf:"bytes,4,opt,name=notFoundDocument"`
+	// SPAFallback serves the root index document for unmatched paths
+	// instead of NotFoundDocument.
+	SPAFallback bool `json:"spaFallback,omitempty" protobuf:"varint,5,opt,name=spaFallback"`
+	// Headers are added to responses by path prefix.
+	Headers []SiteHeaderRule `json:"headers,omitempty" protobuf:"bytes,6,rep,name=headers"`
+}
+
+// SiteStatus is the status of a Site.
+type SiteStatus struct {
+	// ObservedGeneration is the generation the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" protobuf:"varint,1,opt,name=observedGeneration"`
+	// Files is the number of Files served by the site.
+	Files int32 `json:"files,omitempty" protobuf:"varint,2,opt,name=files"`
+	// TotalSize is the combined size in bytes of all Files served by the site.
+	TotalSize int64 `json:"totalSize,omitempty" protobuf:"varint,3,opt,name=totalSize"`
+	// MissingFiles lists referenced Files that do not exist or have no content.
+	MissingFiles []string `json:"missingFiles,omitempty" protobuf:"bytes,4,rep,name=missingFiles"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// Site bundles Files into a routable static website.
+type Site struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Spec              SiteSpec   `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+	Status            SiteStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// SiteList is a list of Site objects.
+type SiteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	Items []Site `json:"items" protobuf:"bytes,2,rep,name=items"`
+}
+
+// +k8s:conversion-gen:explicit-from=net/url.Values
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// SiteServeOptions is the query options for the serve subresource of a Site
+type SiteServeOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Path is the URL path to resolve through the Site.
+	Path string `json:"path,omitempty" protobuf:"bytes,1,opt,name=path"`
+}
+
+// PurgeSpec selects the Files whose cached content is purged. A File is
+// purged if it matches any of Names, Prefixes or Selector.
+type PurgeSpec struct {
+	// Names are exact File names.
+	Names []string `json:"names,omitempty" protobuf:"bytes,1,rep,name=names"`
+	// Prefixes match File names by prefix.
+	Prefixes []string `json:"prefixes,omitempty" protobuf:"bytes,2,rep,name=prefixes"`
+	// Selector matches Files by label. An empty selector matches all Files
+	// in the namespace.
+	Selector *metav1.LabelSelector `json:"selector,omitempty" protobuf:"bytes,3,opt,name=selector"`
+}
+
+// PurgeWebhookStatus is the result of notifying one downstream purge webhook.
+type PurgeWebhookStatus struct {
+	// URL is the webhook URL.
+	URL string `json:"url" protobuf:"bytes,1,opt,name=url"`
+	// Code is the HTTP status code of the last attempt, if a response was received.
+	Code int32 `json:"code,omitempty" protobuf:"varint,2,opt,name=code"`
+	// Error is set if the webhook could not be notified.
+	Error string `json:"error,omitempty" protobuf:"bytes,3,opt,name=error"`
+}
+
+// PurgeStatus is the status of a Purge.
+type PurgeStatus struct {
+	// Files are the names of the purged Files. Until CompletionTime is set, they are the Files purged so far.
+	Files []string `json:"files,omitempty" protobuf:"bytes,1,rep,name=files"`
+	// Webhooks are the results of notifying the downstream purge webhooks.
+	Webhooks []PurgeWebhookStatus `json:"webhooks,omitempty" protobuf:"bytes,2,rep,name=webhooks"`
+	// CompletionTime is when the purge completed. A Purge is processed once.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty" protobuf:"bytes,3,opt,name=completionTime"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// Purge invalidates the cached content of Files. Purging bumps the content
+// generation of each File, which changes its ETag, and notifies the
+// downstream purge webhooks configured on the server.
+type Purge struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Spec              PurgeSpec   `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+	Status            PurgeStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +k8s:prerelease-lifecycle-gen:introduced=1.0
+// +k8s:prerelease-lifecycle-gen:removed=1.10
+
+// PurgeList is a list of Purge objects.
+type PurgeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	Items []Purge `json:"items" protobuf:"bytes,2,rep,name=items"`
+}
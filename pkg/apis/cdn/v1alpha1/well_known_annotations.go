@@ -1,5 +1,5 @@
 /*
-Copyright 2024 The Kubernetes Authors.
+Copyright 2017 The Kubernetes Authors.
 
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
@@ -14,21 +14,10 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package cmd
+package v1alpha1
 
-import (
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
+const (
+	// AnnotationPath is the URL path a File is served at by a Site that
+	// selects it. Files without the annotation are served at "/<name>".
+	AnnotationPath = GroupName + "/path"
 )
-
-var cdnGroupVersion = schema.GroupVersion{Group: "cdn.k8s.toms.place", Version: "v1alpha1"}
-
-var cdnScheme = runtime.NewScheme()
-var cdnCodec = serializer.NewCodecFactory(cdnScheme)
-
-func init() {
-	// Register the types we need for the API
-	metav1.AddToGroupVersion(cdnScheme, cdnGroupVersion)
-}
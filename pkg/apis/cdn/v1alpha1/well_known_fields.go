@@ -0,0 +1,65 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"math/bits"
+	"mime"
+	"strings"
+)
+
+// Field labels of Files derived from their spec. Field selectors only compare
+// values for equality, so these expose the parts of a File that are commonly
+// filtered on in a form that can be compared.
+const (
+	// FieldMediaType is the media type of spec.contentType without its
+	// parameters, such as "text/html" for "text/html; charset=utf-8".
+	FieldMediaType = "spec.mediaType"
+	// FieldTopLevelType is the top-level type of the media type, such as
+	// "image" for "image/png".
+	FieldTopLevelType = "spec.topLevelType"
+	// FieldSizeClass is the number of bits needed for spec.size: class c
+	// holds the sizes from 2^(c-1) to 2^c-1, and class 0 empty Files. Sizes
+	// above or below a bound are selected by excluding the classes that are
+	// entirely below or above it.
+	FieldSizeClass = "spec.sizeClass"
+)
+
+// MediaType returns the lower-cased media type of contentType without its
+// parameters, or "" if it is invalid.
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// TopLevelType returns the top-level type of the media type of contentType,
+// or "" if it is invalid.
+func TopLevelType(contentType string) string {
+	topLevelType, _, _ := strings.Cut(MediaType(contentType), "/")
+	return topLevelType
+}
+
+// SizeClass returns the size class of a File of size bytes.
+func SizeClass(size int64) int {
+	if size < 0 {
+		return 0
+	}
+	return bits.Len64(uint64(size))
+}
@@ -22,8 +22,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	url "net/url"
 	unsafe "unsafe"
 
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	cdn "k8s.toms.place/apiserver/pkg/apis/cdn"
@@ -46,6 +48,46 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*FileArchive)(nil), (*cdn.FileArchive)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileArchive_To_cdn_FileArchive(a.(*FileArchive), b.(*cdn.FileArchive), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileArchive)(nil), (*FileArchive)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileArchive_To_v1alpha1_FileArchive(a.(*cdn.FileArchive), b.(*FileArchive), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileArchiveEntry)(nil), (*cdn.FileArchiveEntry)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileArchiveEntry_To_cdn_FileArchiveEntry(a.(*FileArchiveEntry), b.(*cdn.FileArchiveEntry), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileArchiveEntry)(nil), (*FileArchiveEntry)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileArchiveEntry_To_v1alpha1_FileArchiveEntry(a.(*cdn.FileArchiveEntry), b.(*FileArchiveEntry), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileArchiveManifest)(nil), (*cdn.FileArchiveManifest)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileArchiveManifest_To_cdn_FileArchiveManifest(a.(*FileArchiveManifest), b.(*cdn.FileArchiveManifest), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileArchiveManifest)(nil), (*FileArchiveManifest)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileArchiveManifest_To_v1alpha1_FileArchiveManifest(a.(*cdn.FileArchiveManifest), b.(*FileArchiveManifest), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileArchiveOptions)(nil), (*cdn.FileArchiveOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileArchiveOptions_To_cdn_FileArchiveOptions(a.(*FileArchiveOptions), b.(*cdn.FileArchiveOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileArchiveOptions)(nil), (*FileArchiveOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileArchiveOptions_To_v1alpha1_FileArchiveOptions(a.(*cdn.FileArchiveOptions), b.(*FileArchiveOptions), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*FileContent)(nil), (*cdn.FileContent)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_FileContent_To_cdn_FileContent(a.(*FileContent), b.(*cdn.FileContent), scope)
 	}); err != nil {
@@ -56,6 +98,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*FileCopyOptions)(nil), (*cdn.FileCopyOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileCopyOptions_To_cdn_FileCopyOptions(a.(*FileCopyOptions), b.(*cdn.FileCopyOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileCopyOptions)(nil), (*FileCopyOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileCopyOptions_To_v1alpha1_FileCopyOptions(a.(*cdn.FileCopyOptions), b.(*FileCopyOptions), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*FileList)(nil), (*cdn.FileList)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_FileList_To_cdn_FileList(a.(*FileList), b.(*cdn.FileList), scope)
 	}); err != nil {
@@ -66,6 +118,36 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*FileSignedURL)(nil), (*cdn.FileSignedURL)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileSignedURL_To_cdn_FileSignedURL(a.(*FileSignedURL), b.(*cdn.FileSignedURL), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileSignedURL)(nil), (*FileSignedURL)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileSignedURL_To_v1alpha1_FileSignedURL(a.(*cdn.FileSignedURL), b.(*FileSignedURL), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileSignedURLSpec)(nil), (*cdn.FileSignedURLSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileSignedURLSpec_To_cdn_FileSignedURLSpec(a.(*FileSignedURLSpec), b.(*cdn.FileSignedURLSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileSignedURLSpec)(nil), (*FileSignedURLSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileSignedURLSpec_To_v1alpha1_FileSignedURLSpec(a.(*cdn.FileSignedURLSpec), b.(*FileSignedURLSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FileSignedURLStatus)(nil), (*cdn.FileSignedURLStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileSignedURLStatus_To_cdn_FileSignedURLStatus(a.(*FileSignedURLStatus), b.(*cdn.FileSignedURLStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileSignedURLStatus)(nil), (*FileSignedURLStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileSignedURLStatus_To_v1alpha1_FileSignedURLStatus(a.(*cdn.FileSignedURLStatus), b.(*FileSignedURLStatus), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*FileSpec)(nil), (*cdn.FileSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_FileSpec_To_cdn_FileSpec(a.(*FileSpec), b.(*cdn.FileSpec), scope)
 	}); err != nil {
@@ -76,6 +158,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*FileStat)(nil), (*cdn.FileStat)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileStat_To_cdn_FileStat(a.(*FileStat), b.(*cdn.FileStat), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileStat)(nil), (*FileStat)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileStat_To_v1alpha1_FileStat(a.(*cdn.FileStat), b.(*FileStat), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*FileStatus)(nil), (*cdn.FileStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_FileStatus_To_cdn_FileStatus(a.(*FileStatus), b.(*cdn.FileStatus), scope)
 	}); err != nil {
@@ -86,6 +178,161 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*FileUpload)(nil), (*cdn.FileUpload)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FileUpload_To_cdn_FileUpload(a.(*FileUpload), b.(*cdn.FileUpload), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.FileUpload)(nil), (*FileUpload)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_FileUpload_To_v1alpha1_FileUpload(a.(*cdn.FileUpload), b.(*FileUpload), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Purge)(nil), (*cdn.Purge)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Purge_To_cdn_Purge(a.(*Purge), b.(*cdn.Purge), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.Purge)(nil), (*Purge)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_Purge_To_v1alpha1_Purge(a.(*cdn.Purge), b.(*Purge), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PurgeList)(nil), (*cdn.PurgeList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PurgeList_To_cdn_PurgeList(a.(*PurgeList), b.(*cdn.PurgeList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.PurgeList)(nil), (*PurgeList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_PurgeList_To_v1alpha1_PurgeList(a.(*cdn.PurgeList), b.(*PurgeList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PurgeSpec)(nil), (*cdn.PurgeSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PurgeSpec_To_cdn_PurgeSpec(a.(*PurgeSpec), b.(*cdn.PurgeSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.PurgeSpec)(nil), (*PurgeSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_PurgeSpec_To_v1alpha1_PurgeSpec(a.(*cdn.PurgeSpec), b.(*PurgeSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PurgeStatus)(nil), (*cdn.PurgeStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PurgeStatus_To_cdn_PurgeStatus(a.(*PurgeStatus), b.(*cdn.PurgeStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.PurgeStatus)(nil), (*PurgeStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_PurgeStatus_To_v1alpha1_PurgeStatus(a.(*cdn.PurgeStatus), b.(*PurgeStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PurgeWebhookStatus)(nil), (*cdn.PurgeWebhookStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_PurgeWebhookStatus_To_cdn_PurgeWebhookStatus(a.(*PurgeWebhookStatus), b.(*cdn.PurgeWebhookStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.PurgeWebhookStatus)(nil), (*PurgeWebhookStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_PurgeWebhookStatus_To_v1alpha1_PurgeWebhookStatus(a.(*cdn.PurgeWebhookStatus), b.(*PurgeWebhookStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Site)(nil), (*cdn.Site)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Site_To_cdn_Site(a.(*Site), b.(*cdn.Site), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.Site)(nil), (*Site)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_Site_To_v1alpha1_Site(a.(*cdn.Site), b.(*Site), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SiteHeader)(nil), (*cdn.SiteHeader)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SiteHeader_To_cdn_SiteHeader(a.(*SiteHeader), b.(*cdn.SiteHeader), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.SiteHeader)(nil), (*SiteHeader)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_SiteHeader_To_v1alpha1_SiteHeader(a.(*cdn.SiteHeader), b.(*SiteHeader), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SiteHeaderRule)(nil), (*cdn.SiteHeaderRule)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SiteHeaderRule_To_cdn_SiteHeaderRule(a.(*SiteHeaderRule), b.(*cdn.SiteHeaderRule), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.SiteHeaderRule)(nil), (*SiteHeaderRule)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_SiteHeaderRule_To_v1alpha1_SiteHeaderRule(a.(*cdn.SiteHeaderRule), b.(*SiteHeaderRule), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SiteList)(nil), (*cdn.SiteList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SiteList_To_cdn_SiteList(a.(*SiteList), b.(*cdn.SiteList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.SiteList)(nil), (*SiteList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_SiteList_To_v1alpha1_SiteList(a.(*cdn.SiteList), b.(*SiteList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SiteRoute)(nil), (*cdn.SiteRoute)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SiteRoute_To_cdn_SiteRoute(a.(*SiteRoute), b.(*cdn.SiteRoute), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.SiteRoute)(nil), (*SiteRoute)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_SiteRoute_To_v1alpha1_SiteRoute(a.(*cdn.SiteRoute), b.(*SiteRoute), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SiteServeOptions)(nil), (*cdn.SiteServeOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SiteServeOptions_To_cdn_SiteServeOptions(a.(*SiteServeOptions), b.(*cdn.SiteServeOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.SiteServeOptions)(nil), (*SiteServeOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_SiteServeOptions_To_v1alpha1_SiteServeOptions(a.(*cdn.SiteServeOptions), b.(*SiteServeOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SiteSpec)(nil), (*cdn.SiteSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SiteSpec_To_cdn_SiteSpec(a.(*SiteSpec), b.(*cdn.SiteSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.SiteSpec)(nil), (*SiteSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_SiteSpec_To_v1alpha1_SiteSpec(a.(*cdn.SiteSpec), b.(*SiteSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SiteStatus)(nil), (*cdn.SiteStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SiteStatus_To_cdn_SiteStatus(a.(*SiteStatus), b.(*cdn.SiteStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*cdn.SiteStatus)(nil), (*SiteStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_cdn_SiteStatus_To_v1alpha1_SiteStatus(a.(*cdn.SiteStatus), b.(*SiteStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*url.Values)(nil), (*FileArchiveOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_url_Values_To_v1alpha1_FileArchiveOptions(a.(*url.Values), b.(*FileArchiveOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*url.Values)(nil), (*FileCopyOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_url_Values_To_v1alpha1_FileCopyOptions(a.(*url.Values), b.(*FileCopyOptions), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*url.Values)(nil), (*SiteServeOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_url_Values_To_v1alpha1_SiteServeOptions(a.(*url.Values), b.(*SiteServeOptions), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -121,6 +368,147 @@ func Convert_cdn_File_To_v1alpha1_File(in *cdn.File, out *File, s conversion.Sco
 	return autoConvert_cdn_File_To_v1alpha1_File(in, out, s)
 }
 
+func autoConvert_v1alpha1_FileArchive_To_cdn_FileArchive(in *FileArchive, out *cdn.FileArchive, s conversion.Scope) error {
+	out.Status = in.Status
+	out.Entries = *(*[]cdn.FileArchiveEntry)(unsafe.Pointer(&in.Entries))
+	return nil
+}
+
+// Convert_v1alpha1_FileArchive_To_cdn_FileArchive is an autogenerated conversion function.
+func Convert_v1alpha1_FileArchive_To_cdn_FileArchive(in *FileArchive, out *cdn.FileArchive, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileArchive_To_cdn_FileArchive(in, out, s)
+}
+
+func autoConvert_cdn_FileArchive_To_v1alpha1_FileArchive(in *cdn.FileArchive, out *FileArchive, s conversion.Scope) error {
+	out.Status = in.Status
+	out.Entries = *(*[]FileArchiveEntry)(unsafe.Pointer(&in.Entries))
+	return nil
+}
+
+// Convert_cdn_FileArchive_To_v1alpha1_FileArchive is an autogenerated conversion function.
+func Convert_cdn_FileArchive_To_v1alpha1_FileArchive(in *cdn.FileArchive, out *FileArchive, s conversion.Scope) error {
+	return autoConvert_cdn_FileArchive_To_v1alpha1_FileArchive(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileArchiveEntry_To_cdn_FileArchiveEntry(in *FileArchiveEntry, out *cdn.FileArchiveEntry, s conversion.Scope) error {
+	out.Path = in.Path
+	out.File = in.File
+	out.Size = in.Size
+	out.ContentType = in.ContentType
+	out.Result = cdn.FileArchiveEntryResult(in.Result)
+	out.Error = in.Error
+	out.Checksum = in.Checksum
+	return nil
+}
+
+// Convert_v1alpha1_FileArchiveEntry_To_cdn_FileArchiveEntry is an autogenerated conversion function.
+func Convert_v1alpha1_FileArchiveEntry_To_cdn_FileArchiveEntry(in *FileArchiveEntry, out *cdn.FileArchiveEntry, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileArchiveEntry_To_cdn_FileArchiveEntry(in, out, s)
+}
+
+func autoConvert_cdn_FileArchiveEntry_To_v1alpha1_FileArchiveEntry(in *cdn.FileArchiveEntry, out *FileArchiveEntry, s conversion.Scope) error {
+	out.Path = in.Path
+	out.File = in.File
+	out.Size = in.Size
+	out.ContentType = in.ContentType
+	out.Result = FileArchiveEntryResult(in.Result)
+	out.Error = in.Error
+	out.Checksum = in.Checksum
+	return nil
+}
+
+// Convert_cdn_FileArchiveEntry_To_v1alpha1_FileArchiveEntry is an autogenerated conversion function.
+func Convert_cdn_FileArchiveEntry_To_v1alpha1_FileArchiveEntry(in *cdn.FileArchiveEntry, out *FileArchiveEntry, s conversion.Scope) error {
+	return autoConvert_cdn_FileArchiveEntry_To_v1alpha1_FileArchiveEntry(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileArchiveManifest_To_cdn_FileArchiveManifest(in *FileArchiveManifest, out *cdn.FileArchiveManifest, s conversion.Scope) error {
+	out.Entries = *(*[]cdn.FileArchiveEntry)(unsafe.Pointer(&in.Entries))
+	out.Files = *(*[]cdn.File)(unsafe.Pointer(&in.Files))
+	return nil
+}
+
+// Convert_v1alpha1_FileArchiveManifest_To_cdn_FileArchiveManifest is an autogenerated conversion function.
+func Convert_v1alpha1_FileArchiveManifest_To_cdn_FileArchiveManifest(in *FileArchiveManifest, out *cdn.FileArchiveManifest, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileArchiveManifest_To_cdn_FileArchiveManifest(in, out, s)
+}
+
+func autoConvert_cdn_FileArchiveManifest_To_v1alpha1_FileArchiveManifest(in *cdn.FileArchiveManifest, out *FileArchiveManifest, s conversion.Scope) error {
+	out.Entries = *(*[]FileArchiveEntry)(unsafe.Pointer(&in.Entries))
+	out.Files = *(*[]File)(unsafe.Pointer(&in.Files))
+	return nil
+}
+
+// Convert_cdn_FileArchiveManifest_To_v1alpha1_FileArchiveManifest is an autogenerated conversion function.
+func Convert_cdn_FileArchiveManifest_To_v1alpha1_FileArchiveManifest(in *cdn.FileArchiveManifest, out *FileArchiveManifest, s conversion.Scope) error {
+	return autoConvert_cdn_FileArchiveManifest_To_v1alpha1_FileArchiveManifest(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileArchiveOptions_To_cdn_FileArchiveOptions(in *FileArchiveOptions, out *cdn.FileArchiveOptions, s conversion.Scope) error {
+	out.Prune = in.Prune
+	out.LabelSelector = in.LabelSelector
+	out.FieldSelector = in.FieldSelector
+	out.Format = in.Format
+	return nil
+}
+
+// Convert_v1alpha1_FileArchiveOptions_To_cdn_FileArchiveOptions is an autogenerated conversion function.
+func Convert_v1alpha1_FileArchiveOptions_To_cdn_FileArchiveOptions(in *FileArchiveOptions, out *cdn.FileArchiveOptions, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileArchiveOptions_To_cdn_FileArchiveOptions(in, out, s)
+}
+
+func autoConvert_cdn_FileArchiveOptions_To_v1alpha1_FileArchiveOptions(in *cdn.FileArchiveOptions, out *FileArchiveOptions, s conversion.Scope) error {
+	out.Prune = in.Prune
+	out.LabelSelector = in.LabelSelector
+	out.FieldSelector = in.FieldSelector
+	out.Format = in.Format
+	return nil
+}
+
+// Convert_cdn_FileArchiveOptions_To_v1alpha1_FileArchiveOptions is an autogenerated conversion function.
+func Convert_cdn_FileArchiveOptions_To_v1alpha1_FileArchiveOptions(in *cdn.FileArchiveOptions, out *FileArchiveOptions, s conversion.Scope) error {
+	return autoConvert_cdn_FileArchiveOptions_To_v1alpha1_FileArchiveOptions(in, out, s)
+}
+
+func autoConvert_url_Values_To_v1alpha1_FileArchiveOptions(in *url.Values, out *FileArchiveOptions, s conversion.Scope) error {
+	// WARNING: Field TypeMeta does not have json tag, skipping.
+
+	if values, ok := map[string][]string(*in)["prune"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_bool(&values, &out.Prune, s); err != nil {
+			return err
+		}
+	} else {
+		out.Prune = false
+	}
+	if values, ok := map[string][]string(*in)["labelSelector"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_string(&values, &out.LabelSelector, s); err != nil {
+			return err
+		}
+	} else {
+		out.LabelSelector = ""
+	}
+	if values, ok := map[string][]string(*in)["fieldSelector"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_string(&values, &out.FieldSelector, s); err != nil {
+			return err
+		}
+	} else {
+		out.FieldSelector = ""
+	}
+	if values, ok := map[string][]string(*in)["format"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_string(&values, &out.Format, s); err != nil {
+			return err
+		}
+	} else {
+		out.Format = ""
+	}
+	return nil
+}
+
+// Convert_url_Values_To_v1alpha1_FileArchiveOptions is an autogenerated conversion function.
+func Convert_url_Values_To_v1alpha1_FileArchiveOptions(in *url.Values, out *FileArchiveOptions, s conversion.Scope) error {
+	return autoConvert_url_Values_To_v1alpha1_FileArchiveOptions(in, out, s)
+}
+
 func autoConvert_v1alpha1_FileContent_To_cdn_FileContent(in *FileContent, out *cdn.FileContent, s conversion.Scope) error {
 	out.Status = in.Status
 	return nil
@@ -141,6 +529,71 @@ func Convert_cdn_FileContent_To_v1alpha1_FileContent(in *cdn.FileContent, out *F
 	return autoConvert_cdn_FileContent_To_v1alpha1_FileContent(in, out, s)
 }
 
+func autoConvert_v1alpha1_FileCopyOptions_To_cdn_FileCopyOptions(in *FileCopyOptions, out *cdn.FileCopyOptions, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.DestinationNamespace = in.DestinationNamespace
+	out.Move = in.Move
+	out.Check = in.Check
+	return nil
+}
+
+// Convert_v1alpha1_FileCopyOptions_To_cdn_FileCopyOptions is an autogenerated conversion function.
+func Convert_v1alpha1_FileCopyOptions_To_cdn_FileCopyOptions(in *FileCopyOptions, out *cdn.FileCopyOptions, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileCopyOptions_To_cdn_FileCopyOptions(in, out, s)
+}
+
+func autoConvert_cdn_FileCopyOptions_To_v1alpha1_FileCopyOptions(in *cdn.FileCopyOptions, out *FileCopyOptions, s conversion.Scope) error {
+	out.Destination = in.Destination
+	out.DestinationNamespace = in.DestinationNamespace
+	out.Move = in.Move
+	out.Check = in.Check
+	return nil
+}
+
+// Convert_cdn_FileCopyOptions_To_v1alpha1_FileCopyOptions is an autogenerated conversion function.
+func Convert_cdn_FileCopyOptions_To_v1alpha1_FileCopyOptions(in *cdn.FileCopyOptions, out *FileCopyOptions, s conversion.Scope) error {
+	return autoConvert_cdn_FileCopyOptions_To_v1alpha1_FileCopyOptions(in, out, s)
+}
+
+func autoConvert_url_Values_To_v1alpha1_FileCopyOptions(in *url.Values, out *FileCopyOptions, s conversion.Scope) error {
+	// WARNING: Field TypeMeta does not have json tag, skipping.
+
+	if values, ok := map[string][]string(*in)["destination"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_string(&values, &out.Destination, s); err != nil {
+			return err
+		}
+	} else {
+		out.Destination = ""
+	}
+	if values, ok := map[string][]string(*in)["destinationNamespace"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_string(&values, &out.DestinationNamespace, s); err != nil {
+			return err
+		}
+	} else {
+		out.DestinationNamespace = ""
+	}
+	if values, ok := map[string][]string(*in)["move"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_bool(&values, &out.Move, s); err != nil {
+			return err
+		}
+	} else {
+		out.Move = false
+	}
+	if values, ok := map[string][]string(*in)["check"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_bool(&values, &out.Check, s); err != nil {
+			return err
+		}
+	} else {
+		out.Check = false
+	}
+	return nil
+}
+
+// Convert_url_Values_To_v1alpha1_FileCopyOptions is an autogenerated conversion function.
+func Convert_url_Values_To_v1alpha1_FileCopyOptions(in *url.Values, out *FileCopyOptions, s conversion.Scope) error {
+	return autoConvert_url_Values_To_v1alpha1_FileCopyOptions(in, out, s)
+}
+
 func autoConvert_v1alpha1_FileList_To_cdn_FileList(in *FileList, out *cdn.FileList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
 	out.Items = *(*[]cdn.File)(unsafe.Pointer(&in.Items))
@@ -163,6 +616,80 @@ func Convert_cdn_FileList_To_v1alpha1_FileList(in *cdn.FileList, out *FileList,
 	return autoConvert_cdn_FileList_To_v1alpha1_FileList(in, out, s)
 }
 
+func autoConvert_v1alpha1_FileSignedURL_To_cdn_FileSignedURL(in *FileSignedURL, out *cdn.FileSignedURL, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha1_FileSignedURLSpec_To_cdn_FileSignedURLSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1alpha1_FileSignedURLStatus_To_cdn_FileSignedURLStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_FileSignedURL_To_cdn_FileSignedURL is an autogenerated conversion function.
+func Convert_v1alpha1_FileSignedURL_To_cdn_FileSignedURL(in *FileSignedURL, out *cdn.FileSignedURL, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileSignedURL_To_cdn_FileSignedURL(in, out, s)
+}
+
+func autoConvert_cdn_FileSignedURL_To_v1alpha1_FileSignedURL(in *cdn.FileSignedURL, out *FileSignedURL, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_cdn_FileSignedURLSpec_To_v1alpha1_FileSignedURLSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_cdn_FileSignedURLStatus_To_v1alpha1_FileSignedURLStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_cdn_FileSignedURL_To_v1alpha1_FileSignedURL is an autogenerated conversion function.
+func Convert_cdn_FileSignedURL_To_v1alpha1_FileSignedURL(in *cdn.FileSignedURL, out *FileSignedURL, s conversion.Scope) error {
+	return autoConvert_cdn_FileSignedURL_To_v1alpha1_FileSignedURL(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileSignedURLSpec_To_cdn_FileSignedURLSpec(in *FileSignedURLSpec, out *cdn.FileSignedURLSpec, s conversion.Scope) error {
+	out.ExpirationSeconds = (*int64)(unsafe.Pointer(in.ExpirationSeconds))
+	return nil
+}
+
+// Convert_v1alpha1_FileSignedURLSpec_To_cdn_FileSignedURLSpec is an autogenerated conversion function.
+func Convert_v1alpha1_FileSignedURLSpec_To_cdn_FileSignedURLSpec(in *FileSignedURLSpec, out *cdn.FileSignedURLSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileSignedURLSpec_To_cdn_FileSignedURLSpec(in, out, s)
+}
+
+func autoConvert_cdn_FileSignedURLSpec_To_v1alpha1_FileSignedURLSpec(in *cdn.FileSignedURLSpec, out *FileSignedURLSpec, s conversion.Scope) error {
+	out.ExpirationSeconds = (*int64)(unsafe.Pointer(in.ExpirationSeconds))
+	return nil
+}
+
+// Convert_cdn_FileSignedURLSpec_To_v1alpha1_FileSignedURLSpec is an autogenerated conversion function.
+func Convert_cdn_FileSignedURLSpec_To_v1alpha1_FileSignedURLSpec(in *cdn.FileSignedURLSpec, out *FileSignedURLSpec, s conversion.Scope) error {
+	return autoConvert_cdn_FileSignedURLSpec_To_v1alpha1_FileSignedURLSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_FileSignedURLStatus_To_cdn_FileSignedURLStatus(in *FileSignedURLStatus, out *cdn.FileSignedURLStatus, s conversion.Scope) error {
+	out.URL = in.URL
+	out.ExpirationTimestamp = in.ExpirationTimestamp
+	return nil
+}
+
+// Convert_v1alpha1_FileSignedURLStatus_To_cdn_FileSignedURLStatus is an autogenerated conversion function.
+func Convert_v1alpha1_FileSignedURLStatus_To_cdn_FileSignedURLStatus(in *FileSignedURLStatus, out *cdn.FileSignedURLStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileSignedURLStatus_To_cdn_FileSignedURLStatus(in, out, s)
+}
+
+func autoConvert_cdn_FileSignedURLStatus_To_v1alpha1_FileSignedURLStatus(in *cdn.FileSignedURLStatus, out *FileSignedURLStatus, s conversion.Scope) error {
+	out.URL = in.URL
+	out.ExpirationTimestamp = in.ExpirationTimestamp
+	return nil
+}
+
+// Convert_cdn_FileSignedURLStatus_To_v1alpha1_FileSignedURLStatus is an autogenerated conversion function.
+func Convert_cdn_FileSignedURLStatus_To_v1alpha1_FileSignedURLStatus(in *cdn.FileSignedURLStatus, out *FileSignedURLStatus, s conversion.Scope) error {
+	return autoConvert_cdn_FileSignedURLStatus_To_v1alpha1_FileSignedURLStatus(in, out, s)
+}
+
 func autoConvert_v1alpha1_FileSpec_To_cdn_FileSpec(in *FileSpec, out *cdn.FileSpec, s conversion.Scope) error {
 	out.URL = in.URL
 	out.Size = in.Size
@@ -189,9 +716,48 @@ func Convert_cdn_FileSpec_To_v1alpha1_FileSpec(in *cdn.FileSpec, out *FileSpec,
 	return autoConvert_cdn_FileSpec_To_v1alpha1_FileSpec(in, out, s)
 }
 
+func autoConvert_v1alpha1_FileStat_To_cdn_FileStat(in *FileStat, out *cdn.FileStat, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	out.Stored = in.Stored
+	out.Size = in.Size
+	out.ContentType = in.ContentType
+	out.Checksum = in.Checksum
+	out.Versions = in.Versions
+	out.Modified = (*v1.Time)(unsafe.Pointer(in.Modified))
+	out.LastAccess = (*v1.Time)(unsafe.Pointer(in.LastAccess))
+	out.Cached = in.Cached
+	return nil
+}
+
+// Convert_v1alpha1_FileStat_To_cdn_FileStat is an autogenerated conversion function.
+func Convert_v1alpha1_FileStat_To_cdn_FileStat(in *FileStat, out *cdn.FileStat, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileStat_To_cdn_FileStat(in, out, s)
+}
+
+func autoConvert_cdn_FileStat_To_v1alpha1_FileStat(in *cdn.FileStat, out *FileStat, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	out.Stored = in.Stored
+	out.Size = in.Size
+	out.ContentType = in.ContentType
+	out.Checksum = in.Checksum
+	out.Versions = in.Versions
+	out.Modified = (*v1.Time)(unsafe.Pointer(in.Modified))
+	out.LastAccess = (*v1.Time)(unsafe.Pointer(in.LastAccess))
+	out.Cached = in.Cached
+	return nil
+}
+
+// Convert_cdn_FileStat_To_v1alpha1_FileStat is an autogenerated conversion function.
+func Convert_cdn_FileStat_To_v1alpha1_FileStat(in *cdn.FileStat, out *FileStat, s conversion.Scope) error {
+	return autoConvert_cdn_FileStat_To_v1alpha1_FileStat(in, out, s)
+}
+
 func autoConvert_v1alpha1_FileStatus_To_cdn_FileStatus(in *FileStatus, out *cdn.FileStatus, s conversion.Scope) error {
 	out.Uploaded = in.Uploaded
 	out.Error = in.Error
+	out.Checksum = in.Checksum
+	out.ContentGeneration = in.ContentGeneration
+	out.LastUpload = (*cdn.FileUpload)(unsafe.Pointer(in.LastUpload))
 	return nil
 }
 
@@ -203,6 +769,9 @@ func Convert_v1alpha1_FileStatus_To_cdn_FileStatus(in *FileStatus, out *cdn.File
 func autoConvert_cdn_FileStatus_To_v1alpha1_FileStatus(in *cdn.FileStatus, out *FileStatus, s conversion.Scope) error {
 	out.Uploaded = in.Uploaded
 	out.Error = in.Error
+	out.Checksum = in.Checksum
+	out.ContentGeneration = in.ContentGeneration
+	out.LastUpload = (*FileUpload)(unsafe.Pointer(in.LastUpload))
 	return nil
 }
 
@@ -210,3 +779,371 @@ func autoConvert_cdn_FileStatus_To_v1alpha1_FileStatus(in *cdn.FileStatus, out *
 func Convert_cdn_FileStatus_To_v1alpha1_FileStatus(in *cdn.FileStatus, out *FileStatus, s conversion.Scope) error {
 	return autoConvert_cdn_FileStatus_To_v1alpha1_FileStatus(in, out, s)
 }
+
+func autoConvert_v1alpha1_FileUpload_To_cdn_FileUpload(in *FileUpload, out *cdn.FileUpload, s conversion.Scope) error {
+	out.User = in.User
+	out.Groups = *(*[]string)(unsafe.Pointer(&in.Groups))
+	out.SourceIP = in.SourceIP
+	out.UserAgent = in.UserAgent
+	out.Time = in.Time
+	return nil
+}
+
+// Convert_v1alpha1_FileUpload_To_cdn_FileUpload is an autogenerated conversion function.
+func Convert_v1alpha1_FileUpload_To_cdn_FileUpload(in *FileUpload, out *cdn.FileUpload, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FileUpload_To_cdn_FileUpload(in, out, s)
+}
+
+func autoConvert_cdn_FileUpload_To_v1alpha1_FileUpload(in *cdn.FileUpload, out *FileUpload, s conversion.Scope) error {
+	out.User = in.User
+	out.Groups = *(*[]string)(unsafe.Pointer(&in.Groups))
+	out.SourceIP = in.SourceIP
+	out.UserAgent = in.UserAgent
+	out.Time = in.Time
+	return nil
+}
+
+// Convert_cdn_FileUpload_To_v1alpha1_FileUpload is an autogenerated conversion function.
+func Convert_cdn_FileUpload_To_v1alpha1_FileUpload(in *cdn.FileUpload, out *FileUpload, s conversion.Scope) error {
+	return autoConvert_cdn_FileUpload_To_v1alpha1_FileUpload(in, out, s)
+}
+
+func autoConvert_v1alpha1_Purge_To_cdn_Purge(in *Purge, out *cdn.Purge, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha1_PurgeSpec_To_cdn_PurgeSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1alpha1_PurgeStatus_To_cdn_PurgeStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_Purge_To_cdn_Purge is an autogenerated conversion function.
+func Convert_v1alpha1_Purge_To_cdn_Purge(in *Purge, out *cdn.Purge, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Purge_To_cdn_Purge(in, out, s)
+}
+
+func autoConvert_cdn_Purge_To_v1alpha1_Purge(in *cdn.Purge, out *Purge, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_cdn_PurgeSpec_To_v1alpha1_PurgeSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_cdn_PurgeStatus_To_v1alpha1_PurgeStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_cdn_Purge_To_v1alpha1_Purge is an autogenerated conversion function.
+func Convert_cdn_Purge_To_v1alpha1_Purge(in *cdn.Purge, out *Purge, s conversion.Scope) error {
+	return autoConvert_cdn_Purge_To_v1alpha1_Purge(in, out, s)
+}
+
+func autoConvert_v1alpha1_PurgeList_To_cdn_PurgeList(in *PurgeList, out *cdn.PurgeList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	out.Items = *(*[]cdn.Purge)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_v1alpha1_PurgeList_To_cdn_PurgeList is an autogenerated conversion function.
+func Convert_v1alpha1_PurgeList_To_cdn_PurgeList(in *PurgeList, out *cdn.PurgeList, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PurgeList_To_cdn_PurgeList(in, out, s)
+}
+
+func autoConvert_cdn_PurgeList_To_v1alpha1_PurgeList(in *cdn.PurgeList, out *PurgeList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	out.Items = *(*[]Purge)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_cdn_PurgeList_To_v1alpha1_PurgeList is an autogenerated conversion function.
+func Convert_cdn_PurgeList_To_v1alpha1_PurgeList(in *cdn.PurgeList, out *PurgeList, s conversion.Scope) error {
+	return autoConvert_cdn_PurgeList_To_v1alpha1_PurgeList(in, out, s)
+}
+
+func autoConvert_v1alpha1_PurgeSpec_To_cdn_PurgeSpec(in *PurgeSpec, out *cdn.PurgeSpec, s conversion.Scope) error {
+	out.Names = *(*[]string)(unsafe.Pointer(&in.Names))
+	out.Prefixes = *(*[]string)(unsafe.Pointer(&in.Prefixes))
+	out.Selector = (*v1.LabelSelector)(unsafe.Pointer(in.Selector))
+	return nil
+}
+
+// Convert_v1alpha1_PurgeSpec_To_cdn_PurgeSpec is an autogenerated conversion function.
+func Convert_v1alpha1_PurgeSpec_To_cdn_PurgeSpec(in *PurgeSpec, out *cdn.PurgeSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PurgeSpec_To_cdn_PurgeSpec(in, out, s)
+}
+
+func autoConvert_cdn_PurgeSpec_To_v1alpha1_PurgeSpec(in *cdn.PurgeSpec, out *PurgeSpec, s conversion.Scope) error {
+	out.Names = *(*[]string)(unsafe.Pointer(&in.Names))
+	out.Prefixes = *(*[]string)(unsafe.Pointer(&in.Prefixes))
+	out.Selector = (*v1.LabelSelector)(unsafe.Pointer(in.Selector))
+	return nil
+}
+
+// Convert_cdn_PurgeSpec_To_v1alpha1_PurgeSpec is an autogenerated conversion function.
+func Convert_cdn_PurgeSpec_To_v1alpha1_PurgeSpec(in *cdn.PurgeSpec, out *PurgeSpec, s conversion.Scope) error {
+	return autoConvert_cdn_PurgeSpec_To_v1alpha1_PurgeSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_PurgeStatus_To_cdn_PurgeStatus(in *PurgeStatus, out *cdn.PurgeStatus, s conversion.Scope) error {
+	out.Files = *(*[]string)(unsafe.Pointer(&in.Files))
+	out.Webhooks = *(*[]cdn.PurgeWebhookStatus)(unsafe.Pointer(&in.Webhooks))
+	out.CompletionTime = (*v1.Time)(unsafe.Pointer(in.CompletionTime))
+	return nil
+}
+
+// Convert_v1alpha1_PurgeStatus_To_cdn_PurgeStatus is an autogenerated conversion function.
+func Convert_v1alpha1_PurgeStatus_To_cdn_PurgeStatus(in *PurgeStatus, out *cdn.PurgeStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PurgeStatus_To_cdn_PurgeStatus(in, out, s)
+}
+
+func autoConvert_cdn_PurgeStatus_To_v1alpha1_PurgeStatus(in *cdn.PurgeStatus, out *PurgeStatus, s conversion.Scope) error {
+	out.Files = *(*[]string)(unsafe.Pointer(&in.Files))
+	out.Webhooks = *(*[]PurgeWebhookStatus)(unsafe.Pointer(&in.Webhooks))
+	out.CompletionTime = (*v1.Time)(unsafe.Pointer(in.CompletionTime))
+	return nil
+}
+
+// Convert_cdn_PurgeStatus_To_v1alpha1_PurgeStatus is an autogenerated conversion function.
+func Convert_cdn_PurgeStatus_To_v1alpha1_PurgeStatus(in *cdn.PurgeStatus, out *PurgeStatus, s conversion.Scope) error {
+	return autoConvert_cdn_PurgeStatus_To_v1alpha1_PurgeStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_PurgeWebhookStatus_To_cdn_PurgeWebhookStatus(in *PurgeWebhookStatus, out *cdn.PurgeWebhookStatus, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Code = in.Code
+	out.Error = in.Error
+	return nil
+}
+
+// Convert_v1alpha1_PurgeWebhookStatus_To_cdn_PurgeWebhookStatus is an autogenerated conversion function.
+func Convert_v1alpha1_PurgeWebhookStatus_To_cdn_PurgeWebhookStatus(in *PurgeWebhookStatus, out *cdn.PurgeWebhookStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_PurgeWebhookStatus_To_cdn_PurgeWebhookStatus(in, out, s)
+}
+
+func autoConvert_cdn_PurgeWebhookStatus_To_v1alpha1_PurgeWebhookStatus(in *cdn.PurgeWebhookStatus, out *PurgeWebhookStatus, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Code = in.Code
+	out.Error = in.Error
+	return nil
+}
+
+// Convert_cdn_PurgeWebhookStatus_To_v1alpha1_PurgeWebhookStatus is an autogenerated conversion function.
+func Convert_cdn_PurgeWebhookStatus_To_v1alpha1_PurgeWebhookStatus(in *cdn.PurgeWebhookStatus, out *PurgeWebhookStatus, s conversion.Scope) error {
+	return autoConvert_cdn_PurgeWebhookStatus_To_v1alpha1_PurgeWebhookStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_Site_To_cdn_Site(in *Site, out *cdn.Site, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha1_SiteSpec_To_cdn_SiteSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1alpha1_SiteStatus_To_cdn_SiteStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_Site_To_cdn_Site is an autogenerated conversion function.
+func Convert_v1alpha1_Site_To_cdn_Site(in *Site, out *cdn.Site, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Site_To_cdn_Site(in, out, s)
+}
+
+func autoConvert_cdn_Site_To_v1alpha1_Site(in *cdn.Site, out *Site, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_cdn_SiteSpec_To_v1alpha1_SiteSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_cdn_SiteStatus_To_v1alpha1_SiteStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_cdn_Site_To_v1alpha1_Site is an autogenerated conversion function.
+func Convert_cdn_Site_To_v1alpha1_Site(in *cdn.Site, out *Site, s conversion.Scope) error {
+	return autoConvert_cdn_Site_To_v1alpha1_Site(in, out, s)
+}
+
+func autoConvert_v1alpha1_SiteHeader_To_cdn_SiteHeader(in *SiteHeader, out *cdn.SiteHeader, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Value = in.Value
+	return nil
+}
+
+// Convert_v1alpha1_SiteHeader_To_cdn_SiteHeader is an autogenerated conversion function.
+func Convert_v1alpha1_SiteHeader_To_cdn_SiteHeader(in *SiteHeader, out *cdn.SiteHeader, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SiteHeader_To_cdn_SiteHeader(in, out, s)
+}
+
+func autoConvert_cdn_SiteHeader_To_v1alpha1_SiteHeader(in *cdn.SiteHeader, out *SiteHeader, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Value = in.Value
+	return nil
+}
+
+// Convert_cdn_SiteHeader_To_v1alpha1_SiteHeader is an autogenerated conversion function.
+func Convert_cdn_SiteHeader_To_v1alpha1_SiteHeader(in *cdn.SiteHeader, out *SiteHeader, s conversion.Scope) error {
+	return autoConvert_cdn_SiteHeader_To_v1alpha1_SiteHeader(in, out, s)
+}
+
+func autoConvert_v1alpha1_SiteHeaderRule_To_cdn_SiteHeaderRule(in *SiteHeaderRule, out *cdn.SiteHeaderRule, s conversion.Scope) error {
+	out.PathPrefix = in.PathPrefix
+	out.Headers = *(*[]cdn.SiteHeader)(unsafe.Pointer(&in.Headers))
+	return nil
+}
+
+// Convert_v1alpha1_SiteHeaderRule_To_cdn_SiteHeaderRule is an autogenerated conversion function.
+func Convert_v1alpha1_SiteHeaderRule_To_cdn_SiteHeaderRule(in *SiteHeaderRule, out *cdn.SiteHeaderRule, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SiteHeaderRule_To_cdn_SiteHeaderRule(in, out, s)
+}
+
+func autoConvert_cdn_SiteHeaderRule_To_v1alpha1_SiteHeaderRule(in *cdn.SiteHeaderRule, out *SiteHeaderRule, s conversion.Scope) error {
+	out.PathPrefix = in.PathPrefix
+	out.Headers = *(*[]SiteHeader)(unsafe.Pointer(&in.Headers))
+	return nil
+}
+
+// Convert_cdn_SiteHeaderRule_To_v1alpha1_SiteHeaderRule is an autogenerated conversion function.
+func Convert_cdn_SiteHeaderRule_To_v1alpha1_SiteHeaderRule(in *cdn.SiteHeaderRule, out *SiteHeaderRule, s conversion.Scope) error {
+	return autoConvert_cdn_SiteHeaderRule_To_v1alpha1_SiteHeaderRule(in, out, s)
+}
+
+func autoConvert_v1alpha1_SiteList_To_cdn_SiteList(in *SiteList, out *cdn.SiteList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	out.Items = *(*[]cdn.Site)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_v1alpha1_SiteList_To_cdn_SiteList is an autogenerated conversion function.
+func Convert_v1alpha1_SiteList_To_cdn_SiteList(in *SiteList, out *cdn.SiteList, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SiteList_To_cdn_SiteList(in, out, s)
+}
+
+func autoConvert_cdn_SiteList_To_v1alpha1_SiteList(in *cdn.SiteList, out *SiteList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	out.Items = *(*[]Site)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_cdn_SiteList_To_v1alpha1_SiteList is an autogenerated conversion function.
+func Convert_cdn_SiteList_To_v1alpha1_SiteList(in *cdn.SiteList, out *SiteList, s conversion.Scope) error {
+	return autoConvert_cdn_SiteList_To_v1alpha1_SiteList(in, out, s)
+}
+
+func autoConvert_v1alpha1_SiteRoute_To_cdn_SiteRoute(in *SiteRoute, out *cdn.SiteRoute, s conversion.Scope) error {
+	out.Path = in.Path
+	out.File = in.File
+	return nil
+}
+
+// Convert_v1alpha1_SiteRoute_To_cdn_SiteRoute is an autogenerated conversion function.
+func Convert_v1alpha1_SiteRoute_To_cdn_SiteRoute(in *SiteRoute, out *cdn.SiteRoute, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SiteRoute_To_cdn_SiteRoute(in, out, s)
+}
+
+func autoConvert_cdn_SiteRoute_To_v1alpha1_SiteRoute(in *cdn.SiteRoute, out *SiteRoute, s conversion.Scope) error {
+	out.Path = in.Path
+	out.File = in.File
+	return nil
+}
+
+// Convert_cdn_SiteRoute_To_v1alpha1_SiteRoute is an autogenerated conversion function.
+func Convert_cdn_SiteRoute_To_v1alpha1_SiteRoute(in *cdn.SiteRoute, out *SiteRoute, s conversion.Scope) error {
+	return autoConvert_cdn_SiteRoute_To_v1alpha1_SiteRoute(in, out, s)
+}
+
+func autoConvert_v1alpha1_SiteServeOptions_To_cdn_SiteServeOptions(in *SiteServeOptions, out *cdn.SiteServeOptions, s conversion.Scope) error {
+	out.Path = in.Path
+	return nil
+}
+
+// Convert_v1alpha1_SiteServeOptions_To_cdn_SiteServeOptions is an autogenerated conversion function.
+func Convert_v1alpha1_SiteServeOptions_To_cdn_SiteServeOptions(in *SiteServeOptions, out *cdn.SiteServeOptions, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SiteServeOptions_To_cdn_SiteServeOptions(in, out, s)
+}
+
+func autoConvert_cdn_SiteServeOptions_To_v1alpha1_SiteServeOptions(in *cdn.SiteServeOptions, out *SiteServeOptions, s conversion.Scope) error {
+	out.Path = in.Path
+	return nil
+}
+
+// Convert_cdn_SiteServeOptions_To_v1alpha1_SiteServeOptions is an autogenerated conversion function.
+func Convert_cdn_SiteServeOptions_To_v1alpha1_SiteServeOptions(in *cdn.SiteServeOptions, out *SiteServeOptions, s conversion.Scope) error {
+	return autoConvert_cdn_SiteServeOptions_To_v1alpha1_SiteServeOptions(in, out, s)
+}
+
+func autoConvert_url_Values_To_v1alpha1_SiteServeOptions(in *url.Values, out *SiteServeOptions, s conversion.Scope) error {
+	// WARNING: Field TypeMeta does not have json tag, skipping.
+
+	if values, ok := map[string][]string(*in)["path"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_string(&values, &out.Path, s); err != nil {
+			return err
+		}
+	} else {
+		out.Path = ""
+	}
+	return nil
+}
+
+// Convert_url_Values_To_v1alpha1_SiteServeOptions is an autogenerated conversion function.
+func Convert_url_Values_To_v1alpha1_SiteServeOptions(in *url.Values, out *SiteServeOptions, s conversion.Scope) error {
+	return autoConvert_url_Values_To_v1alpha1_SiteServeOptions(in, out, s)
+}
+
+func autoConvert_v1alpha1_SiteSpec_To_cdn_SiteSpec(in *SiteSpec, out *cdn.SiteSpec, s conversion.Scope) error {
+	out.Selector = (*v1.LabelSelector)(unsafe.Pointer(in.Selector))
+	out.Routes = *(*[]cdn.SiteRoute)(unsafe.Pointer(&in.Routes))
+	out.IndexDocument = in.IndexDocument
+	out.NotFoundDocument = in.NotFoundDocument
+	out.SPAFallback = in.SPAFallback
+	out.Headers = *(*[]cdn.SiteHeaderRule)(unsafe.Pointer(&in.Headers))
+	return nil
+}
+
+// Convert_v1alpha1_SiteSpec_To_cdn_SiteSpec is an autogenerated conversion function.
+func Convert_v1alpha1_SiteSpec_To_cdn_SiteSpec(in *SiteSpec, out *cdn.SiteSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SiteSpec_To_cdn_SiteSpec(in, out, s)
+}
+
+func autoConvert_cdn_SiteSpec_To_v1alpha1_SiteSpec(in *cdn.SiteSpec, out *SiteSpec, s conversion.Scope) error {
+	out.Selector = (*v1.LabelSelector)(unsafe.Pointer(in.Selector))
+	out.Routes = *(*[]SiteRoute)(unsafe.Pointer(&in.Routes))
+	out.IndexDocument = in.IndexDocument
+	out.NotFoundDocument = in.NotFoundDocument
+	out.SPAFallback = in.SPAFallback
+	out.Headers = *(*[]SiteHeaderRule)(unsafe.Pointer(&in.Headers))
+	return nil
+}
+
+// Convert_cdn_SiteSpec_To_v1alpha1_SiteSpec is an autogenerated conversion function.
+func Convert_cdn_SiteSpec_To_v1alpha1_SiteSpec(in *cdn.SiteSpec, out *SiteSpec, s conversion.Scope) error {
+	return autoConvert_cdn_SiteSpec_To_v1alpha1_SiteSpec(in, out, s)
+}
+
+func autoConvert_v1alpha1_SiteStatus_To_cdn_SiteStatus(in *SiteStatus, out *cdn.SiteStatus, s conversion.Scope) error {
+	out.ObservedGeneration = in.ObservedGeneration
+	out.Files = in.Files
+	out.TotalSize = in.TotalSize
+	out.MissingFiles = *(*[]string)(unsafe.Pointer(&in.MissingFiles))
+	return nil
+}
+
+// Convert_v1alpha1_SiteStatus_To_cdn_SiteStatus is an autogenerated conversion function.
+func Convert_v1alpha1_SiteStatus_To_cdn_SiteStatus(in *SiteStatus, out *cdn.SiteStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SiteStatus_To_cdn_SiteStatus(in, out, s)
+}
+
+func autoConvert_cdn_SiteStatus_To_v1alpha1_SiteStatus(in *cdn.SiteStatus, out *SiteStatus, s conversion.Scope) error {
+	out.ObservedGeneration = in.ObservedGeneration
+	out.Files = in.Files
+	out.TotalSize = in.TotalSize
+	out.MissingFiles = *(*[]string)(unsafe.Pointer(&in.MissingFiles))
+	return nil
+}
+
+// Convert_cdn_SiteStatus_To_v1alpha1_SiteStatus is an autogenerated conversion function.
+func Convert_cdn_SiteStatus_To_v1alpha1_SiteStatus(in *cdn.SiteStatus, out *SiteStatus, s conversion.Scope) error {
+	return autoConvert_cdn_SiteStatus_To_v1alpha1_SiteStatus(in, out, s)
+}
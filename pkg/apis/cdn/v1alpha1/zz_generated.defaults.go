@@ -30,7 +30,11 @@ import (
 // All generated defaulters are covering - they call all nested defaulters.
 func RegisterDefaults(scheme *runtime.Scheme) error {
 	scheme.AddTypeDefaultingFunc(&File{}, func(obj interface{}) { SetObjectDefaults_File(obj.(*File)) })
+	scheme.AddTypeDefaultingFunc(&FileArchiveManifest{}, func(obj interface{}) { SetObjectDefaults_FileArchiveManifest(obj.(*FileArchiveManifest)) })
 	scheme.AddTypeDefaultingFunc(&FileList{}, func(obj interface{}) { SetObjectDefaults_FileList(obj.(*FileList)) })
+	scheme.AddTypeDefaultingFunc(&FileSignedURL{}, func(obj interface{}) { SetObjectDefaults_FileSignedURL(obj.(*FileSignedURL)) })
+	scheme.AddTypeDefaultingFunc(&Site{}, func(obj interface{}) { SetObjectDefaults_Site(obj.(*Site)) })
+	scheme.AddTypeDefaultingFunc(&SiteList{}, func(obj interface{}) { SetObjectDefaults_SiteList(obj.(*SiteList)) })
 	return nil
 }
 
@@ -38,9 +42,31 @@ func SetObjectDefaults_File(in *File) {
 	SetDefaults_FileSpec(&in.Spec)
 }
 
+func SetObjectDefaults_FileArchiveManifest(in *FileArchiveManifest) {
+	for i := range in.Files {
+		a := &in.Files[i]
+		SetObjectDefaults_File(a)
+	}
+}
+
 func SetObjectDefaults_FileList(in *FileList) {
 	for i := range in.Items {
 		a := &in.Items[i]
 		SetObjectDefaults_File(a)
 	}
 }
+
+func SetObjectDefaults_FileSignedURL(in *FileSignedURL) {
+	SetDefaults_FileSignedURLSpec(&in.Spec)
+}
+
+func SetObjectDefaults_Site(in *Site) {
+	SetDefaults_SiteSpec(&in.Spec)
+}
+
+func SetObjectDefaults_SiteList(in *SiteList) {
+	for i := range in.Items {
+		a := &in.Items[i]
+		SetObjectDefaults_Site(a)
+	}
+}
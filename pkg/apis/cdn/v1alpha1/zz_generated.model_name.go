@@ -26,22 +26,137 @@ func (in File) OpenAPIModelName() string {
 	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.File"
 }
 
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileArchive) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileArchive"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileArchiveEntry) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileArchiveEntry"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileArchiveManifest) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileArchiveManifest"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileArchiveOptions) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileArchiveOptions"
+}
+
 // OpenAPIModelName returns the OpenAPI model name for this type.
 func (in FileContent) OpenAPIModelName() string {
 	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileContent"
 }
 
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileCopyOptions) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileCopyOptions"
+}
+
 // OpenAPIModelName returns the OpenAPI model name for this type.
 func (in FileList) OpenAPIModelName() string {
 	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileList"
 }
 
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileSignedURL) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileSignedURL"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileSignedURLSpec) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileSignedURLSpec"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileSignedURLStatus) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileSignedURLStatus"
+}
+
 // OpenAPIModelName returns the OpenAPI model name for this type.
 func (in FileSpec) OpenAPIModelName() string {
 	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileSpec"
 }
 
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileStat) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileStat"
+}
+
 // OpenAPIModelName returns the OpenAPI model name for this type.
 func (in FileStatus) OpenAPIModelName() string {
 	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileStatus"
 }
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in FileUpload) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.FileUpload"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in Purge) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.Purge"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in PurgeList) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.PurgeList"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in PurgeSpec) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.PurgeSpec"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in PurgeStatus) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.PurgeStatus"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in PurgeWebhookStatus) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.PurgeWebhookStatus"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in Site) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.Site"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in SiteHeader) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.SiteHeader"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in SiteHeaderRule) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.SiteHeaderRule"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in SiteList) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.SiteList"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in SiteRoute) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.SiteRoute"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in SiteServeOptions) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.SiteServeOptions"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in SiteSpec) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.SiteSpec"
+}
+
+// OpenAPIModelName returns the OpenAPI model name for this type.
+func (in SiteStatus) OpenAPIModelName() string {
+	return "place.toms.k8s.apiserver.pkg.apis.cdn.v1alpha1.SiteStatus"
+}
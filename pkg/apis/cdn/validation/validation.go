@@ -17,8 +17,16 @@ limitations under the License.
 package validation
 
 import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+	apimachineryvalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
 )
 
 // ValidateFile validates a File.
@@ -36,3 +44,184 @@ func ValidateFileSpec(s *cdn.FileSpec, fldPath *field.Path) field.ErrorList {
 
 	return allErrs
 }
+
+// ValidateFileStatusUpdate validates an update of the status of a File. The
+// content generation is part of the content's ETag, so it never goes back.
+func ValidateFileStatusUpdate(f, old *cdn.File) field.ErrorList {
+	fldPath := field.NewPath("status")
+	allErrs := ValidateFileStatus(&f.Status, fldPath)
+
+	if f.Status.ContentGeneration < old.Status.ContentGeneration {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("contentGeneration"), f.Status.ContentGeneration, fmt.Sprintf("must not be less than %d", old.Status.ContentGeneration)))
+	}
+
+	return allErrs
+}
+
+// ValidateFileStatus validates a FileStatus.
+func ValidateFileStatus(s *cdn.FileStatus, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if s.Checksum != "" && !isSHA256Hex(s.Checksum) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("checksum"), s.Checksum, "must be a lowercase hex-encoded SHA-256 digest"))
+	}
+	if s.Uploaded && s.Checksum == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("checksum"), "required when uploaded is true"))
+	}
+	if s.ContentGeneration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("contentGeneration"), s.ContentGeneration, "must not be negative"))
+	}
+
+	return allErrs
+}
+
+// isSHA256Hex returns whether s is a lowercase hex-encoded SHA-256 digest
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateFileSignedURL validates a request for a signed URL.
+func ValidateFileSignedURL(u *cdn.FileSignedURL) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	fldPath := field.NewPath("spec", "expirationSeconds")
+	if u.Spec.ExpirationSeconds == nil {
+		allErrs = append(allErrs, field.Required(fldPath, ""))
+	} else if seconds := *u.Spec.ExpirationSeconds; seconds < cdnv1alpha1.MinSignedURLExpirationSeconds || seconds > cdnv1alpha1.MaxSignedURLExpirationSeconds {
+		allErrs = append(allErrs, field.Invalid(fldPath, seconds, fmt.Sprintf("must be between %d and %d", cdnv1alpha1.MinSignedURLExpirationSeconds, cdnv1alpha1.MaxSignedURLExpirationSeconds)))
+	}
+
+	return allErrs
+}
+
+// ValidateSite validates a Site.
+func ValidateSite(s *cdn.Site) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, ValidateSiteSpec(&s.Spec, field.NewPath("spec"))...)
+
+	return allErrs
+}
+
+// ValidateSiteSpec validates a SiteSpec.
+func ValidateSiteSpec(s *cdn.SiteSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if s.Selector == nil && len(s.Routes) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "either selector or routes must be set"))
+	}
+	if s.Selector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(s.Selector, metav1validation.LabelSelectorValidationOptions{}, fldPath.Child("selector"))...)
+	}
+
+	seen := map[string]bool{}
+	for i, route := range s.Routes {
+		idxPath := fldPath.Child("routes").Index(i)
+		allErrs = append(allErrs, validateSitePath(route.Path, idxPath.Child("path"))...)
+		if seen[route.Path] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("path"), route.Path))
+		}
+		seen[route.Path] = true
+
+		if len(route.File) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath.Child("file"), ""))
+		} else {
+			for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(route.File, false) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("file"), route.File, msg))
+			}
+		}
+	}
+
+	if len(s.IndexDocument) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("indexDocument"), ""))
+	} else if strings.Contains(s.IndexDocument, "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("indexDocument"), s.IndexDocument, "must not contain '/'"))
+	}
+	if len(s.NotFoundDocument) > 0 {
+		allErrs = append(allErrs, validateSitePath(s.NotFoundDocument, fldPath.Child("notFoundDocument"))...)
+	}
+
+	for i, rule := range s.Headers {
+		idxPath := fldPath.Child("headers").Index(i)
+		allErrs = append(allErrs, validateSitePath(rule.PathPrefix, idxPath.Child("pathPrefix"))...)
+		for j, header := range rule.Headers {
+			if !httpguts.ValidHeaderFieldName(header.Name) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("headers").Index(j).Child("name"), header.Name, "must be a valid HTTP header name"))
+			}
+			if !httpguts.ValidHeaderFieldValue(header.Value) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("headers").Index(j).Child("value"), header.Value, "must be a valid HTTP header value"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateSitePath validates an absolute, clean URL path.
+func validateSitePath(p string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(p) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, ""))
+	} else if !strings.HasPrefix(p, "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath, p, "must be an absolute path"))
+	} else if cleaned := path.Clean(p); cleaned != p && cleaned+"/" != p {
+		allErrs = append(allErrs, field.Invalid(fldPath, p, "must be a clean path"))
+	}
+
+	return allErrs
+}
+
+// ValidatePurge validates a Purge.
+func ValidatePurge(p *cdn.Purge) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	allErrs = append(allErrs, ValidatePurgeSpec(&p.Spec, field.NewPath("spec"))...)
+
+	return allErrs
+}
+
+// ValidatePurgeUpdate validates an update of a Purge. The spec is immutable.
+func ValidatePurgeUpdate(p, old *cdn.Purge) field.ErrorList {
+	allErrs := ValidatePurge(p)
+
+	allErrs = append(allErrs, apimachineryvalidation.ValidateImmutableField(p.Spec, old.Spec, field.NewPath("spec"))...)
+
+	return allErrs
+}
+
+// ValidatePurgeSpec validates a PurgeSpec.
+func ValidatePurgeSpec(s *cdn.PurgeSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(s.Names) == 0 && len(s.Prefixes) == 0 && s.Selector == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "one of names, prefixes or selector must be set"))
+	}
+	for i, name := range s.Names {
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(name, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("names").Index(i), name, msg))
+		}
+	}
+	for i, prefix := range s.Prefixes {
+		if len(prefix) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("prefixes").Index(i), ""))
+			continue
+		}
+		for _, msg := range apimachineryvalidation.NameIsDNSSubdomain(prefix, true) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("prefixes").Index(i), prefix, msg))
+		}
+	}
+	if s.Selector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(s.Selector, metav1validation.LabelSelectorValidationOptions{}, fldPath.Child("selector"))...)
+	}
+
+	return allErrs
+}
@@ -23,11 +23,20 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apiserver/pkg/registry/rest"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/tools/record"
 
 	"k8s.toms.place/apiserver/pkg/apis/cdn"
 	cdninstall "k8s.toms.place/apiserver/pkg/apis/cdn/install"
+	"k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/content/replica"
+	"k8s.toms.place/apiserver/pkg/content/signedurl"
+	"k8s.toms.place/apiserver/pkg/events"
+	cdninformers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions/cdn/v1alpha1"
 	registry "k8s.toms.place/apiserver/pkg/registry"
 	filestorage "k8s.toms.place/apiserver/pkg/registry/cdn/file"
+	purgestorage "k8s.toms.place/apiserver/pkg/registry/cdn/purge"
+	sitestorage "k8s.toms.place/apiserver/pkg/registry/cdn/site"
 )
 
 var (
@@ -35,7 +44,10 @@ var (
 	Scheme = runtime.NewScheme()
 	// Codecs provides methods for retrieving codecs and serializers for specific
 	// versions and content types.
-	Codecs           = serializer.NewCodecFactory(Scheme)
+	Codecs = serializer.NewCodecFactory(Scheme)
+	// ParameterCodec handles versioning of query parameters, including the
+	// connect options of subresources.
+	ParameterCodec   = runtime.NewParameterCodec(Scheme)
 	CDNComponentName = "cdn"
 )
 
@@ -62,6 +74,29 @@ type ExtraConfig struct {
 	// ExternalHost is the host used to construct URLs for file content endpoints.
 	// If empty, the request's Host header will be used.
 	ExternalHost string
+	// ContentStore holds the bytes behind File objects.
+	// If nil, content is kept in memory.
+	ContentStore content.Store
+	// ContentReplica shares content with the other replicas of the server.
+	// If set, it replaces ContentStore and its peer endpoint is installed.
+	ContentReplica *replica.Store
+	// ArchiveLimits bounds archive uploads. Unset limits use their defaults.
+	ArchiveLimits filestorage.ArchiveLimits
+	// ContentCache configures the hot-content cache in front of ContentStore.
+	// The cache is disabled if ContentCache.MaxBytes is zero.
+	ContentCache content.CacheOptions
+	// EventRecorder records Events against Files.
+	// If nil, Events are discarded.
+	EventRecorder record.EventRecorder
+	// URLSigner signs URLs for file content.
+	// If nil, a random key is used and signed URLs are only valid for this process.
+	URLSigner *signedurl.Signer
+	// FileInformer watches Files for the routers of served Sites.
+	// If nil, serving a Site lists its Files on every request.
+	FileInformer cdninformers.FileInformer
+	// FsckRefetchOrigins are the "<scheme>://<host>" origins repairs may fetch
+	// content again from. If empty, content is never fetched again.
+	FsckRefetchOrigins []string
 }
 
 // Config defines the config for the apiserver
@@ -92,6 +127,28 @@ func (cfg *Config) Complete() CompletedConfig {
 		&cfg.ExtraConfig,
 	}
 
+	if c.ExtraConfig.ContentReplica != nil {
+		c.ExtraConfig.ContentStore = c.ExtraConfig.ContentReplica
+	}
+	if c.ExtraConfig.ContentStore == nil {
+		c.ExtraConfig.ContentStore = content.NewMemoryStore()
+	}
+	if c.ExtraConfig.EventRecorder == nil {
+		c.ExtraConfig.EventRecorder = events.Discard()
+	}
+	if c.ExtraConfig.URLSigner == nil {
+		c.ExtraConfig.URLSigner = signedurl.NewRandomSigner()
+	}
+	content.RegisterMetrics()
+	if c.ExtraConfig.ContentCache.MaxBytes > 0 {
+		cached := content.NewCachedStore(c.ExtraConfig.ContentStore, c.ExtraConfig.ContentCache)
+		if c.ExtraConfig.ContentReplica != nil {
+			// Content replaced on other replicas must not be served from the cache
+			c.ExtraConfig.ContentReplica.AddInvalidator(cached)
+		}
+		c.ExtraConfig.ContentStore = cached
+	}
+
 	return CompletedConfig{&c}
 }
 
@@ -107,17 +164,39 @@ func (c completedConfig) New() (*Server, error) {
 	}
 
 	// Install CDN API group
-	cdnAPIGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(cdn.GroupName, Scheme, metav1.ParameterCodec, Codecs)
+	cdnAPIGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(cdn.GroupName, Scheme, ParameterCodec, Codecs)
 
-	fileStorage := registry.RESTInPeace(filestorage.NewREST(Scheme, c.GenericConfig.RESTOptionsGetter))
+	fileStorage := registry.RESTInPeace(filestorage.NewREST(Scheme, c.GenericConfig.RESTOptionsGetter, c.ExtraConfig.ContentStore))
+	siteStorage := registry.RESTInPeace(sitestorage.NewREST(Scheme, c.GenericConfig.RESTOptionsGetter))
+	purgeStorage := registry.RESTInPeace(purgestorage.NewREST(Scheme, c.GenericConfig.RESTOptionsGetter))
+	fileStatus := filestorage.NewStatusREST(Scheme, fileStorage)
+	siteRouters, err := sitestorage.NewRouterCache(c.ExtraConfig.FileInformer)
+	if err != nil {
+		return nil, err
+	}
 	cdnV1alpha1storage := map[string]rest.Storage{}
 	cdnV1alpha1storage["files"] = fileStorage
-	cdnV1alpha1storage["files/content"] = filestorage.NewContentREST(fileStorage, c.ExtraConfig.ExternalHost)
+	cdnV1alpha1storage["files/status"] = fileStatus
+	cdnV1alpha1storage["files/content"] = filestorage.NewContentREST(fileStorage, fileStatus, c.ExtraConfig.ContentStore, c.ExtraConfig.EventRecorder, c.GenericConfig.Authorization.Authorizer, c.ExtraConfig.ExternalHost)
+	cdnV1alpha1storage["files/archive"] = filestorage.NewArchiveREST(fileStorage, fileStatus, c.ExtraConfig.ContentStore, c.ExtraConfig.EventRecorder, c.GenericConfig.Authorization.Authorizer, c.ExtraConfig.ExternalHost, c.ExtraConfig.ArchiveLimits, Codecs.LegacyCodec(v1alpha1.SchemeGroupVersion))
+	cdnV1alpha1storage["files/copy"] = filestorage.NewCopyREST(fileStorage, fileStatus, c.ExtraConfig.ContentStore, c.ExtraConfig.EventRecorder, c.GenericConfig.Authorization.Authorizer, c.ExtraConfig.ExternalHost)
+	cdnV1alpha1storage["files/stat"] = filestorage.NewStatREST(fileStorage, c.ExtraConfig.ContentStore)
+	cdnV1alpha1storage["files/signedurl"] = filestorage.NewSignedURLREST(fileStorage, c.ExtraConfig.URLSigner, c.GenericConfig.Authorization.Authorizer, c.ExtraConfig.ExternalHost)
+	cdnV1alpha1storage["sites"] = siteStorage
+	cdnV1alpha1storage["sites/status"] = sitestorage.NewStatusREST(Scheme, siteStorage)
+	cdnV1alpha1storage["sites/serve"] = sitestorage.NewServeREST(siteStorage, fileStorage, c.ExtraConfig.ContentStore, c.ExtraConfig.EventRecorder, c.GenericConfig.Authorization.Authorizer, siteRouters)
+	cdnV1alpha1storage["purges"] = purgeStorage
+	cdnV1alpha1storage["purges/status"] = purgestorage.NewStatusREST(Scheme, purgeStorage)
 	cdnAPIGroupInfo.VersionedResourcesStorageMap["v1alpha1"] = cdnV1alpha1storage
 
 	if err := s.GenericAPIServer.InstallAPIGroup(&cdnAPIGroupInfo); err != nil {
 		return nil, err
 	}
+	s.GenericAPIServer.Handler.NonGoRestfulMux.Handle(filestorage.FsckPath, filestorage.NewFsck(fileStorage, fileStatus, c.ExtraConfig.ContentStore, c.ExtraConfig.EventRecorder, c.ExtraConfig.FsckRefetchOrigins))
+	s.GenericAPIServer.Handler.NonGoRestfulMux.HandlePrefix(filestorage.SignedContentPath, filestorage.NewSignedContent(fileStorage, c.ExtraConfig.ContentStore, c.ExtraConfig.URLSigner, c.ExtraConfig.EventRecorder))
+	if c.ExtraConfig.ContentReplica != nil {
+		s.GenericAPIServer.Handler.NonGoRestfulMux.HandlePrefix(replica.PeerPath, c.ExtraConfig.ContentReplica.Handler())
+	}
 
 	return s, nil
 }
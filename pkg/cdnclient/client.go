@@ -0,0 +1,237 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cdnclient transfers the content of Files. The generated clientset
+// covers the File objects; this package adds the content, stat, copy and
+// signedurl subresources with streaming, checksum verification, retries and
+// resumed downloads.
+package cdnclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/scheme"
+	cdnclientset "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+var (
+	// ErrChecksumMismatch is returned when transferred content does not have
+	// the checksum it was sent with
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrContentChanged is returned when the content changed while it was
+	// downloaded and the writer cannot be rewound to start over
+	ErrContentChanged = errors.New("content changed during download")
+)
+
+// DefaultBackoff is the backoff between attempts of transfers failing with
+// transient errors when the options do not set one. Its Steps are the number
+// of retries.
+var DefaultBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      30 * time.Second,
+}
+
+// Interface transfers the content of Files
+type Interface interface {
+	Files(namespace string) FileInterface
+}
+
+// FileInterface transfers the content of the Files in a namespace
+type FileInterface interface {
+	// Upload stores the content read from r for the named File, creating the
+	// File if it does not exist.
+	Upload(ctx context.Context, name string, r io.Reader, opts UploadOptions) (*ContentInfo, error)
+	// Download writes the content of the named File to w.
+	Download(ctx context.Context, name string, w io.Writer, opts DownloadOptions) (*ContentInfo, error)
+	// Stat describes the content stored for the named File without reading
+	// it.
+	Stat(ctx context.Context, name string) (*cdnv1alpha1.FileStat, error)
+	// Copy copies the content of the named File to another File on the
+	// server, or moves it.
+	Copy(ctx context.Context, name string, opts cdnv1alpha1.FileCopyOptions) (*cdnv1alpha1.FileContent, error)
+	// SignURL returns a URL serving the content of the named File without
+	// credentials until it expires. A zero expiration uses the server's
+	// default of one hour.
+	SignURL(ctx context.Context, name string, expiration time.Duration) (*cdnv1alpha1.FileSignedURL, error)
+	// ReadRange reads length bytes of the content of the named File from
+	// offset on, or up to its end if length is not positive. A negative
+	// offset reads the last -offset bytes.
+	ReadRange(ctx context.Context, name string, offset, length int64) (*ContentRange, error)
+}
+
+// ProgressFunc is called as content is transferred with the number of bytes
+// transferred so far and the total, or -1 if it is not known
+type ProgressFunc func(transferred, total int64)
+
+// RetryFunc is called before a transfer is retried after the transient error
+// err, with the delay until the retry, its number and the number of retries
+// allowed
+type RetryFunc func(err error, delay time.Duration, retry, retries int)
+
+// UploadOptions are the options of an upload
+type UploadOptions struct {
+	// ContentType of the content. If empty, it is detected from the first
+	// 512 bytes.
+	ContentType string
+	// IfMatch makes the upload conditional on the ETag of the current
+	// content, so a concurrent change fails the upload with a Conflict
+	// instead of being overwritten. "*" requires any content.
+	IfMatch string
+	// Backoff between retries after transient errors; DefaultBackoff if nil.
+	// Only content read from an io.Seeker can be sent again, other uploads
+	// are not retried.
+	Backoff *wait.Backoff
+	// Progress is called as the content is sent, if set. Retries report
+	// their progress from the start.
+	Progress ProgressFunc
+	// OnRetry is called before each retry, if set
+	OnRetry RetryFunc
+}
+
+// DownloadOptions are the options of a download
+type DownloadOptions struct {
+	// Backoff between retries after transient errors; DefaultBackoff if nil.
+	// Retries resume where the failed attempt stopped.
+	Backoff *wait.Backoff
+	// Resume continues an earlier download into w, which must then be an
+	// io.ReadWriteSeeker like os.File: the content already in w is kept
+	// and only the rest is requested. If the result does not have the
+	// checksum of the content, the download starts over.
+	Resume bool
+	// Progress is called as the content is received, if set. Resumed
+	// downloads report their progress including the bytes kept.
+	Progress ProgressFunc
+	// OnRetry is called before each retry, if set
+	OnRetry RetryFunc
+}
+
+// ContentInfo describes transferred content
+type ContentInfo struct {
+	// Size is the number of bytes transferred
+	Size int64
+	// ContentType of the content
+	ContentType string
+	// Checksum is the hex-encoded SHA-256 digest of the content
+	Checksum string
+	// ETag identifies the downloaded content in conditional requests. It is
+	// not known after uploads.
+	ETag string
+}
+
+// ContentRange is a part of the content of a File
+type ContentRange struct {
+	// Data are the bytes of the range. It is empty if the content ends
+	// before the offset.
+	Data []byte
+	// Offset of Data in the content
+	Offset int64
+	// Size of the whole content, or -1 if it is not known
+	Size int64
+}
+
+// Client transfers the content of Files with the CDN API
+type Client struct {
+	restClient rest.Interface
+	httpClient *http.Client
+}
+
+var _ Interface = &Client{}
+
+// NewForConfig creates a Client for the API server of config
+func NewForConfig(config *rest.Config) (*Client, error) {
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewForConfigAndClient(config, httpClient)
+}
+
+// NewForConfigAndClient creates a Client for the API server of config that
+// sends its requests with httpClient
+func NewForConfigAndClient(config *rest.Config, httpClient *http.Client) (*Client, error) {
+	files, err := cdnclientset.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{restClient: files.RESTClient(), httpClient: httpClient}, nil
+}
+
+// Files returns the content operations on the Files in namespace
+func (c *Client) Files(namespace string) FileInterface {
+	return &files{client: c, namespace: namespace}
+}
+
+// files implements FileInterface with the CDN API
+type files struct {
+	client    *Client
+	namespace string
+}
+
+// Stat describes the content stored for the named File
+func (f *files) Stat(ctx context.Context, name string) (*cdnv1alpha1.FileStat, error) {
+	stat := &cdnv1alpha1.FileStat{}
+	if err := f.subresource(http.MethodGet, name, "stat").Do(ctx).Into(stat); err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+// Copy copies the content of the named File as described by opts
+func (f *files) Copy(ctx context.Context, name string, opts cdnv1alpha1.FileCopyOptions) (*cdnv1alpha1.FileContent, error) {
+	result := &cdnv1alpha1.FileContent{}
+	err := f.subresource(http.MethodPost, name, "copy").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SignURL signs a URL for the content of the named File
+func (f *files) SignURL(ctx context.Context, name string, expiration time.Duration) (*cdnv1alpha1.FileSignedURL, error) {
+	signedURL := &cdnv1alpha1.FileSignedURL{}
+	if expiration != 0 {
+		seconds := int64(expiration / time.Second)
+		signedURL.Spec.ExpirationSeconds = &seconds
+	}
+	result := &cdnv1alpha1.FileSignedURL{}
+	if err := f.subresource(http.MethodPost, name, "signedurl").Body(signedURL).Do(ctx).Into(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// subresource returns a request for a subresource of the named File
+func (f *files) subresource(verb, name, subresource string) *rest.Request {
+	return f.client.restClient.Verb(verb).
+		Namespace(f.namespace).
+		Resource("files").
+		Name(name).
+		SubResource(subresource)
+}
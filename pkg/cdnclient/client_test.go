@@ -0,0 +1,393 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdnclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+const contentPath = "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/web/files/index.html/content"
+
+var testBackoff = &wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 2}
+
+// newTestClient returns a Client for an API server answering with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return client
+}
+
+func writeJSON(w http.ResponseWriter, code int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(obj)
+}
+
+func sha256Hex(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+func TestUpload(t *testing.T) {
+	data := []byte("<h1>Hello</h1>")
+	digest := sha256.Sum256(data)
+
+	testCases := []struct {
+		desc      string
+		responses []int
+		wantCalls int
+		wantError func(error) bool
+	}{
+		{desc: "success", responses: []int{http.StatusCreated}, wantCalls: 1},
+		{desc: "retried server error", responses: []int{http.StatusServiceUnavailable, http.StatusCreated}, wantCalls: 2},
+		{desc: "retries exhausted", responses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}, wantCalls: 3, wantError: apierrors.IsInternalError},
+		{desc: "conflict is not retried", responses: []int{http.StatusPreconditionFailed}, wantCalls: 1, wantError: apierrors.IsConflict},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			calls := 0
+			client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, http.MethodPut, req.Method)
+				assert.Equal(t, contentPath, req.URL.Path)
+				assert.Equal(t, "text/html; charset=utf-8", req.Header.Get("Content-Type"))
+				assert.Equal(t, "sha-256=:"+base64.StdEncoding.EncodeToString(digest[:])+":", req.Header.Get("Content-Digest"))
+				assert.Equal(t, `"abc-1"`, req.Header.Get("If-Match"))
+				body, _ := io.ReadAll(req.Body)
+				assert.Equal(t, data, body)
+
+				code := tc.responses[calls]
+				calls++
+				status := metav1.Status{TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}, Code: int32(code)}
+				switch code {
+				case http.StatusCreated:
+					status.Status = metav1.StatusSuccess
+				case http.StatusPreconditionFailed:
+					status.Status, status.Reason = metav1.StatusFailure, metav1.StatusReasonConflict
+				default:
+					status.Status, status.Reason = metav1.StatusFailure, metav1.StatusReasonInternalError
+				}
+				writeJSON(w, code, status)
+			})
+
+			info, err := client.Files("web").Upload(context.Background(), "index.html", bytes.NewReader(data), UploadOptions{IfMatch: `"abc-1"`, Backoff: testBackoff})
+			assert.Equal(t, tc.wantCalls, calls)
+			if tc.wantError != nil {
+				assert.True(t, tc.wantError(err), "unexpected error %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &ContentInfo{Size: int64(len(data)), ContentType: "text/html; charset=utf-8", Checksum: sha256Hex(data)}, info)
+		})
+	}
+}
+
+func TestUploadStream(t *testing.T) {
+	data := []byte("streamed content")
+
+	testCases := []struct {
+		desc           string
+		storedChecksum string
+		wantError      error
+	}{
+		{desc: "verified", storedChecksum: sha256Hex(data)},
+		{desc: "checksum mismatch", storedChecksum: sha256Hex([]byte("other")), wantError: ErrChecksumMismatch},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.Method == http.MethodPut && req.URL.Path == contentPath:
+					assert.Empty(t, req.Header.Get("Content-Digest"))
+					assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+					body, _ := io.ReadAll(req.Body)
+					assert.Equal(t, data, body)
+					writeJSON(w, http.StatusCreated, metav1.Status{TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}, Status: metav1.StatusSuccess, Code: http.StatusCreated})
+				case req.Method == http.MethodGet && strings.HasSuffix(req.URL.Path, "/index.html/stat"):
+					writeJSON(w, http.StatusOK, cdnv1alpha1.FileStat{Stored: true, Checksum: tc.storedChecksum})
+				default:
+					t.Errorf("unexpected request %s %s", req.Method, req.URL)
+				}
+			})
+
+			// A pipe cannot be read again
+			r, w := io.Pipe()
+			go func() {
+				w.Write(data)
+				w.Close()
+			}()
+			info, err := client.Files("web").Upload(context.Background(), "index.html", r, UploadOptions{ContentType: "application/json"})
+			if tc.wantError != nil {
+				assert.ErrorIs(t, err, tc.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(data)), info.Size)
+			assert.Equal(t, sha256Hex(data), info.Checksum)
+		})
+	}
+}
+
+// contentServer serves content like the API server, failing the first
+// attempt after half of it and changing it to next once that happened if
+// next is set
+type contentServer struct {
+	data []byte
+	next []byte
+
+	lock     sync.Mutex
+	requests []http.Header
+}
+
+func (s *contentServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.lock.Lock()
+	s.requests = append(s.requests, req.Header.Clone())
+	first := len(s.requests) == 1
+	s.lock.Unlock()
+
+	data, generation := s.data, 1
+	if !first && s.next != nil {
+		data, generation = s.next, 2
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%s-%d"`, sha256Hex(data), generation))
+	w.Header().Set("Content-Type", "text/plain")
+	if first {
+		// Fail the transfer halfway through
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Write(data[:len(data)/2])
+		return
+	}
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(data))
+}
+
+func TestDownload(t *testing.T) {
+	data := []byte("0123456789abcdefghij")
+	changed := []byte("the content changed")
+
+	t.Run("resumed", func(t *testing.T) {
+		server := &contentServer{data: data}
+		client := newTestClient(t, server.ServeHTTP)
+
+		var buf bytes.Buffer
+		var retries []int
+		var transferred, total int64
+		opts := DownloadOptions{
+			Backoff: testBackoff,
+			Progress: func(n, size int64) {
+				transferred, total = n, size
+			},
+			OnRetry: func(err error, delay time.Duration, retry, limit int) {
+				retries = append(retries, retry)
+				assert.Equal(t, testBackoff.Steps, limit)
+			},
+		}
+		info, err := client.Files("web").Download(context.Background(), "index.html", &buf, opts)
+		require.NoError(t, err)
+		assert.Equal(t, data, buf.Bytes())
+		assert.Equal(t, &ContentInfo{Size: int64(len(data)), ContentType: "text/plain", Checksum: sha256Hex(data), ETag: fmt.Sprintf(`"%s-1"`, sha256Hex(data))}, info)
+		assert.Equal(t, []int{1}, retries)
+		assert.Equal(t, int64(len(data)), transferred)
+		assert.Equal(t, int64(len(data)), total)
+
+		require.Len(t, server.requests, 2)
+		assert.Equal(t, "bytes=10-", server.requests[1].Get("Range"))
+		assert.Equal(t, info.ETag, server.requests[1].Get("If-Range"))
+	})
+
+	t.Run("restarted when the content changed", func(t *testing.T) {
+		server := &contentServer{data: data, next: changed}
+		client := newTestClient(t, server.ServeHTTP)
+
+		var buf bytes.Buffer
+		info, err := client.Files("web").Download(context.Background(), "index.html", &buf, DownloadOptions{Backoff: testBackoff})
+		require.NoError(t, err)
+		assert.Equal(t, changed, buf.Bytes())
+		assert.Equal(t, sha256Hex(changed), info.Checksum)
+	})
+
+	t.Run("changed content without rewind", func(t *testing.T) {
+		server := &contentServer{data: data, next: changed}
+		client := newTestClient(t, server.ServeHTTP)
+
+		// A MultiWriter cannot be rewound
+		_, err := client.Files("web").Download(context.Background(), "index.html", io.MultiWriter(&bytes.Buffer{}), DownloadOptions{Backoff: testBackoff})
+		assert.ErrorIs(t, err, ErrContentChanged)
+	})
+
+	t.Run("resumed from a file", func(t *testing.T) {
+		testCases := []struct {
+			desc      string
+			kept      []byte
+			wantRange []string
+		}{
+			{desc: "start of the content", kept: data[:5], wantRange: []string{"bytes=5-"}},
+			{desc: "other content", kept: []byte("other"), wantRange: []string{"bytes=5-", ""}},
+			{desc: "complete", kept: data, wantRange: []string{"bytes=20-"}},
+			{desc: "more than the content", kept: append(bytes.Clone(data), "tail"...), wantRange: []string{"bytes=24-", ""}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.desc, func(t *testing.T) {
+				var ranges []string
+				client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+					ranges = append(ranges, req.Header.Get("Range"))
+					w.Header().Set("ETag", fmt.Sprintf(`"%s-1"`, sha256Hex(data)))
+					http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(data))
+				})
+
+				f, err := os.Create(filepath.Join(t.TempDir(), "index.html.partial"))
+				require.NoError(t, err)
+				defer f.Close()
+				_, err = f.Write(tc.kept)
+				require.NoError(t, err)
+
+				info, err := client.Files("web").Download(context.Background(), "index.html", f, DownloadOptions{Backoff: testBackoff, Resume: true})
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(data)), info.Size)
+				assert.Equal(t, tc.wantRange, ranges)
+				written, err := os.ReadFile(f.Name())
+				require.NoError(t, err)
+				assert.Equal(t, data, written)
+			})
+		}
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("ETag", fmt.Sprintf(`"%s-1"`, sha256Hex(changed)))
+			w.Write(data)
+		})
+
+		_, err := client.Files("web").Download(context.Background(), "index.html", &bytes.Buffer{}, DownloadOptions{})
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+			writeJSON(w, http.StatusNotFound, apierrors.NewNotFound(cdnv1alpha1.Resource("file"), "index.html").Status())
+		})
+
+		_, err := client.Files("web").Download(context.Background(), "index.html", &bytes.Buffer{}, DownloadOptions{})
+		assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+	})
+}
+
+func TestReadRange(t *testing.T) {
+	data := []byte("line 1\nline 2\nline 3\n")
+
+	testCases := []struct {
+		desc      string
+		offset    int64
+		length    int64
+		wantRange string
+		want      *ContentRange
+	}{
+		{desc: "from offset", offset: 7, wantRange: "bytes=7-", want: &ContentRange{Data: data[7:], Offset: 7, Size: 21}},
+		{desc: "length from offset", offset: 7, length: 6, wantRange: "bytes=7-12", want: &ContentRange{Data: data[7:13], Offset: 7, Size: 21}},
+		{desc: "last bytes", offset: -7, wantRange: "bytes=-7", want: &ContentRange{Data: data[14:], Offset: 14, Size: 21}},
+		{desc: "more last bytes than the content", offset: -100, wantRange: "bytes=-100", want: &ContentRange{Data: data, Offset: 0, Size: 21}},
+		{desc: "offset at the end", offset: 21, wantRange: "bytes=21-", want: &ContentRange{Offset: 21, Size: 21}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, tc.wantRange, req.Header.Get("Range"))
+				http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(data))
+			})
+
+			got, err := client.Files("web").ReadRange(context.Background(), "index.html", tc.offset, tc.length)
+			require.NoError(t, err)
+			if len(tc.want.Data) == 0 {
+				assert.Empty(t, got.Data)
+				got.Data = nil
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("server error", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+			writeJSON(w, http.StatusServiceUnavailable, apierrors.NewServiceUnavailable("unavailable").Status())
+		})
+
+		_, err := client.Files("web").ReadRange(context.Background(), "index.html", 0, 0)
+		assert.True(t, apierrors.IsServiceUnavailable(err), "unexpected error %v", err)
+	})
+}
+
+func TestStatCopyAndSignURL(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/web/files/index.html/stat":
+			writeJSON(w, http.StatusOK, cdnv1alpha1.FileStat{Stored: true, Size: 42, Checksum: "abc"})
+		case req.Method == http.MethodPost && req.URL.Path == "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/web/files/index.html/copy":
+			query := req.URL.Query()
+			assert.Equal(t, "home.html", query.Get("destination"))
+			assert.Equal(t, "prod", query.Get("destinationNamespace"))
+			assert.Equal(t, "true", query.Get("move"))
+			writeJSON(w, http.StatusCreated, cdnv1alpha1.FileContent{Status: metav1.Status{Status: metav1.StatusSuccess, Message: "moved", Code: http.StatusCreated}})
+		case req.Method == http.MethodPost && req.URL.Path == "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/web/files/index.html/signedurl":
+			var signedURL cdnv1alpha1.FileSignedURL
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&signedURL))
+			require.NotNil(t, signedURL.Spec.ExpirationSeconds)
+			assert.Equal(t, int64(600), *signedURL.Spec.ExpirationSeconds)
+			signedURL.Status.URL = "https://cdn.example.com/cdn/signed/web/index.html?expires=1&signature=abc"
+			writeJSON(w, http.StatusCreated, signedURL)
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL)
+		}
+	})
+	files := client.Files("web")
+
+	stat, err := files.Stat(context.Background(), "index.html")
+	require.NoError(t, err)
+	assert.True(t, stat.Stored)
+	assert.Equal(t, int64(42), stat.Size)
+
+	result, err := files.Copy(context.Background(), "index.html", cdnv1alpha1.FileCopyOptions{Destination: "home.html", DestinationNamespace: "prod", Move: true})
+	require.NoError(t, err)
+	assert.Equal(t, "moved", result.Status.Message)
+
+	signedURL, err := files.SignURL(context.Background(), "index.html", 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/cdn/signed/web/index.html?expires=1&signature=abc", signedURL.Status.URL)
+}
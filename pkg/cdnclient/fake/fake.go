@@ -0,0 +1,456 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake implements cdnclient.Interface in memory for tests. It keeps
+// the Files of a clientset, usually the fake Clientset of the generated
+// clientset, in step with the content it stores, like the API server does.
+package fake
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/cdnclient"
+	"k8s.toms.place/apiserver/pkg/content/signedurl"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned"
+)
+
+// copyOptionsKind and signedURLKind are the kinds invalid requests are
+// reported for
+var (
+	copyOptionsKind = schema.GroupKind{Group: cdnv1alpha1.GroupName, Kind: "FileCopyOptions"}
+	signedURLKind   = schema.GroupKind{Group: cdnv1alpha1.GroupName, Kind: "FileSignedURL"}
+)
+
+// SignedURLHost is the host of the URLs signed by the fake. Nothing serves
+// them.
+const SignedURLHost = "cdn.fake.invalid"
+
+// Client is a cdnclient.Interface storing content in memory and the Files
+// it belongs to in a clientset. Content of Files deleted through the
+// clientset is ignored.
+type Client struct {
+	clientset versioned.Interface
+	signer    *signedurl.Signer
+
+	lock sync.Mutex
+	// objects are the stored contents by File
+	objects map[types.NamespacedName]*object
+}
+
+// object is the content stored for a File
+type object struct {
+	data        []byte
+	contentType string
+	checksum    string
+	versions    int64
+	modified    time.Time
+	accessed    time.Time
+}
+
+var _ cdnclient.Interface = &Client{}
+
+// New returns a Client keeping the Files of clientset in step with their
+// content
+func New(clientset versioned.Interface) *Client {
+	return &Client{clientset: clientset, signer: signedurl.NewRandomSigner(), objects: map[types.NamespacedName]*object{}}
+}
+
+// Files returns the content operations on the Files in namespace
+func (c *Client) Files(namespace string) cdnclient.FileInterface {
+	return &files{client: c, namespace: namespace}
+}
+
+// files implements cdnclient.FileInterface for a namespace
+type files struct {
+	client    *Client
+	namespace string
+}
+
+// Upload stores the content read from r and creates or updates the File
+func (f *files) Upload(ctx context.Context, name string, r io.Reader, opts cdnclient.UploadOptions) (*cdnclient.ContentInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	f.client.lock.Lock()
+	defer f.client.lock.Unlock()
+	if _, err := f.client.write(ctx, f.namespace, name, data, contentType, opts.IfMatch, nil); err != nil {
+		return nil, err
+	}
+	if opts.Progress != nil {
+		opts.Progress(int64(len(data)), int64(len(data)))
+	}
+	return &cdnclient.ContentInfo{Size: int64(len(data)), ContentType: contentType, Checksum: sha256Hex(data)}, nil
+}
+
+// Download writes the stored content of the File to w. Resumed downloads
+// replace the content kept in w, with the same result.
+func (f *files) Download(ctx context.Context, name string, w io.Writer, opts cdnclient.DownloadOptions) (*cdnclient.ContentInfo, error) {
+	f.client.lock.Lock()
+	defer f.client.lock.Unlock()
+
+	file, obj, err := f.client.read(ctx, f.namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Resume {
+		kept, ok := w.(interface {
+			io.Seeker
+			Truncate(size int64) error
+		})
+		if !ok {
+			return nil, fmt.Errorf("cannot resume a download into %T", w)
+		}
+		if err := kept.Truncate(0); err != nil {
+			return nil, err
+		}
+		if _, err := kept.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := w.Write(obj.data); err != nil {
+		return nil, err
+	}
+	if opts.Progress != nil {
+		opts.Progress(int64(len(obj.data)), int64(len(obj.data)))
+	}
+	obj.accessed = time.Now()
+	return &cdnclient.ContentInfo{
+		Size:        int64(len(obj.data)),
+		ContentType: obj.contentType,
+		Checksum:    obj.checksum,
+		ETag:        etag(file),
+	}, nil
+}
+
+// ReadRange returns a range of the stored content of the File
+func (f *files) ReadRange(ctx context.Context, name string, offset, length int64) (*cdnclient.ContentRange, error) {
+	f.client.lock.Lock()
+	defer f.client.lock.Unlock()
+
+	_, obj, err := f.client.read(ctx, f.namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(len(obj.data))
+	start := offset
+	if offset < 0 {
+		start = max(size+offset, 0)
+	}
+	start = min(start, size)
+	end := size
+	if offset >= 0 && length > 0 {
+		end = min(start+length, size)
+	}
+	obj.accessed = time.Now()
+	return &cdnclient.ContentRange{Data: bytes.Clone(obj.data[start:end]), Offset: start, Size: size}, nil
+}
+
+// Stat describes the content stored for the File
+func (f *files) Stat(ctx context.Context, name string) (*cdnv1alpha1.FileStat, error) {
+	f.client.lock.Lock()
+	defer f.client.lock.Unlock()
+
+	file, err := f.client.clientset.CdnV1alpha1().Files(f.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	stat := &cdnv1alpha1.FileStat{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              file.Name,
+			Namespace:         file.Namespace,
+			UID:               file.UID,
+			ResourceVersion:   file.ResourceVersion,
+			CreationTimestamp: file.CreationTimestamp,
+		},
+	}
+	obj, ok := f.client.objects[types.NamespacedName{Namespace: f.namespace, Name: name}]
+	if !ok {
+		return stat, nil
+	}
+	stat.Stored = true
+	stat.Size = int64(len(obj.data))
+	stat.ContentType = obj.contentType
+	stat.Checksum = obj.checksum
+	stat.Versions = obj.versions
+	modified := metav1.NewTime(obj.modified)
+	stat.Modified = &modified
+	if !obj.accessed.IsZero() {
+		accessed := metav1.NewTime(obj.accessed)
+		stat.LastAccess = &accessed
+	}
+	return stat, nil
+}
+
+// Copy copies the content of the File to the destination in opts, deleting
+// the File if it is moved
+func (f *files) Copy(ctx context.Context, name string, opts cdnv1alpha1.FileCopyOptions) (*cdnv1alpha1.FileContent, error) {
+	destNamespace := opts.DestinationNamespace
+	if destNamespace == "" {
+		destNamespace = f.namespace
+	}
+	if opts.Destination == "" {
+		return nil, apierrors.NewInvalid(copyOptionsKind, name, field.ErrorList{field.Required(field.NewPath("destination"), "")})
+	}
+	if destNamespace == f.namespace && opts.Destination == name {
+		return nil, apierrors.NewInvalid(copyOptionsKind, name, field.ErrorList{field.Invalid(field.NewPath("destination"), opts.Destination, "must differ from the copied File")})
+	}
+
+	f.client.lock.Lock()
+	defer f.client.lock.Unlock()
+
+	file, obj, err := f.client.read(ctx, f.namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	verb := "copied"
+	if opts.Move {
+		verb = "moved"
+	}
+	message := fmt.Sprintf("file %s/%s %s to %s/%s (%d bytes)", f.namespace, name, verb, destNamespace, opts.Destination, len(obj.data))
+	code := http.StatusOK
+	if opts.Check {
+		message += " (dry run)"
+	} else {
+		var metadata *metav1.ObjectMeta
+		if opts.Move {
+			// A moved File keeps its metadata
+			metadata = &file.ObjectMeta
+		}
+		created, err := f.client.write(ctx, destNamespace, opts.Destination, obj.data, obj.contentType, "", metadata)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			code = http.StatusCreated
+		}
+		if opts.Move {
+			if err := f.client.clientset.CdnV1alpha1().Files(f.namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+				return nil, err
+			}
+			delete(f.client.objects, types.NamespacedName{Namespace: f.namespace, Name: name})
+		}
+	}
+	return &cdnv1alpha1.FileContent{
+		Status: metav1.Status{
+			Status:  metav1.StatusSuccess,
+			Message: message,
+			Details: &metav1.StatusDetails{
+				Name: opts.Destination,
+				Kind: "File",
+			},
+			Code: int32(code),
+		},
+	}, nil
+}
+
+// SignURL signs a URL for the content of the File at SignedURLHost
+func (f *files) SignURL(ctx context.Context, name string, expiration time.Duration) (*cdnv1alpha1.FileSignedURL, error) {
+	seconds := int64(cdnv1alpha1.DefaultSignedURLExpirationSeconds)
+	if expiration != 0 {
+		seconds = int64(expiration / time.Second)
+	}
+	if seconds < cdnv1alpha1.MinSignedURLExpirationSeconds || seconds > cdnv1alpha1.MaxSignedURLExpirationSeconds {
+		return nil, apierrors.NewInvalid(signedURLKind, name, field.ErrorList{field.Invalid(field.NewPath("spec", "expirationSeconds"), seconds,
+			fmt.Sprintf("must be between %d and %d", cdnv1alpha1.MinSignedURLExpirationSeconds, cdnv1alpha1.MaxSignedURLExpirationSeconds))})
+	}
+
+	file, err := f.client.clientset.CdnV1alpha1().Files(f.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(time.Duration(seconds) * time.Second).Truncate(time.Second)
+	query := f.client.signer.Sign(file.Namespace, file.Name, file.UID, expires)
+	return &cdnv1alpha1.FileSignedURL{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              file.Name,
+			Namespace:         file.Namespace,
+			UID:               file.UID,
+			CreationTimestamp: file.CreationTimestamp,
+		},
+		Spec: cdnv1alpha1.FileSignedURLSpec{ExpirationSeconds: &seconds},
+		Status: cdnv1alpha1.FileSignedURLStatus{
+			URL:                 fmt.Sprintf("https://%s/cdn/signed/%s/%s?%s", SignedURLHost, file.Namespace, file.Name, query.Encode()),
+			ExpirationTimestamp: metav1.NewTime(expires),
+		},
+	}, nil
+}
+
+// read returns the named File and its stored content. The caller holds the
+// lock.
+func (c *Client) read(ctx context.Context, namespace, name string) (*cdnv1alpha1.File, *object, error) {
+	file, err := c.clientset.CdnV1alpha1().Files(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, ok := c.objects[types.NamespacedName{Namespace: namespace, Name: name}]
+	if !ok {
+		return nil, nil, apierrors.NewNotFound(cdnv1alpha1.Resource("file"), name)
+	}
+	return file, obj, nil
+}
+
+// write stores data for the named File and creates or updates the File like
+// an upload to the API server, merging the labels and annotations of
+// metadata if it is set. It returns whether the File was created. The caller
+// holds the lock.
+func (c *Client) write(ctx context.Context, namespace, name string, data []byte, contentType, ifMatch string, metadata *metav1.ObjectMeta) (bool, error) {
+	files := c.clientset.CdnV1alpha1().Files(namespace)
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	checksum := sha256Hex(data)
+
+	file, err := files.Get(ctx, name, metav1.GetOptions{})
+	created := apierrors.IsNotFound(err)
+	switch {
+	case created:
+		if ifMatch != "" {
+			return false, preconditionFailed(name)
+		}
+		file = &cdnv1alpha1.File{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	case err != nil:
+		return false, err
+	case !matches(ifMatch, etag(file)):
+		return false, preconditionFailed(name)
+	}
+
+	if metadata != nil {
+		file.Labels = mergeStringMaps(file.Labels, metadata.Labels)
+		file.Annotations = mergeStringMaps(file.Annotations, metadata.Annotations)
+	}
+	file.Spec.URL = fmt.Sprintf("/apis/%s/%s/namespaces/%s/files/%s/content",
+		cdnv1alpha1.GroupName, cdnv1alpha1.SchemeGroupVersion.Version, namespace, name)
+	file.Spec.Size = int64(len(data))
+	file.Spec.ContentType = contentType
+	if obj, ok := c.objects[key]; !ok || obj.checksum != checksum {
+		// New content gets a new ETag
+		file.Status.ContentGeneration++
+	}
+	file.Status.Uploaded = true
+	file.Status.Error = ""
+	file.Status.Checksum = checksum
+
+	// Like the API server, the status is written through the status
+	// subresource once the File is stored
+	status := file.Status
+	var stored *cdnv1alpha1.File
+	if created {
+		file.Status = cdnv1alpha1.FileStatus{}
+		stored, err = files.Create(ctx, file, metav1.CreateOptions{})
+	} else {
+		stored, err = files.Update(ctx, file, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return false, err
+	}
+	stored.Status = status
+	if _, err := files.UpdateStatus(ctx, stored, metav1.UpdateOptions{}); err != nil {
+		return false, err
+	}
+
+	obj, ok := c.objects[key]
+	if !ok {
+		obj = &object{}
+		c.objects[key] = obj
+	}
+	obj.data = bytes.Clone(data)
+	obj.contentType = contentType
+	obj.checksum = checksum
+	obj.versions++
+	obj.modified = time.Now()
+	return created, nil
+}
+
+// etag returns the entity tag the API server serves the content of file
+// with, or "" if it has none
+func etag(file *cdnv1alpha1.File) string {
+	if !file.Status.Uploaded || file.Status.Checksum == "" {
+		return ""
+	}
+	return fmt.Sprintf("\"%s-%d\"", file.Status.Checksum, file.Status.ContentGeneration)
+}
+
+// matches returns whether the If-Match header value ifMatch, if set, matches
+// the entity tag of the current content
+func matches(ifMatch, current string) bool {
+	if ifMatch == "" {
+		return true
+	}
+	if current == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == current {
+			return true
+		}
+	}
+	return false
+}
+
+// preconditionFailed returns the error of the API server for an upload whose
+// If-Match header does not match the content of the File name
+func preconditionFailed(name string) error {
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusPreconditionFailed,
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{
+			Group: cdnv1alpha1.GroupName,
+			Kind:  "files",
+			Name:  name,
+		},
+		Message: fmt.Sprintf("content of file %q does not match If-Match: it was changed since it was read", name),
+	}}
+}
+
+func mergeStringMaps(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func sha256Hex(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/cdnclient"
+	fakeclientset "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/fake"
+)
+
+func TestUploadAndDownload(t *testing.T) {
+	ctx := context.Background()
+	clientset := fakeclientset.NewSimpleClientset()
+	files := New(clientset).Files("web")
+
+	info, err := files.Upload(ctx, "index.html", strings.NewReader("<h1>v1</h1>"), cdnclient.UploadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", info.ContentType)
+
+	file, err := clientset.CdnV1alpha1().Files("web").Get(ctx, "index.html", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, file.Status.Uploaded)
+	assert.Equal(t, info.Checksum, file.Status.Checksum)
+	assert.Equal(t, int64(1), file.Status.ContentGeneration)
+	assert.Equal(t, int64(11), file.Spec.Size)
+
+	var buf bytes.Buffer
+	downloaded, err := files.Download(ctx, "index.html", &buf, cdnclient.DownloadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>v1</h1>", buf.String())
+	assert.Equal(t, `"`+info.Checksum+`-1"`, downloaded.ETag)
+
+	// Uploads conditional on stale content fail like on the API server
+	_, err = files.Upload(ctx, "index.html", strings.NewReader("<h1>v2</h1>"), cdnclient.UploadOptions{IfMatch: downloaded.ETag})
+	require.NoError(t, err)
+	_, err = files.Upload(ctx, "index.html", strings.NewReader("<h1>v3</h1>"), cdnclient.UploadOptions{IfMatch: downloaded.ETag})
+	assert.True(t, apierrors.IsConflict(err))
+	var status apierrors.APIStatus
+	require.ErrorAs(t, err, &status)
+	assert.Equal(t, int32(http.StatusPreconditionFailed), status.Status().Code)
+	_, err = files.Upload(ctx, "new.html", strings.NewReader("new"), cdnclient.UploadOptions{IfMatch: "*"})
+	assert.True(t, apierrors.IsConflict(err))
+
+	tail, err := files.ReadRange(ctx, "index.html", -5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, &cdnclient.ContentRange{Data: []byte("</h1>"), Offset: 6, Size: 11}, tail)
+	head, err := files.ReadRange(ctx, "index.html", 0, 4)
+	require.NoError(t, err)
+	assert.Equal(t, &cdnclient.ContentRange{Data: []byte("<h1>"), Offset: 0, Size: 11}, head)
+
+	stat, err := files.Stat(ctx, "index.html")
+	require.NoError(t, err)
+	assert.True(t, stat.Stored)
+	assert.Equal(t, int64(2), stat.Versions)
+	assert.NotNil(t, stat.LastAccess)
+
+	// A File created without content has nothing to download
+	_, err = clientset.CdnV1alpha1().Files("web").Create(ctx, &cdnv1alpha1.File{ObjectMeta: metav1.ObjectMeta{Name: "empty"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = files.Download(ctx, "empty", &buf, cdnclient.DownloadOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	stat, err = files.Stat(ctx, "empty")
+	require.NoError(t, err)
+	assert.False(t, stat.Stored)
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	clientset := fakeclientset.NewSimpleClientset(&cdnv1alpha1.File{
+		ObjectMeta: metav1.ObjectMeta{Name: "index.html", Namespace: "web", Labels: map[string]string{"app": "site"}},
+	})
+	client := New(clientset)
+	_, err := client.Files("web").Upload(ctx, "index.html", strings.NewReader("hello"), cdnclient.UploadOptions{ContentType: "text/plain"})
+	require.NoError(t, err)
+
+	_, err = client.Files("web").Copy(ctx, "index.html", cdnv1alpha1.FileCopyOptions{Destination: "index.html"})
+	assert.True(t, apierrors.IsInvalid(err))
+
+	result, err := client.Files("web").Copy(ctx, "index.html", cdnv1alpha1.FileCopyOptions{Destination: "home.html", DestinationNamespace: "prod", Check: true})
+	require.NoError(t, err)
+	assert.Contains(t, result.Status.Message, "(dry run)")
+	_, err = clientset.CdnV1alpha1().Files("prod").Get(ctx, "home.html", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	result, err = client.Files("web").Copy(ctx, "index.html", cdnv1alpha1.FileCopyOptions{Destination: "home.html", DestinationNamespace: "prod", Move: true})
+	require.NoError(t, err)
+	assert.Equal(t, int32(http.StatusCreated), result.Status.Code)
+
+	moved, err := clientset.CdnV1alpha1().Files("prod").Get(ctx, "home.html", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "site", moved.Labels["app"])
+	assert.Equal(t, "text/plain", moved.Spec.ContentType)
+	_, err = clientset.CdnV1alpha1().Files("web").Get(ctx, "index.html", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	var buf bytes.Buffer
+	_, err = client.Files("prod").Download(ctx, "home.html", &buf, cdnclient.DownloadOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", buf.String())
+}
+
+func TestSignURL(t *testing.T) {
+	ctx := context.Background()
+	clientset := fakeclientset.NewSimpleClientset()
+	files := New(clientset).Files("web")
+
+	_, err := files.SignURL(ctx, "index.html", 0)
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+
+	_, err = files.Upload(ctx, "index.html", strings.NewReader("<h1>v1</h1>"), cdnclient.UploadOptions{})
+	require.NoError(t, err)
+	signedURL, err := files.SignURL(ctx, "index.html", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(cdnv1alpha1.DefaultSignedURLExpirationSeconds), *signedURL.Spec.ExpirationSeconds)
+	assert.True(t, strings.HasPrefix(signedURL.Status.URL, "https://"+SignedURLHost+"/cdn/signed/web/index.html?"), signedURL.Status.URL)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), signedURL.Status.ExpirationTimestamp.Time, time.Minute)
+
+	_, err = files.SignURL(ctx, "index.html", time.Second)
+	assert.True(t, apierrors.IsInvalid(err), "unexpected error %v", err)
+}
@@ -0,0 +1,550 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cdnclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+// sniffLen is how much content is used to detect its type
+const sniffLen = 512
+
+// Upload stores the content read from r for the named File. Content read
+// from an io.Seeker is sent with its digest, so the server rejects it if it
+// was corrupted in transit, and sent again after transient errors. Other
+// content, including pipes, is streamed once and its checksum verified with
+// the stat subresource afterwards.
+func (f *files) Upload(ctx context.Context, name string, r io.Reader, opts UploadOptions) (*ContentInfo, error) {
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			return f.uploadSeeker(ctx, name, seeker, start, opts)
+		}
+	}
+	return f.uploadStream(ctx, name, r, opts)
+}
+
+// uploadSeeker uploads the content of r from offset start on
+func (f *files) uploadSeeker(ctx context.Context, name string, r io.ReadSeeker, start int64, opts UploadOptions) (*ContentInfo, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+	digest := sha256.New()
+	digest.Write(head[:n])
+	rest, err := io.Copy(digest, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+	info := &ContentInfo{
+		Size:        int64(n) + rest,
+		ContentType: contentTypeOf(opts.ContentType, head[:n]),
+		Checksum:    hex.EncodeToString(digest.Sum(nil)),
+	}
+
+	err = retry(ctx, opts.Backoff, opts.OnRetry, func() (bool, error) {
+		if _, err := r.Seek(start, io.SeekStart); err != nil {
+			return false, fmt.Errorf("failed to read content: %w", err)
+		}
+		body := &countingReader{Reader: r, total: info.Size, progress: opts.Progress}
+		return false, transientAPIError(f.put(ctx, name, body, info, digest.Sum(nil), opts))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// uploadStream uploads the content of r, which cannot be read again
+func (f *files) uploadStream(ctx context.Context, name string, r io.Reader, opts UploadOptions) (*ContentInfo, error) {
+	in := bufio.NewReaderSize(r, sniffLen)
+	head, _ := in.Peek(sniffLen)
+	info := &ContentInfo{ContentType: contentTypeOf(opts.ContentType, head)}
+
+	digest := sha256.New()
+	body := &countingReader{Reader: io.TeeReader(in, digest), total: -1, progress: opts.Progress}
+	if err := f.put(ctx, name, body, info, nil, opts); err != nil {
+		return nil, err
+	}
+	info.Size = body.n
+	info.Checksum = hex.EncodeToString(digest.Sum(nil))
+
+	stat, err := f.Stat(ctx, name)
+	if err != nil {
+		return info, fmt.Errorf("failed to verify upload: %w", err)
+	}
+	if stat.Checksum != info.Checksum {
+		return info, fmt.Errorf("%w: content stored for %s/%s has checksum %s, uploaded %s", ErrChecksumMismatch, f.namespace, name, stat.Checksum, info.Checksum)
+	}
+	return info, nil
+}
+
+// put sends body as the content of the named File, with its SHA-256 digest
+// if it is known
+func (f *files) put(ctx context.Context, name string, body io.Reader, info *ContentInfo, digest []byte, opts UploadOptions) error {
+	req := f.subresource(http.MethodPut, name, "content").
+		SetHeader("Content-Type", info.ContentType).
+		Body(body)
+	if digest != nil {
+		req.SetHeader("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(digest)+":")
+	}
+	if opts.IfMatch != "" {
+		req.SetHeader("If-Match", opts.IfMatch)
+	}
+	return req.Do(ctx).Error()
+}
+
+// contentTypeOf returns contentType, or the type detected from head if it is
+// empty
+func contentTypeOf(contentType string, head []byte) string {
+	if contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(head)
+}
+
+// countingReader counts the bytes read through it and reports them to
+// progress, if it is set
+type countingReader struct {
+	io.Reader
+	n int64
+	// total is the number of bytes expected, or -1 if it is not known
+	total    int64
+	progress ProgressFunc
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.n += int64(n)
+	if r.progress != nil && n > 0 {
+		r.progress(r.n, r.total)
+	}
+	return n, err
+}
+
+// Download writes the content of the named File to w and verifies it against
+// the checksum in its ETag. Downloads interrupted by transient errors resume
+// with Range requests. If the content changes in between, the download
+// starts over if w can be rewound: if it has a Reset method, like
+// bytes.Buffer, or can be truncated, like os.File. Otherwise it fails with
+// ErrContentChanged.
+func (f *files) Download(ctx context.Context, name string, w io.Writer, opts DownloadOptions) (*ContentInfo, error) {
+	d := &download{
+		client:   f.client.httpClient,
+		url:      f.subresource(http.MethodGet, name, "content").URL().String(),
+		out:      w,
+		hash:     sha256.New(),
+		progress: opts.Progress,
+	}
+	resumed := false
+	if opts.Resume {
+		kept, ok := w.(io.ReadSeeker)
+		if !ok {
+			return nil, fmt.Errorf("cannot resume a download into %T", w)
+		}
+		if _, err := kept.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read downloaded content: %w", err)
+		}
+		d.rewind = rewinder(w)
+		n, err := io.Copy(d.hash, kept)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read downloaded content: %w", err)
+		}
+		d.offset, resumed = n, n > 0
+	} else {
+		d.rewind = rewinder(w)
+	}
+
+	attempt := func() (bool, error) {
+		return d.attempt(ctx)
+	}
+	err := retry(ctx, opts.Backoff, opts.OnRetry, attempt)
+	if err == nil && resumed && !d.verified() {
+		// The content kept in w was not the start of this content
+		if err := d.restart(); err != nil {
+			return nil, err
+		}
+		err = retry(ctx, opts.Backoff, opts.OnRetry, attempt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ContentInfo{
+		Size:        d.offset,
+		ContentType: d.contentType,
+		Checksum:    hex.EncodeToString(d.hash.Sum(nil)),
+		ETag:        d.etag,
+	}
+	if !d.verified() {
+		return nil, fmt.Errorf("%w: downloaded content of %s/%s has checksum %s, expected %s", ErrChecksumMismatch, f.namespace, name, info.Checksum, etagChecksum(d.etag))
+	}
+	return info, nil
+}
+
+// ReadRange reads a range of the content of the named File with a Range
+// request. Reads are not retried.
+func (f *files) ReadRange(ctx context.Context, name string, offset, length int64) (*ContentRange, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.subresource(http.MethodGet, name, "content").URL().String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case offset < 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=-%d", -offset))
+	case length > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	default:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := f.client.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &ContentRange{Data: data, Size: int64(len(data))}, nil
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, err
+		}
+		size, err := contentRangeSize(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &ContentRange{Data: data, Offset: start, Size: size}, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The content ends before offset
+		size, err := contentRangeSize(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, err
+		}
+		return &ContentRange{Offset: size, Size: size}, nil
+	default:
+		return nil, responseError(resp)
+	}
+}
+
+// download streams content to out, resuming with Range requests where an
+// interrupted attempt stopped
+type download struct {
+	client *http.Client
+	url    string
+	// out receives the content, hash everything written to it
+	out  io.Writer
+	hash hash.Hash
+	// rewind empties out to start over, or is nil if out cannot be rewound
+	rewind   func() error
+	progress ProgressFunc
+
+	// offset is the number of bytes in out
+	offset int64
+	// etag of the content in out, used to resume only unchanged content
+	etag        string
+	contentType string
+}
+
+// attempt requests the content from offset on and copies it to out. It
+// returns whether bytes were written.
+func (d *download) attempt(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return false, err
+	}
+	if d.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+		if d.etag != "" {
+			req.Header.Set("If-Range", d.etag)
+		}
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return false, transient(err)
+	}
+	defer resp.Body.Close()
+
+	total := int64(-1)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		total = resp.ContentLength
+		if d.offset > 0 {
+			// The content changed since the previous attempt
+			if err := d.restart(); err != nil {
+				return false, err
+			}
+		}
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return false, err
+		}
+		if start != d.offset {
+			return false, fmt.Errorf("server resumed at byte %d instead of %d", start, d.offset)
+		}
+		total, _ = contentRangeSize(resp.Header.Get("Content-Range"))
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Nothing is left to download, unless out holds more than the content
+		if size, err := contentRangeSize(resp.Header.Get("Content-Range")); err == nil && size == d.offset {
+			d.etag = resp.Header.Get("ETag")
+			return false, nil
+		}
+		if err := d.restart(); err != nil {
+			return false, err
+		}
+		return false, transient(fmt.Errorf("downloaded more bytes than the content has"))
+	default:
+		if isTransientStatus(resp.StatusCode) {
+			return false, transient(responseError(resp))
+		}
+		return false, responseError(resp)
+	}
+	d.etag = resp.Header.Get("ETag")
+	d.contentType = resp.Header.Get("Content-Type")
+
+	body := &countingReader{Reader: resp.Body, n: d.offset, total: total, progress: d.progress}
+	out := &errorWriter{Writer: io.MultiWriter(d.out, d.hash)}
+	n, err := io.Copy(out, body)
+	d.offset += n
+	if out.err != nil {
+		return n > 0, out.err
+	}
+	if err != nil {
+		return n > 0, transient(err)
+	}
+	return n > 0, nil
+}
+
+// restart discards the downloaded bytes to start over
+func (d *download) restart() error {
+	if d.rewind == nil {
+		return fmt.Errorf("%w after %d bytes were written", ErrContentChanged, d.offset)
+	}
+	if err := d.rewind(); err != nil {
+		return err
+	}
+	d.hash.Reset()
+	d.offset, d.etag = 0, ""
+	return nil
+}
+
+// verified returns whether the downloaded bytes have the checksum in the
+// ETag of the content, if it has one
+func (d *download) verified() bool {
+	expected := etagChecksum(d.etag)
+	return expected == "" || expected == hex.EncodeToString(d.hash.Sum(nil))
+}
+
+// rewinder returns a function emptying what was written to w, or nil if w
+// cannot be rewound
+func rewinder(w io.Writer) func() error {
+	switch w := w.(type) {
+	case interface{ Reset() }:
+		return func() error {
+			w.Reset()
+			return nil
+		}
+	case interface {
+		io.Seeker
+		Truncate(size int64) error
+	}:
+		start, err := w.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil
+		}
+		return func() error {
+			if err := w.Truncate(start); err != nil {
+				return err
+			}
+			_, err := w.Seek(start, io.SeekStart)
+			return err
+		}
+	}
+	return nil
+}
+
+// errorWriter records the error of its writer, to tell it from errors of the
+// reader it is copied from
+type errorWriter struct {
+	io.Writer
+	err error
+}
+
+func (w *errorWriter) Write(b []byte) (int, error) {
+	n, err := w.Writer.Write(b)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// etagChecksum returns the checksum in an ETag of the API server,
+// "<checksum>-<contentGeneration>", or "" if it has none
+func etagChecksum(etag string) string {
+	checksum, _, ok := strings.Cut(strings.Trim(etag, `"`), "-")
+	if !ok {
+		return ""
+	}
+	return checksum
+}
+
+// contentRangeStart returns the first byte of a Content-Range header value
+func contentRangeStart(header string) (int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	first, _, _ := strings.Cut(spec, "-")
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	return start, nil
+}
+
+// contentRangeSize returns the total size of a Content-Range header value,
+// or -1 if it is unknown
+func contentRangeSize(header string) (int64, error) {
+	_, size, ok := strings.Cut(header, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	if size == "*" {
+		return -1, nil
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	return total, nil
+}
+
+// transientError marks an error a transfer is retried after
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// transient wraps err so that retry retries it
+func transient(err error) error {
+	return &transientError{err: err}
+}
+
+// isTransientStatus returns whether a response with the status code may
+// succeed when retried
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// transientAPIError marks errors of client-go requests that may succeed when
+// retried as transient: connection failures and server-side errors
+func transientAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || isTransientStatus(int(status.Status().Code)) {
+		return transient(err)
+	}
+	return err
+}
+
+// responseError returns the error of a failed content request, decoding the
+// Status returned by the API server if there is one
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	status := &metav1.Status{}
+	if json.Unmarshal(body, status) == nil && status.Kind == "Status" {
+		return apierrors.FromObject(status)
+	}
+	return apierrors.NewGenericServerResponse(resp.StatusCode, resp.Request.Method, cdnv1alpha1.Resource("files"), "", strings.TrimSpace(string(body)), 0, false)
+}
+
+// retry calls attempt until it succeeds, fails with an error that is not
+// transient, or failed more often in a row than backoff has steps. attempt
+// returns whether it made progress; attempts that did are not counted as
+// failures, so long transfers over flaky connections still complete. Each
+// retry is announced to onRetry, if it is set.
+func retry(ctx context.Context, backoff *wait.Backoff, onRetry RetryFunc, attempt func() (bool, error)) error {
+	initial := DefaultBackoff
+	if backoff != nil {
+		initial = *backoff
+	}
+	current, failures := initial, 0
+	for {
+		progressed, err := attempt()
+		if err == nil {
+			return nil
+		}
+		var transientErr *transientError
+		if !errors.As(err, &transientErr) {
+			return err
+		}
+		if progressed {
+			current, failures = initial, 0
+		}
+		if failures >= initial.Steps {
+			return transientErr.err
+		}
+		failures++
+		delay := current.Step()
+		if onRetry != nil {
+			onRetry(transientErr.err, delay, failures, initial.Steps)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -21,9 +21,14 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -35,6 +40,10 @@ import (
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 	"k8s.io/apiserver/pkg/util/compatibility"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	basecompatibility "k8s.io/component-base/compatibility"
 	"k8s.io/component-base/featuregate"
 	baseversion "k8s.io/component-base/version"
@@ -42,9 +51,21 @@ import (
 	initializer "k8s.toms.place/apiserver/pkg/admission/initializer"
 	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
 	"k8s.toms.place/apiserver/pkg/apiserver"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/content/encryption"
+	"k8s.toms.place/apiserver/pkg/content/recovery"
+	"k8s.toms.place/apiserver/pkg/content/replica"
+	"k8s.toms.place/apiserver/pkg/content/signedurl"
+	purgecontroller "k8s.toms.place/apiserver/pkg/controller/purge"
+	rewrapcontroller "k8s.toms.place/apiserver/pkg/controller/rewrap"
+	sitecontroller "k8s.toms.place/apiserver/pkg/controller/site"
+	"k8s.toms.place/apiserver/pkg/events"
 	clientset "k8s.toms.place/apiserver/pkg/generated/clientset/versioned"
 	informers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions"
 	sampleopenapi "k8s.toms.place/apiserver/pkg/generated/openapi"
+	"k8s.toms.place/apiserver/pkg/indexers"
+	"k8s.toms.place/apiserver/pkg/purge"
+	filestorage "k8s.toms.place/apiserver/pkg/registry/cdn/file"
 )
 
 const defaultEtcdPathPrefix = "/registry/k8s.toms.place"
@@ -64,6 +85,53 @@ type ServerOptions struct {
 	// ExternalHost is the host used to construct URLs for file content endpoints.
 	// If empty, the request's Host header will be used.
 	ExternalHost string
+
+	// ArchiveLimits bounds the size and number of entries of archive uploads.
+	ArchiveLimits filestorage.ArchiveLimits
+
+	// ContentCache configures the hot-content cache in front of the content store.
+	ContentCache content.CacheOptions
+
+	// PurgeWebhooks are the URLs notified about every completed Purge.
+	PurgeWebhooks []string
+	// PurgeWebhookTimeout bounds each request to a purge webhook.
+	PurgeWebhookTimeout time.Duration
+
+	// ContentEncryptionConfig is the path of the content encryption
+	// configuration. Content is stored in plaintext if it is empty.
+	ContentEncryptionConfig string
+	// ContentRewrapInterval is how often the content of all Files is rewrapped
+	// with the current key encryption keys.
+	ContentRewrapInterval time.Duration
+
+	// ContentPeers are the base URLs of the replicas to fetch content from
+	// when it is missing locally.
+	ContentPeers []string
+	// ContentPeerService is the "<namespace>/<name>" of the Service whose
+	// endpoints are the replicas to fetch content from.
+	ContentPeerService string
+	// ContentPeerCAFile is the CA bundle verifying the serving certificates
+	// of the peers. The system roots are used if it is empty.
+	ContentPeerCAFile string
+	// ContentPeerServerName is the server name expected in the serving
+	// certificates of the peers, instead of their address.
+	ContentPeerServerName string
+	// ContentPeerMaxBytes is the size limit of content fetched from peers
+	// for Files this replica doesn't know yet.
+	ContentPeerMaxBytes int64
+
+	// FsckRefetchOrigins are the "<scheme>://<host>" origins consistency
+	// check repairs may fetch content again from.
+	FsckRefetchOrigins []string
+
+	// ContentURLSigningKeyFile is the path of the key signing URLs for file
+	// content. A random key is used if it is empty.
+	ContentURLSigningKeyFile string
+
+	// encryptedContent is the content store if encryption is configured
+	encryptedContent *encryption.Store
+	// peerInformerFactory watches the endpoints of ContentPeerService
+	peerInformerFactory kubeinformers.SharedInformerFactory
 }
 
 func VersionToKubeVersion(ver *version.Version) *version.Version {
@@ -98,6 +166,8 @@ func NewServerOptions(out, errOut io.Writer) *ServerOptions {
 		cdnv1alpha1.SchemeGroupVersion,                 // default target for cdn group
 		schema.GroupKind{Group: cdnv1alpha1.GroupName}, // cdn.k8s.toms.place
 	)
+	// The signature of a signed URL authorizes its request
+	o.RecommendedOptions.Authorization = o.RecommendedOptions.Authorization.WithAlwaysAllowPaths(filestorage.SignedContentPath + "*")
 	return o
 }
 
@@ -132,6 +202,24 @@ func NewCommandStartServer(ctx context.Context, defaults *ServerOptions, skipDef
 	flags := cmd.Flags()
 	o.RecommendedOptions.AddFlags(flags)
 	flags.StringVar(&o.ExternalHost, "external-host", "", "External host (host:port) used to construct URLs for file content endpoints. If empty, uses the request's Host header.")
+	flags.IntVar(&o.ArchiveLimits.MaxEntries, "archive-max-entries", filestorage.DefaultArchiveLimits.MaxEntries, "Maximum number of entries in an uploaded archive.")
+	flags.Int64Var(&o.ArchiveLimits.MaxEntryBytes, "archive-max-entry-bytes", filestorage.DefaultArchiveLimits.MaxEntryBytes, "Maximum uncompressed size in bytes of a single archive entry.")
+	flags.Int64Var(&o.ArchiveLimits.MaxTotalBytes, "archive-max-bytes", filestorage.DefaultArchiveLimits.MaxTotalBytes, "Maximum size in bytes of an uploaded archive, compressed and uncompressed.")
+	flags.Int64Var(&o.ContentCache.MaxBytes, "content-cache-size", 256<<20, "Size in bytes of the in-memory cache of recently read file content. 0 disables the cache.")
+	flags.Int64Var(&o.ContentCache.MinObjectBytes, "content-cache-min-object-size", 0, "Size in bytes below which file content is not cached.")
+	flags.Int64Var(&o.ContentCache.MaxObjectBytes, "content-cache-max-object-size", 8<<20, "Size in bytes above which file content is not cached.")
+	flags.StringSliceVar(&o.PurgeWebhooks, "purge-webhook", o.PurgeWebhooks, "URL of a downstream cache purge webhook notified about every Purge. May be repeated.")
+	flags.DurationVar(&o.PurgeWebhookTimeout, "purge-webhook-timeout", 10*time.Second, "Timeout of a single request to a purge webhook.")
+	flags.Int64Var(&o.ArchiveLimits.MaxCompressionRatio, "archive-max-compression-ratio", filestorage.DefaultArchiveLimits.MaxCompressionRatio, "Maximum ratio of uncompressed to compressed size of a zip archive entry.")
+	flags.StringVar(&o.ContentEncryptionConfig, "content-encryption-config", "", "File with the ContentEncryptionConfiguration of the providers that encrypt file content at rest.")
+	flags.DurationVar(&o.ContentRewrapInterval, "content-rewrap-interval", time.Hour, "How often the data keys of all file content are rewrapped with the current key encryption keys, if content encryption is configured.")
+	flags.StringSliceVar(&o.ContentPeers, "content-peers", o.ContentPeers, "Base URL of a replica of this server to fetch file content from when it is missing locally. May be repeated.")
+	flags.StringVar(&o.ContentPeerService, "content-peer-service", "", "Service (<namespace>/<name>) whose endpoints are the replicas of this server to fetch file content from when it is missing locally.")
+	flags.StringVar(&o.ContentPeerCAFile, "content-peer-ca-file", "", "CA bundle verifying the serving certificates of content peers. The system roots are used if empty.")
+	flags.StringVar(&o.ContentPeerServerName, "content-peer-server-name", "", "Server name expected in the serving certificates of content peers, instead of their address.")
+	flags.Int64Var(&o.ContentPeerMaxBytes, "content-peer-max-bytes", replica.DefaultMaxFetchBytes, "Maximum size in bytes of content fetched from a peer for a file this replica doesn't know yet. Content of known files is limited to their size.")
+	flags.StringSliceVar(&o.FsckRefetchOrigins, "fsck-refetch-origins", nil, "Origin (<scheme>://<host>[:<port>]) a consistency check repair with refetch=true may fetch file content again from, if it is the file's spec.resourceLocation. May be repeated; if unset, content is never fetched again.")
+	flags.StringVar(&o.ContentURLSigningKeyFile, "content-url-signing-key-file", "", "File with the key (at least 32 bytes) signing URLs for file content. If empty, a random key is used and signed URLs are only valid for this process; required with --content-peers or --content-peer-service.")
 
 	// The following lines demonstrate how to configure version compatibility and feature gates
 	// for the "Wardle" component, as an example of KEP-4330.
@@ -181,6 +269,18 @@ func (o ServerOptions) Validate(args []string) error {
 	errors := []error{}
 	errors = append(errors, o.RecommendedOptions.Validate()...)
 	errors = append(errors, o.ComponentGlobalsRegistry.Validate()...)
+	if len(o.ContentPeers) > 0 && o.ContentPeerService != "" {
+		errors = append(errors, fmt.Errorf("--content-peers and --content-peer-service are mutually exclusive"))
+	}
+	// Replicas verify the URLs signed by each other
+	if (len(o.ContentPeers) > 0 || o.ContentPeerService != "") && o.ContentURLSigningKeyFile == "" {
+		errors = append(errors, fmt.Errorf("--content-url-signing-key-file is required with --content-peers or --content-peer-service"))
+	}
+	for _, origin := range o.FsckRefetchOrigins {
+		if err := filestorage.ValidateOrigin(origin); err != nil {
+			errors = append(errors, fmt.Errorf("--fsck-refetch-origins: %w", err))
+		}
+	}
 	return utilerrors.NewAggregate(errors)
 }
 
@@ -204,11 +304,10 @@ func (o *ServerOptions) Config() (*apiserver.Config, error) {
 	}
 
 	o.RecommendedOptions.ExtraAdmissionInitializers = func(c *genericapiserver.RecommendedConfig) ([]admission.PluginInitializer, error) {
-		client, err := clientset.NewForConfig(c.LoopbackClientConfig)
+		informerFactory, err := newInformerFactory(c.LoopbackClientConfig)
 		if err != nil {
 			return nil, err
 		}
-		informerFactory := informers.NewSharedInformerFactory(client, c.LoopbackClientConfig.Timeout)
 		o.SharedInformerFactory = informerFactory
 		return []admission.PluginInitializer{initializer.New(informerFactory)}, nil
 	}
@@ -229,18 +328,166 @@ func (o *ServerOptions) Config() (*apiserver.Config, error) {
 	if err := o.RecommendedOptions.ApplyTo(serverConfig); err != nil {
 		return nil, err
 	}
+	if o.SharedInformerFactory == nil {
+		// Admission is disabled, so the informer factory was not created by its initializer
+		informerFactory, err := newInformerFactory(serverConfig.LoopbackClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		o.SharedInformerFactory = informerFactory
+	}
+	eventRecorder, err := newEventRecorder(serverConfig)
+	if err != nil {
+		return nil, err
+	}
+	if o.ContentEncryptionConfig != "" {
+		encryptionConfig, err := encryption.LoadConfig(o.ContentEncryptionConfig)
+		if err != nil {
+			return nil, err
+		}
+		// KMS plugin connections live as long as the process
+		keyring, err := encryption.NewKeyring(encryptionConfig, encryption.DialGRPC(context.Background()))
+		if err != nil {
+			return nil, err
+		}
+		o.encryptedContent = encryption.NewStore(content.NewMemoryStore(), keyring)
+	}
+
+	urlSigner, err := o.newURLSigner()
+	if err != nil {
+		return nil, err
+	}
 
 	config := &apiserver.Config{
 		GenericConfig: serverConfig,
 		ExtraConfig: apiserver.ExtraConfig{
-			ExternalHost: o.ExternalHost,
+			ExternalHost:       o.ExternalHost,
+			ArchiveLimits:      o.ArchiveLimits,
+			ContentCache:       o.ContentCache,
+			EventRecorder:      eventRecorder,
+			URLSigner:          urlSigner,
+			FileInformer:       o.SharedInformerFactory.Cdn().V1alpha1().Files(),
+			FsckRefetchOrigins: o.FsckRefetchOrigins,
 		},
 	}
+	if o.encryptedContent != nil {
+		config.ExtraConfig.ContentStore = o.encryptedContent
+	}
+	contentReplica, err := o.newContentReplica(serverConfig, config.ExtraConfig.ContentStore)
+	if err != nil {
+		return nil, err
+	}
+	config.ExtraConfig.ContentReplica = contentReplica
 	return config, nil
 }
 
+// newURLSigner returns the signer of URLs for file content with the key from
+// ContentURLSigningKeyFile, or nil for a random key if it is not set, which
+// Validate only allows without peers.
+func (o *ServerOptions) newURLSigner() (*signedurl.Signer, error) {
+	if o.ContentURLSigningKeyFile == "" {
+		return nil, nil
+	}
+	return signedurl.LoadSigner(o.ContentURLSigningKeyFile)
+}
+
+// newContentReplica returns the store sharing content with the peers set by
+// the content peer flags, in front of local, or nil if no peers are set.
+func (o *ServerOptions) newContentReplica(c *genericapiserver.RecommendedConfig, local content.Store) (*replica.Store, error) {
+	if len(o.ContentPeers) == 0 && o.ContentPeerService == "" {
+		return nil, nil
+	}
+	if local == nil {
+		local = content.NewMemoryStore()
+	}
+
+	var peers replica.Peers = replica.StaticPeers(o.ContentPeers)
+	if o.ContentPeerService != "" {
+		namespace, name, ok := strings.Cut(o.ContentPeerService, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("--content-peer-service must be <namespace>/<name>, got %q", o.ContentPeerService)
+		}
+		if c.ClientConfig == nil {
+			return nil, fmt.Errorf("--content-peer-service requires access to the core API")
+		}
+		client, err := kubernetes.NewForConfig(c.ClientConfig)
+		if err != nil {
+			return nil, err
+		}
+		o.peerInformerFactory = kubeinformers.NewSharedInformerFactoryWithOptions(client, 0,
+			kubeinformers.WithNamespace(namespace),
+			kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = discoveryv1.LabelServiceName + "=" + name
+			}),
+		)
+		peers = replica.NewEndpointSlicePeers(o.peerInformerFactory.Discovery().V1().EndpointSlices())
+	}
+
+	client, err := newPeerClient(c.ClientConfig, o.ContentPeerCAFile, o.ContentPeerServerName)
+	if err != nil {
+		return nil, err
+	}
+	return replica.NewStore(local, o.SharedInformerFactory.Cdn().V1alpha1().Files(), peers, client, o.ContentPeerMaxBytes)
+}
+
+// newPeerClient returns the client fetching content from peers. It
+// authenticates with the credentials this server uses for the core API, if
+// any, and verifies the peers with caFile and serverName.
+func newPeerClient(clientConfig *rest.Config, caFile, serverName string) (*http.Client, error) {
+	config := &rest.Config{}
+	if clientConfig != nil {
+		config.BearerToken = clientConfig.BearerToken
+		config.BearerTokenFile = clientConfig.BearerTokenFile
+		config.CertFile = clientConfig.CertFile
+		config.CertData = clientConfig.CertData
+		config.KeyFile = clientConfig.KeyFile
+		config.KeyData = clientConfig.KeyData
+	}
+	config.CAFile = caFile
+	config.ServerName = serverName
+	return rest.HTTPClientFor(config)
+}
+
+// newInformerFactory returns an informer factory for the CDN API group served
+// at the given loopback config, with the File indexers registered.
+func newInformerFactory(loopbackConfig *rest.Config) (informers.SharedInformerFactory, error) {
+	client, err := clientset.NewForConfig(loopbackConfig)
+	if err != nil {
+		return nil, err
+	}
+	informerFactory := informers.NewSharedInformerFactory(client, loopbackConfig.Timeout)
+	if err := indexers.AddFileIndexers(informerFactory); err != nil {
+		return nil, err
+	}
+	return informerFactory, nil
+}
+
+// newEventRecorder returns a recorder writing Events to the cluster's core API,
+// which this server does not serve itself. Events are discarded if no core API
+// is configured.
+func newEventRecorder(c *genericapiserver.RecommendedConfig) (record.EventRecorder, error) {
+	if c.ClientConfig == nil {
+		return events.Discard(), nil
+	}
+	client, err := kubernetes.NewForConfig(c.ClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	broadcaster := record.NewBroadcaster()
+	if err := c.AddPostStartHook("start-event-broadcaster", func(context genericapiserver.PostStartHookContext) error {
+		events.Start(broadcaster, client.CoreV1(), context.Done())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return events.NewRecorder(broadcaster, apiserver.Scheme), nil
+}
+
 // RunServer starts a new Server given ServerOptions
 func (o ServerOptions) RunServer(ctx context.Context) error {
+	// Content staged before now belongs to uploads of a previous process
+	started := time.Now()
+
 	config, err := o.Config()
 	if err != nil {
 		return err
@@ -251,11 +498,67 @@ func (o ServerOptions) RunServer(ctx context.Context) error {
 		return err
 	}
 
+	client, err := clientset.NewForConfig(config.GenericConfig.LoopbackClientConfig)
+	if err != nil {
+		return err
+	}
+	siteController, err := sitecontroller.NewController(client,
+		o.SharedInformerFactory.Cdn().V1alpha1().Sites(),
+		o.SharedInformerFactory.Cdn().V1alpha1().Files(),
+	)
+	if err != nil {
+		return err
+	}
+
+	purgeController, err := purgecontroller.NewController(client,
+		o.SharedInformerFactory.Cdn().V1alpha1().Purges(),
+		o.SharedInformerFactory.Cdn().V1alpha1().Files(),
+		purge.NewNotifier(o.PurgeWebhooks, o.PurgeWebhookTimeout),
+		config.ExtraConfig.EventRecorder,
+	)
+	if err != nil {
+		return err
+	}
+
 	server.GenericAPIServer.AddPostStartHookOrDie("start-sample-server-informers", func(context genericapiserver.PostStartHookContext) error {
-		config.GenericConfig.SharedInformerFactory.Start(context.Done())
+		if config.GenericConfig.SharedInformerFactory != nil {
+			config.GenericConfig.SharedInformerFactory.Start(context.Done())
+		}
 		o.SharedInformerFactory.Start(context.Done())
+		if o.peerInformerFactory != nil {
+			o.peerInformerFactory.Start(context.Done())
+		}
 		return nil
 	})
 
+	// Interrupted uploads are recovered in the background; uploads in flight
+	// were staged after started and are left alone
+	server.GenericAPIServer.AddPostStartHookOrDie("recover-staged-content", func(context genericapiserver.PostStartHookContext) error {
+		go func() {
+			if _, err := recovery.Recover(context, config.ExtraConfig.ContentStore, client.CdnV1alpha1(), started); err != nil {
+				utilruntime.HandleErrorWithContext(context, err, "Failed to recover staged content")
+			}
+		}()
+		return nil
+	})
+
+	server.GenericAPIServer.AddPostStartHookOrDie("start-site-controller", func(context genericapiserver.PostStartHookContext) error {
+		go siteController.Run(context, 1)
+		return nil
+	})
+
+	server.GenericAPIServer.AddPostStartHookOrDie("start-purge-controller", func(context genericapiserver.PostStartHookContext) error {
+		go purgeController.Run(context, 1)
+		return nil
+	})
+
+	if o.encryptedContent != nil {
+		rewrapController := rewrapcontroller.NewController(o.SharedInformerFactory.Cdn().V1alpha1().Files(), o.encryptedContent, o.ContentRewrapInterval)
+		server.GenericAPIServer.AddPostStartHookOrDie("start-content-rewrap-controller", func(context genericapiserver.PostStartHookContext) error {
+			go rewrapController.Run(context)
+			return nil
+		})
+	}
+
 	return server.GenericAPIServer.PrepareRun().RunWithContext(ctx)
 }
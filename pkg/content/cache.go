@@ -0,0 +1,267 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CacheOptions configures a CachedStore.
+type CacheOptions struct {
+	// MaxBytes is the total size of cached content. Zero disables the cache.
+	MaxBytes int64
+	// MinObjectBytes is the size below which objects are not cached.
+	MinObjectBytes int64
+	// MaxObjectBytes is the size above which objects are not cached.
+	MaxObjectBytes int64
+}
+
+// CacheStats are counters of a CachedStore.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	// Objects and Bytes describe what is currently cached.
+	Objects int64
+	Bytes   int64
+}
+
+// CachedStore is a Store that keeps recently read content in a size-bounded
+// LRU cache in front of another Store. Concurrent misses for the same object
+// are filled by a single backend read. Put and Delete invalidate the cached
+// object. Cached objects are shared and must not be modified.
+type CachedStore struct {
+	backend Store
+	options CacheOptions
+	group   singleflight.Group
+
+	lock    sync.Mutex
+	lru     *list.List
+	entries map[types.NamespacedName]*list.Element
+	// invalidations counts Put and Delete calls, so fills racing with them
+	// are not cached
+	invalidations uint64
+	stats         CacheStats
+}
+
+type cacheEntry struct {
+	key types.NamespacedName
+	obj *Object
+	// accessed is when the object was last read from the cache, which the
+	// backend does not see
+	accessed time.Time
+}
+
+var _ Store = &CachedStore{}
+
+// NewCachedStore returns a CachedStore in front of backend. Its counters are
+// also published as metrics once RegisterMetrics is called.
+func NewCachedStore(backend Store, options CacheOptions) *CachedStore {
+	return &CachedStore{
+		backend: backend,
+		options: options,
+		lru:     list.New(),
+		entries: make(map[types.NamespacedName]*list.Element),
+	}
+}
+
+func (s *CachedStore) Get(ctx context.Context, namespace, name string) (*Object, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+
+	s.lock.Lock()
+	if elem, ok := s.entries[key]; ok {
+		s.lru.MoveToFront(elem)
+		elem.Value.(*cacheEntry).accessed = time.Now()
+		s.stats.Hits++
+		s.updateGauges()
+		s.lock.Unlock()
+		cacheHits.Inc()
+		return elem.Value.(*cacheEntry).obj, nil
+	}
+	s.stats.Misses++
+	s.updateGauges()
+	s.lock.Unlock()
+	cacheMisses.Inc()
+
+	// The fill is shared with concurrent callers, so it isn't canceled with
+	// the context of the caller that started it. Each caller stops waiting
+	// once its own context is done.
+	fill := s.group.DoChan(key.String(), func() (interface{}, error) {
+		s.lock.Lock()
+		invalidations := s.invalidations
+		s.lock.Unlock()
+
+		obj, err := s.backend.Get(context.WithoutCancel(ctx), namespace, name)
+		if err != nil {
+			if !IsNotFound(err) {
+				originFetchErrors.Inc()
+			}
+			return nil, err
+		}
+		s.add(key, obj, invalidations)
+		return obj, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-fill:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*Object), nil
+	}
+}
+
+// Stat describes the content in the backend, marked as Cached and with its
+// last cache hit if it is held by the cache.
+func (s *CachedStore) Stat(ctx context.Context, namespace, name string) (*ObjectInfo, error) {
+	info, err := s.backend.Stat(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if elem, ok := s.entries[types.NamespacedName{Namespace: namespace, Name: name}]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.obj.Checksum == info.Checksum {
+			info.Cached = true
+			if entry.accessed.After(info.Accessed) {
+				info.Accessed = entry.accessed
+			}
+		}
+	}
+	return info, nil
+}
+
+// Open reads the content from the backend without caching it
+func (s *CachedStore) Open(ctx context.Context, namespace, name string) (io.ReadCloser, error) {
+	return Open(ctx, s.backend, namespace, name)
+}
+
+func (s *CachedStore) Put(ctx context.Context, namespace, name string, obj *Object) error {
+	defer s.invalidate(types.NamespacedName{Namespace: namespace, Name: name})
+	return s.backend.Put(ctx, namespace, name, obj)
+}
+
+func (s *CachedStore) Delete(ctx context.Context, namespace, name string) error {
+	defer s.invalidate(types.NamespacedName{Namespace: namespace, Name: name})
+	return s.backend.Delete(ctx, namespace, name)
+}
+
+func (s *CachedStore) Stage(ctx context.Context, namespace, name string, obj *Object) (string, error) {
+	return s.backend.Stage(ctx, namespace, name, obj)
+}
+
+func (s *CachedStore) Promote(ctx context.Context, namespace, name, id string) error {
+	defer s.invalidate(types.NamespacedName{Namespace: namespace, Name: name})
+	return s.backend.Promote(ctx, namespace, name, id)
+}
+
+func (s *CachedStore) Discard(ctx context.Context, namespace, name, id string) error {
+	return s.backend.Discard(ctx, namespace, name, id)
+}
+
+func (s *CachedStore) ListStaged(ctx context.Context) ([]StagedObject, error) {
+	return s.backend.ListStaged(ctx)
+}
+
+func (s *CachedStore) List(ctx context.Context) ([]types.NamespacedName, error) {
+	return s.backend.List(ctx)
+}
+
+// Invalidate drops the cached content of the named File, which was changed
+// without going through the CachedStore.
+func (s *CachedStore) Invalidate(namespace, name string) {
+	s.invalidate(types.NamespacedName{Namespace: namespace, Name: name})
+}
+
+// Stats returns the current counters of the cache.
+func (s *CachedStore) Stats() CacheStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stats
+}
+
+// add caches obj unless it is outside the configured object size bounds or
+// the cache was invalidated since the fill started
+func (s *CachedStore) add(key types.NamespacedName, obj *Object, invalidations uint64) {
+	size := int64(len(obj.Data))
+	if size < s.options.MinObjectBytes || size > s.options.MaxBytes ||
+		(s.options.MaxObjectBytes > 0 && size > s.options.MaxObjectBytes) {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.invalidations != invalidations {
+		return
+	}
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	s.entries[key] = s.lru.PushFront(&cacheEntry{key: key, obj: obj})
+	s.stats.Objects++
+	s.stats.Bytes += size
+
+	for s.stats.Bytes > s.options.MaxBytes {
+		s.remove(s.lru.Back())
+		s.stats.Evictions++
+		cacheEvictions.Inc()
+	}
+	s.updateGauges()
+}
+
+// invalidate drops the cached object for key. Fills in flight are forgotten,
+// so later reads don't share a fill that may have read the old content.
+func (s *CachedStore) invalidate(key types.NamespacedName) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.invalidations++
+	s.group.Forget(key.String())
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+		s.updateGauges()
+	}
+}
+
+// updateGauges publishes the size and hit ratio of the cache. The lock must
+// be held.
+func (s *CachedStore) updateGauges() {
+	if reads := s.stats.Hits + s.stats.Misses; reads > 0 {
+		cacheHitRatio.Set(float64(s.stats.Hits) / float64(reads))
+	}
+	cacheBytes.Set(float64(s.stats.Bytes))
+	cacheObjects.Set(float64(s.stats.Objects))
+}
+
+// remove drops elem from the cache. The lock must be held.
+func (s *CachedStore) remove(elem *list.Element) {
+	entry := s.lru.Remove(elem).(*cacheEntry)
+	delete(s.entries, entry.key)
+	s.stats.Objects--
+	s.stats.Bytes -= int64(len(entry.obj.Data))
+}
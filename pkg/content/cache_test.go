@@ -0,0 +1,206 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts Get calls and optionally blocks them after reading
+// until release is closed
+type countingStore struct {
+	Store
+	gets    atomic.Int32
+	release chan struct{}
+}
+
+func (s *countingStore) Get(ctx context.Context, namespace, name string) (*Object, error) {
+	obj, err := s.Store.Get(ctx, namespace, name)
+	s.gets.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return obj, err
+}
+
+func newObject(size int) *Object {
+	return &Object{Data: []byte(strings.Repeat("x", size))}
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStore{Store: NewMemoryStore()}
+	cache := NewCachedStore(backend, CacheOptions{MaxBytes: 10, MinObjectBytes: 2, MaxObjectBytes: 6})
+
+	for name, size := range map[string]int{"a": 4, "b": 4, "c": 4, "tiny": 1, "huge": 7} {
+		require.NoError(t, cache.Put(ctx, "ns", name, newObject(size)))
+	}
+
+	read := func(name string) {
+		_, err := cache.Get(ctx, "ns", name)
+		require.NoError(t, err)
+	}
+
+	// Objects outside the size bounds are never cached
+	read("tiny")
+	read("tiny")
+	read("huge")
+	read("huge")
+	assert.EqualValues(t, 4, backend.gets.Load())
+	assert.Equal(t, CacheStats{Misses: 4}, cache.Stats())
+
+	// a and b fit, reading c evicts the least recently used a
+	read("a")
+	read("b")
+	read("a")
+	read("c")
+	assert.EqualValues(t, 7, backend.gets.Load())
+	read("a")
+	read("c")
+	assert.EqualValues(t, 7, backend.gets.Load())
+	read("b")
+	assert.EqualValues(t, 8, backend.gets.Load())
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 8, Evictions: 2, Objects: 2, Bytes: 8}, cache.Stats())
+
+	// Put and Delete invalidate
+	require.NoError(t, cache.Put(ctx, "ns", "b", newObject(3)))
+	obj, err := cache.Get(ctx, "ns", "b")
+	require.NoError(t, err)
+	assert.Len(t, obj.Data, 3)
+	require.NoError(t, cache.Delete(ctx, "ns", "b"))
+	_, err = cache.Get(ctx, "ns", "b")
+	assert.True(t, IsNotFound(err))
+
+	// Stat reports whether the content is cached and its last cache hit
+	info, err := cache.Stat(ctx, "ns", "c")
+	require.NoError(t, err)
+	assert.True(t, info.Cached)
+	assert.False(t, info.Accessed.IsZero())
+	info, err = cache.Stat(ctx, "ns", "huge")
+	require.NoError(t, err)
+	assert.False(t, info.Cached)
+	_, err = cache.Stat(ctx, "ns", "b")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestCachedStoreSingleFlight(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStore{Store: NewMemoryStore(), release: make(chan struct{})}
+	cache := NewCachedStore(backend, CacheOptions{MaxBytes: 100})
+	require.NoError(t, backend.Store.Put(ctx, "ns", "a", newObject(4)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obj, err := cache.Get(ctx, "ns", "a")
+			assert.NoError(t, err)
+			assert.Len(t, obj.Data, 4)
+		}()
+	}
+	// Wait until one fill is in flight, then let it finish
+	require.Eventually(t, func() bool { return backend.gets.Load() > 0 }, time.Second, time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+
+	assert.LessOrEqual(t, backend.gets.Load(), int32(10))
+	_, err := cache.Get(ctx, "ns", "a")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, cache.Stats().Objects)
+}
+
+func TestCachedStoreFillOutlivesCaller(t *testing.T) {
+	backend := &countingStore{Store: NewMemoryStore(), release: make(chan struct{})}
+	release := sync.OnceFunc(func() { close(backend.release) })
+	defer release()
+	cache := NewCachedStore(backend, CacheOptions{MaxBytes: 100})
+	require.NoError(t, backend.Store.Put(context.Background(), "ns", "a", newObject(4)))
+
+	// The first caller starts the fill and gives up while it is in flight
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := cache.Get(ctx, "ns", "a")
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return backend.gets.Load() > 0 }, time.Second, time.Millisecond)
+	second := make(chan error)
+	go func() {
+		_, err := cache.Get(context.Background(), "ns", "a")
+		second <- err
+	}()
+	cancel()
+	select {
+	case err := <-first:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("Get did not return when its context was canceled")
+	}
+
+	// The caller waiting for the same fill still gets the content
+	release()
+	assert.NoError(t, <-second)
+	assert.EqualValues(t, 1, backend.gets.Load())
+	assert.EqualValues(t, 1, cache.Stats().Objects)
+}
+
+func TestCachedStoreFillRacingPut(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStore{Store: NewMemoryStore(), release: make(chan struct{})}
+	release := sync.OnceFunc(func() { close(backend.release) })
+	defer release()
+	cache := NewCachedStore(backend, CacheOptions{MaxBytes: 100})
+	require.NoError(t, backend.Store.Put(ctx, "ns", "a", newObject(4)))
+
+	first := make(chan *Object, 1)
+	go func() {
+		obj, err := cache.Get(ctx, "ns", "a")
+		assert.NoError(t, err)
+		first <- obj
+	}()
+	require.Eventually(t, func() bool { return backend.gets.Load() > 0 }, time.Second, time.Millisecond)
+	// The fill read the old content, which must not end up in the cache
+	require.NoError(t, cache.Put(ctx, "ns", "a", newObject(5)))
+
+	// A read after the Put starts its own fill instead of sharing the old one
+	second := make(chan *Object, 1)
+	go func() {
+		obj, err := cache.Get(ctx, "ns", "a")
+		assert.NoError(t, err)
+		second <- obj
+	}()
+	require.Eventually(t, func() bool { return backend.gets.Load() > 1 }, time.Second, time.Millisecond)
+	release()
+	assert.Len(t, (<-first).Data, 4)
+	assert.Len(t, (<-second).Data, 5)
+
+	obj, err := cache.Get(ctx, "ns", "a")
+	require.NoError(t, err)
+	assert.Len(t, obj.Data, 5)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package encryption encrypts File content at rest with envelope encryption:
+// every object is encrypted with its own AES-GCM data key, which is wrapped by
+// a key encryption key from a local key or a KMS plugin.
+package encryption
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// ConfigKind is the kind of a content encryption configuration file.
+const ConfigKind = "ContentEncryptionConfiguration"
+
+// AllNamespaces matches every namespace in Rule.Namespaces.
+const AllNamespaces = "*"
+
+// defaultKMSTimeout bounds calls to KMS plugins without a configured timeout.
+const defaultKMSTimeout = 3 * time.Second
+
+// Config configures the encryption of content at rest. It is modelled on the
+// apiserver's EncryptionConfiguration, with namespaces in place of resources.
+type Config struct {
+	metav1.TypeMeta `json:",inline"`
+	// Rules select the providers for the content of a namespace. The first
+	// rule matching a namespace applies; content of namespaces without a rule
+	// is stored in plaintext.
+	Rules []Rule `json:"rules"`
+}
+
+// Rule selects the providers for the content of a set of namespaces.
+type Rule struct {
+	// Namespaces are the namespaces the rule applies to. "*" matches every
+	// namespace.
+	Namespaces []string `json:"namespaces"`
+	// Providers wrap the data keys of the content. The first provider wraps
+	// new data keys; all of them can unwrap existing ones.
+	Providers []ProviderConfig `json:"providers"`
+}
+
+// ProviderConfig configures exactly one provider.
+type ProviderConfig struct {
+	// AESGCM wraps data keys with local AES-GCM keys.
+	AESGCM *AESGCMConfig `json:"aesgcm,omitempty"`
+	// KMS wraps data keys with a KMS v2 plugin.
+	KMS *KMSConfig `json:"kms,omitempty"`
+	// Identity stores content in plaintext and reads unencrypted content.
+	Identity *IdentityConfig `json:"identity,omitempty"`
+}
+
+// AESGCMConfig configures local key encryption keys.
+type AESGCMConfig struct {
+	// Keys are the key encryption keys. The first key wraps new data keys.
+	Keys []Key `json:"keys"`
+}
+
+// Key is a named key encryption key.
+type Key struct {
+	// Name identifies the key in wrapped data keys.
+	Name string `json:"name"`
+	// Secret is the base64-encoded 16, 24 or 32 byte key.
+	Secret string `json:"secret"`
+}
+
+// KMSConfig configures a KMS v2 plugin.
+type KMSConfig struct {
+	// Name identifies the plugin in wrapped data keys.
+	Name string `json:"name"`
+	// Endpoint is the unix socket of the plugin, e.g. unix:///tmp/kms.sock.
+	Endpoint string `json:"endpoint"`
+	// Timeout bounds calls to the plugin. Defaults to 3s.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// IdentityConfig configures the identity provider. It has no options.
+type IdentityConfig struct{}
+
+// LoadConfig reads and validates the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading content encryption configuration: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes and validates a YAML or JSON configuration.
+func ParseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("error decoding content encryption configuration: %w", err)
+	}
+	if errs := ValidateConfig(config); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid content encryption configuration: %w", errs.ToAggregate())
+	}
+	return config, nil
+}
+
+// ValidateConfig validates a configuration.
+func ValidateConfig(config *Config) field.ErrorList {
+	var allErrs field.ErrorList
+	if config.Kind != "" && config.Kind != ConfigKind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), config.Kind, []string{ConfigKind}))
+	}
+
+	rulesPath := field.NewPath("rules")
+	kmsNames := sets.New[string]()
+	for i, rule := range config.Rules {
+		rulePath := rulesPath.Index(i)
+		if len(rule.Namespaces) == 0 {
+			allErrs = append(allErrs, field.Required(rulePath.Child("namespaces"), ""))
+		}
+		for j, namespace := range rule.Namespaces {
+			if namespace == "" {
+				allErrs = append(allErrs, field.Required(rulePath.Child("namespaces").Index(j), ""))
+			}
+		}
+		if len(rule.Providers) == 0 {
+			allErrs = append(allErrs, field.Required(rulePath.Child("providers"), ""))
+		}
+		for j, provider := range rule.Providers {
+			allErrs = append(allErrs, validateProvider(&provider, rulePath.Child("providers").Index(j), kmsNames)...)
+		}
+	}
+	return allErrs
+}
+
+func validateProvider(provider *ProviderConfig, fldPath *field.Path, kmsNames sets.Set[string]) field.ErrorList {
+	var allErrs field.ErrorList
+	count := 0
+	if provider.AESGCM != nil {
+		count++
+		allErrs = append(allErrs, validateAESGCM(provider.AESGCM, fldPath.Child("aesgcm"))...)
+	}
+	if provider.KMS != nil {
+		count++
+		allErrs = append(allErrs, validateKMS(provider.KMS, fldPath.Child("kms"), kmsNames)...)
+	}
+	if provider.Identity != nil {
+		count++
+	}
+	if count != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, count, "must configure exactly one of aesgcm, kms or identity"))
+	}
+	return allErrs
+}
+
+func validateAESGCM(config *AESGCMConfig, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(config.Keys) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("keys"), ""))
+	}
+	names := sets.New[string]()
+	for i, key := range config.Keys {
+		keyPath := fldPath.Child("keys").Index(i)
+		switch {
+		case key.Name == "":
+			allErrs = append(allErrs, field.Required(keyPath.Child("name"), ""))
+		case names.Has(key.Name):
+			allErrs = append(allErrs, field.Duplicate(keyPath.Child("name"), key.Name))
+		}
+		names.Insert(key.Name)
+		if _, err := decodeKey(key.Secret); err != nil {
+			allErrs = append(allErrs, field.Invalid(keyPath.Child("secret"), "REDACTED", err.Error()))
+		}
+	}
+	return allErrs
+}
+
+func validateKMS(config *KMSConfig, fldPath *field.Path, kmsNames sets.Set[string]) field.ErrorList {
+	var allErrs field.ErrorList
+	// Wrapped data keys name their plugin, so names must be unique
+	switch {
+	case config.Name == "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	case kmsNames.Has(config.Name):
+		allErrs = append(allErrs, field.Duplicate(fldPath.Child("name"), config.Name))
+	}
+	kmsNames.Insert(config.Name)
+	if config.Endpoint == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("endpoint"), ""))
+	}
+	if config.Timeout != nil && config.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), config.Timeout.Duration.String(), "must be positive"))
+	}
+	return allErrs
+}
+
+// decodeKey decodes a base64-encoded AES key
+func decodeKey(secret string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("must be base64-encoded: %v", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("must be 16, 24 or 32 bytes, got %d", len(key))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+kind: ContentEncryptionConfiguration
+apiVersion: cdn.k8s.toms.place/v1alpha1
+rules:
+  - namespaces: [web]
+    providers:
+      - aesgcm:
+          keys:
+            - name: web-2
+              secret: AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=
+            - name: web-1
+              secret: AAAAAAAAAAAAAAAAAAAAAA==
+      - identity: {}
+  - namespaces: ["*"]
+    providers:
+      - kms:
+          name: vault
+          endpoint: unix:///run/kms/vault.sock
+          timeout: 5s
+`))
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 2)
+	assert.Equal(t, []string{"web"}, config.Rules[0].Namespaces)
+	assert.Equal(t, "web-2", config.Rules[0].Providers[0].AESGCM.Keys[0].Name)
+	assert.NotNil(t, config.Rules[0].Providers[1].Identity)
+	assert.Equal(t, "5s", config.Rules[1].Providers[0].KMS.Timeout.Duration.String())
+}
+
+func TestParseConfigInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": `
+rules:
+  - namespaces: [web]
+    providers: [{identity: {}}]
+    resources: [files]
+`,
+		"wrong kind": `
+kind: EncryptionConfiguration
+rules: []
+`,
+		"no namespaces": `
+rules:
+  - providers: [{identity: {}}]
+`,
+		"no providers": `
+rules:
+  - namespaces: [web]
+`,
+		"two provider types": `
+rules:
+  - namespaces: [web]
+    providers:
+      - identity: {}
+        aesgcm: {keys: [{name: a, secret: AAAAAAAAAAAAAAAAAAAAAA==}]}
+`,
+		"short key": `
+rules:
+  - namespaces: [web]
+    providers:
+      - aesgcm: {keys: [{name: a, secret: AAAA}]}
+`,
+		"duplicate key name": `
+rules:
+  - namespaces: [web]
+    providers:
+      - aesgcm:
+          keys:
+            - {name: a, secret: AAAAAAAAAAAAAAAAAAAAAA==}
+            - {name: a, secret: AAAAAAAAAAAAAAAAAAAAAA==}
+`,
+		"duplicate kms name": `
+rules:
+  - namespaces: [web]
+    providers: [{kms: {name: vault, endpoint: unix:///a.sock}}]
+  - namespaces: [shop]
+    providers: [{kms: {name: vault, endpoint: unix:///b.sock}}]
+`,
+		"kms without endpoint": `
+rules:
+  - namespaces: [web]
+    providers: [{kms: {name: vault}}]
+`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fakekms implements an in-process KMS v2 plugin for tests and local
+// development. Its keys live in memory and are lost when the process exits.
+package fakekms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"sync"
+
+	kmsservice "k8s.io/kms/pkg/service"
+)
+
+// Service is a KMS v2 plugin holding its key encryption keys in memory.
+type Service struct {
+	lock sync.Mutex
+	// keys are the key encryption keys by key ID
+	keys map[string]cipher.AEAD
+	// keyID is the ID of the key encrypting new data
+	keyID string
+	// err is returned by all calls if set
+	err error
+}
+
+var _ kmsservice.Service = &Service{}
+
+// New returns a Service with a single key.
+func New() *Service {
+	s := &Service{keys: map[string]cipher.AEAD{}}
+	s.Rotate()
+	return s
+}
+
+// Rotate adds a key and uses it to encrypt new data. Previous keys can still
+// decrypt. It returns the ID of the new key.
+func (s *Service) Rotate() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	s.keyID = "fake-key-" + strconv.Itoa(len(s.keys)+1)
+	s.keys[s.keyID] = aead
+	return s.keyID
+}
+
+// Forget removes the key with keyID, so data encrypted with it can no longer
+// be decrypted.
+func (s *Service) Forget(keyID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.keys, keyID)
+}
+
+// SetError makes all calls fail with err, or succeed again if err is nil.
+func (s *Service) SetError(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.err = err
+}
+
+// Encrypt encrypts data with the current key.
+func (s *Service) Encrypt(_ context.Context, uid string, data []byte) (*kmsservice.EncryptResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	aead := s.keys[s.keyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &kmsservice.EncryptResponse{
+		Ciphertext: aead.Seal(nonce, nonce, data, []byte(s.keyID)),
+		KeyID:      s.keyID,
+	}, nil
+}
+
+// Decrypt decrypts data encrypted with any key that was not forgotten.
+func (s *Service) Decrypt(_ context.Context, uid string, req *kmsservice.DecryptRequest) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	aead, ok := s.keys[req.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", req.KeyID)
+	}
+	if len(req.Ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, ciphertext := req.Ciphertext[:aead.NonceSize()], req.Ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(req.KeyID))
+}
+
+// Status reports the current key ID.
+func (s *Service) Status(context.Context) (*kmsservice.StatusResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &kmsservice.StatusResponse{Version: "v2", Healthz: "ok", KeyID: s.keyID}, nil
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/storage/value/encrypt/envelope/kmsv2"
+	kmsservice "k8s.io/kms/pkg/service"
+)
+
+// kmsKeyIDTTL is how long the current key ID reported by a KMS plugin is
+// trusted before it is queried again.
+const kmsKeyIDTTL = time.Minute
+
+// KMSDialer connects to the plugin of a KMS provider.
+type KMSDialer func(config *KMSConfig) (kmsservice.Service, error)
+
+// DialGRPC returns a KMSDialer connecting to KMS v2 plugins over gRPC. The
+// connections are closed when ctx is done.
+func DialGRPC(ctx context.Context) KMSDialer {
+	return func(config *KMSConfig) (kmsservice.Service, error) {
+		timeout := defaultKMSTimeout
+		if config.Timeout != nil {
+			timeout = config.Timeout.Duration
+		}
+		return kmsv2.NewGRPCService(ctx, config.Endpoint, config.Name, timeout)
+	}
+}
+
+// Keyring holds the providers of every rule of a configuration.
+type Keyring struct {
+	rules []*ruleKeys
+}
+
+// ruleKeys are the providers of a rule
+type ruleKeys struct {
+	namespaces sets.Set[string]
+	// write wraps new data keys. It is nil if the first provider is identity.
+	write wrapper
+	// wrappers unwrap existing data keys by provider name
+	wrappers map[string]wrapper
+	// identity is true if unencrypted content may be read
+	identity bool
+}
+
+// NewKeyring returns the Keyring for config, connecting to KMS plugins with
+// dial. config must be valid.
+func NewKeyring(config *Config, dial KMSDialer) (*Keyring, error) {
+	keyring := &Keyring{}
+	kmsWrappers := map[string]wrapper{}
+	for _, rule := range config.Rules {
+		keys := &ruleKeys{
+			namespaces: sets.New(rule.Namespaces...),
+			wrappers:   map[string]wrapper{},
+		}
+		for i, provider := range rule.Providers {
+			var w wrapper
+			switch {
+			case provider.Identity != nil:
+				keys.identity = true
+				continue
+			case provider.AESGCM != nil:
+				aesWrapper, err := newAESGCMWrapper(provider.AESGCM)
+				if err != nil {
+					return nil, err
+				}
+				w = aesWrapper
+			case provider.KMS != nil:
+				w = kmsWrappers[provider.KMS.Name]
+				if w == nil {
+					service, err := dial(provider.KMS)
+					if err != nil {
+						return nil, fmt.Errorf("error connecting to KMS plugin %s: %w", provider.KMS.Name, err)
+					}
+					w = &kmsWrapper{pluginName: provider.KMS.Name, service: service}
+					kmsWrappers[provider.KMS.Name] = w
+				}
+			}
+			if i == 0 {
+				keys.write = w
+			}
+			if _, ok := keys.wrappers[w.name()]; !ok {
+				keys.wrappers[w.name()] = w
+			}
+		}
+		keyring.rules = append(keyring.rules, keys)
+	}
+	return keyring, nil
+}
+
+// forNamespace returns the providers for namespace, or nil if its content is
+// stored in plaintext.
+func (k *Keyring) forNamespace(namespace string) *ruleKeys {
+	for _, rule := range k.rules {
+		if rule.namespaces.Has(namespace) || rule.namespaces.Has(AllNamespaces) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// wrapper wraps data keys with a key encryption key
+type wrapper interface {
+	// name identifies the provider in envelopes
+	name() string
+	// wrap returns an envelope holding dek
+	wrap(ctx context.Context, uid string, dek []byte) (*envelope, error)
+	// unwrap returns the data key held by e
+	unwrap(ctx context.Context, uid string, e *envelope) ([]byte, error)
+	// current returns true if e is wrapped with the key that wraps new data keys
+	current(ctx context.Context, e *envelope) (bool, error)
+}
+
+// aesgcmWrapper wraps data keys with local AES-GCM keys
+type aesgcmWrapper struct {
+	// keys are in configuration order; the first wraps new data keys
+	keys []namedAEAD
+}
+
+type namedAEAD struct {
+	name string
+	aead cipher.AEAD
+}
+
+var _ wrapper = &aesgcmWrapper{}
+
+func newAESGCMWrapper(config *AESGCMConfig) (*aesgcmWrapper, error) {
+	w := &aesgcmWrapper{}
+	for _, key := range config.Keys {
+		secret, err := decodeKey(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.Name, err)
+		}
+		aead, err := newAEAD(secret)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.Name, err)
+		}
+		w.keys = append(w.keys, namedAEAD{name: key.Name, aead: aead})
+	}
+	return w, nil
+}
+
+func (w *aesgcmWrapper) name() string {
+	return "aesgcm"
+}
+
+func (w *aesgcmWrapper) wrap(_ context.Context, _ string, dek []byte) (*envelope, error) {
+	key := w.keys[0]
+	wrapped, err := seal(key.aead, dek, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope{Provider: w.name(), Key: key.name, WrappedKey: wrapped}, nil
+}
+
+func (w *aesgcmWrapper) unwrap(_ context.Context, _ string, e *envelope) ([]byte, error) {
+	for _, key := range w.keys {
+		if key.name == e.Key {
+			return open(key.aead, e.WrappedKey, nil)
+		}
+	}
+	return nil, fmt.Errorf("aesgcm key %q is not configured", e.Key)
+}
+
+func (w *aesgcmWrapper) current(_ context.Context, e *envelope) (bool, error) {
+	return e.Key == w.keys[0].name, nil
+}
+
+// kmsWrapper wraps data keys with a KMS v2 plugin
+type kmsWrapper struct {
+	pluginName string
+	service    kmsservice.Service
+
+	lock      sync.Mutex
+	keyID     string
+	keyIDTime time.Time
+}
+
+var _ wrapper = &kmsWrapper{}
+
+func (w *kmsWrapper) name() string {
+	return "kms:" + w.pluginName
+}
+
+func (w *kmsWrapper) wrap(ctx context.Context, uid string, dek []byte) (*envelope, error) {
+	resp, err := w.service.Encrypt(ctx, uid, dek)
+	if err != nil {
+		return nil, fmt.Errorf("KMS plugin %s failed to wrap data key: %w", w.pluginName, err)
+	}
+	if resp.KeyID == "" {
+		return nil, fmt.Errorf("KMS plugin %s returned an empty key ID", w.pluginName)
+	}
+	return &envelope{Provider: w.name(), Key: resp.KeyID, WrappedKey: resp.Ciphertext, Annotations: resp.Annotations}, nil
+}
+
+func (w *kmsWrapper) unwrap(ctx context.Context, uid string, e *envelope) ([]byte, error) {
+	dek, err := w.service.Decrypt(ctx, uid, &kmsservice.DecryptRequest{
+		Ciphertext:  e.WrappedKey,
+		KeyID:       e.Key,
+		Annotations: e.Annotations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("KMS plugin %s failed to unwrap data key: %w", w.pluginName, err)
+	}
+	return dek, nil
+}
+
+func (w *kmsWrapper) current(ctx context.Context, e *envelope) (bool, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.keyID == "" || time.Since(w.keyIDTime) > kmsKeyIDTTL {
+		status, err := w.service.Status(ctx)
+		if err != nil {
+			return false, fmt.Errorf("KMS plugin %s failed to report its status: %w", w.pluginName, err)
+		}
+		w.keyID, w.keyIDTime = status.KeyID, time.Now()
+	}
+	return e.Key == w.keyID, nil
+}
+
+// newAEAD returns AES-GCM with key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealOverhead is how many bytes seal adds to the plaintext: the nonce and
+// tag of AES-GCM
+const sealOverhead = 12 + 16
+
+// seal encrypts plaintext with a random nonce, which is prepended to the result
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts data sealed by seal
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
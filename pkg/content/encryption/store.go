@@ -0,0 +1,380 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+// envelopePrefix starts all encrypted content. It is followed by the length of
+// the JSON-encoded envelope as a big-endian uint32, the envelope, and the
+// content sealed with the data key.
+const envelopePrefix = "k8s:cdn:enc:v1:"
+
+// dataKeySize is the size of the AES-256 data keys.
+const dataKeySize = 32
+
+// envelope holds the wrapped data key of an object
+type envelope struct {
+	// Provider is the name of the provider that wrapped the data key.
+	Provider string `json:"provider"`
+	// Key identifies the key encryption key: an aesgcm key name or a KMS key ID.
+	Key string `json:"key"`
+	// WrappedKey is the wrapped data key.
+	WrappedKey []byte `json:"wrappedKey"`
+	// Annotations are returned by KMS plugins along with the wrapped key.
+	Annotations map[string][]byte `json:"annotations,omitempty"`
+}
+
+// Store encrypts the content of a backend Store. Objects are encrypted with a
+// fresh data key that is stored, wrapped, next to the ciphertext. The
+// ciphertext is bound to the namespace and name of the File.
+type Store struct {
+	backend content.Store
+	keyring *Keyring
+
+	// locks serialize writes and rewraps of the same object
+	locks [64]sync.Mutex
+}
+
+var _ content.Store = &Store{}
+
+// NewStore returns a Store encrypting the content of backend with the providers
+// of keyring.
+func NewStore(backend content.Store, keyring *Keyring) *Store {
+	return &Store{backend: backend, keyring: keyring}
+}
+
+// Get returns the decrypted content of the named File.
+func (s *Store) Get(ctx context.Context, namespace, name string) (*content.Object, error) {
+	obj, err := s.backend.Get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.decrypt(ctx, namespace, name, obj.Data)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := *obj
+	decrypted.Data = data
+	return &decrypted, nil
+}
+
+// Stat describes the stored content of the named File. Its Size is that of
+// the plaintext: only the envelope of encrypted content is read, to subtract
+// it from the stored size.
+func (s *Store) Stat(ctx context.Context, namespace, name string) (*content.ObjectInfo, error) {
+	lock := s.lock(namespace, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	info, err := s.backend.Stat(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	overhead, err := s.overhead(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := *info
+	plaintext.Size -= overhead
+	return &plaintext, nil
+}
+
+// overhead returns how many bytes encryption adds to the stored content of
+// the named File, reading its envelope header but not its ciphertext
+func (s *Store) overhead(ctx context.Context, namespace, name string) (int64, error) {
+	r, err := content.Open(ctx, s.backend, namespace, name)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	header := make([]byte, len(envelopePrefix)+4)
+	if _, err := io.ReadFull(r, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+		// Too short to be encrypted
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	length, ok := bytes.CutPrefix(header, []byte(envelopePrefix))
+	if !ok {
+		return 0, nil
+	}
+	return int64(len(header)) + int64(binary.BigEndian.Uint32(length)) + sealOverhead, nil
+}
+
+// Put encrypts and stores the content of the named File.
+func (s *Store) Put(ctx context.Context, namespace, name string, obj *content.Object) error {
+	lock := s.lock(namespace, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	data, err := s.encrypt(ctx, namespace, name, obj.Data)
+	if err != nil {
+		return err
+	}
+	encrypted := *obj
+	encrypted.Data = data
+	return s.backend.Put(ctx, namespace, name, &encrypted)
+}
+
+// Delete removes the content of the named File.
+func (s *Store) Delete(ctx context.Context, namespace, name string) error {
+	lock := s.lock(namespace, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return s.backend.Delete(ctx, namespace, name)
+}
+
+// Stage encrypts and stages content for the named File.
+func (s *Store) Stage(ctx context.Context, namespace, name string, obj *content.Object) (string, error) {
+	data, err := s.encrypt(ctx, namespace, name, obj.Data)
+	if err != nil {
+		return "", err
+	}
+	encrypted := *obj
+	encrypted.Data = data
+	return s.backend.Stage(ctx, namespace, name, &encrypted)
+}
+
+// Promote makes the staged content id the content of the named File.
+func (s *Store) Promote(ctx context.Context, namespace, name, id string) error {
+	lock := s.lock(namespace, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return s.backend.Promote(ctx, namespace, name, id)
+}
+
+// Discard removes the staged content id.
+func (s *Store) Discard(ctx context.Context, namespace, name, id string) error {
+	return s.backend.Discard(ctx, namespace, name, id)
+}
+
+// ListStaged returns all staged content.
+func (s *Store) ListStaged(ctx context.Context) ([]content.StagedObject, error) {
+	return s.backend.ListStaged(ctx)
+}
+
+// List returns the namespace and name of every File with stored content.
+func (s *Store) List(ctx context.Context) ([]types.NamespacedName, error) {
+	return s.backend.List(ctx)
+}
+
+// Rewrap stores the content of the named File as a Put would today: data keys
+// wrapped with an old key encryption key are wrapped again with the current
+// one, and plaintext is encrypted or decrypted as the namespace's first
+// provider requires. Rewrapping a data key leaves the ciphertext untouched. It
+// returns true if the stored content changed.
+func (s *Store) Rewrap(ctx context.Context, namespace, name string) (bool, error) {
+	lock := s.lock(namespace, name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	obj, err := s.backend.Get(ctx, namespace, name)
+	if err != nil {
+		return false, err
+	}
+	data, err := s.rewrap(ctx, namespace, name, obj.Data)
+	if err != nil || data == nil {
+		return false, err
+	}
+	rewrapped := *obj
+	rewrapped.Data = data
+	return true, s.backend.Put(ctx, namespace, name, &rewrapped)
+}
+
+// rewrap returns the rewrapped data, or nil if data is current
+func (s *Store) rewrap(ctx context.Context, namespace, name string, data []byte) ([]byte, error) {
+	keys := s.keyring.forNamespace(namespace)
+	e, ciphertext, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case e == nil && (keys == nil || keys.write == nil):
+		// Plaintext, as it should be
+		return nil, nil
+	case e == nil || keys == nil || keys.write == nil:
+		// Encrypt plaintext or decrypt content of namespaces that no longer
+		// encrypt it
+		plaintext, err := s.decrypt(ctx, namespace, name, data)
+		if err != nil {
+			return nil, err
+		}
+		return s.encrypt(ctx, namespace, name, plaintext)
+	}
+
+	if e.Provider == keys.write.name() {
+		current, err := keys.write.current(ctx, e)
+		if err != nil || current {
+			return nil, err
+		}
+	}
+	dek, err := s.unwrap(ctx, keys, namespace, name, e)
+	if err != nil {
+		return nil, err
+	}
+	rewrapped, err := keys.write.wrap(ctx, uid(namespace, name), dek)
+	if err != nil {
+		return nil, err
+	}
+	return encodeEnvelope(rewrapped, ciphertext)
+}
+
+// encrypt returns data encrypted for the namespace
+func (s *Store) encrypt(ctx context.Context, namespace, name string, data []byte) ([]byte, error) {
+	keys := s.keyring.forNamespace(namespace)
+	if keys == nil || keys.write == nil {
+		return data, nil
+	}
+
+	dek := make([]byte, dataKeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(aead, data, additionalData(namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	e, err := keys.write.wrap(ctx, uid(namespace, name), dek)
+	if err != nil {
+		return nil, err
+	}
+	return encodeEnvelope(e, ciphertext)
+}
+
+// decrypt returns the plaintext of data stored for the named File
+func (s *Store) decrypt(ctx context.Context, namespace, name string, data []byte) ([]byte, error) {
+	keys := s.keyring.forNamespace(namespace)
+	e, ciphertext, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		if keys != nil && !keys.identity {
+			return nil, fmt.Errorf("content of %s/%s is not encrypted and no identity provider is configured for the namespace", namespace, name)
+		}
+		return data, nil
+	}
+	if keys == nil {
+		return nil, fmt.Errorf("content of %s/%s is encrypted but no providers are configured for the namespace", namespace, name)
+	}
+
+	dek, err := s.unwrap(ctx, keys, namespace, name, e)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(aead, ciphertext, additionalData(namespace, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt content of %s/%s: %w", namespace, name, err)
+	}
+	return plaintext, nil
+}
+
+// unwrap returns the data key held by e
+func (s *Store) unwrap(ctx context.Context, keys *ruleKeys, namespace, name string, e *envelope) ([]byte, error) {
+	w, ok := keys.wrappers[e.Provider]
+	if !ok {
+		return nil, fmt.Errorf("content of %s/%s is encrypted with provider %s, which is not configured for the namespace", namespace, name, e.Provider)
+	}
+	dek, err := w.unwrap(ctx, uid(namespace, name), e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key of %s/%s: %w", namespace, name, err)
+	}
+	return dek, nil
+}
+
+// lock returns the lock of the named File
+func (s *Store) lock(namespace, name string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(namespace))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	return &s.locks[h.Sum32()%uint32(len(s.locks))]
+}
+
+// uid identifies the named File in calls to KMS plugins
+func uid(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// additionalData binds ciphertext to the named File, so that content cannot be
+// moved between Files
+func additionalData(namespace, name string) []byte {
+	return []byte(envelopePrefix + namespace + "/" + name)
+}
+
+// encodeEnvelope returns the stored form of ciphertext and its envelope
+func encodeEnvelope(e *envelope, ciphertext []byte) ([]byte, error) {
+	header, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(envelopePrefix) + 4 + len(header) + len(ciphertext))
+	buf.WriteString(envelopePrefix)
+	binary.Write(&buf, binary.BigEndian, uint32(len(header)))
+	buf.Write(header)
+	buf.Write(ciphertext)
+	return buf.Bytes(), nil
+}
+
+// parseEnvelope splits stored data into its envelope and ciphertext. It
+// returns a nil envelope for plaintext.
+func parseEnvelope(data []byte) (*envelope, []byte, error) {
+	rest, ok := bytes.CutPrefix(data, []byte(envelopePrefix))
+	if !ok {
+		return nil, nil, nil
+	}
+	if len(rest) < 4 {
+		return nil, nil, fmt.Errorf("encrypted content is truncated")
+	}
+	length := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	if uint64(len(rest)) < uint64(length) {
+		return nil, nil, fmt.Errorf("encrypted content is truncated")
+	}
+	e := &envelope{}
+	if err := json.Unmarshal(rest[:length], e); err != nil {
+		return nil, nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	return e, rest[length:], nil
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kmsservice "k8s.io/kms/pkg/service"
+
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/content/encryption/fakekms"
+)
+
+const (
+	key1 = "MTExMTExMTExMTExMTExMTExMTExMTExMTExMTExMTE="
+	key2 = "MjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjIyMjI="
+)
+
+// newTestStore returns a Store for the configuration in data, with the KMS
+// plugins served by the fakes in plugins
+func newTestStore(t *testing.T, backend content.Store, data string, plugins map[string]*fakekms.Service) *Store {
+	config, err := ParseConfig([]byte(data))
+	require.NoError(t, err)
+	keyring, err := NewKeyring(config, func(config *KMSConfig) (kmsservice.Service, error) {
+		plugin, ok := plugins[config.Name]
+		if !ok {
+			return nil, errors.New("no such plugin")
+		}
+		return plugin, nil
+	})
+	require.NoError(t, err)
+	return NewStore(backend, keyring)
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	backend := content.NewMemoryStore()
+	plugins := map[string]*fakekms.Service{"vault": fakekms.New()}
+	store := newTestStore(t, backend, `
+rules:
+  - namespaces: [web]
+    providers: [{aesgcm: {keys: [{name: web-1, secret: `+key1+`}]}}]
+  - namespaces: [shop]
+    providers: [{kms: {name: vault, endpoint: unix:///vault.sock}}]
+`, plugins)
+
+	for _, namespace := range []string{"web", "shop", "plain"} {
+		t.Run(namespace, func(t *testing.T) {
+			obj := &content.Object{Data: []byte("<h1>hello</h1>"), ContentType: "text/html", Checksum: "abc"}
+			require.NoError(t, store.Put(ctx, namespace, "index.html", obj))
+
+			got, err := store.Get(ctx, namespace, "index.html")
+			require.NoError(t, err)
+			assert.Equal(t, obj, got)
+
+			stored, err := backend.Get(ctx, namespace, "index.html")
+			require.NoError(t, err)
+			assert.Equal(t, "text/html", stored.ContentType)
+			assert.Equal(t, "abc", stored.Checksum)
+			info, err := store.Stat(ctx, namespace, "index.html")
+			require.NoError(t, err)
+			assert.EqualValues(t, len(obj.Data), info.Size, "Stat reports the plaintext size")
+
+			if namespace == "plain" {
+				assert.Equal(t, obj.Data, stored.Data)
+			} else {
+				assert.True(t, bytes.HasPrefix(stored.Data, []byte(envelopePrefix)))
+				assert.NotContains(t, string(stored.Data), "hello")
+			}
+		})
+	}
+
+	require.NoError(t, store.Delete(ctx, "web", "index.html"))
+	_, err := store.Get(ctx, "web", "index.html")
+	assert.True(t, content.IsNotFound(err))
+}
+
+func TestStoreStaging(t *testing.T) {
+	ctx := context.Background()
+	backend := content.NewMemoryStore()
+	store := newTestStore(t, backend, `
+rules:
+  - namespaces: ["*"]
+    providers: [{aesgcm: {keys: [{name: key-1, secret: `+key1+`}]}}]
+`, nil)
+
+	obj := &content.Object{Data: []byte("staged secret"), Checksum: "abc"}
+	id, err := store.Stage(ctx, "web", "f", obj)
+	require.NoError(t, err)
+
+	// Staged content is encrypted like stored content
+	staged, err := store.ListStaged(ctx)
+	require.NoError(t, err)
+	require.Len(t, staged, 1)
+	assert.Equal(t, "abc", staged[0].Checksum)
+	require.NoError(t, store.Promote(ctx, "web", "f", id))
+	assert.NotContains(t, string(storedCiphertext(t, backend, "web", "f")), "secret")
+
+	got, err := store.Get(ctx, "web", "f")
+	require.NoError(t, err)
+	assert.Equal(t, obj, got)
+
+	id, err = store.Stage(ctx, "web", "f", &content.Object{Data: []byte("discarded")})
+	require.NoError(t, err)
+	require.NoError(t, store.Discard(ctx, "web", "f", id))
+	assert.True(t, content.IsNotFound(store.Promote(ctx, "web", "f", id)))
+}
+
+func TestStoreRejects(t *testing.T) {
+	ctx := context.Background()
+	backend := content.NewMemoryStore()
+	config := `
+rules:
+  - namespaces: [web, shop]
+    providers: [{aesgcm: {keys: [{name: k1, secret: ` + key1 + `}]}}]
+`
+	store := newTestStore(t, backend, config, nil)
+	require.NoError(t, store.Put(ctx, "web", "a", &content.Object{Data: []byte("a")}))
+	require.NoError(t, backend.Put(ctx, "web", "plain", &content.Object{Data: []byte("plain")}))
+
+	// Ciphertext is bound to its File
+	stored, err := backend.Get(ctx, "web", "a")
+	require.NoError(t, err)
+	require.NoError(t, backend.Put(ctx, "shop", "a", stored))
+	_, err = store.Get(ctx, "shop", "a")
+	assert.ErrorContains(t, err, "failed to decrypt")
+
+	// Tampered ciphertext
+	tampered := bytes.Clone(stored.Data)
+	tampered[len(tampered)-1] ^= 1
+	require.NoError(t, backend.Put(ctx, "web", "a", &content.Object{Data: tampered}))
+	_, err = store.Get(ctx, "web", "a")
+	assert.ErrorContains(t, err, "failed to decrypt")
+
+	// Plaintext is only read with an identity provider
+	_, err = store.Get(ctx, "web", "plain")
+	assert.ErrorContains(t, err, "not encrypted")
+
+	// Keys that are no longer configured
+	other := newTestStore(t, backend, `
+rules:
+  - namespaces: [web]
+    providers: [{aesgcm: {keys: [{name: k2, secret: `+key2+`}]}}]
+`, nil)
+	require.NoError(t, store.Put(ctx, "web", "a", &content.Object{Data: []byte("a")}))
+	_, err = other.Get(ctx, "web", "a")
+	assert.ErrorContains(t, err, `aesgcm key "k1" is not configured`)
+}
+
+func TestStoreRewrapAESGCM(t *testing.T) {
+	ctx := context.Background()
+	backend := content.NewMemoryStore()
+	before := newTestStore(t, backend, `
+rules:
+  - namespaces: ["*"]
+    providers:
+      - identity: {}
+`, nil)
+	require.NoError(t, before.Put(ctx, "web", "a", &content.Object{Data: []byte("a")}))
+
+	// Encrypting existing plaintext
+	encrypting := newTestStore(t, backend, `
+rules:
+  - namespaces: ["*"]
+    providers:
+      - aesgcm: {keys: [{name: k1, secret: `+key1+`}]}
+      - identity: {}
+`, nil)
+	changed, err := encrypting.Rewrap(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assertEnvelopeKey(t, backend, "web", "a", "k1")
+	changed, err = encrypting.Rewrap(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	ciphertext := storedCiphertext(t, backend, "web", "a")
+
+	// Rotating to a new key keeps the ciphertext
+	rotated := newTestStore(t, backend, `
+rules:
+  - namespaces: ["*"]
+    providers:
+      - aesgcm: {keys: [{name: k2, secret: `+key2+`}, {name: k1, secret: `+key1+`}]}
+`, nil)
+	changed, err = rotated.Rewrap(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assertEnvelopeKey(t, backend, "web", "a", "k2")
+	assert.Equal(t, ciphertext, storedCiphertext(t, backend, "web", "a"))
+
+	// The old key can be removed
+	removed := newTestStore(t, backend, `
+rules:
+  - namespaces: ["*"]
+    providers:
+      - aesgcm: {keys: [{name: k2, secret: `+key2+`}]}
+`, nil)
+	got, err := removed.Get(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), got.Data)
+
+	// Decrypting for namespaces that no longer encrypt content
+	decrypting := newTestStore(t, backend, `
+rules:
+  - namespaces: ["*"]
+    providers:
+      - identity: {}
+      - aesgcm: {keys: [{name: k2, secret: `+key2+`}]}
+`, nil)
+	changed, err = decrypting.Rewrap(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	stored, err := backend.Get(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), stored.Data)
+
+	_, err = decrypting.Rewrap(ctx, "web", "missing")
+	assert.True(t, content.IsNotFound(err))
+}
+
+func TestStoreRewrapKMS(t *testing.T) {
+	ctx := context.Background()
+	backend := content.NewMemoryStore()
+	plugin := fakekms.New()
+	store := newTestStore(t, backend, `
+rules:
+  - namespaces: ["*"]
+    providers: [{kms: {name: vault, endpoint: unix:///vault.sock}}]
+`, map[string]*fakekms.Service{"vault": plugin})
+
+	require.NoError(t, store.Put(ctx, "web", "a", &content.Object{Data: []byte("a")}))
+	assertEnvelopeKey(t, backend, "web", "a", "fake-key-1")
+	changed, err := store.Rewrap(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// The plugin rotates its key
+	newKeyID := plugin.Rotate()
+	expireKMSKeyIDs(store)
+	changed, err = store.Rewrap(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assertEnvelopeKey(t, backend, "web", "a", newKeyID)
+
+	plugin.Forget("fake-key-1")
+	got, err := store.Get(ctx, "web", "a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), got.Data)
+
+	// Plugin failures fail uploads and reads instead of storing plaintext
+	plugin.SetError(errors.New("unavailable"))
+	assert.ErrorContains(t, store.Put(ctx, "web", "b", &content.Object{Data: []byte("b")}), "unavailable")
+	_, err = store.Get(ctx, "web", "a")
+	assert.ErrorContains(t, err, "unavailable")
+}
+
+// expireKMSKeyIDs makes KMS wrappers query the current key ID again
+func expireKMSKeyIDs(store *Store) {
+	for _, rule := range store.keyring.rules {
+		for _, w := range rule.wrappers {
+			if kms, ok := w.(*kmsWrapper); ok {
+				kms.keyIDTime = time.Time{}
+			}
+		}
+	}
+}
+
+func assertEnvelopeKey(t *testing.T, backend content.Store, namespace, name, key string) {
+	t.Helper()
+	stored, err := backend.Get(context.Background(), namespace, name)
+	require.NoError(t, err)
+	e, _, err := parseEnvelope(stored.Data)
+	require.NoError(t, err)
+	require.NotNil(t, e, "content is not encrypted")
+	assert.Equal(t, key, e.Key)
+}
+
+func storedCiphertext(t *testing.T, backend content.Store, namespace, name string) []byte {
+	t.Helper()
+	stored, err := backend.Get(context.Background(), namespace, name)
+	require.NoError(t, err)
+	_, ciphertext, err := parseEnvelope(stored.Data)
+	require.NoError(t, err)
+	return ciphertext
+}
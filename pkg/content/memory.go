@@ -0,0 +1,215 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// memoryBackend is the backend label of the memory store in metrics
+const memoryBackend = "memory"
+
+// memoryStore is a process-local Store backed by a map
+type memoryStore struct {
+	lock    sync.RWMutex
+	entries map[types.NamespacedName]*memoryEntry
+	staged  map[stageKey]*stagedEntry
+}
+
+// memoryEntry is the current content of a File
+type memoryEntry struct {
+	obj      *Object
+	versions int64
+	modified time.Time
+	// accessed is the UnixNano time of the last Get, which only holds the
+	// read lock
+	accessed atomic.Int64
+}
+
+// stageKey identifies staged content
+type stageKey struct {
+	types.NamespacedName
+	id string
+}
+
+type stagedEntry struct {
+	obj    *Object
+	staged time.Time
+}
+
+// NewMemoryStore returns a Store that keeps all content in memory.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[types.NamespacedName]*memoryEntry),
+		staged:  make(map[stageKey]*stagedEntry),
+	}
+}
+
+func (s *memoryStore) Get(ctx context.Context, namespace, name string) (*Object, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entry, ok := s.entries[types.NamespacedName{Namespace: namespace, Name: name}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	entry.accessed.Store(time.Now().UnixNano())
+	return entry.obj, nil
+}
+
+// Open reads the stored content, which is never modified in place. Unlike
+// Get, it doesn't count as an access.
+func (s *memoryStore) Open(ctx context.Context, namespace, name string) (io.ReadCloser, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entry, ok := s.entries[types.NamespacedName{Namespace: namespace, Name: name}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(entry.obj.Data)), nil
+}
+
+func (s *memoryStore) Stat(ctx context.Context, namespace, name string) (*ObjectInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entry, ok := s.entries[types.NamespacedName{Namespace: namespace, Name: name}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	info := &ObjectInfo{
+		Size:        int64(len(entry.obj.Data)),
+		ContentType: entry.obj.ContentType,
+		Checksum:    entry.obj.Checksum,
+		Versions:    entry.versions,
+		Modified:    entry.modified,
+	}
+	if accessed := entry.accessed.Load(); accessed != 0 {
+		info.Accessed = time.Unix(0, accessed)
+	}
+	return info, nil
+}
+
+func (s *memoryStore) Put(ctx context.Context, namespace, name string, obj *Object) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	storedBytes.WithLabelValues(memoryBackend).Add(float64(len(obj.Data)))
+	s.replace(types.NamespacedName{Namespace: namespace, Name: name}, obj)
+	return nil
+}
+
+// replace makes obj the current content of the File. The lock must be held.
+func (s *memoryStore) replace(key types.NamespacedName, obj *Object) {
+	var versions int64
+	if old, ok := s.entries[key]; ok {
+		storedBytes.WithLabelValues(memoryBackend).Add(-float64(len(old.obj.Data)))
+		versions = old.versions
+	}
+	s.entries[key] = &memoryEntry{obj: obj, versions: versions + 1, modified: time.Now()}
+}
+
+func (s *memoryStore) Delete(ctx context.Context, namespace, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := types.NamespacedName{Namespace: namespace, Name: name}
+	if old, ok := s.entries[key]; ok {
+		storedBytes.WithLabelValues(memoryBackend).Add(-float64(len(old.obj.Data)))
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) Stage(ctx context.Context, namespace, name string, obj *Object) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := stageKey{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}, id: hex.EncodeToString(id)}
+	s.staged[key] = &stagedEntry{obj: obj, staged: time.Now()}
+	storedBytes.WithLabelValues(memoryBackend).Add(float64(len(obj.Data)))
+	return key.id, nil
+}
+
+func (s *memoryStore) Promote(ctx context.Context, namespace, name, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := stageKey{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}, id: id}
+	entry, ok := s.staged[key]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.staged, key)
+	s.replace(key.NamespacedName, entry.obj)
+	return nil
+}
+
+func (s *memoryStore) Discard(ctx context.Context, namespace, name, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := stageKey{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}, id: id}
+	if entry, ok := s.staged[key]; ok {
+		storedBytes.WithLabelValues(memoryBackend).Add(-float64(len(entry.obj.Data)))
+		delete(s.staged, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) ListStaged(ctx context.Context) ([]StagedObject, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	staged := make([]StagedObject, 0, len(s.staged))
+	for key, entry := range s.staged {
+		staged = append(staged, StagedObject{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			ID:        key.id,
+			Checksum:  entry.obj.Checksum,
+			Staged:    entry.staged,
+		})
+	}
+	return staged, nil
+}
+
+func (s *memoryStore) List(ctx context.Context) ([]types.NamespacedName, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	keys := make([]types.NamespacedName, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
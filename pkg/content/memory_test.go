@@ -0,0 +1,110 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreStaging(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	v1 := &Object{Data: []byte("v1"), Checksum: "1"}
+	v2 := &Object{Data: []byte("v2"), Checksum: "2"}
+	require.NoError(t, store.Put(ctx, "ns", "f", v1))
+
+	// Staged content is not visible until it is promoted
+	promoted, err := store.Stage(ctx, "ns", "f", v2)
+	require.NoError(t, err)
+	discarded, err := store.Stage(ctx, "ns", "f", v2)
+	require.NoError(t, err)
+	assert.NotEqual(t, promoted, discarded)
+	obj, err := store.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.Equal(t, v1, obj)
+
+	staged, err := store.ListStaged(ctx)
+	require.NoError(t, err)
+	require.Len(t, staged, 2)
+	assert.Equal(t, "ns", staged[0].Namespace)
+	assert.Equal(t, "f", staged[0].Name)
+	assert.Equal(t, "2", staged[0].Checksum)
+	assert.False(t, staged[0].Staged.IsZero())
+
+	require.NoError(t, store.Promote(ctx, "ns", "f", promoted))
+	obj, err = store.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.Equal(t, v2, obj)
+	assert.True(t, IsNotFound(store.Promote(ctx, "ns", "f", promoted)))
+
+	require.NoError(t, store.Discard(ctx, "ns", "f", discarded))
+	require.NoError(t, store.Discard(ctx, "ns", "f", discarded))
+	assert.True(t, IsNotFound(store.Promote(ctx, "ns", "f", discarded)))
+	staged, err = store.ListStaged(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, staged)
+}
+
+func TestMemoryStoreStat(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	_, err := store.Stat(ctx, "ns", "f")
+	assert.True(t, IsNotFound(err))
+
+	before := time.Now()
+	require.NoError(t, store.Put(ctx, "ns", "f", &Object{Data: []byte("v1"), ContentType: "text/plain", Checksum: "1"}))
+	info, err := store.Stat(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, info.Size)
+	assert.Equal(t, "text/plain", info.ContentType)
+	assert.Equal(t, "1", info.Checksum)
+	assert.EqualValues(t, 1, info.Versions)
+	assert.False(t, info.Modified.Before(before))
+	assert.True(t, info.Accessed.IsZero())
+	assert.False(t, info.Cached)
+
+	// Reads are recorded for the current content, replacing it counts versions
+	_, err = store.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	id, err := store.Stage(ctx, "ns", "f", &Object{Data: []byte("v2 "), Checksum: "2"})
+	require.NoError(t, err)
+	require.NoError(t, store.Promote(ctx, "ns", "f", id))
+	info, err = store.Stat(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, info.Size)
+	assert.Equal(t, "2", info.Checksum)
+	assert.EqualValues(t, 2, info.Versions)
+	assert.True(t, info.Accessed.IsZero())
+
+	_, err = store.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	info, err = store.Stat(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.False(t, info.Accessed.Before(info.Modified))
+
+	// Deleting content resets the versions
+	require.NoError(t, store.Delete(ctx, "ns", "f"))
+	require.NoError(t, store.Put(ctx, "ns", "f", &Object{Data: []byte("v3"), Checksum: "3"}))
+	info, err = store.Stat(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, info.Versions)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	subsystem      = "cdn_content"
+	cacheSubsystem = "cdn_content_cache"
+)
+
+// Operations recorded by the request metrics.
+const (
+	OperationUpload          = "upload"
+	OperationDownload        = "download"
+	OperationArchiveUpload   = "archive_upload"
+	OperationArchiveDownload = "archive_download"
+	OperationCopy            = "copy"
+	OperationSignedDownload  = "signed_download"
+)
+
+// Reasons recorded by the validation rejection metric.
+const (
+	RejectionInvalidContentType = "invalid_content_type"
+	RejectionTooLarge           = "too_large"
+	RejectionInvalidArchive     = "invalid_archive"
+	RejectionInvalidArchiveName = "invalid_archive_name"
+	RejectionInvalidSelector    = "invalid_selector"
+	RejectionInvalidFormat      = "invalid_format"
+	RejectionInvalidDigest      = "invalid_digest"
+	RejectionChecksumMismatch   = "checksum_mismatch"
+)
+
+var (
+	requests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "requests_total",
+			Help:           "Number of content requests by namespace, operation and HTTP status code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "operation", "code"},
+	)
+	requestBytes = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "bytes_total",
+			Help:           "Number of content bytes uploaded and downloaded by namespace, operation and HTTP status code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace", "operation", "code"},
+	)
+	requestDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      subsystem,
+			Name:           "request_duration_seconds",
+			Help:           "Latency of content requests by operation.",
+			Buckets:        []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation"},
+	)
+	uploadsInFlight = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      subsystem,
+			Name:           "uploads_in_flight",
+			Help:           "Number of content uploads currently being processed.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	storedBytes = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      subsystem,
+			Name:           "stored_bytes",
+			Help:           "Size in bytes of the content held by each store backend.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"backend"},
+	)
+	originFetchErrors = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "origin_fetch_errors_total",
+			Help:           "Number of failed content reads from the store behind the cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	validationRejections = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      subsystem,
+			Name:           "validation_rejections_total",
+			Help:           "Number of content requests rejected by validation, by reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+
+	cacheHits = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "hits_total",
+			Help:           "Number of content reads served from the cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	cacheMisses = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "misses_total",
+			Help:           "Number of content reads not found in the cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	cacheEvictions = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "evictions_total",
+			Help:           "Number of objects evicted from the cache to make room.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	cacheBytes = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "bytes",
+			Help:           "Size in bytes of the cached content.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	cacheHitRatio = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "hit_ratio",
+			Help:           "Ratio of content reads served from the cache since start.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	cacheObjects = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      cacheSubsystem,
+			Name:           "objects",
+			Help:           "Number of cached objects.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+var registerMetrics sync.Once
+
+// RegisterMetrics registers the content metrics with the legacy registry.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(requests)
+		legacyregistry.MustRegister(requestBytes)
+		legacyregistry.MustRegister(requestDuration)
+		legacyregistry.MustRegister(uploadsInFlight)
+		legacyregistry.MustRegister(storedBytes)
+		legacyregistry.MustRegister(originFetchErrors)
+		legacyregistry.MustRegister(validationRejections)
+		legacyregistry.MustRegister(cacheHits)
+		legacyregistry.MustRegister(cacheMisses)
+		legacyregistry.MustRegister(cacheEvictions)
+		legacyregistry.MustRegister(cacheHitRatio)
+		legacyregistry.MustRegister(cacheBytes)
+		legacyregistry.MustRegister(cacheObjects)
+	})
+}
+
+// RecordRequest records a completed content request.
+func RecordRequest(namespace, operation string, code int, bytes int64, elapsed time.Duration) {
+	codeLabel := strconv.Itoa(code)
+	requests.WithLabelValues(namespace, operation, codeLabel).Inc()
+	requestBytes.WithLabelValues(namespace, operation, codeLabel).Add(float64(bytes))
+	requestDuration.WithLabelValues(operation).Observe(elapsed.Seconds())
+}
+
+// UploadStarted records an upload in flight until the returned func is called.
+func UploadStarted() func() {
+	uploadsInFlight.Inc()
+	return uploadsInFlight.Dec
+}
+
+// RecordValidationRejection records a content request rejected for reason.
+func RecordValidationRejection(reason string) {
+	validationRejections.WithLabelValues(reason).Inc()
+}
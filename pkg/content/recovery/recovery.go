@@ -0,0 +1,106 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recovery reconciles content staged by uploads that were interrupted
+// between staging their content and promoting it.
+package recovery
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"k8s.toms.place/apiserver/pkg/content"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// Result counts what a recovery pass did with the staged content.
+type Result struct {
+	// Promoted is the staged content whose File was committed with it.
+	Promoted int
+	// Discarded is the staged content no File refers to.
+	Discarded int
+	// Failed is the staged content that could not be reconciled.
+	Failed int
+}
+
+// Recover reconciles the content staged before the server started with the
+// Files of files. Content the File was committed with but which was never
+// promoted is promoted; content whose File does not exist or was committed
+// with another checksum is discarded. Content staged at or after started
+// belongs to uploads in flight and is left alone.
+//
+// Files whose content is not in store are left alone: another replica may
+// still have it, and /cdn/fsck reports the Files whose content is lost.
+func Recover(ctx context.Context, store content.Store, files cdnclient.FilesGetter, started time.Time) (Result, error) {
+	var result Result
+	staged, err := store.ListStaged(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, s := range staged {
+		if !s.Staged.Before(started) {
+			continue
+		}
+		promote, err := committed(ctx, store, files, s)
+		if err == nil {
+			if promote {
+				err = store.Promote(ctx, s.Namespace, s.Name, s.ID)
+			} else {
+				err = store.Discard(ctx, s.Namespace, s.Name, s.ID)
+			}
+		}
+		switch {
+		case err != nil:
+			result.Failed++
+			utilruntime.HandleErrorWithContext(ctx, err, "Failed to recover staged content", "namespace", s.Namespace, "name", s.Name, "stage", s.ID)
+		case promote:
+			result.Promoted++
+		default:
+			result.Discarded++
+		}
+	}
+
+	klog.FromContext(ctx).Info("Recovered staged content", "promoted", result.Promoted, "discarded", result.Discarded, "failed", result.Failed)
+	return result, nil
+}
+
+// committed returns whether the File of s was committed with the staged
+// content and does not have it as its content yet
+func committed(ctx context.Context, store content.Store, files cdnclient.FilesGetter, s content.StagedObject) (bool, error) {
+	file, err := files.Files(s.Namespace).Get(ctx, s.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !file.Status.Uploaded || file.Status.Checksum != s.Checksum {
+		return false, nil
+	}
+
+	current, err := store.Stat(ctx, s.Namespace, s.Name)
+	if err != nil && !content.IsNotFound(err) {
+		return false, err
+	}
+	// Another stage with the same content was promoted already
+	return current == nil || current.Checksum != s.Checksum, nil
+}
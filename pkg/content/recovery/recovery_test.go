@@ -0,0 +1,112 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recovery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/fake"
+)
+
+func newObject(data string) *content.Object {
+	sum := sha256.Sum256([]byte(data))
+	return &content.Object{Data: []byte(data), Checksum: hex.EncodeToString(sum[:])}
+}
+
+func newFile(name string, uploaded bool, checksum string) *v1alpha1.File {
+	return &v1alpha1.File{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Status:     v1alpha1.FileStatus{Uploaded: uploaded, Checksum: checksum},
+	}
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+	store := content.NewMemoryStore()
+	v1, v2 := newObject("version 1"), newObject("version 2")
+
+	client := fake.NewSimpleClientset(
+		newFile("committed", true, v2.Checksum),
+		newFile("replaced", true, v2.Checksum),
+		newFile("promoted", true, v1.Checksum),
+		newFile("failed", false, v1.Checksum),
+		newFile("in-flight", true, v1.Checksum),
+	)
+
+	// The previous content of "committed" must be replaced
+	require.NoError(t, store.Put(ctx, "ns", "committed", v1))
+	require.NoError(t, store.Put(ctx, "ns", "promoted", v1))
+	stage := func(name string, obj *content.Object) string {
+		id, err := store.Stage(ctx, "ns", name, obj)
+		require.NoError(t, err)
+		return id
+	}
+	stage("committed", v2)
+	stage("replaced", v1)
+	stage("missing", v1)
+	stage("promoted", v1)
+	stage("failed", v1)
+
+	time.Sleep(time.Millisecond)
+	started := time.Now()
+	inFlight := stage("in-flight", v1)
+
+	result, err := Recover(ctx, store, client.CdnV1alpha1(), started)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Promoted: 1, Discarded: 4}, result)
+
+	obj, err := store.Get(ctx, "ns", "committed")
+	require.NoError(t, err)
+	assert.Equal(t, v2.Data, obj.Data)
+	for _, name := range []string{"replaced", "missing", "failed", "in-flight"} {
+		_, err := store.Get(ctx, "ns", name)
+		assert.True(t, content.IsNotFound(err), "%s: unexpected error %v", name, err)
+	}
+
+	staged, err := store.ListStaged(ctx)
+	require.NoError(t, err)
+	require.Len(t, staged, 1)
+	assert.Equal(t, inFlight, staged[0].ID)
+	assert.Equal(t, "in-flight", staged[0].Name)
+}
+
+func TestRecoverLeavesFilesWithoutContent(t *testing.T) {
+	ctx := context.Background()
+	v1 := newObject("version 1")
+	client := fake.NewSimpleClientset(newFile("lost", true, v1.Checksum))
+
+	// The server restarts with a new store; another replica may still have
+	// the content
+	result, err := Recover(ctx, content.NewMemoryStore(), client.CdnV1alpha1(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, Result{}, result)
+
+	file, err := client.CdnV1alpha1().Files("ns").Get(ctx, "lost", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, file.Status.Uploaded)
+	assert.Empty(t, file.Status.Error)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replica
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	discoveryinformers "k8s.io/client-go/informers/discovery/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+)
+
+// Peers returns the base URLs of the replicas to fetch content from. The list
+// may include the replica itself, which simply has no content to offer.
+type Peers interface {
+	Peers() []string
+}
+
+// StaticPeers is a fixed list of peer base URLs.
+type StaticPeers []string
+
+func (p StaticPeers) Peers() []string {
+	return p
+}
+
+// EndpointSlicePeers discovers the peers from the ready endpoints of the
+// EndpointSlices of the server's Service.
+type EndpointSlicePeers struct {
+	lister discoverylisters.EndpointSliceLister
+}
+
+// NewEndpointSlicePeers returns the peers listed by the EndpointSlices of
+// informer, which must only watch the slices of the server's Service.
+func NewEndpointSlicePeers(informer discoveryinformers.EndpointSliceInformer) *EndpointSlicePeers {
+	return &EndpointSlicePeers{lister: informer.Lister()}
+}
+
+func (p *EndpointSlicePeers) Peers() []string {
+	slices, err := p.lister.List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var peers []string
+	for _, slice := range slices {
+		port := slicePort(slice)
+		if port == 0 {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			for _, address := range endpoint.Addresses {
+				peer := fmt.Sprintf("https://%s", net.JoinHostPort(address, strconv.Itoa(int(port))))
+				if !seen[peer] {
+					seen[peer] = true
+					peers = append(peers, peer)
+				}
+			}
+		}
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// slicePort returns the first port of slice, or 0 if it has none
+func slicePort(slice *discoveryv1.EndpointSlice) int32 {
+	for _, port := range slice.Ports {
+		if port.Port != nil {
+			return *port.Port
+		}
+	}
+	return 0
+}
@@ -0,0 +1,352 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package replica shares File content between the replicas of the server.
+//
+// Each replica keeps the content uploaded to it in its local store. A read
+// that misses locally, or finds content other than the File's status.checksum,
+// is served by fetching the content from a peer replica and keeping a local
+// copy. Replicas learn about content replaced or deleted elsewhere from the
+// watch of File objects, and drop their stale copies.
+package replica
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	cdninformers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions/cdn/v1alpha1"
+	cdnlisters "k8s.toms.place/apiserver/pkg/generated/listers/cdn/v1alpha1"
+)
+
+// PeerPath is the path below which replicas serve their local content to
+// their peers, as PeerPath + "<namespace>/<name>". Peers need the "get"
+// permission on the non-resource URL PeerPath + "*".
+const PeerPath = "/cdn-peer/content/"
+
+// peerTimeout bounds a single content fetch from a peer
+const peerTimeout = 30 * time.Second
+
+// DefaultMaxFetchBytes is the default size limit of content fetched from peers
+// for Files not known yet
+const DefaultMaxFetchBytes = 1 << 30
+
+// Invalidator drops cached copies of the content of a File.
+type Invalidator interface {
+	Invalidate(namespace, name string)
+}
+
+// Store is a content.Store that fetches content missing from its local store
+// from peer replicas. Content is written to the local store only; the other
+// replicas fetch it when it is first read there.
+type Store struct {
+	local      content.Store
+	fileLister cdnlisters.FileLister
+	peers      Peers
+	client     *http.Client
+	maxBytes   int64
+	group      singleflight.Group
+
+	lock         sync.Mutex
+	invalidators []Invalidator
+}
+
+var _ content.Store = &Store{}
+
+// NewStore returns a Store in front of local, which fetches missing content
+// from peers with client. The Files of fileInformer tell which content is
+// current; their watch events drop local copies of replaced content. Fetched
+// content may not be larger than its File's size, or maxBytes if the File is
+// not known yet.
+func NewStore(local content.Store, fileInformer cdninformers.FileInformer, peers Peers, client *http.Client, maxBytes int64) (*Store, error) {
+	s := &Store{
+		local:      local,
+		fileLister: fileInformer.Lister(),
+		peers:      peers,
+		client:     client,
+		maxBytes:   maxBytes,
+	}
+	_, err := fileInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: s.fileUpdated,
+		DeleteFunc: s.fileDeleted,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// AddInvalidator registers invalidator to be called whenever the content of a
+// File was replaced or deleted on another replica, such as a cache in front
+// of the Store.
+func (s *Store) AddInvalidator(invalidator Invalidator) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.invalidators = append(s.invalidators, invalidator)
+}
+
+func (s *Store) Get(ctx context.Context, namespace, name string) (*content.Object, error) {
+	expected, size := s.current(namespace, name)
+
+	obj, err := s.local.Get(ctx, namespace, name)
+	if err == nil && (expected == "" || obj.Checksum == expected) {
+		return obj, nil
+	}
+	if err != nil && !content.IsNotFound(err) {
+		return nil, err
+	}
+
+	// Concurrent misses for the same content share one round of peer fetches,
+	// which isn't canceled with the context of the caller that started it.
+	// Each caller stops waiting once its own context is done.
+	key := fmt.Sprintf("%s/%s@%s", namespace, name, expected)
+	fetched := s.group.DoChan(key, func() (interface{}, error) {
+		return s.fetch(context.WithoutCancel(ctx), namespace, name, expected, size)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-fetched:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*content.Object), nil
+	}
+}
+
+// Open reads the local content of the named File without fetching it from
+// peers.
+func (s *Store) Open(ctx context.Context, namespace, name string) (io.ReadCloser, error) {
+	return content.Open(ctx, s.local, namespace, name)
+}
+
+// Stat describes the local content of the named File without fetching it
+// from peers.
+func (s *Store) Stat(ctx context.Context, namespace, name string) (*content.ObjectInfo, error) {
+	return s.local.Stat(ctx, namespace, name)
+}
+
+func (s *Store) Put(ctx context.Context, namespace, name string, obj *content.Object) error {
+	return s.local.Put(ctx, namespace, name, obj)
+}
+
+func (s *Store) Delete(ctx context.Context, namespace, name string) error {
+	return s.local.Delete(ctx, namespace, name)
+}
+
+func (s *Store) Stage(ctx context.Context, namespace, name string, obj *content.Object) (string, error) {
+	return s.local.Stage(ctx, namespace, name, obj)
+}
+
+func (s *Store) Promote(ctx context.Context, namespace, name, id string) error {
+	return s.local.Promote(ctx, namespace, name, id)
+}
+
+func (s *Store) Discard(ctx context.Context, namespace, name, id string) error {
+	return s.local.Discard(ctx, namespace, name, id)
+}
+
+func (s *Store) ListStaged(ctx context.Context) ([]content.StagedObject, error) {
+	return s.local.ListStaged(ctx)
+}
+
+func (s *Store) List(ctx context.Context) ([]types.NamespacedName, error) {
+	return s.local.List(ctx)
+}
+
+// Handler returns the handler serving the local content to peers below
+// PeerPath. It never fetches from other peers itself.
+func (s *Store) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		namespace, name, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, PeerPath), "/")
+		if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+			http.NotFound(w, req)
+			return
+		}
+
+		obj, err := s.local.Get(req.Context(), namespace, name)
+		if content.IsNotFound(err) {
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum, err := hex.DecodeString(obj.Checksum)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid checksum: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+		content.WriteObject(w, obj, http.StatusOK, req.Method == http.MethodHead)
+	})
+}
+
+// current returns the checksum and size of the named File's current content,
+// or "" and the maximum size of fetched content if the File is not known yet
+func (s *Store) current(namespace, name string) (string, int64) {
+	file, err := s.fileLister.Files(namespace).Get(name)
+	if err != nil || file.Status.Checksum == "" {
+		return "", s.maxBytes
+	}
+	return file.Status.Checksum, file.Spec.Size
+}
+
+// fetch fetches the named content from the first peer that has it with the
+// expected checksum, or any checksum if expected is empty, and at most size
+// bytes, and stores a local copy
+func (s *Store) fetch(ctx context.Context, namespace, name, expected string, size int64) (*content.Object, error) {
+	logger := klog.FromContext(ctx)
+	for _, peer := range s.peers.Peers() {
+		obj, err := s.fetchFrom(ctx, peer, namespace, name, size)
+		if err != nil {
+			if !content.IsNotFound(err) {
+				logger.Error(err, "Failed to fetch content from peer", "peer", peer, "namespace", namespace, "name", name)
+			}
+			continue
+		}
+		if expected != "" && obj.Checksum != expected {
+			// The peer holds an older or newer version
+			continue
+		}
+		if err := s.local.Put(ctx, namespace, name, obj); err != nil {
+			return nil, err
+		}
+		logger.V(2).Info("Fetched content from peer", "peer", peer, "namespace", namespace, "name", name, "checksum", obj.Checksum)
+		return obj, nil
+	}
+	return nil, content.ErrNotFound
+}
+
+// fetchFrom fetches the named content from peer and verifies it against its
+// Content-Digest. Content larger than limit is rejected.
+func (s *Store) fetchFrom(ctx context.Context, peer, namespace, name string, limit int64) (*content.Object, error) {
+	ctx, cancel := context.WithTimeout(ctx, peerTimeout)
+	defer cancel()
+
+	u := strings.TrimSuffix(peer, "/") + PeerPath + url.PathEscape(namespace) + "/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, content.ErrNotFound
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("peer %s returned %s: %s", peer, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("content from peer %s exceeds %d bytes", peer, limit)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("content from peer %s exceeds %d bytes", peer, limit)
+	}
+	sum := sha256.Sum256(data)
+	digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	if resp.Header.Get("Content-Digest") != digest {
+		return nil, fmt.Errorf("content from peer %s does not match its Content-Digest", peer)
+	}
+	return &content.Object{
+		Data:        data,
+		ContentType: resp.Header.Get("Content-Type"),
+		Checksum:    hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// fileUpdated drops the local copy of a File's content when another replica
+// replaced it. Watch events arrive in resourceVersion order, so the checksum
+// of the newest event is the current one.
+func (s *Store) fileUpdated(oldObj, newObj interface{}) {
+	oldFile, ok := oldObj.(*v1alpha1.File)
+	if !ok {
+		return
+	}
+	newFile, ok := newObj.(*v1alpha1.File)
+	if !ok || oldFile.ResourceVersion == newFile.ResourceVersion || oldFile.Status.Checksum == newFile.Status.Checksum {
+		return
+	}
+	s.invalidate(newFile.Namespace, newFile.Name, func(obj *content.Object) bool {
+		return obj.Checksum != newFile.Status.Checksum
+	})
+}
+
+// fileDeleted drops the local copy of a deleted File's content. Content that
+// was uploaded again since, to a File recreated with the same name, is kept.
+func (s *Store) fileDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	file, ok := obj.(*v1alpha1.File)
+	if !ok {
+		return
+	}
+	s.invalidate(file.Namespace, file.Name, func(obj *content.Object) bool {
+		return file.Status.Checksum == "" || obj.Checksum == file.Status.Checksum
+	})
+}
+
+// invalidate deletes the local copy of the named content if stale returns
+// true for it, and notifies the invalidators
+func (s *Store) invalidate(namespace, name string, stale func(*content.Object) bool) {
+	ctx := context.Background()
+	obj, err := s.local.Get(ctx, namespace, name)
+	if err == nil && stale(obj) {
+		if err := s.local.Delete(ctx, namespace, name); err != nil {
+			utilruntime.HandleErrorWithContext(ctx, err, "Failed to delete stale content", "namespace", namespace, "name", name)
+		}
+	}
+
+	s.lock.Lock()
+	invalidators := s.invalidators
+	s.lock.Unlock()
+	for _, invalidator := range invalidators {
+		invalidator.Invalidate(namespace, name)
+	}
+}
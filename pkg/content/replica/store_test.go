@@ -0,0 +1,320 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replica
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/fake"
+	informers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions"
+)
+
+// testReplica is one in-process server replica
+type testReplica struct {
+	local       content.Store
+	store       *Store
+	invalidated *recordingInvalidator
+}
+
+type recordingInvalidator struct {
+	lock  sync.Mutex
+	names []string
+}
+
+func (i *recordingInvalidator) Invalidate(namespace, name string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.names = append(i.names, namespace+"/"+name)
+}
+
+func (i *recordingInvalidator) Names() []string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return append([]string(nil), i.names...)
+}
+
+func newTestObject(data string) *content.Object {
+	sum := sha256.Sum256([]byte(data))
+	return &content.Object{Data: []byte(data), ContentType: "text/plain", Checksum: hex.EncodeToString(sum[:])}
+}
+
+// startReplicas starts n replicas sharing the Files of client, each serving
+// its local content to the others over HTTP
+func startReplicas(t *testing.T, ctx context.Context, client *fake.Clientset, n int) []*testReplica {
+	replicas := make([]*testReplica, n)
+	var peers StaticPeers
+	for i := range replicas {
+		mux := http.NewServeMux()
+		mux.HandleFunc(PeerPath, func(w http.ResponseWriter, req *http.Request) {
+			replicas[i].store.Handler().ServeHTTP(w, req)
+		})
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+		peers = append(peers, server.URL)
+	}
+
+	for i := range replicas {
+		factory := informers.NewSharedInformerFactory(client, 0)
+		local := content.NewMemoryStore()
+		store, err := NewStore(local, factory.Cdn().V1alpha1().Files(), peers, http.DefaultClient, DefaultMaxFetchBytes)
+		require.NoError(t, err)
+		invalidated := &recordingInvalidator{}
+		store.AddInvalidator(invalidated)
+		replicas[i] = &testReplica{local: local, store: store, invalidated: invalidated}
+
+		factory.Start(ctx.Done())
+		factory.WaitForCacheSync(ctx.Done())
+	}
+	return replicas
+}
+
+// waitForChecksum waits until every replica watched the File with checksum
+func waitForChecksum(t *testing.T, replicas []*testReplica, checksum string) {
+	for _, r := range replicas {
+		require.Eventually(t, func() bool {
+			current, _ := r.store.current("ns", "f")
+			return current == checksum
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+}
+
+func TestStoreMultipleReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	v1, v2 := newTestObject("version 1"), newTestObject("version 2")
+	client := fake.NewSimpleClientset()
+	replicas := startReplicas(t, ctx, client, 3)
+
+	// Upload to replica 0: the content is stored before the File status
+	require.NoError(t, replicas[0].store.Put(ctx, "ns", "f", v1))
+	file, err := client.CdnV1alpha1().Files("ns").Create(ctx, &v1alpha1.File{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "f", ResourceVersion: "1"},
+		Spec:       v1alpha1.FileSpec{Size: int64(len(v1.Data))},
+		Status:     v1alpha1.FileStatus{Uploaded: true, Checksum: v1.Checksum},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	waitForChecksum(t, replicas, v1.Checksum)
+
+	// Replica 1 fetches the content from replica 0 and keeps a copy
+	obj, err := replicas[1].store.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.Equal(t, v1.Data, obj.Data)
+	assert.Equal(t, "text/plain", obj.ContentType)
+	obj, err = replicas[1].local.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.Equal(t, v1.Checksum, obj.Checksum)
+
+	// Replace the content through replica 2
+	require.NoError(t, replicas[2].store.Put(ctx, "ns", "f", v2))
+	file = file.DeepCopy()
+	file.ResourceVersion = "2"
+	file.Spec.Size = int64(len(v2.Data))
+	file.Status.Checksum = v2.Checksum
+	_, err = client.CdnV1alpha1().Files("ns").Update(ctx, file, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	waitForChecksum(t, replicas, v2.Checksum)
+
+	// The stale copies are dropped and the caches in front are invalidated,
+	// the new content is kept where it was uploaded
+	for _, i := range []int{0, 1} {
+		require.Eventually(t, func() bool {
+			_, err := replicas[i].local.Get(ctx, "ns", "f")
+			return content.IsNotFound(err)
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, replicas[i].invalidated.Names(), "ns/f")
+	}
+	obj, err = replicas[2].local.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.Equal(t, v2.Checksum, obj.Checksum)
+
+	// Every replica reads the new content
+	for _, r := range replicas {
+		obj, err := r.store.Get(ctx, "ns", "f")
+		require.NoError(t, err)
+		assert.Equal(t, v2.Data, obj.Data)
+	}
+
+	// Deleting the File drops the content everywhere
+	require.NoError(t, client.CdnV1alpha1().Files("ns").Delete(ctx, "f", metav1.DeleteOptions{}))
+	for _, r := range replicas {
+		require.Eventually(t, func() bool {
+			_, err := r.local.Get(ctx, "ns", "f")
+			return content.IsNotFound(err)
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	_, err = replicas[0].store.Get(ctx, "ns", "f")
+	assert.True(t, content.IsNotFound(err), "unexpected error %v", err)
+}
+
+func TestStoreRejectsStaleLocalContent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	v1, v2 := newTestObject("version 1"), newTestObject("version 2")
+	client := fake.NewSimpleClientset(&v1alpha1.File{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "f"},
+		Spec:       v1alpha1.FileSpec{Size: int64(len(v2.Data))},
+		Status:     v1alpha1.FileStatus{Uploaded: true, Checksum: v2.Checksum},
+	})
+	replicas := startReplicas(t, ctx, client, 2)
+
+	// Replica 0 missed the invalidation, but the File tells it is stale
+	require.NoError(t, replicas[0].local.Put(ctx, "ns", "f", v1))
+	_, err := replicas[0].store.Get(ctx, "ns", "f")
+	assert.True(t, content.IsNotFound(err), "unexpected error %v", err)
+
+	require.NoError(t, replicas[1].local.Put(ctx, "ns", "f", v2))
+	obj, err := replicas[0].store.Get(ctx, "ns", "f")
+	require.NoError(t, err)
+	assert.Equal(t, v2.Data, obj.Data)
+}
+
+func TestStoreRejectsCorruptPeerContent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Digest", "sha-256=:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=:")
+		w.Write([]byte("corrupt"))
+	}))
+	defer peer.Close()
+
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	store, err := NewStore(content.NewMemoryStore(), factory.Cdn().V1alpha1().Files(), StaticPeers{peer.URL}, peer.Client(), DefaultMaxFetchBytes)
+	require.NoError(t, err)
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	_, err = store.Get(ctx, "ns", "f")
+	assert.True(t, content.IsNotFound(err), "unexpected error %v", err)
+}
+
+func TestStoreRejectsOversizedPeerContent(t *testing.T) {
+	obj := newTestObject("0123456789")
+	testCases := []struct {
+		desc     string
+		files    []runtime.Object
+		maxBytes int64
+		wantErr  bool
+	}{
+		{
+			desc:     "known File",
+			files:    []runtime.Object{testFile(obj, 10)},
+			maxBytes: 1,
+		},
+		{
+			desc:     "larger than the File",
+			files:    []runtime.Object{testFile(obj, 9)},
+			maxBytes: DefaultMaxFetchBytes,
+			wantErr:  true,
+		},
+		{
+			desc:     "unknown File",
+			maxBytes: 10,
+		},
+		{
+			desc:     "unknown File larger than the maximum",
+			maxBytes: 9,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			peer := content.NewMemoryStore()
+			require.NoError(t, peer.Put(ctx, "ns", "f", obj))
+			peerStore, err := NewStore(peer, informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Cdn().V1alpha1().Files(), StaticPeers{}, nil, DefaultMaxFetchBytes)
+			require.NoError(t, err)
+			server := httptest.NewServer(peerStore.Handler())
+			defer server.Close()
+
+			factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(tc.files...), 0)
+			store, err := NewStore(content.NewMemoryStore(), factory.Cdn().V1alpha1().Files(), StaticPeers{server.URL}, server.Client(), tc.maxBytes)
+			require.NoError(t, err)
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+
+			got, err := store.Get(ctx, "ns", "f")
+			if tc.wantErr {
+				assert.True(t, content.IsNotFound(err), "unexpected error %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, obj.Data, got.Data)
+		})
+	}
+}
+
+// testFile returns the File of obj with the given size
+func testFile(obj *content.Object, size int64) *v1alpha1.File {
+	return &v1alpha1.File{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "f"},
+		Spec:       v1alpha1.FileSpec{Size: size},
+		Status:     v1alpha1.FileStatus{Uploaded: true, Checksum: obj.Checksum},
+	}
+}
+
+func TestEndpointSlicePeers(t *testing.T) {
+	factory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 0)
+	informer := factory.Discovery().V1().EndpointSlices()
+	peers := NewEndpointSlicePeers(informer)
+	assert.Empty(t, peers.Peers())
+
+	addSlice := func(indexer cache.Indexer, name string, port *int32, endpoints ...discoveryv1.Endpoint) {
+		slice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cdn", Name: name},
+			Endpoints:  endpoints,
+		}
+		if port != nil {
+			slice.Ports = []discoveryv1.EndpointPort{{Port: port}}
+		}
+		require.NoError(t, indexer.Add(slice))
+	}
+	indexer := informer.Informer().GetIndexer()
+	addSlice(indexer, "ipv4", ptr.To[int32](443),
+		discoveryv1.Endpoint{Addresses: []string{"10.0.0.2"}},
+		discoveryv1.Endpoint{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+		discoveryv1.Endpoint{Addresses: []string{"10.0.0.3"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}},
+	)
+	addSlice(indexer, "ipv6", ptr.To[int32](8443), discoveryv1.Endpoint{Addresses: []string{"fd00::1"}})
+	addSlice(indexer, "no-ports", nil, discoveryv1.Endpoint{Addresses: []string{"10.0.0.4"}})
+
+	assert.Equal(t, []string{"https://10.0.0.1:443", "https://10.0.0.2:443", "https://[fd00::1]:8443"}, peers.Peers())
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package signedurl signs and verifies URLs that serve the content of a File
+// without credentials until they expire.
+package signedurl
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// KeySize is the minimum size of signing keys in bytes.
+	KeySize = 32
+
+	expiresParam   = "expires"
+	signatureParam = "signature"
+)
+
+// ErrInvalid is returned for URLs whose signature is missing, does not match
+// or has expired.
+var ErrInvalid = errors.New("invalid or expired signature")
+
+// Signer signs URLs with an HMAC-SHA256 key. URLs signed by one Signer are
+// only valid for Signers with the same key.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a Signer for key, which must be at least KeySize bytes.
+func NewSigner(key []byte) (*Signer, error) {
+	if len(key) < KeySize {
+		return nil, fmt.Errorf("signing key has %d bytes, at least %d are required", len(key), KeySize)
+	}
+	return &Signer{key: bytes.Clone(key)}, nil
+}
+
+// NewRandomSigner returns a Signer with a random key. Its URLs are not valid
+// for other processes.
+func NewRandomSigner() *Signer {
+	key := make([]byte, KeySize)
+	rand.Read(key)
+	return &Signer{key: key}
+}
+
+// LoadSigner returns a Signer for the key in the file at path. Leading and
+// trailing whitespace is not part of the key.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := NewSigner(bytes.TrimSpace(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return signer, nil
+}
+
+// Sign returns the query parameters that make a URL for the content of the
+// File with uid valid until expires.
+func (s *Signer) Sign(namespace, name string, uid types.UID, expires time.Time) url.Values {
+	unix := expires.Unix()
+	return url.Values{
+		expiresParam:   {strconv.FormatInt(unix, 10)},
+		signatureParam: {base64.RawURLEncoding.EncodeToString(s.mac(namespace, name, uid, unix))},
+	}
+}
+
+// Verify returns ErrInvalid unless query was signed by Sign for the File with
+// uid and has not expired at now.
+func (s *Signer) Verify(namespace, name string, uid types.UID, query url.Values, now time.Time) error {
+	unix, err := strconv.ParseInt(query.Get(expiresParam), 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(query.Get(signatureParam))
+	if err != nil || !hmac.Equal(signature, s.mac(namespace, name, uid, unix)) {
+		return ErrInvalid
+	}
+	if !now.Before(time.Unix(unix, 0)) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// mac returns the signature of the File with uid until the Unix time expires
+func (s *Signer) mac(namespace, name string, uid types.UID, expires int64) []byte {
+	h := hmac.New(sha256.New, s.key)
+	// Namespaces, names and UIDs cannot contain newlines
+	fmt.Fprintf(h, "%s\n%s\n%s\n%d", namespace, name, uid, expires)
+	return h.Sum(nil)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package signedurl
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestVerify(t *testing.T) {
+	signer, err := NewSigner([]byte(strings.Repeat("k", KeySize)))
+	require.NoError(t, err)
+	other := NewRandomSigner()
+
+	now := time.Unix(1700000000, 0)
+	expires := now.Add(time.Hour)
+	query := signer.Sign("web", "index.html", "uid-1", expires)
+
+	tampered := url.Values{expiresParam: {"1700999999"}, signatureParam: query[signatureParam]}
+	testCases := []struct {
+		desc   string
+		signer *Signer
+		name   string
+		uid    string
+		query  url.Values
+		now    time.Time
+		valid  bool
+	}{
+		{desc: "valid", valid: true},
+		{desc: "just before expiry", now: expires.Add(-time.Second), valid: true},
+		{desc: "expired", now: expires},
+		{desc: "other name", name: "secret.html"},
+		{desc: "recreated File", uid: "uid-2"},
+		{desc: "other key", signer: other},
+		{desc: "extended expiry", query: tampered},
+		{desc: "no signature", query: url.Values{expiresParam: query[expiresParam]}},
+		{desc: "invalid expiry", query: url.Values{expiresParam: {"soon"}, signatureParam: query[signatureParam]}},
+		{desc: "no parameters", query: url.Values{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, name, uid, q, at := signer, "index.html", "uid-1", query, now
+			if tc.signer != nil {
+				s = tc.signer
+			}
+			if tc.name != "" {
+				name = tc.name
+			}
+			if tc.uid != "" {
+				uid = tc.uid
+			}
+			if tc.query != nil {
+				q = tc.query
+			}
+			if !tc.now.IsZero() {
+				at = tc.now
+			}
+			err := s.Verify("web", name, types.UID(uid), q, at)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalid)
+			}
+		})
+	}
+}
+
+func TestLoadSigner(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(key, []byte(strings.Repeat("k", KeySize)+"\n"), 0o600))
+	signer, err := LoadSigner(key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(strings.Repeat("k", KeySize)), signer.key)
+
+	short := filepath.Join(dir, "short")
+	require.NoError(t, os.WriteFile(short, []byte("secret"), 0o600))
+	_, err = LoadSigner(short)
+	assert.ErrorContains(t, err, "at least 32 are required")
+
+	_, err = LoadSigner(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
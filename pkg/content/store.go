@@ -0,0 +1,204 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package content stores the bytes behind File objects.
+package content
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ErrNotFound is returned by a Store when no content is stored for a File.
+var ErrNotFound = errors.New("content not found")
+
+// IsNotFound returns true if err indicates that no content is stored.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// Object is the stored content of a single File.
+type Object struct {
+	// Data is the raw content.
+	Data []byte
+	// ContentType is the normalized MIME type of the content.
+	ContentType string
+	// Checksum is the hex-encoded SHA-256 digest of Data.
+	Checksum string
+}
+
+// ObjectInfo describes stored content without its data.
+type ObjectInfo struct {
+	// Size is the number of bytes stored.
+	Size int64
+	// ContentType is the normalized MIME type of the content.
+	ContentType string
+	// Checksum is the hex-encoded SHA-256 digest of the content.
+	Checksum string
+	// Versions counts the contents stored for the File, including the
+	// current one, since the store was created or the content deleted.
+	Versions int64
+	// Modified is when the current content was stored.
+	Modified time.Time
+	// Accessed is when the content was last read, or zero if it was not.
+	Accessed time.Time
+	// Cached is true if the content is held by a CachedStore.
+	Cached bool
+}
+
+// StagedObject describes content staged with Store.Stage.
+type StagedObject struct {
+	Namespace string
+	Name      string
+	// ID identifies the staged content to Promote or Discard.
+	ID string
+	// Checksum is the hex-encoded SHA-256 digest of the staged content.
+	Checksum string
+	// Staged is when the content was staged.
+	Staged time.Time
+}
+
+// Store persists File content keyed by namespace and name.
+//
+// Uploads write content in two phases: the content is staged, the File is
+// committed with the content's checksum, and then the staged content is
+// promoted to be the File's content. Staged content whose File was never
+// committed is discarded.
+type Store interface {
+	// Get returns the content of the named File, or ErrNotFound.
+	Get(ctx context.Context, namespace, name string) (*Object, error)
+	// Stat describes the content of the named File without reading it, or
+	// returns ErrNotFound.
+	Stat(ctx context.Context, namespace, name string) (*ObjectInfo, error)
+	// Put stores the content of the named File, replacing any previous content.
+	Put(ctx context.Context, namespace, name string, obj *Object) error
+	// Delete removes the content of the named File. Deleting content that
+	// does not exist is not an error.
+	Delete(ctx context.Context, namespace, name string) error
+	// Stage stores content for the named File without making it visible to
+	// Get. It returns the ID to Promote or Discard the staged content with.
+	Stage(ctx context.Context, namespace, name string, obj *Object) (string, error)
+	// Promote replaces the content of the named File with the staged content
+	// id, or returns ErrNotFound if no such content is staged.
+	Promote(ctx context.Context, namespace, name, id string) error
+	// Discard removes the staged content id. Discarding content that is not
+	// staged is not an error.
+	Discard(ctx context.Context, namespace, name, id string) error
+	// ListStaged returns all staged content.
+	ListStaged(ctx context.Context) ([]StagedObject, error)
+	// List returns the namespace and name of every File with stored content.
+	List(ctx context.Context) ([]types.NamespacedName, error)
+}
+
+// Opener is implemented by Stores that can read content as a stream, without
+// going through caches or fetching it from elsewhere.
+type Opener interface {
+	// Open returns a reader of the content of the named File, or ErrNotFound.
+	Open(ctx context.Context, namespace, name string) (io.ReadCloser, error)
+}
+
+// Open returns a reader of the content of the named File in store. Stores
+// that are not Openers are read with Get.
+func Open(ctx context.Context, store Store, namespace, name string) (io.ReadCloser, error) {
+	if opener, ok := store.(Opener); ok {
+		return opener.Open(ctx, namespace, name)
+	}
+	obj, err := store.Get(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(obj.Data)), nil
+}
+
+// WriteObject writes obj as the response body with the given status code,
+// setting Content-Type and Content-Length. Only headers are written if
+// headOnly is true. Callers may set additional headers before calling it.
+func WriteObject(w http.ResponseWriter, obj *Object, code int, headOnly bool) {
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(obj.Data)))
+	w.WriteHeader(code)
+	if !headOnly {
+		w.Write(obj.Data)
+	}
+}
+
+// ServeObject writes obj as the response to req with the given entity tag.
+// Unlike WriteObject, it answers Range requests with the requested bytes, so
+// interrupted downloads can be resumed, and handles If-None-Match, If-Match
+// and If-Range against etag.
+func ServeObject(w http.ResponseWriter, req *http.Request, obj *Object, etag string) {
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(obj.Data))
+}
+
+// ETag returns the entity tag of content with the given checksum and content
+// generation. Bumping the generation changes the tag, so caches revalidating
+// with If-None-Match fetch the content again.
+func ETag(checksum string, generation int64) string {
+	return fmt.Sprintf("\"%s-%d\"", checksum, generation)
+}
+
+// NotModified returns true if the If-None-Match header of req matches etag.
+func NotModified(req *http.Request, etag string) bool {
+	header := req.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// PreconditionFailed returns true if the If-Match header value ifMatch does not
+// match etag, the entity tag of the current content. An empty etag means there
+// is no content yet, which fails any condition. Weak tags never match, as
+// If-Match uses strong comparison.
+func PreconditionFailed(ifMatch, etag string) bool {
+	if ifMatch == "" {
+		return false
+	}
+	if etag == "" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return false
+		}
+	}
+	return true
+}
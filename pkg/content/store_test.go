@@ -0,0 +1,93 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeObject(t *testing.T) {
+	obj := &Object{Data: []byte("0123456789"), ContentType: "text/plain"}
+	etag := ETag("abc", 1)
+
+	tests := []struct {
+		name      string
+		method    string
+		headers   map[string]string
+		wantCode  int
+		wantBody  string
+		wantRange string
+	}{
+		{name: "full", method: http.MethodGet, wantCode: http.StatusOK, wantBody: "0123456789"},
+		{name: "head", method: http.MethodHead, wantCode: http.StatusOK},
+		{name: "range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=4-"}, wantCode: http.StatusPartialContent, wantBody: "456789", wantRange: "bytes 4-9/10"},
+		{name: "matching if-range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=0-1", "If-Range": etag}, wantCode: http.StatusPartialContent, wantBody: "01", wantRange: "bytes 0-1/10"},
+		{name: "stale if-range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=4-", "If-Range": ETag("abc", 0)}, wantCode: http.StatusOK, wantBody: "0123456789"},
+		{name: "unsatisfiable range", method: http.MethodGet, headers: map[string]string{"Range": "bytes=20-"}, wantCode: http.StatusRequestedRangeNotSatisfiable, wantRange: "bytes */10"},
+		{name: "not modified", method: http.MethodGet, headers: map[string]string{"If-None-Match": etag}, wantCode: http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/content", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			ServeObject(w, req, obj, etag)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode != http.StatusRequestedRangeNotSatisfiable {
+				assert.Equal(t, etag, w.Header().Get("ETag"))
+			}
+			assert.Equal(t, tt.wantRange, w.Header().Get("Content-Range"))
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, w.Body.String())
+				assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestPreconditionFailed(t *testing.T) {
+	etag := ETag("abc", 1)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		etag    string
+		want    bool
+	}{
+		{name: "unconditional", etag: etag, want: false},
+		{name: "unconditional without content", want: false},
+		{name: "matching", ifMatch: etag, etag: etag, want: false},
+		{name: "one of several", ifMatch: ETag("abc", 0) + ", " + etag, etag: etag, want: false},
+		{name: "stale", ifMatch: ETag("abc", 0), etag: etag, want: true},
+		{name: "weak", ifMatch: "W/" + etag, etag: etag, want: true},
+		{name: "any", ifMatch: "*", etag: etag, want: false},
+		{name: "any without content", ifMatch: "*", want: true},
+		{name: "without content", ifMatch: etag, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PreconditionFailed(tt.ifMatch, tt.etag))
+		})
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package purge implements the controller that carries out Purges.
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/events"
+	clientset "k8s.toms.place/apiserver/pkg/generated/clientset/versioned"
+	cdninformers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions/cdn/v1alpha1"
+	cdnlisters "k8s.toms.place/apiserver/pkg/generated/listers/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/purge"
+)
+
+// Controller purges the Files selected by new Purges: it bumps their content
+// generation, notifies the downstream webhooks and records the result.
+type Controller struct {
+	client      clientset.Interface
+	purgeLister cdnlisters.PurgeLister
+	fileLister  cdnlisters.FileLister
+	notifier    *purge.Notifier
+	recorder    record.EventRecorder
+	synced      []cache.InformerSynced
+	queue       workqueue.TypedRateLimitingInterface[string]
+}
+
+// NewController returns a Controller watching the given informers. notifier
+// may be nil if no webhooks are configured. A Purged event is recorded against
+// every purged File.
+func NewController(client clientset.Interface, purgeInformer cdninformers.PurgeInformer, fileInformer cdninformers.FileInformer, notifier *purge.Notifier, recorder record.EventRecorder) (*Controller, error) {
+	c := &Controller{
+		client:      client,
+		purgeLister: purgeInformer.Lister(),
+		fileLister:  fileInformer.Lister(),
+		notifier:    notifier,
+		recorder:    recorder,
+		synced:      []cache.InformerSynced{purgeInformer.Informer().HasSynced, fileInformer.Informer().HasSynced},
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "purge"},
+		),
+	}
+
+	if _, err := purgeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueuePurge,
+		UpdateFunc: func(_, obj interface{}) { c.enqueuePurge(obj) },
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run starts workers and blocks until ctx is done.
+func (c *Controller) Run(ctx context.Context, workers int) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := klog.FromContext(ctx)
+	logger.Info("Starting purge controller")
+	defer logger.Info("Shutting down purge controller")
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.synced...) {
+		return
+	}
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+	<-ctx.Done()
+}
+
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.sync(ctx, key); err != nil {
+		utilruntime.HandleErrorWithContext(ctx, err, "Failed to sync purge", "purge", key)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) enqueuePurge(obj interface{}) {
+	p, ok := obj.(*cdnv1alpha1.Purge)
+	if !ok || p.Status.CompletionTime != nil {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *Controller) sync(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	p, err := c.purgeLister.Purges(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if p.Status.CompletionTime != nil {
+		return nil
+	}
+
+	// Bumping the content generation isn't idempotent, so the Files purged by
+	// an earlier, failed sync are read from the latest Purge rather than from
+	// the informer cache, which may not have seen its status yet
+	p, err = c.client.CdnV1alpha1().Purges(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if p.Status.CompletionTime != nil {
+		return nil
+	}
+
+	names, err := c.selectFiles(p)
+	if err != nil {
+		return err
+	}
+
+	request := &purge.WebhookRequest{Namespace: namespace, Purge: name, Files: []purge.WebhookFile{}}
+	purged := sets.New(p.Status.Files...)
+	for _, fileName := range sets.List(purged) {
+		file, err := c.client.CdnV1alpha1().Files(namespace).Get(ctx, fileName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		request.Files = append(request.Files, webhookFile(file))
+	}
+	for _, fileName := range names {
+		if purged.Has(fileName) {
+			continue
+		}
+		file, err := c.bumpContentGeneration(ctx, namespace, fileName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			// Record the Files purged so far, so the retry doesn't purge them again
+			_, statusErr := c.updateStatus(ctx, p, request, nil, false)
+			return errors.Join(err, statusErr)
+		}
+		c.recorder.Eventf(events.FileReference(file), corev1.EventTypeNormal, events.ReasonPurged,
+			"Purged by %s, content generation is now %d", name, file.Status.ContentGeneration)
+		request.Files = append(request.Files, webhookFile(file))
+	}
+
+	// The purged Files are recorded before the webhooks are notified, so a
+	// failure to complete the Purge afterwards doesn't purge them again
+	p, err = c.updateStatus(ctx, p, request, nil, false)
+	if err != nil {
+		return err
+	}
+	_, err = c.updateStatus(ctx, p, request, c.notifier.Notify(ctx, request), true)
+	return err
+}
+
+// updateStatus records the Files of request as purged by p, completing p if
+// complete is true, and returns the updated Purge.
+func (c *Controller) updateStatus(ctx context.Context, p *cdnv1alpha1.Purge, request *purge.WebhookRequest, webhooks []cdnv1alpha1.PurgeWebhookStatus, complete bool) (*cdnv1alpha1.Purge, error) {
+	status := cdnv1alpha1.PurgeStatus{Webhooks: webhooks}
+	for _, file := range request.Files {
+		status.Files = append(status.Files, file.Name)
+	}
+	if complete {
+		now := metav1.Now()
+		status.CompletionTime = &now
+	}
+
+	p = p.DeepCopy()
+	p.Status = status
+	return c.client.CdnV1alpha1().Purges(p.Namespace).UpdateStatus(ctx, p, metav1.UpdateOptions{})
+}
+
+// webhookFile describes a purged File to the webhooks
+func webhookFile(file *cdnv1alpha1.File) purge.WebhookFile {
+	return purge.WebhookFile{
+		Name: file.Name,
+		URL:  file.Spec.URL,
+		ETag: content.ETag(file.Status.Checksum, file.Status.ContentGeneration),
+	}
+}
+
+// selectFiles returns the sorted names of the Files matched by the Purge.
+func (c *Controller) selectFiles(p *cdnv1alpha1.Purge) ([]string, error) {
+	names := sets.New[string]()
+	files := c.fileLister.Files(p.Namespace)
+
+	for _, name := range p.Spec.Names {
+		if _, err := files.Get(name); err == nil {
+			names.Insert(name)
+		} else if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	if len(p.Spec.Prefixes) > 0 {
+		all, err := files.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range all {
+			for _, prefix := range p.Spec.Prefixes {
+				if strings.HasPrefix(file.Name, prefix) {
+					names.Insert(file.Name)
+					break
+				}
+			}
+		}
+	}
+	if p.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(p.Spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector: %w", err)
+		}
+		selected, err := files.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range selected {
+			names.Insert(file.Name)
+		}
+	}
+
+	return sets.List(names), nil
+}
+
+// bumpContentGeneration increments the content generation of the named File,
+// which changes the ETag of its content. The status of Files can only be
+// changed through the status subresource.
+func (c *Controller) bumpContentGeneration(ctx context.Context, namespace, name string) (*cdnv1alpha1.File, error) {
+	var updated *cdnv1alpha1.File
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		file, err := c.client.CdnV1alpha1().Files(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		file.Status.ContentGeneration++
+		updated, err = c.client.CdnV1alpha1().Files(namespace).UpdateStatus(ctx, file, metav1.UpdateOptions{})
+		return err
+	})
+	return updated, err
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/fake"
+	informers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions"
+)
+
+func newFile(name string) *cdnv1alpha1.File {
+	return &cdnv1alpha1.File{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+		Status:     cdnv1alpha1.FileStatus{Uploaded: true, Checksum: "abc", ContentGeneration: 1},
+	}
+}
+
+// failOnce fails the first status update of the resource matching match
+func failOnce(client *fake.Clientset, resource string, match func(obj runtime.Object) bool) {
+	failed := false
+	client.PrependReactor("update", resource, func(action clienttesting.Action) (bool, runtime.Object, error) {
+		update := action.(clienttesting.UpdateAction)
+		if failed || update.GetSubresource() != "status" || !match(update.GetObject()) {
+			return false, nil, nil
+		}
+		failed = true
+		return true, nil, errors.New("etcd unavailable")
+	})
+}
+
+func TestSyncRetriesWithoutPurgingAgain(t *testing.T) {
+	testCases := []struct {
+		desc string
+		fail func(client *fake.Clientset)
+	}{
+		{
+			desc: "purging a File fails",
+			fail: func(client *fake.Clientset) {
+				failOnce(client, "files", func(obj runtime.Object) bool {
+					return obj.(*cdnv1alpha1.File).Name == "b"
+				})
+			},
+		},
+		{
+			desc: "completing the Purge fails",
+			fail: func(client *fake.Clientset) {
+				failOnce(client, "purges", func(obj runtime.Object) bool {
+					return obj.(*cdnv1alpha1.Purge).Status.CompletionTime != nil
+				})
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			p := &cdnv1alpha1.Purge{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "p"},
+				Spec:       cdnv1alpha1.PurgeSpec{Names: []string{"a", "b"}},
+			}
+			client := fake.NewSimpleClientset(newFile("a"), newFile("b"), p)
+			factory := informers.NewSharedInformerFactory(client, 0)
+			c, err := NewController(client, factory.Cdn().V1alpha1().Purges(), factory.Cdn().V1alpha1().Files(), nil, record.NewFakeRecorder(10))
+			require.NoError(t, err)
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+			tc.fail(client)
+
+			require.Error(t, c.sync(ctx, "ns/p"))
+			require.NoError(t, c.sync(ctx, "ns/p"))
+
+			for _, name := range []string{"a", "b"} {
+				file, err := client.CdnV1alpha1().Files("ns").Get(ctx, name, metav1.GetOptions{})
+				require.NoError(t, err)
+				assert.EqualValues(t, 2, file.Status.ContentGeneration, name)
+			}
+			p, err = client.CdnV1alpha1().Purges("ns").Get(ctx, "p", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a", "b"}, p.Status.Files)
+			assert.NotNil(t, p.Status.CompletionTime)
+		})
+	}
+}
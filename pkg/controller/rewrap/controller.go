@@ -0,0 +1,102 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rewrap
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"k8s.toms.place/apiserver/pkg/content"
+	cdninformers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions/cdn/v1alpha1"
+	cdnlisters "k8s.toms.place/apiserver/pkg/generated/listers/cdn/v1alpha1"
+)
+
+// Rewrapper re-encrypts the stored content of a File with the current keys.
+type Rewrapper interface {
+	// Rewrap returns true if the stored content changed.
+	Rewrap(ctx context.Context, namespace, name string) (bool, error)
+}
+
+// Controller periodically rewraps the content of every File, so that key
+// encryption keys can be rotated: after a new key is put first in the
+// encryption configuration, or a KMS plugin reports a new key ID, the data
+// keys wrapped with the old key are wrapped again with the new one. The old
+// key can be removed once a pass reports no failures.
+type Controller struct {
+	fileLister cdnlisters.FileLister
+	synced     cache.InformerSynced
+	rewrapper  Rewrapper
+	interval   time.Duration
+}
+
+// NewController returns a Controller rewrapping the content of the Files of
+// fileInformer every interval.
+func NewController(fileInformer cdninformers.FileInformer, rewrapper Rewrapper, interval time.Duration) *Controller {
+	return &Controller{
+		fileLister: fileInformer.Lister(),
+		synced:     fileInformer.Informer().HasSynced,
+		rewrapper:  rewrapper,
+		interval:   interval,
+	}
+}
+
+// Run rewraps content until ctx is done, starting with a pass right away.
+func (c *Controller) Run(ctx context.Context) {
+	defer utilruntime.HandleCrash()
+
+	logger := klog.FromContext(ctx)
+	logger.Info("Starting content rewrap controller")
+	defer logger.Info("Shutting down content rewrap controller")
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.synced) {
+		return
+	}
+	wait.UntilWithContext(ctx, c.rewrapAll, c.interval)
+}
+
+// rewrapAll rewraps the content of all Files
+func (c *Controller) rewrapAll(ctx context.Context) {
+	files, err := c.fileLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleErrorWithContext(ctx, err, "Failed to list files")
+		return
+	}
+
+	var rewrapped, failed int
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		changed, err := c.rewrapper.Rewrap(ctx, file.Namespace, file.Name)
+		switch {
+		case content.IsNotFound(err):
+			// Nothing uploaded yet
+		case err != nil:
+			failed++
+			utilruntime.HandleErrorWithContext(ctx, err, "Failed to rewrap content", "file", klog.KObj(file))
+		case changed:
+			rewrapped++
+		}
+	}
+	klog.FromContext(ctx).Info("Rewrapped content", "files", len(files), "rewrapped", rewrapped, "failed", failed)
+}
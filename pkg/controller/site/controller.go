@@ -0,0 +1,213 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package site implements the controller that reports the status of Sites.
+package site
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	clientset "k8s.toms.place/apiserver/pkg/generated/clientset/versioned"
+	cdninformers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions/cdn/v1alpha1"
+	cdnlisters "k8s.toms.place/apiserver/pkg/generated/listers/cdn/v1alpha1"
+	siteregistry "k8s.toms.place/apiserver/pkg/registry/cdn/site"
+)
+
+// Controller keeps the status of Sites up to date with the Files they serve.
+type Controller struct {
+	client     clientset.Interface
+	siteLister cdnlisters.SiteLister
+	fileLister cdnlisters.FileLister
+	synced     []cache.InformerSynced
+	queue      workqueue.TypedRateLimitingInterface[string]
+}
+
+// NewController returns a Controller watching the given informers.
+func NewController(client clientset.Interface, siteInformer cdninformers.SiteInformer, fileInformer cdninformers.FileInformer) (*Controller, error) {
+	c := &Controller{
+		client:     client,
+		siteLister: siteInformer.Lister(),
+		fileLister: fileInformer.Lister(),
+		synced:     []cache.InformerSynced{siteInformer.Informer().HasSynced, fileInformer.Informer().HasSynced},
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "site"},
+		),
+	}
+
+	if _, err := siteInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueueSite,
+		UpdateFunc: func(_, obj interface{}) { c.enqueueSite(obj) },
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := fileInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueueNamespaceOf,
+		UpdateFunc: func(_, obj interface{}) { c.enqueueNamespaceOf(obj) },
+		DeleteFunc: c.enqueueNamespaceOf,
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run starts workers and blocks until ctx is done.
+func (c *Controller) Run(ctx context.Context, workers int) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := klog.FromContext(ctx)
+	logger.Info("Starting site controller")
+	defer logger.Info("Shutting down site controller")
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.synced...) {
+		return
+	}
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+	<-ctx.Done()
+}
+
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.sync(ctx, key); err != nil {
+		utilruntime.HandleErrorWithContext(ctx, err, "Failed to sync site", "site", key)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) enqueueSite(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueNamespaceOf enqueues all Sites in the namespace of a changed File.
+func (c *Controller) enqueueNamespaceOf(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	file, ok := obj.(*cdnv1alpha1.File)
+	if !ok {
+		return
+	}
+	sites, err := c.siteLister.Sites(file.Namespace).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, site := range sites {
+		c.enqueueSite(site)
+	}
+}
+
+func (c *Controller) sync(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	site, err := c.siteLister.Sites(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := c.computeStatus(site)
+	if err != nil {
+		return err
+	}
+	if apiequality.Semantic.DeepEqual(site.Status, status) {
+		return nil
+	}
+
+	site = site.DeepCopy()
+	site.Status = status
+	_, err = c.client.CdnV1alpha1().Sites(namespace).UpdateStatus(ctx, site, metav1.UpdateOptions{})
+	return err
+}
+
+// computeStatus resolves the Files served by site and reports their total
+// size and the ones that are missing or have no content.
+func (c *Controller) computeStatus(site *cdnv1alpha1.Site) (cdnv1alpha1.SiteStatus, error) {
+	router := siteregistry.NewRouter(site.Spec.IndexDocument, site.Spec.NotFoundDocument, site.Spec.SPAFallback)
+	files := c.fileLister.Files(site.Namespace)
+
+	if site.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(site.Spec.Selector)
+		if err != nil {
+			return cdnv1alpha1.SiteStatus{}, fmt.Errorf("invalid selector: %w", err)
+		}
+		selected, err := files.List(selector)
+		if err != nil {
+			return cdnv1alpha1.SiteStatus{}, err
+		}
+		for _, file := range selected {
+			router.Add(siteregistry.FilePath(file.Name, file.Annotations), file.Name)
+		}
+	}
+	for _, route := range site.Spec.Routes {
+		router.Add(route.Path, route.File)
+	}
+
+	status := cdnv1alpha1.SiteStatus{ObservedGeneration: site.Generation}
+	for _, name := range router.Files() {
+		file, err := files.Get(name)
+		if apierrors.IsNotFound(err) || (err == nil && !file.Status.Uploaded) {
+			status.MissingFiles = append(status.MissingFiles, name)
+			continue
+		}
+		if err != nil {
+			return cdnv1alpha1.SiteStatus{}, err
+		}
+		status.Files++
+		status.TotalSize += file.Spec.Size
+	}
+	sort.Strings(status.MissingFiles)
+	return status, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events records Kubernetes Events against CDN objects.
+package events
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+// Component is the source component of recorded Events.
+const Component = "cdn-apiserver"
+
+// Reasons of Events recorded against Files.
+const (
+	// ReasonUploaded is recorded when new content is stored for a File.
+	ReasonUploaded = "Uploaded"
+	// ReasonUploadFailed is recorded when an upload to an existing File fails.
+	ReasonUploadFailed = "UploadFailed"
+	// ReasonChecksumMismatch is recorded when content does not match the
+	// checksum supplied by the client or recorded on the File.
+	ReasonChecksumMismatch = "ChecksumMismatch"
+	// ReasonPurged is recorded when a Purge changes a File's ETag.
+	ReasonPurged = "Purged"
+	// ReasonOriginFetchFailed is recorded when content cannot be read from
+	// the content store.
+	ReasonOriginFetchFailed = "OriginFetchFailed"
+	// ReasonRepaired is recorded when a consistency check repairs a File.
+	ReasonRepaired = "Repaired"
+)
+
+// FileReference returns a reference to the File described by obj. Internal and
+// versioned Files are both referenced by the served version, so Events are
+// found by kubectl describe.
+func FileReference(obj metav1.Object) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion:      cdnv1alpha1.SchemeGroupVersion.String(),
+		Kind:            "File",
+		Namespace:       obj.GetNamespace(),
+		Name:            obj.GetName(),
+		UID:             obj.GetUID(),
+		ResourceVersion: obj.GetResourceVersion(),
+	}
+}
+
+// NewRecorder returns a recorder whose Events are written by broadcaster once
+// it is started with Start.
+func NewRecorder(broadcaster record.EventBroadcaster, scheme *runtime.Scheme) record.EventRecorder {
+	return broadcaster.NewRecorder(scheme, corev1.EventSource{Component: Component})
+}
+
+// Start writes the Events of broadcaster through client until stopCh is
+// closed.
+func Start(broadcaster record.EventBroadcaster, client typedcorev1.EventsGetter, stopCh <-chan struct{}) {
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.Events("")})
+	go func() {
+		<-stopCh
+		broadcaster.Shutdown()
+	}()
+}
+
+// Discard returns a recorder that drops all Events.
+func Discard() record.EventRecorder {
+	return &record.FakeRecorder{}
+}
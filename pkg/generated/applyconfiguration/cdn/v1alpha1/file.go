@@ -35,9 +35,10 @@ type FileApplyConfiguration struct {
 
 // File constructs a declarative configuration of the File type for use with
 // apply.
-func File(name string) *FileApplyConfiguration {
+func File(name, namespace string) *FileApplyConfiguration {
 	b := &FileApplyConfiguration{}
 	b.WithName(name)
+	b.WithNamespace(namespace)
 	b.WithKind("File")
 	b.WithAPIVersion("cdn.k8s.toms.place/v1alpha1")
 	return b
@@ -27,6 +27,13 @@ type FileStatusApplyConfiguration struct {
 	Uploaded *bool `json:"uploaded,omitempty"`
 	// Error is an error message if the file upload failed.
 	Error *string `json:"error,omitempty"`
+	// Checksum is the hex-encoded SHA-256 digest of the uploaded content.
+	Checksum *string `json:"checksum,omitempty"`
+	// ContentGeneration is incremented whenever the content changes or is
+	// purged. It is part of the content's ETag.
+	ContentGeneration *int64 `json:"contentGeneration,omitempty"`
+	// LastUpload records who last uploaded the content.
+	LastUpload *FileUploadApplyConfiguration `json:"lastUpload,omitempty"`
 }
 
 // FileStatusApplyConfiguration constructs a declarative configuration of the FileStatus type for use with
@@ -50,3 +57,27 @@ func (b *FileStatusApplyConfiguration) WithError(value string) *FileStatusApplyC
 	b.Error = &value
 	return b
 }
+
+// WithChecksum sets the Checksum field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Checksum field is set to the value of the last call.
+func (b *FileStatusApplyConfiguration) WithChecksum(value string) *FileStatusApplyConfiguration {
+	b.Checksum = &value
+	return b
+}
+
+// WithContentGeneration sets the ContentGeneration field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ContentGeneration field is set to the value of the last call.
+func (b *FileStatusApplyConfiguration) WithContentGeneration(value int64) *FileStatusApplyConfiguration {
+	b.ContentGeneration = &value
+	return b
+}
+
+// WithLastUpload sets the LastUpload field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the LastUpload field is set to the value of the last call.
+func (b *FileStatusApplyConfiguration) WithLastUpload(value *FileUploadApplyConfiguration) *FileStatusApplyConfiguration {
+	b.LastUpload = value
+	return b
+}
@@ -38,6 +38,28 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 		return &cdnv1alpha1.FileSpecApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("FileStatus"):
 		return &cdnv1alpha1.FileStatusApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("FileUpload"):
+		return &cdnv1alpha1.FileUploadApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Purge"):
+		return &cdnv1alpha1.PurgeApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("PurgeSpec"):
+		return &cdnv1alpha1.PurgeSpecApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("PurgeStatus"):
+		return &cdnv1alpha1.PurgeStatusApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("PurgeWebhookStatus"):
+		return &cdnv1alpha1.PurgeWebhookStatusApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("Site"):
+		return &cdnv1alpha1.SiteApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("SiteHeader"):
+		return &cdnv1alpha1.SiteHeaderApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("SiteHeaderRule"):
+		return &cdnv1alpha1.SiteHeaderRuleApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("SiteRoute"):
+		return &cdnv1alpha1.SiteRouteApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("SiteSpec"):
+		return &cdnv1alpha1.SiteSpecApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("SiteStatus"):
+		return &cdnv1alpha1.SiteStatusApplyConfiguration{}
 
 	}
 	return nil
@@ -29,6 +29,8 @@ import (
 type CdnV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	FilesGetter
+	PurgesGetter
+	SitesGetter
 }
 
 // CdnV1alpha1Client is used to interact with features provided by the cdn.k8s.toms.place group.
@@ -36,8 +38,16 @@ type CdnV1alpha1Client struct {
 	restClient rest.Interface
 }
 
-func (c *CdnV1alpha1Client) Files() FileInterface {
-	return newFiles(c)
+func (c *CdnV1alpha1Client) Files(namespace string) FileInterface {
+	return newFiles(c, namespace)
+}
+
+func (c *CdnV1alpha1Client) Purges(namespace string) PurgeInterface {
+	return newPurges(c, namespace)
+}
+
+func (c *CdnV1alpha1Client) Sites(namespace string) SiteInterface {
+	return newSites(c, namespace)
 }
 
 // NewForConfig creates a new CdnV1alpha1Client for the given config.
@@ -28,8 +28,16 @@ type FakeCdnV1alpha1 struct {
 	*testing.Fake
 }
 
-func (c *FakeCdnV1alpha1) Files() v1alpha1.FileInterface {
-	return newFakeFiles(c)
+func (c *FakeCdnV1alpha1) Files(namespace string) v1alpha1.FileInterface {
+	return newFakeFiles(c, namespace)
+}
+
+func (c *FakeCdnV1alpha1) Purges(namespace string) v1alpha1.PurgeInterface {
+	return newFakePurges(c, namespace)
+}
+
+func (c *FakeCdnV1alpha1) Sites(namespace string) v1alpha1.SiteInterface {
+	return newFakeSites(c, namespace)
 }
 
 // RESTClient returns a RESTClient that is used to communicate
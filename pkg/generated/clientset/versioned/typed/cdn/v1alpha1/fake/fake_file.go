@@ -19,7 +19,11 @@ limitations under the License.
 package fake
 
 import (
+	context "context"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gentype "k8s.io/client-go/gentype"
+	testing "k8s.io/client-go/testing"
 	v1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
 	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/generated/applyconfiguration/cdn/v1alpha1"
 	typedcdnv1alpha1 "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
@@ -31,11 +35,11 @@ type fakeFiles struct {
 	Fake *FakeCdnV1alpha1
 }
 
-func newFakeFiles(fake *FakeCdnV1alpha1) typedcdnv1alpha1.FileInterface {
+func newFakeFiles(fake *FakeCdnV1alpha1, namespace string) typedcdnv1alpha1.FileInterface {
 	return &fakeFiles{
 		gentype.NewFakeClientWithListAndApply[*v1alpha1.File, *v1alpha1.FileList, *cdnv1alpha1.FileApplyConfiguration](
 			fake.Fake,
-			"",
+			namespace,
 			v1alpha1.SchemeGroupVersion.WithResource("files"),
 			v1alpha1.SchemeGroupVersion.WithKind("File"),
 			func() *v1alpha1.File { return &v1alpha1.File{} },
@@ -47,3 +51,15 @@ func newFakeFiles(fake *FakeCdnV1alpha1) typedcdnv1alpha1.FileInterface {
 		fake,
 	}
 }
+
+// CreateSignedURL takes the representation of a fileSignedURL and creates it.  Returns the server's representation of the fileSignedURL, and an error, if there is any.
+func (c *fakeFiles) CreateSignedURL(ctx context.Context, fileName string, fileSignedURL *v1alpha1.FileSignedURL, opts v1.CreateOptions) (result *v1alpha1.FileSignedURL, err error) {
+	emptyResult := &v1alpha1.FileSignedURL{}
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateSubresourceActionWithOptions(c.Resource(), fileName, "signedurl", c.Namespace(), fileSignedURL, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1alpha1.FileSignedURL), err
+}
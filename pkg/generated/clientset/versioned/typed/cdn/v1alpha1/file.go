@@ -33,7 +33,7 @@ import (
 // FilesGetter has a method to return a FileInterface.
 // A group's client should implement this interface.
 type FilesGetter interface {
-	Files() FileInterface
+	Files(namespace string) FileInterface
 }
 
 // FileInterface has methods to work with File resources.
@@ -51,6 +51,8 @@ type FileInterface interface {
 	Apply(ctx context.Context, file *applyconfigurationcdnv1alpha1.FileApplyConfiguration, opts v1.ApplyOptions) (result *cdnv1alpha1.File, err error)
 	// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
 	ApplyStatus(ctx context.Context, file *applyconfigurationcdnv1alpha1.FileApplyConfiguration, opts v1.ApplyOptions) (result *cdnv1alpha1.File, err error)
+	CreateSignedURL(ctx context.Context, fileName string, fileSignedURL *cdnv1alpha1.FileSignedURL, opts v1.CreateOptions) (*cdnv1alpha1.FileSignedURL, error)
+
 	FileExpansion
 }
 
@@ -60,15 +62,30 @@ type files struct {
 }
 
 // newFiles returns a Files
-func newFiles(c *CdnV1alpha1Client) *files {
+func newFiles(c *CdnV1alpha1Client, namespace string) *files {
 	return &files{
 		gentype.NewClientWithListAndApply[*cdnv1alpha1.File, *cdnv1alpha1.FileList, *applyconfigurationcdnv1alpha1.FileApplyConfiguration](
 			"files",
 			c.RESTClient(),
 			scheme.ParameterCodec,
-			"",
+			namespace,
 			func() *cdnv1alpha1.File { return &cdnv1alpha1.File{} },
 			func() *cdnv1alpha1.FileList { return &cdnv1alpha1.FileList{} },
 		),
 	}
 }
+
+// CreateSignedURL takes the representation of a fileSignedURL and creates it.  Returns the server's representation of the fileSignedURL, and an error, if there is any.
+func (c *files) CreateSignedURL(ctx context.Context, fileName string, fileSignedURL *cdnv1alpha1.FileSignedURL, opts v1.CreateOptions) (result *cdnv1alpha1.FileSignedURL, err error) {
+	result = &cdnv1alpha1.FileSignedURL{}
+	err = c.GetClient().Post().
+		Namespace(c.GetNamespace()).
+		Resource("files").
+		Name(fileName).
+		SubResource("signedurl").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(fileSignedURL).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -19,3 +19,7 @@ limitations under the License.
 package v1alpha1
 
 type FileExpansion interface{}
+
+type PurgeExpansion interface{}
+
+type SiteExpansion interface{}
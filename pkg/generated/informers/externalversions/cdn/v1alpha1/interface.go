@@ -26,6 +26,10 @@ import (
 type Interface interface {
 	// Files returns a FileInformer.
 	Files() FileInformer
+	// Purges returns a PurgeInformer.
+	Purges() PurgeInformer
+	// Sites returns a SiteInformer.
+	Sites() SiteInformer
 }
 
 type version struct {
@@ -41,5 +45,15 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 
 // Files returns a FileInformer.
 func (v *version) Files() FileInformer {
-	return &fileInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+	return &fileInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// Purges returns a PurgeInformer.
+func (v *version) Purges() PurgeInformer {
+	return &purgeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// Sites returns a SiteInformer.
+func (v *version) Sites() SiteInformer {
+	return &siteInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
@@ -55,6 +55,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=cdn.k8s.toms.place, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("files"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Cdn().V1alpha1().Files().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("purges"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Cdn().V1alpha1().Purges().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("sites"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Cdn().V1alpha1().Sites().Informer()}, nil
 
 	}
 
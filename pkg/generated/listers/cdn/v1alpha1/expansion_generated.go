@@ -21,3 +21,23 @@ package v1alpha1
 // FileListerExpansion allows custom methods to be added to
 // FileLister.
 type FileListerExpansion interface{}
+
+// FileNamespaceListerExpansion allows custom methods to be added to
+// FileNamespaceLister.
+type FileNamespaceListerExpansion interface{}
+
+// PurgeListerExpansion allows custom methods to be added to
+// PurgeLister.
+type PurgeListerExpansion interface{}
+
+// PurgeNamespaceListerExpansion allows custom methods to be added to
+// PurgeNamespaceLister.
+type PurgeNamespaceListerExpansion interface{}
+
+// SiteListerExpansion allows custom methods to be added to
+// SiteLister.
+type SiteListerExpansion interface{}
+
+// SiteNamespaceListerExpansion allows custom methods to be added to
+// SiteNamespaceLister.
+type SiteNamespaceListerExpansion interface{}
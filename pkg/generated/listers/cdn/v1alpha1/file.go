@@ -31,9 +31,8 @@ type FileLister interface {
 	// List lists all Files in the indexer.
 	// Objects returned here must be treated as read-only.
 	List(selector labels.Selector) (ret []*cdnv1alpha1.File, err error)
-	// Get retrieves the File from the index for a given name.
-	// Objects returned here must be treated as read-only.
-	Get(name string) (*cdnv1alpha1.File, error)
+	// Files returns an object that can list and get Files.
+	Files(namespace string) FileNamespaceLister
 	FileListerExpansion
 }
 
@@ -46,3 +45,26 @@ type fileLister struct {
 func NewFileLister(indexer cache.Indexer) FileLister {
 	return &fileLister{listers.New[*cdnv1alpha1.File](indexer, cdnv1alpha1.Resource("file"))}
 }
+
+// Files returns an object that can list and get Files.
+func (s *fileLister) Files(namespace string) FileNamespaceLister {
+	return fileNamespaceLister{listers.NewNamespaced[*cdnv1alpha1.File](s.ResourceIndexer, namespace)}
+}
+
+// FileNamespaceLister helps list and get Files.
+// All objects returned here must be treated as read-only.
+type FileNamespaceLister interface {
+	// List lists all Files in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*cdnv1alpha1.File, err error)
+	// Get retrieves the File from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*cdnv1alpha1.File, error)
+	FileNamespaceListerExpansion
+}
+
+// fileNamespaceLister implements the FileNamespaceLister
+// interface.
+type fileNamespaceLister struct {
+	listers.ResourceIndexer[*cdnv1alpha1.File]
+}
@@ -88,10 +88,33 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		runtime.Unknown{}.OpenAPIModelName():              schema_k8sio_apimachinery_pkg_runtime_Unknown(ref),
 		version.Info{}.OpenAPIModelName():                 schema_k8sio_apimachinery_pkg_version_Info(ref),
 		v1alpha1.File{}.OpenAPIModelName():                schema_pkg_apis_cdn_v1alpha1_File(ref),
+		v1alpha1.FileArchive{}.OpenAPIModelName():         schema_pkg_apis_cdn_v1alpha1_FileArchive(ref),
+		v1alpha1.FileArchiveEntry{}.OpenAPIModelName():    schema_pkg_apis_cdn_v1alpha1_FileArchiveEntry(ref),
+		v1alpha1.FileArchiveManifest{}.OpenAPIModelName(): schema_pkg_apis_cdn_v1alpha1_FileArchiveManifest(ref),
+		v1alpha1.FileArchiveOptions{}.OpenAPIModelName():  schema_pkg_apis_cdn_v1alpha1_FileArchiveOptions(ref),
 		v1alpha1.FileContent{}.OpenAPIModelName():         schema_pkg_apis_cdn_v1alpha1_FileContent(ref),
+		v1alpha1.FileCopyOptions{}.OpenAPIModelName():     schema_pkg_apis_cdn_v1alpha1_FileCopyOptions(ref),
 		v1alpha1.FileList{}.OpenAPIModelName():            schema_pkg_apis_cdn_v1alpha1_FileList(ref),
+		v1alpha1.FileSignedURL{}.OpenAPIModelName():       schema_pkg_apis_cdn_v1alpha1_FileSignedURL(ref),
+		v1alpha1.FileSignedURLSpec{}.OpenAPIModelName():   schema_pkg_apis_cdn_v1alpha1_FileSignedURLSpec(ref),
+		v1alpha1.FileSignedURLStatus{}.OpenAPIModelName(): schema_pkg_apis_cdn_v1alpha1_FileSignedURLStatus(ref),
 		v1alpha1.FileSpec{}.OpenAPIModelName():            schema_pkg_apis_cdn_v1alpha1_FileSpec(ref),
+		v1alpha1.FileStat{}.OpenAPIModelName():            schema_pkg_apis_cdn_v1alpha1_FileStat(ref),
 		v1alpha1.FileStatus{}.OpenAPIModelName():          schema_pkg_apis_cdn_v1alpha1_FileStatus(ref),
+		v1alpha1.FileUpload{}.OpenAPIModelName():          schema_pkg_apis_cdn_v1alpha1_FileUpload(ref),
+		v1alpha1.Purge{}.OpenAPIModelName():               schema_pkg_apis_cdn_v1alpha1_Purge(ref),
+		v1alpha1.PurgeList{}.OpenAPIModelName():           schema_pkg_apis_cdn_v1alpha1_PurgeList(ref),
+		v1alpha1.PurgeSpec{}.OpenAPIModelName():           schema_pkg_apis_cdn_v1alpha1_PurgeSpec(ref),
+		v1alpha1.PurgeStatus{}.OpenAPIModelName():         schema_pkg_apis_cdn_v1alpha1_PurgeStatus(ref),
+		v1alpha1.PurgeWebhookStatus{}.OpenAPIModelName():  schema_pkg_apis_cdn_v1alpha1_PurgeWebhookStatus(ref),
+		v1alpha1.Site{}.OpenAPIModelName():                schema_pkg_apis_cdn_v1alpha1_Site(ref),
+		v1alpha1.SiteHeader{}.OpenAPIModelName():          schema_pkg_apis_cdn_v1alpha1_SiteHeader(ref),
+		v1alpha1.SiteHeaderRule{}.OpenAPIModelName():      schema_pkg_apis_cdn_v1alpha1_SiteHeaderRule(ref),
+		v1alpha1.SiteList{}.OpenAPIModelName():            schema_pkg_apis_cdn_v1alpha1_SiteList(ref),
+		v1alpha1.SiteRoute{}.OpenAPIModelName():           schema_pkg_apis_cdn_v1alpha1_SiteRoute(ref),
+		v1alpha1.SiteServeOptions{}.OpenAPIModelName():    schema_pkg_apis_cdn_v1alpha1_SiteServeOptions(ref),
+		v1alpha1.SiteSpec{}.OpenAPIModelName():            schema_pkg_apis_cdn_v1alpha1_SiteSpec(ref),
+		v1alpha1.SiteStatus{}.OpenAPIModelName():          schema_pkg_apis_cdn_v1alpha1_SiteStatus(ref),
 	}
 }
 
@@ -2807,11 +2830,944 @@ func schema_pkg_apis_cdn_v1alpha1_File(ref common.ReferenceCallback) common.Open
 	}
 }
 
+func schema_pkg_apis_cdn_v1alpha1_FileArchive(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileArchive is the response of the archive subresource of a File",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.Status{}.OpenAPIModelName()),
+						},
+					},
+					"entries": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.FileArchiveEntry{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1.Status{}.OpenAPIModelName(), v1alpha1.FileArchiveEntry{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileArchiveEntry(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileArchiveEntry is the result for a single entry of an uploaded archive.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path is the path of the entry in the archive.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"file": {
+						SchemaProps: spec.SchemaProps{
+							Description: "File is the name of the File the entry was written to.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Size is the size of the entry in bytes.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"contentType": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContentType is the detected MIME type of the entry.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"result": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Result is the outcome for the entry.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Error is set if Result is Failed.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"checksum": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Checksum is the hex-encoded SHA-256 digest of the entry.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"path"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileArchiveManifest(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileArchiveManifest describes the Files of a downloaded archive. It is stored as the last entry of the archive.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"entries": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Entries maps the archive entries to their Files.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.FileArchiveEntry{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+					"files": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Files are the selected Files, including those without content.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.File{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1alpha1.File{}.OpenAPIModelName(), v1alpha1.FileArchiveEntry{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileArchiveOptions(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileArchiveOptions is the query options for the archive subresource of a File",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"prune": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Prune deletes Files of the archive that are not part of the upload.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"labelSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LabelSelector selects the Files of a downloaded archive. Defaults to all Files in the namespace.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"fieldSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FieldSelector selects the Files of a downloaded archive.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"format": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Format is the format of a downloaded archive, \"tar.gz\" or \"zip\". Defaults to \"tar.gz\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_cdn_v1alpha1_FileContent(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "FileContent is the content subresource for a File",
+				Description: "FileContent is the content subresource for a File",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.Status{}.OpenAPIModelName()),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1.Status{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileCopyOptions(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileCopyOptions is the query options for the copy subresource of a File",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"destination": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Destination is the name of the File the content is copied to.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"destinationNamespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DestinationNamespace is the namespace of the destination File. Defaults to the namespace of the copied File.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"move": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Move deletes the copied File once the destination File is committed.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"check": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Check checks the copy, including its authorization, without changing any File. The API server reserves the dryRun parameter of connect requests, so copies use their own.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileList is a list of File objects.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.ListMeta{}.OpenAPIModelName()),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.File{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"items"},
+			},
+		},
+		Dependencies: []string{
+			v1.ListMeta{}.OpenAPIModelName(), v1alpha1.File{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileSignedURL(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileSignedURL is the signedurl subresource of a File. Creating it signs a URL that serves the content of the File without credentials until it expires.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.ObjectMeta{}.OpenAPIModelName()),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1alpha1.FileSignedURLSpec{}.OpenAPIModelName()),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1alpha1.FileSignedURLStatus{}.OpenAPIModelName()),
+						},
+					},
+				},
+				Required: []string{"spec"},
+			},
+		},
+		Dependencies: []string{
+			v1.ObjectMeta{}.OpenAPIModelName(), v1alpha1.FileSignedURLSpec{}.OpenAPIModelName(), v1alpha1.FileSignedURLStatus{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileSignedURLSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileSignedURLSpec is the requested validity of a signed URL.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"expirationSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ExpirationSeconds is how long the URL is valid for, between 60 seconds and 7 days. Defaults to one hour.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileSignedURLStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileSignedURLStatus is the signed URL.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL serves the content of the File. It is signed for the File's UID, so it stops working if the File is deleted, even if it is recreated.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"expirationTimestamp": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ExpirationTimestamp is when the URL stops working.",
+							Ref:         ref(v1.Time{}.OpenAPIModelName()),
+						},
+					},
+				},
+				Required: []string{"url", "expirationTimestamp"},
+			},
+		},
+		Dependencies: []string{
+			v1.Time{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileSpec is the specification of a File.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the URL of the file.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Size is the size of the file in bytes.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"contentType": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContentType is the MIME type of the file.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resourceLocation": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Add a resource location for the content",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileStat(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileStat is the stat subresource of a File. It describes the content stored for the File without the content itself.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.ObjectMeta{}.OpenAPIModelName()),
+						},
+					},
+					"stored": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Stored is false if no content is stored for the File.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Size is the size of the content in bytes, excluding any encryption overhead.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"contentType": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContentType is the content type stored with the content.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"checksum": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Checksum is the hex-encoded SHA-256 digest of the stored content.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"versions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Versions counts the contents stored for the File by this replica since it started.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"modified": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Modified is when the current content was stored.",
+							Ref:         ref(v1.Time{}.OpenAPIModelName()),
+						},
+					},
+					"lastAccess": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastAccess is when the content was last read, if it was.",
+							Ref:         ref(v1.Time{}.OpenAPIModelName()),
+						},
+					},
+					"cached": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Cached is true if the content is in the replica's content cache.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"stored"},
+			},
+		},
+		Dependencies: []string{
+			v1.ObjectMeta{}.OpenAPIModelName(), v1.Time{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileStatus is the status of a File.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"uploaded": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Uploaded is true if the file has been uploaded.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Error is an error message if the file upload failed.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"checksum": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Checksum is the hex-encoded SHA-256 digest of the uploaded content.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"contentGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ContentGeneration is incremented whenever the content changes or is purged. It is part of the content's ETag.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"lastUpload": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastUpload records who last uploaded the content.",
+							Ref:         ref(v1alpha1.FileUpload{}.OpenAPIModelName()),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1alpha1.FileUpload{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_FileUpload(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "FileUpload records the request that uploaded the content of a File.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"user": {
+						SchemaProps: spec.SchemaProps{
+							Description: "User is the name of the authenticated user.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"groups": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Groups are the groups of the authenticated user.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"sourceIP": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SourceIP is the client address of the request.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"userAgent": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UserAgent is the User-Agent of the request.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"time": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time is when the content was uploaded.",
+							Ref:         ref(v1.Time{}.OpenAPIModelName()),
+						},
+					},
+				},
+				Required: []string{"time"},
+			},
+		},
+		Dependencies: []string{
+			v1.Time{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_Purge(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Purge invalidates the cached content of Files. Purging bumps the content generation of each File, which changes its ETag, and notifies the downstream purge webhooks configured on the server.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.ObjectMeta{}.OpenAPIModelName()),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1alpha1.PurgeSpec{}.OpenAPIModelName()),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1alpha1.PurgeStatus{}.OpenAPIModelName()),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1.ObjectMeta{}.OpenAPIModelName(), v1alpha1.PurgeSpec{}.OpenAPIModelName(), v1alpha1.PurgeStatus{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_PurgeList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PurgeList is a list of Purge objects.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.ListMeta{}.OpenAPIModelName()),
+						},
+					},
+					"items": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.Purge{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"items"},
+			},
+		},
+		Dependencies: []string{
+			v1.ListMeta{}.OpenAPIModelName(), v1alpha1.Purge{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_PurgeSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PurgeSpec selects the Files whose cached content is purged. A File is purged if it matches any of Names, Prefixes or Selector.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"names": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Names are exact File names.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"prefixes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Prefixes match File names by prefix.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"selector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Selector matches Files by label. An empty selector matches all Files in the namespace.",
+							Ref:         ref(v1.LabelSelector{}.OpenAPIModelName()),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1.LabelSelector{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_PurgeStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PurgeStatus is the status of a Purge.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"files": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Files are the names of the purged Files. Until CompletionTime is set, they are the Files purged so far.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"webhooks": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Webhooks are the results of notifying the downstream purge webhooks.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.PurgeWebhookStatus{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CompletionTime is when the purge completed. A Purge is processed once.",
+							Ref:         ref(v1.Time{}.OpenAPIModelName()),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1.Time{}.OpenAPIModelName(), v1alpha1.PurgeWebhookStatus{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_PurgeWebhookStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "PurgeWebhookStatus is the result of notifying one downstream purge webhook.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL is the webhook URL.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"code": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Code is the HTTP status code of the last attempt, if a response was received.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Error is set if the webhook could not be notified.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"url"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_Site(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Site bundles Files into a routable static website.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"kind": {
@@ -2828,25 +3784,105 @@ func schema_pkg_apis_cdn_v1alpha1_FileContent(ref common.ReferenceCallback) comm
 							Format:      "",
 						},
 					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1.ObjectMeta{}.OpenAPIModelName()),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref(v1alpha1.SiteSpec{}.OpenAPIModelName()),
+						},
+					},
 					"status": {
 						SchemaProps: spec.SchemaProps{
 							Default: map[string]interface{}{},
-							Ref:     ref(v1.Status{}.OpenAPIModelName()),
+							Ref:     ref(v1alpha1.SiteStatus{}.OpenAPIModelName()),
 						},
 					},
 				},
 			},
 		},
 		Dependencies: []string{
-			v1.Status{}.OpenAPIModelName()},
+			v1.ObjectMeta{}.OpenAPIModelName(), v1alpha1.SiteSpec{}.OpenAPIModelName(), v1alpha1.SiteStatus{}.OpenAPIModelName()},
 	}
 }
 
-func schema_pkg_apis_cdn_v1alpha1_FileList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_cdn_v1alpha1_SiteHeader(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "FileList is a list of File objects.",
+				Description: "SiteHeader is an HTTP header added to responses.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name is the header name.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"value": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value is the header value.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name", "value"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_SiteHeaderRule(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SiteHeaderRule adds headers to responses for paths under a prefix.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pathPrefix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PathPrefix selects the paths the headers apply to.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"headers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Headers are set on every response below PathPrefix.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.SiteHeader{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"pathPrefix"},
+			},
+		},
+		Dependencies: []string{
+			v1alpha1.SiteHeader{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_SiteList(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SiteList is a list of Site objects.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"kind": {
@@ -2876,7 +3912,7 @@ func schema_pkg_apis_cdn_v1alpha1_FileList(ref common.ReferenceCallback) common.
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
 										Default: map[string]interface{}{},
-										Ref:     ref(v1alpha1.File{}.OpenAPIModelName()),
+										Ref:     ref(v1alpha1.Site{}.OpenAPIModelName()),
 									},
 								},
 							},
@@ -2887,41 +3923,64 @@ func schema_pkg_apis_cdn_v1alpha1_FileList(ref common.ReferenceCallback) common.
 			},
 		},
 		Dependencies: []string{
-			v1.ListMeta{}.OpenAPIModelName(), v1alpha1.File{}.OpenAPIModelName()},
+			v1.ListMeta{}.OpenAPIModelName(), v1alpha1.Site{}.OpenAPIModelName()},
 	}
 }
 
-func schema_pkg_apis_cdn_v1alpha1_FileSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_cdn_v1alpha1_SiteRoute(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "FileSpec is the specification of a File.",
+				Description: "SiteRoute maps a URL path to a File.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"url": {
+					"path": {
 						SchemaProps: spec.SchemaProps{
-							Description: "URL is the URL of the file.",
+							Description: "Path is the absolute URL path, e.g. \"/docs/getting-started\".",
+							Default:     "",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"size": {
+					"file": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Size is the size of the file in bytes.",
-							Type:        []string{"integer"},
-							Format:      "int64",
+							Description: "File is the name of the File in the Site's namespace.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"contentType": {
+				},
+				Required: []string{"path", "file"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_SiteServeOptions(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SiteServeOptions is the query options for the serve subresource of a Site",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
 						SchemaProps: spec.SchemaProps{
-							Description: "ContentType is the MIME type of the file.",
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"resourceLocation": {
+					"apiVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Add a resource location for the content",
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"path": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path is the URL path to resolve through the Site.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -2932,27 +3991,119 @@ func schema_pkg_apis_cdn_v1alpha1_FileSpec(ref common.ReferenceCallback) common.
 	}
 }
 
-func schema_pkg_apis_cdn_v1alpha1_FileStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_cdn_v1alpha1_SiteSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "FileStatus is the status of a File.",
+				Description: "SiteSpec is the specification of a Site.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"uploaded": {
+					"selector": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Uploaded is true if the file has been uploaded.",
-							Type:        []string{"boolean"},
+							Description: "Selector selects the Files that make up the site. A selected File is served at the path in its cdn.k8s.toms.place/path annotation, or at \"/<name>\" if it has none.",
+							Ref:         ref(v1.LabelSelector{}.OpenAPIModelName()),
+						},
+					},
+					"routes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Routes maps paths to Files explicitly. Routes take precedence over Files matched by Selector.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.SiteRoute{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+					"indexDocument": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IndexDocument is appended to directory paths. Defaults to \"index.html\".",
+							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"error": {
+					"notFoundDocument": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Error is an error message if the file upload failed.",
+							Description: "NotFoundDocument is the path served with status 404 when no route matches.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"spaFallback": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SPAFallback serves the root index document for unmatched paths instead of NotFoundDocument.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"headers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Headers are added to responses by path prefix.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref(v1alpha1.SiteHeaderRule{}.OpenAPIModelName()),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			v1.LabelSelector{}.OpenAPIModelName(), v1alpha1.SiteHeaderRule{}.OpenAPIModelName(), v1alpha1.SiteRoute{}.OpenAPIModelName()},
+	}
+}
+
+func schema_pkg_apis_cdn_v1alpha1_SiteStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SiteStatus is the status of a Site.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the generation the status was computed for.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"files": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Files is the number of Files served by the site.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"totalSize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "TotalSize is the combined size in bytes of all Files served by the site.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"missingFiles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MissingFiles lists referenced Files that do not exist or have no content.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
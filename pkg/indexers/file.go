@@ -0,0 +1,89 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package indexers provides cache indexers for the generated informers so
+// controllers can look up Files by the same fields exposed to field selectors.
+package indexers
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/client-go/tools/cache"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	informers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions"
+)
+
+// Index names registered on the File informer. They match the field selector
+// labels accepted by the API server.
+const (
+	FileURLIndex         = "spec.url"
+	FileContentTypeIndex = "spec.contentType"
+	FileUploadedIndex    = "status.uploaded"
+	FileChecksumIndex    = "status.checksum"
+)
+
+// FileIndexers returns the indexers for File objects, including the
+// namespace indexer.
+func FileIndexers() cache.Indexers {
+	return cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		FileURLIndex: fileIndexFunc(func(f *cdnv1alpha1.File) string {
+			return f.Spec.URL
+		}),
+		FileContentTypeIndex: fileIndexFunc(func(f *cdnv1alpha1.File) string {
+			return f.Spec.ContentType
+		}),
+		FileUploadedIndex: fileIndexFunc(func(f *cdnv1alpha1.File) string {
+			return strconv.FormatBool(f.Status.Uploaded)
+		}),
+		FileChecksumIndex: fileIndexFunc(func(f *cdnv1alpha1.File) string {
+			return f.Status.Checksum
+		}),
+	}
+}
+
+// AddFileIndexers registers FileIndexers on the File informer of the given
+// factory. It must be called before the factory is started.
+func AddFileIndexers(factory informers.SharedInformerFactory) error {
+	informer := factory.Cdn().V1alpha1().Files().Informer()
+	existing := informer.GetIndexer().GetIndexers()
+
+	toAdd := cache.Indexers{}
+	for name, fn := range FileIndexers() {
+		if _, ok := existing[name]; !ok {
+			toAdd[name] = fn
+		}
+	}
+	return informer.AddIndexers(toAdd)
+}
+
+// fileIndexFunc adapts a File field accessor to a cache.IndexFunc. Empty
+// values are not indexed.
+func fileIndexFunc(value func(*cdnv1alpha1.File) string) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		file, ok := obj.(*cdnv1alpha1.File)
+		if !ok {
+			return nil, fmt.Errorf("object is not a File: %T", obj)
+		}
+		v := value(file)
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	}
+}
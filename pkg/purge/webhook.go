@@ -0,0 +1,143 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package purge notifies downstream caches about purged File content.
+package purge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+// WebhookRequest is the JSON body POSTed to downstream purge webhooks.
+type WebhookRequest struct {
+	// Namespace is the namespace of the Purge and its Files.
+	Namespace string `json:"namespace"`
+	// Purge is the name of the Purge.
+	Purge string `json:"purge"`
+	// Files are the purged Files.
+	Files []WebhookFile `json:"files"`
+}
+
+// WebhookFile is a purged File.
+type WebhookFile struct {
+	// Name is the name of the File.
+	Name string `json:"name"`
+	// URL is the content URL of the File.
+	URL string `json:"url,omitempty"`
+	// ETag is the new entity tag of the content.
+	ETag string `json:"etag,omitempty"`
+}
+
+// DefaultBackoff is the retry backoff for webhook requests.
+var DefaultBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Steps:    3,
+}
+
+// Notifier POSTs purge requests to a fixed set of webhooks.
+type Notifier struct {
+	urls    []string
+	client  *http.Client
+	backoff wait.Backoff
+}
+
+// NewNotifier returns a Notifier for the given webhook URLs. Every request is
+// bounded by timeout.
+func NewNotifier(urls []string, timeout time.Duration) *Notifier {
+	return &Notifier{
+		urls:    urls,
+		client:  &http.Client{Timeout: timeout},
+		backoff: DefaultBackoff,
+	}
+}
+
+// Notify sends req to every webhook and returns the result per webhook.
+// Requests failing with a transport error or a 5xx response are retried.
+func (n *Notifier) Notify(ctx context.Context, req *WebhookRequest) []cdnv1alpha1.PurgeWebhookStatus {
+	if n == nil || len(n.urls) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		// Marshalling plain structs does not fail
+		panic(err)
+	}
+
+	statuses := make([]cdnv1alpha1.PurgeWebhookStatus, 0, len(n.urls))
+	for _, url := range n.urls {
+		statuses = append(statuses, n.notify(ctx, url, body))
+	}
+	return statuses
+}
+
+// notify POSTs body to a single webhook, retrying with backoff
+func (n *Notifier) notify(ctx context.Context, url string, body []byte) cdnv1alpha1.PurgeWebhookStatus {
+	status := cdnv1alpha1.PurgeWebhookStatus{URL: url}
+	var lastErr error
+	err := wait.ExponentialBackoffWithContext(ctx, n.backoff, func(ctx context.Context) (bool, error) {
+		code, err := n.post(ctx, url, body)
+		status.Code = int32(code)
+		switch {
+		case err != nil:
+			lastErr = err
+			return false, nil
+		case code >= 500:
+			lastErr = fmt.Errorf("webhook returned %d", code)
+			return false, nil
+		case code >= 300:
+			// Client errors do not go away by retrying
+			return false, fmt.Errorf("webhook returned %d", code)
+		}
+		return true, nil
+	})
+	if wait.Interrupted(err) && lastErr != nil {
+		err = lastErr
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// post sends a single request and returns the response status code
+func (n *Notifier) post(ctx context.Context, url string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
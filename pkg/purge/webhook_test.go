@@ -0,0 +1,91 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestNotify(t *testing.T) {
+	request := &WebhookRequest{
+		Namespace: "web",
+		Purge:     "release-1",
+		Files:     []WebhookFile{{Name: "index.html", ETag: `"abc-2"`}},
+	}
+
+	testCases := []struct {
+		desc      string
+		responses []int
+		wantCode  int32
+		wantCalls int32
+		wantError bool
+	}{
+		{desc: "success", responses: []int{http.StatusOK}, wantCode: http.StatusOK, wantCalls: 1},
+		{desc: "retried server error", responses: []int{http.StatusServiceUnavailable, http.StatusNoContent}, wantCode: http.StatusNoContent, wantCalls: 2},
+		{desc: "client error is not retried", responses: []int{http.StatusBadRequest}, wantCode: http.StatusBadRequest, wantCalls: 1, wantError: true},
+		{desc: "persistent server error", responses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, wantCode: http.StatusBadGateway, wantCalls: 3, wantError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := calls.Add(1) - 1
+				var got WebhookRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+				assert.Equal(t, *request, got)
+				w.WriteHeader(tc.responses[i])
+			}))
+			defer server.Close()
+
+			n := NewNotifier([]string{server.URL}, time.Second)
+			n.backoff = wait.Backoff{Duration: time.Millisecond, Steps: 3}
+
+			statuses := n.Notify(context.Background(), request)
+			require.Len(t, statuses, 1)
+			assert.Equal(t, server.URL, statuses[0].URL)
+			assert.Equal(t, tc.wantCode, statuses[0].Code)
+			assert.Equal(t, tc.wantError, statuses[0].Error != "")
+			assert.Equal(t, tc.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestNotifyUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	n := NewNotifier([]string{url}, time.Second)
+	n.backoff = wait.Backoff{Duration: time.Millisecond, Steps: 2}
+
+	statuses := n.Notify(context.Background(), &WebhookRequest{Namespace: "web", Purge: "p"})
+	require.Len(t, statuses, 1)
+	assert.Zero(t, statuses[0].Code)
+	assert.NotEmpty(t, statuses[0].Error)
+}
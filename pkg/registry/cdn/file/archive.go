@@ -0,0 +1,636 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/registry"
+)
+
+// ArchiveLimits bounds the resources a single archive upload may use
+type ArchiveLimits struct {
+	// MaxEntries is the maximum number of entries, including directories.
+	MaxEntries int
+	// MaxEntryBytes is the maximum uncompressed size of a single entry.
+	MaxEntryBytes int64
+	// MaxTotalBytes is the maximum size of the request body and the maximum
+	// uncompressed size of all entries together. Zip uploads are spooled to a
+	// temporary file of up to this size.
+	MaxTotalBytes int64
+	// MaxCompressionRatio is the maximum ratio of uncompressed to compressed
+	// size of a zip entry.
+	MaxCompressionRatio int64
+}
+
+// DefaultArchiveLimits are the limits used for unset ArchiveLimits fields
+var DefaultArchiveLimits = ArchiveLimits{
+	MaxEntries:          10000,
+	MaxEntryBytes:       100 << 20,
+	MaxTotalBytes:       1 << 30,
+	MaxCompressionRatio: 100,
+}
+
+// Complete fills unset limits with their defaults
+func (l ArchiveLimits) Complete() ArchiveLimits {
+	if l.MaxEntries <= 0 {
+		l.MaxEntries = DefaultArchiveLimits.MaxEntries
+	}
+	if l.MaxEntryBytes <= 0 {
+		l.MaxEntryBytes = DefaultArchiveLimits.MaxEntryBytes
+	}
+	if l.MaxTotalBytes <= 0 {
+		l.MaxTotalBytes = DefaultArchiveLimits.MaxTotalBytes
+	}
+	if l.MaxCompressionRatio <= 0 {
+		l.MaxCompressionRatio = DefaultArchiveLimits.MaxCompressionRatio
+	}
+	return l
+}
+
+// ArchiveREST implements rest.Connecter for expanding a tar or zip archive
+// into many Files and for downloading many Files as one archive. The name in
+// the request is the archive name: uploaded Files are named
+// "<archive>-<entry path>" and labelled with cdn.k8s.toms.place/archive.
+type ArchiveREST struct {
+	store        *registry.REST
+	status       *StatusREST
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
+	externalHost string
+	limits       ArchiveLimits
+	encoder      runtime.Encoder
+}
+
+// NewArchiveREST creates a new ArchiveREST. The encoder is used for the
+// manifest of downloaded archives. authorizer checks the File permissions of
+// uploads and downloads; if nil, they are not checked.
+func NewArchiveREST(store *registry.REST, status *StatusREST, contentStore content.Store, recorder record.EventRecorder, authorizer authorizer.Authorizer, externalHost string, limits ArchiveLimits, encoder runtime.Encoder) *ArchiveREST {
+	return &ArchiveREST{
+		store:        store,
+		status:       status,
+		contentStore: contentStore,
+		recorder:     recorder,
+		authorizer:   authorizer,
+		externalHost: externalHost,
+		limits:       limits.Complete(),
+		encoder:      encoder,
+	}
+}
+
+var _ rest.Connecter = &ArchiveREST{}
+var _ rest.StorageMetadata = &ArchiveREST{}
+
+// New returns an empty object that can be used with Create and Update
+func (r *ArchiveREST) New() runtime.Object {
+	return &cdn.FileArchive{}
+}
+
+// Destroy cleans up resources on shutdown
+func (r *ArchiveREST) Destroy() {}
+
+// Connect returns an http.Handler that expands the uploaded archive
+func (r *ArchiveREST) Connect(ctx context.Context, name string, options runtime.Object, responder rest.Responder) (http.Handler, error) {
+	opts, ok := options.(*cdn.FileArchiveOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options object: %#v", options)
+	}
+
+	return &archiveHandler{
+		ctx:          ctx,
+		store:        r.store,
+		status:       r.status,
+		contentStore: r.contentStore,
+		recorder:     r.recorder,
+		authorizer:   r.authorizer,
+		name:         name,
+		options:      opts,
+		responder:    responder,
+		externalHost: r.externalHost,
+		limits:       r.limits,
+		encoder:      r.encoder,
+	}, nil
+}
+
+// NewConnectOptions returns an empty options object for the Connect method
+func (r *ArchiveREST) NewConnectOptions() (runtime.Object, bool, string) {
+	return &cdn.FileArchiveOptions{}, false, ""
+}
+
+// ConnectMethods returns the list of HTTP methods handled by Connect
+func (r *ArchiveREST) ConnectMethods() []string {
+	return []string{"GET", "POST", "PUT"}
+}
+
+// ProducesMIMETypes returns a list of MIME types the verb can respond with
+func (r *ArchiveREST) ProducesMIMETypes(verb string) []string {
+	if verb == "GET" {
+		return []string{"application/gzip", "application/zip"}
+	}
+	return nil
+}
+
+// ProducesObject returns the object the verb responds with
+func (r *ArchiveREST) ProducesObject(verb string) interface{} {
+	if verb == "GET" {
+		return ""
+	}
+	return &cdn.FileArchive{}
+}
+
+// archiveHandler handles HTTP requests for archive uploads
+type archiveHandler struct {
+	ctx          context.Context
+	store        *registry.REST
+	status       *StatusREST
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
+	name         string
+	options      *cdn.FileArchiveOptions
+	responder    rest.Responder
+	externalHost string
+	limits       ArchiveLimits
+	encoder      runtime.Encoder
+}
+
+// archiveEntry is a regular file read from an archive
+type archiveEntry struct {
+	path string
+	data []byte
+}
+
+// ServeHTTP handles GET requests downloading an archive and POST and PUT
+// requests uploading one
+func (h *archiveHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	operation := content.OperationArchiveDownload
+	if req.Method != http.MethodGet {
+		operation = content.OperationArchiveUpload
+		defer content.UploadStarted()()
+	}
+	recorder, responder := instrumentRequest(w, req, h.responder, operation)
+	defer recorder.done(h.ctx)
+	w, h.responder = recorder, responder
+
+	switch req.Method {
+	case http.MethodGet:
+		h.handleGet(w, req)
+	case http.MethodPost, http.MethodPut:
+		h.handleUpload(w, req)
+	default:
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+	}
+}
+
+// handleUpload expands a tar, tar.gz or zip body into Files. Entries are
+// written as they are read, so only one entry is held in memory at a time.
+func (h *archiveHandler) handleUpload(w http.ResponseWriter, req *http.Request) {
+	if errs := validation.IsValidLabelValue(h.name); len(errs) > 0 {
+		content.RecordValidationRejection(content.RejectionInvalidArchiveName)
+		h.responder.Error(apierrors.NewBadRequest(fmt.Sprintf("archive name %q must be a valid label value: %s", h.name, strings.Join(errs, "; "))))
+		return
+	}
+
+	body := &countingReader{ReadCloser: http.MaxBytesReader(w, req.Body, h.limits.MaxTotalBytes)}
+	archive, closeArchive, err := openArchive(body, h.limits)
+	if err != nil {
+		h.archiveError(err, 0)
+		return
+	}
+	defer closeArchive()
+
+	namespace := request.NamespaceValue(h.ctx)
+	upload := newFileUpload(h.ctx, req)
+	writer := &fileWriter{store: h.store, status: h.status, contentStore: h.contentStore, recorder: h.recorder, authorizer: h.authorizer}
+	response := &cdn.FileArchive{}
+	written := map[string]bool{}
+	for {
+		entry, err := archive.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			auditUpload(h.ctx, upload, body.read, AuditAnnotationEntries, strconv.Itoa(len(response.Entries)))
+			h.archiveError(err, len(written))
+			return
+		}
+		// Archives downloaded from this endpoint can be uploaded again
+		if entry.path == ArchiveManifestName {
+			continue
+		}
+		response.Entries = append(response.Entries, h.writeEntry(req, writer, namespace, upload, entry, written))
+	}
+	auditUpload(h.ctx, upload, body.read, AuditAnnotationEntries, strconv.Itoa(len(response.Entries)))
+	sort.SliceStable(response.Entries, func(i, j int) bool {
+		return response.Entries[i].Path < response.Entries[j].Path
+	})
+
+	if h.options.Prune {
+		pruned, err := h.prune(written)
+		response.Entries = append(response.Entries, pruned...)
+		if err != nil {
+			h.responder.Error(err)
+			return
+		}
+	}
+
+	response.Status = archiveStatus(h.name, response.Entries)
+	h.responder.Object(http.StatusOK, response)
+}
+
+// writeEntry writes an archive entry to its File, recording the File's name
+// in written. Existing Files of other archives, or of none, are not replaced.
+func (h *archiveHandler) writeEntry(req *http.Request, writer *fileWriter, namespace string, upload *cdn.FileUpload, entry *archiveEntry, written map[string]bool) cdn.FileArchiveEntry {
+	result := cdn.FileArchiveEntry{
+		Path: entry.path,
+		Size: int64(len(entry.data)),
+	}
+
+	name, err := archiveFileName(h.name, entry.path)
+	if err == nil && written[name] {
+		err = fmt.Errorf("entry maps to File %s which is already written by another entry", name)
+	}
+	if err != nil {
+		result.Result = cdn.FileArchiveEntryFailed
+		result.Error = err.Error()
+		return result
+	}
+	result.File = name
+	result.ContentType = detectContentType(entry.path, entry.data)
+	result.Checksum = sha256Hex(entry.data)
+	written[name] = true
+
+	result.Result, err = writer.write(h.ctx, &fileWrite{
+		name:        name,
+		url:         buildContentURL(req, h.externalHost, namespace, name),
+		data:        entry.data,
+		contentType: result.ContentType,
+		labels:      map[string]string{cdnv1alpha1.LabelArchive: h.name},
+		annotations: map[string]string{cdnv1alpha1.AnnotationPath: "/" + entry.path},
+		archive:     h.name,
+		upload:      upload,
+	})
+	if err != nil {
+		result.Result = cdn.FileArchiveEntryFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// archiveOwnerError describes why file, which an entry of archive maps to,
+// is not replaced
+func archiveOwnerError(file *cdn.File, archive string) error {
+	owner, ok := file.Labels[cdnv1alpha1.LabelArchive]
+	if !ok {
+		return fmt.Errorf("File was not uploaded from archive %s", archive)
+	}
+	return fmt.Errorf("File belongs to archive %s, not %s", owner, archive)
+}
+
+// archiveError responds with the error that stopped reading the archive.
+// Entries read before it were already written, which isn't rolled back; the
+// archive isn't pruned.
+func (h *archiveHandler) archiveError(err error, written int) {
+	var maxBytesErr *http.MaxBytesError
+	var status apierrors.APIStatus
+	switch {
+	case errors.As(err, &maxBytesErr):
+		content.RecordValidationRejection(content.RejectionTooLarge)
+		err = apierrors.NewRequestEntityTooLargeError(fmt.Sprintf("archive exceeds %d bytes%s", h.limits.MaxTotalBytes, writtenSuffix(written)))
+	case errors.As(err, &status):
+	default:
+		content.RecordValidationRejection(content.RejectionInvalidArchive)
+		err = apierrors.NewBadRequest(fmt.Sprintf("invalid archive: %v%s", err, writtenSuffix(written)))
+	}
+	h.responder.Error(err)
+}
+
+// writtenSuffix notes the Files written before an archive error
+func writtenSuffix(written int) string {
+	if written == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d Files were written before the error)", written)
+}
+
+// prune deletes the Files of the archive that were not written by this upload
+func (h *archiveHandler) prune(written map[string]bool) ([]cdn.FileArchiveEntry, error) {
+	selector := labels.SelectorFromSet(labels.Set{cdnv1alpha1.LabelArchive: h.name})
+	obj, err := h.store.List(h.ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []cdn.FileArchiveEntry
+	for _, file := range obj.(*cdn.FileList).Items {
+		if written[file.Name] {
+			continue
+		}
+		entry := cdn.FileArchiveEntry{
+			Path:        strings.TrimPrefix(file.Annotations[cdnv1alpha1.AnnotationPath], "/"),
+			File:        file.Name,
+			Size:        file.Spec.Size,
+			ContentType: file.Spec.ContentType,
+			Result:      cdn.FileArchiveEntryPruned,
+		}
+		// The File storage removes the content of deleted Files
+		err := Authorize(h.ctx, h.authorizer, "delete", "", file.Name)
+		if err == nil {
+			_, _, err = h.store.Delete(h.ctx, file.Name, rest.ValidateAllObjectFunc, &metav1.DeleteOptions{})
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			entry.Result = cdn.FileArchiveEntryFailed
+			entry.Error = err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// archiveStatus summarizes the entry results of an upload
+func archiveStatus(name string, entries []cdn.FileArchiveEntry) metav1.Status {
+	counts := map[cdn.FileArchiveEntryResult]int{}
+	for _, entry := range entries {
+		counts[entry.Result]++
+	}
+
+	status := metav1.Status{
+		Status: metav1.StatusSuccess,
+		Message: fmt.Sprintf("archive %s: %d created, %d updated, %d unchanged, %d pruned, %d failed", name,
+			counts[cdn.FileArchiveEntryCreated], counts[cdn.FileArchiveEntryUpdated], counts[cdn.FileArchiveEntryUnchanged],
+			counts[cdn.FileArchiveEntryPruned], counts[cdn.FileArchiveEntryFailed]),
+		Details: &metav1.StatusDetails{
+			Name: name,
+			Kind: "File",
+		},
+		Code: http.StatusOK,
+	}
+	if counts[cdn.FileArchiveEntryFailed] > 0 {
+		status.Status = metav1.StatusFailure
+	}
+	return status
+}
+
+// archiveReader reads the regular files of an archive one at a time
+type archiveReader interface {
+	// next returns the next regular file, or io.EOF after the last one
+	next() (*archiveEntry, error)
+}
+
+// openArchive opens a tar, tar.gz or zip archive read from body, enforcing
+// limits. The format is detected from the content. The returned function
+// releases the archive's resources.
+func openArchive(body io.Reader, limits ArchiveLimits) (archiveReader, func(), error) {
+	br := bufio.NewReader(body)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &tarArchive{tr: tar.NewReader(gz), limits: limits}, func() { gz.Close() }, nil
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return openZip(br, limits)
+	default:
+		return &tarArchive{tr: tar.NewReader(br), limits: limits}, func() {}, nil
+	}
+}
+
+// tarArchive reads the regular files of a tar stream
+type tarArchive struct {
+	tr     *tar.Reader
+	limits ArchiveLimits
+	count  int
+	total  int64
+}
+
+func (a *tarArchive) next() (*archiveEntry, error) {
+	for {
+		header, err := a.tr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if a.count++; a.count > a.limits.MaxEntries {
+			return nil, fmt.Errorf("archive has more than %d entries", a.limits.MaxEntries)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, fmt.Errorf("entry %q: links and special files are not supported", header.Name)
+		}
+
+		p, err := cleanArchivePath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if header.Size > a.limits.MaxEntryBytes {
+			return nil, fmt.Errorf("entry %q exceeds %d bytes", header.Name, a.limits.MaxEntryBytes)
+		}
+		data, err := readLimited(a.tr, a.limits.MaxEntryBytes)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", header.Name, err)
+		}
+		if a.total += int64(len(data)); a.total > a.limits.MaxTotalBytes {
+			return nil, fmt.Errorf("archive exceeds %d bytes uncompressed", a.limits.MaxTotalBytes)
+		}
+		return &archiveEntry{path: p, data: data}, nil
+	}
+}
+
+// zipArchive reads the regular files of a zip archive
+type zipArchive struct {
+	files  []*zip.File
+	limits ArchiveLimits
+	total  int64
+}
+
+// openZip spools a zip archive to a temporary file, as its directory is at
+// the end, and opens it. The returned function removes the file.
+func openZip(r io.Reader, limits ArchiveLimits) (archiveReader, func(), error) {
+	f, err := os.CreateTemp("", "archive-*.zip")
+	if err != nil {
+		return nil, nil, apierrors.NewInternalError(fmt.Errorf("failed to spool archive: %w", err))
+	}
+	remove := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+
+	size, err := io.Copy(f, r)
+	if err != nil {
+		remove()
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, nil, err
+		}
+		return nil, nil, apierrors.NewInternalError(fmt.Errorf("failed to spool archive: %w", err))
+	}
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		remove()
+		return nil, nil, err
+	}
+	if len(zr.File) > limits.MaxEntries {
+		remove()
+		return nil, nil, fmt.Errorf("archive has more than %d entries", limits.MaxEntries)
+	}
+	return &zipArchive{files: zr.File, limits: limits}, remove, nil
+}
+
+func (a *zipArchive) next() (*archiveEntry, error) {
+	for len(a.files) > 0 {
+		f := a.files[0]
+		a.files = a.files[1:]
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return nil, fmt.Errorf("entry %q: links and special files are not supported", f.Name)
+		}
+
+		p, err := cleanArchivePath(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		if f.UncompressedSize64 > uint64(a.limits.MaxEntryBytes) {
+			return nil, fmt.Errorf("entry %q exceeds %d bytes", f.Name, a.limits.MaxEntryBytes)
+		}
+		if f.CompressedSize64 > 0 && f.UncompressedSize64/f.CompressedSize64 > uint64(a.limits.MaxCompressionRatio) {
+			return nil, fmt.Errorf("entry %q exceeds the maximum compression ratio of %d", f.Name, a.limits.MaxCompressionRatio)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", f.Name, err)
+		}
+		// The declared sizes may lie, so the limits are enforced while reading
+		data, err := readLimited(rc, a.limits.MaxEntryBytes)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("entry %q: %w", f.Name, err)
+		}
+		if a.total += int64(len(data)); a.total > a.limits.MaxTotalBytes {
+			return nil, fmt.Errorf("archive exceeds %d bytes uncompressed", a.limits.MaxTotalBytes)
+		}
+		return &archiveEntry{path: p, data: data}, nil
+	}
+	return nil, io.EOF
+}
+
+// readLimited reads r to the end, failing if it is larger than limit
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("exceeds %d bytes", limit)
+	}
+	return data, nil
+}
+
+// cleanArchivePath returns the cleaned, relative path of an archive entry.
+// Absolute paths and paths escaping the archive root are rejected.
+func cleanArchivePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("entry %q: absolute paths are not allowed", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("entry %q: paths must not contain '..'", name)
+		}
+	}
+	p := path.Clean(name)
+	if p == "." || p == "" {
+		return "", fmt.Errorf("entry %q: empty path", name)
+	}
+	return p, nil
+}
+
+// archiveFileName maps an entry path to the name of its File
+func archiveFileName(archive, p string) (string, error) {
+	var b strings.Builder
+	b.WriteString(archive)
+	b.WriteByte('-')
+	for _, r := range strings.ToLower(p) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+
+	name := strings.TrimRight(b.String(), "-.")
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("cannot map path to a File name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return name, nil
+}
+
+// detectContentType returns the MIME type of an entry from its extension,
+// falling back to sniffing its content
+func detectContentType(p string, data []byte) string {
+	contentType := mime.TypeByExtension(path.Ext(p))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	normalized, err := normalizeContentType(contentType)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return normalized
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/events"
+)
+
+// ArchiveManifestName is the path of the manifest in a downloaded archive
+const ArchiveManifestName = ".cdn-manifest.json"
+
+// handleGet streams the selected Files as a tar.gz or zip archive. Content is
+// read from the content store one File at a time and written straight to the
+// response; the manifest is written last, once all checksums are known.
+func (h *archiveHandler) handleGet(w http.ResponseWriter, req *http.Request) {
+	format := h.options.Format
+	if format == "" {
+		format = cdn.FileArchiveFormatTarGz
+	}
+	if format != cdn.FileArchiveFormatTarGz && format != cdn.FileArchiveFormatZip {
+		content.RecordValidationRejection(content.RejectionInvalidFormat)
+		h.responder.Error(apierrors.NewBadRequest(fmt.Sprintf("unsupported archive format %q, must be %q or %q", format, cdn.FileArchiveFormatTarGz, cdn.FileArchiveFormatZip)))
+		return
+	}
+	labelSelector, err := labels.Parse(h.options.LabelSelector)
+	if err != nil {
+		content.RecordValidationRejection(content.RejectionInvalidSelector)
+		h.responder.Error(apierrors.NewBadRequest(fmt.Sprintf("invalid label selector: %v", err)))
+		return
+	}
+	fieldSelector, err := fields.ParseSelector(h.options.FieldSelector)
+	if err != nil {
+		content.RecordValidationRejection(content.RejectionInvalidSelector)
+		h.responder.Error(apierrors.NewBadRequest(fmt.Sprintf("invalid field selector: %v", err)))
+		return
+	}
+
+	// The archive exposes the metadata and content of every selected File
+	for _, subresource := range []string{"", "content"} {
+		verb := "get"
+		if subresource == "" {
+			verb = "list"
+		}
+		if err := Authorize(h.ctx, h.authorizer, verb, subresource, ""); err != nil {
+			h.responder.Error(err)
+			return
+		}
+	}
+
+	obj, err := h.store.List(h.ctx, &metainternalversion.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+	files := obj.(*cdn.FileList).Items
+	namespace := request.NamespaceValue(h.ctx)
+	paths := archiveEntryPaths(files)
+
+	contentType := "application/gzip"
+	if format == cdn.FileArchiveFormatZip {
+		contentType = "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", h.name+"."+format))
+	w.WriteHeader(http.StatusOK)
+
+	aw := newArchiveWriter(format, w)
+	modTime := time.Now()
+	manifest := &cdn.FileArchiveManifest{Files: files}
+	for _, file := range files {
+		obj, err := h.contentStore.Get(h.ctx, file.Namespace, file.Name)
+		if content.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			h.recorder.Eventf(events.FileReference(&file), corev1.EventTypeWarning, events.ReasonOriginFetchFailed, "Failed to read content: %v", err)
+			abortArchive(err, "Failed to read content for archive", file.Namespace, file.Name)
+		}
+
+		p := paths[file.Name]
+		if err := aw.WriteEntry(p, obj.Data, modTime); err != nil {
+			abortArchive(err, "Failed to write archive entry", file.Namespace, file.Name)
+		}
+		contentType := obj.ContentType
+		if contentType == "" {
+			contentType = file.Spec.ContentType
+		}
+		manifest.Entries = append(manifest.Entries, cdn.FileArchiveEntry{
+			Path:        p,
+			File:        file.Name,
+			Size:        int64(len(obj.Data)),
+			ContentType: contentType,
+			Checksum:    sha256Hex(obj.Data),
+		})
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := h.encoder.Encode(manifest, &buf); err != nil {
+		abortArchive(err, "Failed to encode archive manifest", namespace, h.name)
+	}
+	if err := aw.WriteEntry(ArchiveManifestName, buf.Bytes(), modTime); err != nil {
+		abortArchive(err, "Failed to write archive manifest", namespace, h.name)
+	}
+	if err := aw.Close(); err != nil {
+		abortArchive(err, "Failed to finish archive", namespace, h.name)
+	}
+}
+
+// abortArchive logs err and aborts the response. The status has already been
+// sent, so the truncated archive is how the client learns about the failure.
+func abortArchive(err error, msg, namespace, name string) {
+	klog.ErrorS(err, msg, "namespace", namespace, "name", name)
+	panic(http.ErrAbortHandler)
+}
+
+// archiveEntryPaths returns the archive path for each File. Files are stored
+// at their cdn.k8s.toms.place/path annotation, falling back to their name if
+// the annotation is missing, invalid or already taken, and to a numbered
+// name under _files/ if that is taken too.
+func archiveEntryPaths(files []cdn.File) map[string]string {
+	paths := make(map[string]string, len(files))
+	used := map[string]bool{ArchiveManifestName: true}
+	for _, file := range files {
+		candidates := []string{file.Name, "_files/" + file.Name}
+		if annotation, ok := file.Annotations[cdnv1alpha1.AnnotationPath]; ok {
+			if p, err := cleanArchivePath(strings.TrimPrefix(annotation, "/")); err == nil {
+				candidates = append([]string{p}, candidates...)
+			}
+		}
+		p := ""
+		for _, candidate := range candidates {
+			if !used[candidate] {
+				p = candidate
+				break
+			}
+		}
+		for n := 1; p == ""; n++ {
+			if candidate := fmt.Sprintf("_files/%s-%d", file.Name, n); !used[candidate] {
+				p = candidate
+			}
+		}
+		paths[file.Name] = p
+		used[p] = true
+	}
+	return paths
+}
+
+// archiveWriter writes entries to a streamed archive
+type archiveWriter interface {
+	WriteEntry(name string, data []byte, modTime time.Time) error
+	Close() error
+}
+
+// newArchiveWriter returns an archiveWriter for format writing to w
+func newArchiveWriter(format string, w io.Writer) archiveWriter {
+	if format == cdn.FileArchiveFormatZip {
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}
+	}
+	gz := gzip.NewWriter(w)
+	return &tarGzArchiveWriter{gz: gz, tw: tar.NewWriter(gz)}
+}
+
+// tarGzArchiveWriter writes a gzip-compressed tar archive
+type tarGzArchiveWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (a *tarGzArchiveWriter) WriteEntry(name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := a.tw.Write(data); err != nil {
+		return err
+	}
+	// Push the entry through gzip so it reaches the client right away
+	return a.gz.Flush()
+}
+
+func (a *tarGzArchiveWriter) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+// zipArchiveWriter writes a zip archive
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiveWriter) WriteEntry(name string, data []byte, modTime time.Time) error {
+	w, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return a.zw.Flush()
+}
+
+func (a *zipArchiveWriter) Close() error {
+	return a.zw.Close()
+}
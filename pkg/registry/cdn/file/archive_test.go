@@ -0,0 +1,341 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+type testEntry struct {
+	name     string
+	body     string
+	typeflag byte
+}
+
+func buildTar(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		header := &tar.Header{Name: e.name, Mode: 0o644, Typeflag: typeflag}
+		if typeflag == tar.TypeReg {
+			header.Size = int64(len(e.body))
+		} else if typeflag == tar.TypeSymlink {
+			header.Linkname = e.body
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		if typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(e.body))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(e.body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// readAll reads all entries of the archive in body, sorted by path
+func readAll(body []byte, limits ArchiveLimits) ([]archiveEntry, error) {
+	archive, closeArchive, err := openArchive(bytes.NewReader(body), limits)
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+
+	var entries []archiveEntry
+	for {
+		entry, err := archive.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].path < entries[j].path
+	})
+	return entries, nil
+}
+
+func TestReadArchive(t *testing.T) {
+	entries := []testEntry{
+		{name: "dist/", typeflag: tar.TypeDir},
+		{name: "dist/index.html", body: "<html></html>"},
+		{name: "./dist/app.js", body: "console.log(1)"},
+	}
+	limits := DefaultArchiveLimits
+
+	testCases := []struct {
+		desc string
+		body []byte
+	}{
+		{desc: "tar", body: buildTar(t, entries)},
+		{desc: "tar.gz", body: gzipBytes(t, buildTar(t, entries))},
+		{desc: "zip", body: buildZip(t, entries)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := readAll(tc.body, limits)
+			require.NoError(t, err)
+			require.Len(t, got, 2)
+			assert.Equal(t, "dist/app.js", got[0].path)
+			assert.Equal(t, "console.log(1)", string(got[0].data))
+			assert.Equal(t, "dist/index.html", got[1].path)
+		})
+	}
+}
+
+func TestReadArchiveRejects(t *testing.T) {
+	limits := ArchiveLimits{MaxEntries: 3, MaxEntryBytes: 16, MaxTotalBytes: 24, MaxCompressionRatio: 10}
+
+	testCases := []struct {
+		desc string
+		body []byte
+	}{
+		{desc: "parent path", body: buildTar(t, []testEntry{{name: "../etc/passwd", body: "x"}})},
+		{desc: "nested parent path", body: buildZip(t, []testEntry{{name: "a/../../b", body: "x"}})},
+		{desc: "absolute path", body: buildTar(t, []testEntry{{name: "/etc/passwd", body: "x"}})},
+		{desc: "symlink", body: buildTar(t, []testEntry{{name: "link", body: "/etc/passwd", typeflag: tar.TypeSymlink}})},
+		{desc: "too many entries", body: buildTar(t, []testEntry{{name: "a"}, {name: "b"}, {name: "c"}, {name: "d"}})},
+		{desc: "entry too large", body: buildTar(t, []testEntry{{name: "a", body: "0123456789abcdefg"}})},
+		{desc: "total too large", body: buildTar(t, []testEntry{{name: "a", body: "0123456789ab"}, {name: "b", body: "0123456789ab"}, {name: "c", body: "0"}})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := readAll(tc.body, limits)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReadArchiveStreams(t *testing.T) {
+	body := buildTar(t, []testEntry{{name: "a", body: "first"}, {name: "b", body: "second"}})
+	// Cut the body within the second entry
+	body = body[:len(body)-1024-512+2]
+
+	archive, closeArchive, err := openArchive(bytes.NewReader(body), DefaultArchiveLimits)
+	require.NoError(t, err)
+	defer closeArchive()
+
+	entry, err := archive.next()
+	require.NoError(t, err)
+	assert.Equal(t, "a", entry.path)
+	assert.Equal(t, "first", string(entry.data))
+
+	_, err = archive.next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestReadArchiveCompressionRatio(t *testing.T) {
+	body := buildZip(t, []testEntry{{name: "bomb", body: string(make([]byte, 1<<16))}})
+	limits := ArchiveLimits{MaxEntries: 1, MaxEntryBytes: 1 << 20, MaxTotalBytes: 1 << 20, MaxCompressionRatio: 10}
+
+	_, err := readAll(body, limits)
+	assert.ErrorContains(t, err, "compression ratio")
+}
+
+func TestArchiveFileName(t *testing.T) {
+	testCases := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "index.html", want: "site-index.html"},
+		{path: "assets/Logo_Dark.PNG", want: "site-assets-logo-dark.png"},
+		{path: "docs/getting started.md", want: "site-docs-getting-started.md"},
+		{path: string(bytes.Repeat([]byte("a"), 260)), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := archiveFileName("site", tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestArchiveWriterRoundTrip(t *testing.T) {
+	for _, format := range []string{cdn.FileArchiveFormatTarGz, cdn.FileArchiveFormatZip} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			aw := newArchiveWriter(format, &buf)
+			require.NoError(t, aw.WriteEntry("index.html", []byte("<html></html>"), time.Now()))
+			require.NoError(t, aw.WriteEntry("docs/a.md", []byte("# a"), time.Now()))
+			require.NoError(t, aw.Close())
+
+			got, err := readAll(buf.Bytes(), DefaultArchiveLimits)
+			require.NoError(t, err)
+			require.Len(t, got, 2)
+			assert.Equal(t, "docs/a.md", got[0].path)
+			assert.Equal(t, "<html></html>", string(got[1].data))
+		})
+	}
+}
+
+func TestArchiveEntryPaths(t *testing.T) {
+	file := func(name, p string) cdn.File {
+		f := cdn.File{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if p != "" {
+			f.Annotations = map[string]string{cdnv1alpha1.AnnotationPath: p}
+		}
+		return f
+	}
+	files := []cdn.File{
+		file("a-index.html", "/index.html"),
+		file("b-index.html", "/index.html"),
+		file("logo.png", ""),
+		file("escape", "/../etc/passwd"),
+		file("manifest", "/"+ArchiveManifestName),
+		file("a-x", "/x"),
+		file("b-x", "/_files/x"),
+		file("c-x", "/_files/x-1"),
+		file("x", ""),
+	}
+
+	assert.Equal(t, map[string]string{
+		"a-index.html": "index.html",
+		"b-index.html": "b-index.html",
+		"logo.png":     "logo.png",
+		"escape":       "escape",
+		"manifest":     "manifest",
+		"a-x":          "x",
+		"b-x":          "_files/x",
+		"c-x":          "_files/x-1",
+		"x":            "_files/x-2",
+	}, archiveEntryPaths(files))
+}
+
+func TestArchiveWriteEntryConflicts(t *testing.T) {
+	existing := func(name string, labels map[string]string) *cdn.File {
+		return &cdn.File{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Labels: labels},
+			Spec:       cdn.FileSpec{Size: 3, ContentType: "text/plain"},
+			Status:     cdn.FileStatus{Uploaded: true, Checksum: sha256Hex([]byte("old")), ContentGeneration: 1},
+		}
+	}
+
+	testCases := []struct {
+		desc      string
+		archive   string
+		path      string
+		existing  *cdn.File
+		want      cdn.FileArchiveEntryResult
+		wantError string
+	}{
+		{
+			desc:    "new File",
+			archive: "a",
+			path:    "b/c.txt",
+			want:    cdn.FileArchiveEntryCreated,
+		},
+		{
+			desc:     "File of the same archive",
+			archive:  "a",
+			path:     "b/c.txt",
+			existing: existing("a-b-c.txt", map[string]string{cdnv1alpha1.LabelArchive: "a"}),
+			want:     cdn.FileArchiveEntryUpdated,
+		},
+		{
+			desc:      "File of another archive",
+			archive:   "a-b",
+			path:      "c.txt",
+			existing:  existing("a-b-c.txt", map[string]string{cdnv1alpha1.LabelArchive: "a"}),
+			want:      cdn.FileArchiveEntryFailed,
+			wantError: `Operation cannot be fulfilled on files.cdn.k8s.toms.place "a-b-c.txt": File belongs to archive a, not a-b`,
+		},
+		{
+			desc:      "File not uploaded from an archive",
+			archive:   "a",
+			path:      "b/c.txt",
+			existing:  existing("a-b-c.txt", map[string]string{"app": "web"}),
+			want:      cdn.FileArchiveEntryFailed,
+			wantError: `Operation cannot be fulfilled on files.cdn.k8s.toms.place "a-b-c.txt": File was not uploaded from archive a`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := request.WithNamespace(context.Background(), "default")
+			storage := &testFileStorage{files: map[string]*cdn.File{}}
+			if tc.existing != nil {
+				storage.files[tc.existing.Name] = tc.existing.DeepCopy()
+			}
+			writer := &fileWriter{store: storage, status: testStatusStorage{storage}, contentStore: content.NewMemoryStore(), recorder: record.NewFakeRecorder(10)}
+			handler := &archiveHandler{ctx: ctx, name: tc.archive}
+			req := httptest.NewRequest("PUT", "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/default/files/"+tc.archive+"/archive", nil)
+
+			entry := handler.writeEntry(req, writer, "default", nil, &archiveEntry{path: tc.path, data: []byte("new")}, map[string]bool{})
+			assert.Equal(t, tc.want, entry.Result)
+			assert.Equal(t, tc.wantError, entry.Error)
+			if tc.existing != nil && tc.want == cdn.FileArchiveEntryFailed {
+				assert.Equal(t, tc.existing, storage.files[tc.existing.Name])
+			}
+		})
+	}
+}
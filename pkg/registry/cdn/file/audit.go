@@ -0,0 +1,73 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+)
+
+// Audit annotations added to content writes. The uploader is repeated from the
+// audit event so that content writes can be attributed from the annotations
+// alone.
+const (
+	auditAnnotationPrefix = cdn.GroupName + "/"
+
+	AuditAnnotationUser        = auditAnnotationPrefix + "upload-user"
+	AuditAnnotationSourceIP    = auditAnnotationPrefix + "upload-source-ip"
+	AuditAnnotationUserAgent   = auditAnnotationPrefix + "upload-user-agent"
+	AuditAnnotationSize        = auditAnnotationPrefix + "content-size"
+	AuditAnnotationContentType = auditAnnotationPrefix + "content-type"
+	AuditAnnotationResult      = auditAnnotationPrefix + "upload-result"
+	AuditAnnotationEntries     = auditAnnotationPrefix + "archive-entries"
+	AuditAnnotationCopySource  = auditAnnotationPrefix + "copy-source"
+)
+
+// newFileUpload returns the attribution of the upload request req
+func newFileUpload(ctx context.Context, req *http.Request) *cdn.FileUpload {
+	upload := &cdn.FileUpload{
+		UserAgent: req.UserAgent(),
+		Time:      metav1.Now(),
+	}
+	if user, ok := request.UserFrom(ctx); ok {
+		upload.User = user.GetName()
+		upload.Groups = user.GetGroups()
+	}
+	if ip := utilnet.GetClientIP(req); ip != nil {
+		upload.SourceIP = ip.String()
+	}
+	return upload
+}
+
+// auditUpload adds audit annotations for an upload of size bytes by upload.
+// keysAndValues are added as further annotations.
+func auditUpload(ctx context.Context, upload *cdn.FileUpload, size int64, keysAndValues ...string) {
+	audit.AddAuditAnnotations(ctx, append([]string{
+		AuditAnnotationUser, upload.User,
+		AuditAnnotationSourceIP, upload.SourceIP,
+		AuditAnnotationUserAgent, upload.UserAgent,
+		AuditAnnotationSize, strconv.FormatInt(size, 10),
+	}, keysAndValues...)...)
+}
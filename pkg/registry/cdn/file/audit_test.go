@@ -0,0 +1,48 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestNewFileUpload(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/default/files/index.html/content", nil)
+	req.RemoteAddr = "192.0.2.10:53412"
+	req.Header.Set("User-Agent", "kubectl-cdn/v0.1")
+	ctx := request.WithUser(req.Context(), &user.DefaultInfo{
+		Name:   "jane",
+		Groups: []string{"web-admins", "system:authenticated"},
+	})
+
+	upload := newFileUpload(ctx, req)
+	assert.Equal(t, "jane", upload.User)
+	assert.Equal(t, []string{"web-admins", "system:authenticated"}, upload.Groups)
+	assert.Equal(t, "192.0.2.10", upload.SourceIP)
+	assert.Equal(t, "kubectl-cdn/v0.1", upload.UserAgent)
+	assert.False(t, upload.Time.IsZero())
+
+	anonymous := newFileUpload(req.Context(), req)
+	assert.Empty(t, anonymous.User)
+	assert.Equal(t, "192.0.2.10", anonymous.SourceIP)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+// Authorize checks that the user in ctx may perform verb on the files
+// subresource named name in the namespace from ctx. The generic authorization
+// filter only checks the subresource a content request was sent to, so
+// handlers call Authorize for the Files they create, replace, delete or read
+// on its behalf. An empty name checks all Files of the namespace, and a nil
+// authz allows everything.
+func Authorize(ctx context.Context, authz authorizer.Authorizer, verb, subresource, name string) error {
+	if authz == nil {
+		return nil
+	}
+	user, ok := request.UserFrom(ctx)
+	if !ok {
+		return apierrors.NewInternalError(fmt.Errorf("no user found for request"))
+	}
+
+	attrs := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            verb,
+		Namespace:       request.NamespaceValue(ctx),
+		APIGroup:        cdn.GroupName,
+		APIVersion:      cdnv1alpha1.SchemeGroupVersion.Version,
+		Resource:        "files",
+		Subresource:     subresource,
+		Name:            name,
+		ResourceRequest: true,
+	}
+	decision, reason, err := authz.Authorize(ctx, attrs)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Authorization error", "user", user.GetName(), "verb", verb, "subresource", subresource, "name", name)
+	}
+	if decision == authorizer.DecisionAllow {
+		return nil
+	}
+	return responsewriters.ForbiddenStatusError(attrs, reason)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestAuthorize(t *testing.T) {
+	ctx := request.WithNamespace(context.Background(), "web")
+	ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "jane"})
+
+	// jane may upload new Files and download content, but not replace Files
+	var got []authorizer.Attributes
+	authz := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		got = append(got, a)
+		switch {
+		case a.GetVerb() == "create" && a.GetSubresource() == "":
+			return authorizer.DecisionAllow, "", nil
+		case a.GetVerb() == "get" && a.GetSubresource() == "content":
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionNoOpinion, "not allowed", nil
+	})
+
+	tests := []struct {
+		verb        string
+		subresource string
+		name        string
+		allowed     bool
+	}{
+		{verb: "create", allowed: true},
+		{verb: "update", name: "index.html"},
+		{verb: "delete", name: "index.html"},
+		{verb: "get", subresource: "content", allowed: true},
+		{verb: "get", subresource: "archive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.verb+"/"+tt.subresource, func(t *testing.T) {
+			got = nil
+			err := Authorize(ctx, authz, tt.verb, tt.subresource, tt.name)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, apierrors.IsForbidden(err), "expected Forbidden, got %v", err)
+			}
+
+			require.Len(t, got, 1)
+			assert.Equal(t, "jane", got[0].GetUser().GetName())
+			assert.Equal(t, "web", got[0].GetNamespace())
+			assert.Equal(t, "cdn.k8s.toms.place", got[0].GetAPIGroup())
+			assert.Equal(t, "files", got[0].GetResource())
+			assert.Equal(t, tt.subresource, got[0].GetSubresource())
+			assert.Equal(t, tt.name, got[0].GetName())
+			assert.True(t, got[0].IsResourceRequest())
+		})
+	}
+
+	assert.NoError(t, Authorize(ctx, nil, "update", "", "index.html"))
+}
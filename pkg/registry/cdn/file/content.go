@@ -19,38 +19,33 @@ package file
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime"
 	"net/http"
 	"strings"
-	"sync"
 
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
 
 	"k8s.toms.place/apiserver/pkg/apis/cdn"
 	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/events"
 	"k8s.toms.place/apiserver/pkg/registry"
 )
 
-// contentEntry holds the content and its last status
-type contentEntry struct {
-	data   []byte
-	status metav1.Status
-}
-
-// contentStore is an in-memory store for file contents and their status
-var contentStore = struct {
-	sync.RWMutex
-	entries map[string]*contentEntry
-}{
-	entries: make(map[string]*contentEntry),
-}
-
 // allowedMIMETypes defines the valid top-level MIME type categories
 var allowedMIMETypes = map[string]bool{
 	"application": true,
@@ -87,17 +82,52 @@ func isValidMIMEType(mediaType string) bool {
 	return true
 }
 
+// buildContentURL constructs the full URL for a file's content endpoint
+// based on the configured external host (or request host as fallback)
+func buildContentURL(req *http.Request, externalHost, namespace, name string) string {
+	// Use configured external host, or fall back to request host
+	host := externalHost
+	if host == "" {
+		host = req.Host
+	}
+
+	// Determine the scheme
+	scheme := "https"
+	if req.TLS == nil {
+		scheme = "http"
+	}
+
+	// Build the URL: /apis/{group}/{version}/namespaces/{namespace}/files/{name}/content
+	path := fmt.Sprintf("/apis/%s/%s/namespaces/%s/files/%s/content",
+		cdnv1alpha1.GroupName,
+		cdnv1alpha1.SchemeGroupVersion.Version,
+		namespace,
+		name,
+	)
+
+	return fmt.Sprintf("%s://%s%s", scheme, host, path)
+}
+
 // ContentREST implements rest.Connecter for streaming file content
 type ContentREST struct {
 	store        *registry.REST
+	status       *StatusREST
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
 	externalHost string
 }
 
 // NewContentREST creates a new ContentREST
 // externalHost is optional - if empty, the request's Host header will be used
-func NewContentREST(store *registry.REST, externalHost string) *ContentREST {
+// authorizer checks the File permissions of uploads; if nil, they are not checked
+func NewContentREST(store *registry.REST, status *StatusREST, contentStore content.Store, recorder record.EventRecorder, authorizer authorizer.Authorizer, externalHost string) *ContentREST {
 	return &ContentREST{
 		store:        store,
+		status:       status,
+		contentStore: contentStore,
+		recorder:     recorder,
+		authorizer:   authorizer,
 		externalHost: externalHost,
 	}
 }
@@ -123,6 +153,10 @@ func (r *ContentREST) Connect(ctx context.Context, name string, options runtime.
 	return &contentHandler{
 		ctx:          ctx,
 		store:        r.store,
+		status:       r.status,
+		contentStore: r.contentStore,
+		recorder:     r.recorder,
+		authorizer:   r.authorizer,
 		name:         name,
 		options:      opts,
 		responder:    responder,
@@ -153,48 +187,31 @@ func (r *ContentREST) ProducesObject(verb string) interface{} {
 // contentHandler handles HTTP requests for file content streaming
 type contentHandler struct {
 	ctx          context.Context
-	store        *registry.REST
+	store        fileStorage
+	status       rest.Updater
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
 	name         string
 	options      *cdn.FileContent
 	responder    rest.Responder
 	externalHost string
 }
 
-// buildContentURL constructs the full URL for a file's content endpoint
-// based on the configured external host (or request host as fallback) and the namespace/name from context
-func (h *contentHandler) buildContentURL(req *http.Request) string {
-	namespace := request.NamespaceValue(h.ctx)
-
-	// Use configured external host, or fall back to request host
-	host := h.externalHost
-	if host == "" {
-		host = req.Host
-	}
-
-	// Determine the scheme
-	scheme := "https"
-	if req.TLS == nil {
-		scheme = "http"
-	}
-
-	// Build the URL: /apis/{group}/{version}/namespaces/{namespace}/files/{name}/content
-	path := fmt.Sprintf("/apis/%s/%s/namespaces/%s/files/%s/content",
-		cdnv1alpha1.GroupName,
-		cdnv1alpha1.SchemeGroupVersion.Version,
-		namespace,
-		h.name,
-	)
-
-	return fmt.Sprintf("%s://%s%s", scheme, host, path)
-}
-
 // ServeHTTP handles GET, HEAD, and PUT requests for file content
 func (h *contentHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	operation := content.OperationDownload
+	if req.Method == http.MethodPut {
+		operation = content.OperationUpload
+		defer content.UploadStarted()()
+	}
+	recorder, responder := instrumentRequest(w, req, h.responder, operation)
+	defer recorder.done(h.ctx)
+	w, h.responder = recorder, responder
+
 	switch req.Method {
-	case http.MethodGet:
-		h.handleGet(w, req, false)
-	case http.MethodHead:
-		h.handleGet(w, req, true)
+	case http.MethodGet, http.MethodHead:
+		h.handleGet(w, req)
 	case http.MethodPut:
 		h.handlePut(w, req)
 	default:
@@ -202,8 +219,9 @@ func (h *contentHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// handleGet streams the file content (or just headers if headOnly is true)
-func (h *contentHandler) handleGet(w http.ResponseWriter, req *http.Request, headOnly bool) {
+// handleGet streams the file content, or just the headers for HEAD requests.
+// Range requests are answered with the requested bytes.
+func (h *contentHandler) handleGet(w http.ResponseWriter, req *http.Request) {
 	// Get the File object from the store
 	obj, err := h.store.Get(h.ctx, h.name, &metav1.GetOptions{})
 	if err != nil {
@@ -217,30 +235,27 @@ func (h *contentHandler) handleGet(w http.ResponseWriter, req *http.Request, hea
 		return
 	}
 
-	// First check if we have content stored locally
-	contentStore.RLock()
-	entry, hasLocal := contentStore.entries[h.name]
-	contentStore.RUnlock()
-
-	if hasLocal {
-		// Serve from local store
-		contentType := file.Spec.ContentType
-		if contentType == "" {
-			contentType = "application/octet-stream"
-		}
-		w.Header().Set("Content-Type", contentType)
-		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(entry.data)))
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", h.name))
-		w.WriteHeader(http.StatusOK)
-		if !headOnly {
-			w.Write(entry.data)
+	entry, err := h.contentStore.Get(h.ctx, file.Namespace, h.name)
+	if err != nil {
+		if content.IsNotFound(err) {
+			// No content uploaded yet, return not found status
+			h.responder.Error(apierrors.NewNotFound(cdn.Resource("file"), h.name))
+			return
 		}
+		h.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonOriginFetchFailed, "Failed to read content: %v", err)
+		h.responder.Error(err)
 		return
 	}
+	if file.Status.Checksum != "" && entry.Checksum != file.Status.Checksum {
+		h.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonChecksumMismatch,
+			"Stored content has checksum %s, expected %s", entry.Checksum, file.Status.Checksum)
+	}
 
-	// No local content, return not found status
-	h.responder.Error(apierrors.NewNotFound(cdn.Resource("file"), h.name))
-
+	// The File spec is authoritative for the content type
+	served := *entry
+	served.ContentType = file.Spec.ContentType
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", h.name))
+	content.ServeObject(w, req, &served, content.ETag(entry.Checksum, file.Status.ContentGeneration))
 }
 
 // handlePut uploads content to the file
@@ -256,8 +271,64 @@ func (h *contentHandler) handlePut(w http.ResponseWriter, req *http.Request) {
 	contentSize := int64(len(contentBytes))
 
 	// Determine and validate content type from request header
-	contentType := req.Header.Get("Content-Type")
+	contentType, err := normalizeContentType(req.Header.Get("Content-Type"))
+	if err != nil {
+		content.RecordValidationRejection(content.RejectionInvalidContentType)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// An optional Content-Digest lets the client detect corruption in transit
+	checksum, err := parseContentDigest(req.Header.Get("Content-Digest"))
+	if err != nil {
+		content.RecordValidationRejection(content.RejectionInvalidDigest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	namespace := request.NamespaceValue(h.ctx)
+	upload := newFileUpload(h.ctx, req)
+	writer := &fileWriter{store: h.store, status: h.status, contentStore: h.contentStore, recorder: h.recorder, authorizer: h.authorizer}
+	result, err := writer.write(h.ctx, &fileWrite{
+		name:        h.name,
+		url:         buildContentURL(req, h.externalHost, namespace, h.name),
+		data:        contentBytes,
+		contentType: contentType,
+		checksum:    checksum,
+		ifMatch:     req.Header.Get("If-Match"),
+		upload:      upload,
+	})
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+	auditUpload(h.ctx, upload, contentSize,
+		AuditAnnotationContentType, contentType,
+		AuditAnnotationResult, string(result),
+	)
+
+	// Build the status response
+	status := metav1.Status{
+		Status:  metav1.StatusSuccess,
+		Message: fmt.Sprintf("content uploaded successfully for file %s (%d bytes, %s)", h.name, contentSize, contentType),
+		Details: &metav1.StatusDetails{
+			Name: h.name,
+			Kind: "File",
+		},
+		Code: http.StatusCreated,
+	}
+
+	// Return success response using FileContent with Status
+	response := &cdn.FileContent{
+		Status: status,
+	}
+	h.responder.Object(http.StatusCreated, response)
+}
 
+// normalizeContentType validates a Content-Type header value and returns the
+// media type with its charset, if any. An empty value is treated as
+// application/octet-stream.
+func normalizeContentType(contentType string) (string, error) {
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
@@ -265,101 +336,357 @@ func (h *contentHandler) handlePut(w http.ResponseWriter, req *http.Request) {
 	// Parse and validate the MIME type
 	mediaType, params, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("invalid Content-Type: %v", err), http.StatusBadRequest)
-		return
+		return "", fmt.Errorf("invalid Content-Type: %v", err)
 	}
 
 	// Validate the MIME type is a recognized type
 	if !isValidMIMEType(mediaType) {
-		http.Error(w, fmt.Sprintf("unsupported Content-Type: %s (must be a valid MIME type like text/*, application/*, image/*, etc.)", mediaType), http.StatusBadRequest)
-		return
+		return "", fmt.Errorf("unsupported Content-Type: %s (must be a valid MIME type like text/*, application/*, image/*, etc.)", mediaType)
 	}
 
 	// Reconstruct a normalized content type (media type with charset if present)
 	if charset, ok := params["charset"]; ok {
-		contentType = fmt.Sprintf("%s; charset=%s", mediaType, charset)
-	} else {
-		contentType = mediaType
+		return fmt.Sprintf("%s; charset=%s", mediaType, charset), nil
+	}
+	return mediaType, nil
+}
+
+// parseContentDigest returns the hex-encoded SHA-256 digest from an RFC 9530
+// Content-Digest header value, or "" if it has none.
+func parseContentDigest(header string) (string, error) {
+	for _, member := range strings.Split(header, ",") {
+		algorithm, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok || !strings.EqualFold(algorithm, "sha-256") {
+			continue
+		}
+		encoded, ok := strings.CutPrefix(value, ":")
+		if ok {
+			encoded, ok = strings.CutSuffix(encoded, ":")
+		}
+		if !ok {
+			return "", fmt.Errorf("invalid Content-Digest: sha-256 value must be a byte sequence")
+		}
+		digest, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(digest) != sha256.Size {
+			return "", fmt.Errorf("invalid Content-Digest: sha-256 value must be %d base64-encoded bytes", sha256.Size)
+		}
+		return hex.EncodeToString(digest), nil
 	}
+	return "", nil
+}
+
+// sha256Hex returns the hex-encoded SHA-256 digest of data
+func sha256Hex(data []byte) string {
+	digest := sha256.Sum256(data)
+	return hex.EncodeToString(digest[:])
+}
+
+// fileWrite describes content to be written to a File
+type fileWrite struct {
+	name        string
+	url         string
+	data        []byte
+	contentType string
+	// checksum, if set, is the hex-encoded SHA-256 digest data must have
+	checksum string
+	// ifMatch, if set, is an If-Match header value the entity tag of the
+	// current content must match
+	ifMatch string
+	// upload attributes the write to the request that made it
+	upload *cdn.FileUpload
+	// labels and annotations are merged into the File's metadata
+	labels      map[string]string
+	annotations map[string]string
+	// archive, if set, is the archive an existing File must have been
+	// uploaded from; Files of other archives or none are not replaced
+	archive string
+	// dryRun authorizes and verifies the write without changing anything
+	dryRun bool
+}
+
+// fileStorage is the File storage uploads are written to
+type fileStorage interface {
+	rest.Getter
+	rest.Creater
+	rest.Updater
+}
+
+// fileWriter writes uploaded content to Files on behalf of the user in the
+// request context
+type fileWriter struct {
+	store fileStorage
+	// status is the status subresource of store, which is the only way to
+	// change the status of a File
+	status       rest.Updater
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
+}
+
+// write creates or updates the File for fw and stores its content in the
+// namespace from ctx. Files whose content, metadata and URL already match are
+// left unchanged. Events about the outcome are recorded against the File.
+//
+// The content is staged before the File is committed with its checksum, and
+// only promoted once the commit succeeded, so a failed commit never replaces
+// the content and a crash leaves staged content for startup recovery.
+//
+// Uploading to a new File requires "create files", and replacing the content
+// of an existing one "update files", in addition to the permission for the
+// subresource that received the upload.
+func (w *fileWriter) write(ctx context.Context, fw *fileWrite) (cdn.FileArchiveEntryResult, error) {
+	namespace := request.NamespaceValue(ctx)
+	checksum := sha256Hex(fw.data)
 
 	// Try to get the existing File
-	obj, err := h.store.Get(h.ctx, h.name, &metav1.GetOptions{})
+	obj, err := w.store.Get(ctx, fw.name, &metav1.GetOptions{})
 	if err != nil {
 		if !apierrors.IsNotFound(err) {
-			h.responder.Error(err)
-			return
+			return "", err
+		}
+		if err := Authorize(ctx, w.authorizer, "create", "", ""); err != nil {
+			return "", err
+		}
+		if content.PreconditionFailed(fw.ifMatch, "") {
+			return "", newPreconditionFailed(fw.name)
+		}
+		if err := verifyChecksum(fw, checksum); err != nil {
+			return "", err
+		}
+		if fw.dryRun {
+			return cdn.FileArchiveEntryCreated, nil
 		}
 
-		// Build the URL for this file's content endpoint
-		contentURL := h.buildContentURL(req)
-
-		// File doesn't exist, create it
+		// File doesn't exist, create it. The status is committed through
+		// the status subresource once the File exists.
 		newFile := &cdn.File{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: h.name,
+				Name:        fw.name,
+				Labels:      fw.labels,
+				Annotations: fw.annotations,
 			},
 			Spec: cdn.FileSpec{
-				URL:         contentURL,
-				Size:        contentSize,
-				ContentType: contentType,
-			},
-			Status: cdn.FileStatus{
-				Uploaded: true,
+				URL:         fw.url,
+				Size:        int64(len(fw.data)),
+				ContentType: fw.contentType,
 			},
 		}
 
-		_, err = h.store.Create(h.ctx, newFile, rest.ValidateAllObjectFunc, &metav1.CreateOptions{})
+		stage, err := stageContent(ctx, w.contentStore, namespace, fw, checksum)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to create file resource: %v", err), http.StatusInternalServerError)
-			return
+			return "", err
 		}
-	} else {
-		// File exists, update it with new size and content type
-		file, ok := obj.(*cdn.File)
-		if !ok {
-			h.responder.Error(fmt.Errorf("object is not a File"))
-			return
+		obj, err := w.store.Create(ctx, newFile, rest.ValidateAllObjectFunc, &metav1.CreateOptions{})
+		if err != nil {
+			discardContent(ctx, w.contentStore, namespace, fw.name, stage)
+			return "", err
+		}
+		created := obj.(*cdn.File).DeepCopy()
+		created.Status = cdn.FileStatus{
+			Uploaded:          true,
+			Checksum:          checksum,
+			ContentGeneration: 1,
+			LastUpload:        fw.upload,
+		}
+		committed, err := w.commit(ctx, obj.(*cdn.File), created)
+		if err != nil {
+			discardContent(ctx, w.contentStore, namespace, fw.name, stage)
+			w.recorder.Eventf(events.FileReference(created), corev1.EventTypeWarning, events.ReasonUploadFailed, "Failed to update File: %v", err)
+			return "", err
 		}
+		if err := promoteContent(ctx, w.contentStore, namespace, fw.name, stage); err != nil {
+			return "", w.uploadFailed(ctx, committed, err)
+		}
+		w.recordUploaded(committed)
+		return cdn.FileArchiveEntryCreated, nil
+	}
 
-		// Build the URL for this file's content endpoint
-		contentURL := h.buildContentURL(req)
+	// File exists, update it with new size and content type
+	file, ok := obj.(*cdn.File)
+	if !ok {
+		return "", fmt.Errorf("object is not a File")
+	}
+	if err := Authorize(ctx, w.authorizer, "update", "", fw.name); err != nil {
+		return "", err
+	}
+	if fw.archive != "" && file.Labels[cdnv1alpha1.LabelArchive] != fw.archive {
+		return "", apierrors.NewConflict(cdn.Resource("files"), fw.name, archiveOwnerError(file, fw.archive))
+	}
+	// The update below carries the resourceVersion of file, so content
+	// replaced after this check still fails with a Conflict
+	if content.PreconditionFailed(fw.ifMatch, currentETag(file)) {
+		return "", newPreconditionFailed(fw.name)
+	}
+	if err := verifyChecksum(fw, checksum); err != nil {
+		w.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonChecksumMismatch,
+			"Rejected upload of %d bytes with checksum %s, expected %s", len(fw.data), checksum, fw.checksum)
+		return "", err
+	}
+	updated := file.DeepCopy()
+	updated.Labels = mergeStringMaps(updated.Labels, fw.labels)
+	updated.Annotations = mergeStringMaps(updated.Annotations, fw.annotations)
+	updated.Spec.URL = fw.url
+	updated.Spec.Size = int64(len(fw.data))
+	updated.Spec.ContentType = fw.contentType
+	updated.Status.Uploaded = true
+	updated.Status.Error = ""
+	updated.Status.Checksum = checksum
+
+	if _, err := w.contentStore.Get(ctx, namespace, fw.name); err != nil || file.Status.Checksum != checksum {
+		// New content gets a new ETag
+		updated.Status.ContentGeneration++
+	}
+	if apiequality.Semantic.DeepEqual(file, updated) {
+		return cdn.FileArchiveEntryUnchanged, nil
+	}
+	if fw.dryRun {
+		return cdn.FileArchiveEntryUpdated, nil
+	}
+	updated.Status.LastUpload = fw.upload
+	stage, err := stageContent(ctx, w.contentStore, namespace, fw, checksum)
+	if err != nil {
+		w.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonUploadFailed, "%v", err)
+		return "", err
+	}
+	committed, err := w.commit(ctx, file, updated)
+	if err != nil {
+		discardContent(ctx, w.contentStore, namespace, fw.name, stage)
+		w.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonUploadFailed, "Failed to update File: %v", err)
+		return "", err
+	}
+	if err := promoteContent(ctx, w.contentStore, namespace, fw.name, stage); err != nil {
+		return "", w.uploadFailed(ctx, committed, err)
+	}
+	w.recordUploaded(committed)
+	return cdn.FileArchiveEntryUpdated, nil
+}
 
-		// Update file spec with URL, size and content type
-		file.Spec.URL = contentURL
-		file.Spec.Size = contentSize
-		file.Spec.ContentType = contentType
-		file.Status.Uploaded = true
-		file.Status.Error = ""
+// commit writes updated over file with commitFile
+func (w *fileWriter) commit(ctx context.Context, file, updated *cdn.File) (*cdn.File, error) {
+	return commitFile(ctx, w.store, w.status, file, updated)
+}
 
-		_, _, err = h.store.Update(h.ctx, h.name, rest.DefaultUpdatedObjectInfo(file), rest.ValidateAllObjectFunc, rest.ValidateAllObjectUpdateFunc, false, &metav1.UpdateOptions{})
+// commitFile writes the metadata and spec of updated through store and its
+// status through the status subresource, skipping either if it is unchanged
+// from file. Both writes carry the resourceVersion of file, so the commit
+// fails with a Conflict if file changed since it was read.
+func commitFile(ctx context.Context, store, status rest.Updater, file, updated *cdn.File) (*cdn.File, error) {
+	current := file
+	if !apiequality.Semantic.DeepEqual(file.ObjectMeta, updated.ObjectMeta) || !apiequality.Semantic.DeepEqual(file.Spec, updated.Spec) {
+		obj, _, err := store.Update(ctx, file.Name, rest.DefaultUpdatedObjectInfo(updated), rest.ValidateAllObjectFunc, rest.ValidateAllObjectUpdateFunc, false, &metav1.UpdateOptions{})
 		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to update file resource: %v", err), http.StatusInternalServerError)
-			return
+			return nil, err
 		}
+		current = obj.(*cdn.File)
+	}
+	if apiequality.Semantic.DeepEqual(current.Status, updated.Status) {
+		return current, nil
+	}
+	withStatus := current.DeepCopy()
+	withStatus.Status = updated.Status
+	obj, _, err := status.Update(ctx, file.Name, rest.DefaultUpdatedObjectInfo(withStatus), rest.ValidateAllObjectFunc, rest.ValidateAllObjectUpdateFunc, false, &metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
 	}
+	return obj.(*cdn.File), nil
+}
 
-	// Build the status response
-	status := metav1.Status{
-		Status:  metav1.StatusSuccess,
-		Message: fmt.Sprintf("content uploaded successfully for file %s (%d bytes, %s)", h.name, contentSize, contentType),
+// verifyChecksum returns a BadRequest error if fw has an expected checksum
+// other than checksum.
+func verifyChecksum(fw *fileWrite, checksum string) error {
+	if fw.checksum == "" || fw.checksum == checksum {
+		return nil
+	}
+	content.RecordValidationRejection(content.RejectionChecksumMismatch)
+	return apierrors.NewBadRequest(fmt.Sprintf("content checksum %s does not match expected checksum %s", checksum, fw.checksum))
+}
+
+// currentETag returns the entity tag of the content of file, or "" if it has
+// none
+func currentETag(file *cdn.File) string {
+	if !file.Status.Uploaded || file.Status.Checksum == "" {
+		return ""
+	}
+	return content.ETag(file.Status.Checksum, file.Status.ContentGeneration)
+}
+
+// newPreconditionFailed returns a 412 Precondition Failed error for a write
+// to the File name whose If-Match header does not match its content. The
+// reason is Conflict, so clients handle it like any other lost update.
+func newPreconditionFailed(name string) error {
+	return &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusPreconditionFailed,
+		Reason: metav1.StatusReasonConflict,
 		Details: &metav1.StatusDetails{
-			Name: h.name,
-			Kind: "File",
+			Group: cdn.GroupName,
+			Kind:  "files",
+			Name:  name,
 		},
-		Code: http.StatusCreated,
+		Message: fmt.Sprintf("content of file %q does not match If-Match: it was changed since it was read", name),
+	}}
+}
+
+// recordUploaded records an Uploaded event for file
+func (w *fileWriter) recordUploaded(file *cdn.File) {
+	w.recorder.Eventf(events.FileReference(file), corev1.EventTypeNormal, events.ReasonUploaded,
+		"Uploaded %d bytes of %s with checksum %s", file.Spec.Size, file.Spec.ContentType, file.Status.Checksum)
+}
+
+// uploadFailed records that the content of file could not be stored and marks
+// the File as not uploaded. It returns err.
+func (w *fileWriter) uploadFailed(ctx context.Context, file *cdn.File, err error) error {
+	w.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonUploadFailed, "%v", err)
+
+	failed := file.DeepCopy()
+	failed.Status.Uploaded = false
+	failed.Status.Error = err.Error()
+	_, updateErr := w.commit(ctx, file, failed)
+	if updateErr != nil {
+		klog.FromContext(ctx).Error(updateErr, "Failed to record upload failure", "namespace", file.Namespace, "name", file.Name)
 	}
+	return err
+}
 
-	// Store the content and status
-	contentStore.Lock()
-	contentStore.entries[h.name] = &contentEntry{
-		data:   contentBytes,
-		status: status,
+// stageContent stages the content of fw and returns its stage ID
+func stageContent(ctx context.Context, contentStore content.Store, namespace string, fw *fileWrite, checksum string) (string, error) {
+	stage, err := contentStore.Stage(ctx, namespace, fw.name, &content.Object{
+		Data:        fw.data,
+		ContentType: fw.contentType,
+		Checksum:    checksum,
+	})
+	if err != nil {
+		return "", apierrors.NewInternalError(fmt.Errorf("failed to store content: %w", err))
 	}
-	contentStore.Unlock()
+	return stage, nil
+}
 
-	// Return success response using FileContent with Status
-	response := &cdn.FileContent{
-		Status: status,
+// promoteContent makes the staged content the content of the named File
+func promoteContent(ctx context.Context, contentStore content.Store, namespace, name, stage string) error {
+	if err := contentStore.Promote(ctx, namespace, name, stage); err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("failed to store content: %w", err))
 	}
-	h.responder.Object(http.StatusCreated, response)
+	return nil
+}
+
+// discardContent discards staged content whose File was not committed.
+// Content that cannot be discarded now is discarded by startup recovery.
+func discardContent(ctx context.Context, contentStore content.Store, namespace, name, stage string) {
+	if err := contentStore.Discard(ctx, namespace, name, stage); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to discard staged content", "namespace", namespace, "name", name)
+	}
+}
+
+// mergeStringMaps returns dst with all entries of src added
+func mergeStringMaps(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
 }
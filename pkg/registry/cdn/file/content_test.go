@@ -0,0 +1,343 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+func TestParseContentDigest(t *testing.T) {
+	digest := sha256.Sum256([]byte("hello"))
+	encoded := base64.StdEncoding.EncodeToString(digest[:])
+	want := sha256Hex([]byte("hello"))
+
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", header: "", want: ""},
+		{name: "sha-256", header: "sha-256=:" + encoded + ":", want: want},
+		{name: "case insensitive algorithm", header: "SHA-256=:" + encoded + ":", want: want},
+		{name: "among other algorithms", header: "sha-512=:AAAA:, sha-256=:" + encoded + ":", want: want},
+		{name: "unsupported algorithm only", header: "sha-512=:AAAA:", want: ""},
+		{name: "not a byte sequence", header: "sha-256=" + encoded, wantErr: true},
+		{name: "invalid base64", header: "sha-256=:not base64:", wantErr: true},
+		{name: "wrong length", header: "sha-256=:AAAA:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseContentDigest(tt.header)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	checksum := sha256Hex([]byte("hello"))
+
+	assert.NoError(t, verifyChecksum(&fileWrite{}, checksum))
+	assert.NoError(t, verifyChecksum(&fileWrite{checksum: checksum}, checksum))
+
+	err := verifyChecksum(&fileWrite{checksum: sha256Hex([]byte("other"))}, checksum)
+	assert.True(t, apierrors.IsBadRequest(err))
+}
+
+func TestCurrentETag(t *testing.T) {
+	file := &cdn.File{Status: cdn.FileStatus{Uploaded: true, Checksum: "abc", ContentGeneration: 2}}
+	assert.Equal(t, `"abc-2"`, currentETag(file))
+
+	file.Status.Uploaded = false
+	assert.Empty(t, currentETag(file))
+}
+
+func TestNewPreconditionFailed(t *testing.T) {
+	err := newPreconditionFailed("index.html")
+	assert.True(t, apierrors.IsConflict(err))
+
+	var status apierrors.APIStatus
+	require.ErrorAs(t, err, &status)
+	assert.Equal(t, int32(http.StatusPreconditionFailed), status.Status().Code)
+	assert.Equal(t, "index.html", status.Status().Details.Name)
+}
+
+// testFileStorage stores Files in memory like the File storage: writes
+// through it never change the status
+type testFileStorage struct {
+	files map[string]*cdn.File
+}
+
+func (s *testFileStorage) New() runtime.Object { return &cdn.File{} }
+
+func (s *testFileStorage) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	file, ok := s.files[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(cdn.Resource("files"), name)
+	}
+	return file.DeepCopy(), nil
+}
+
+func (s *testFileStorage) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	file := obj.(*cdn.File).DeepCopy()
+	file.Namespace = request.NamespaceValue(ctx)
+	file.Status = cdn.FileStatus{}
+	s.files[file.Name] = file
+	return file.DeepCopy(), nil
+}
+
+func (s *testFileStorage) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	return s.update(ctx, name, objInfo, func(old, updated *cdn.File) {
+		updated.Status = old.Status
+	})
+}
+
+func (s *testFileStorage) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	file, ok := s.files[name]
+	if !ok {
+		return nil, false, apierrors.NewNotFound(cdn.Resource("files"), name)
+	}
+	delete(s.files, name)
+	return file, true, nil
+}
+
+func (s *testFileStorage) update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, reset func(old, updated *cdn.File)) (runtime.Object, bool, error) {
+	old, err := s.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	obj, err := objInfo.UpdatedObject(ctx, old)
+	if err != nil {
+		return nil, false, err
+	}
+	updated := obj.(*cdn.File).DeepCopy()
+	reset(old.(*cdn.File), updated)
+	s.files[name] = updated
+	return updated.DeepCopy(), false, nil
+}
+
+// testStatusStorage is the status subresource of a testFileStorage, which
+// only changes the status
+type testStatusStorage struct {
+	*testFileStorage
+}
+
+func (s testStatusStorage) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	return s.update(ctx, name, objInfo, func(old, updated *cdn.File) {
+		updated.ObjectMeta, updated.Spec = old.ObjectMeta, old.Spec
+	})
+}
+
+// failingStore is a content.Store failing to stage or promote content
+type failingStore struct {
+	content.Store
+	stageErr   error
+	promoteErr error
+}
+
+func (s *failingStore) Stage(ctx context.Context, namespace, name string, obj *content.Object) (string, error) {
+	if s.stageErr != nil {
+		return "", s.stageErr
+	}
+	return s.Store.Stage(ctx, namespace, name, obj)
+}
+
+func (s *failingStore) Promote(ctx context.Context, namespace, name, id string) error {
+	if s.promoteErr != nil {
+		return s.promoteErr
+	}
+	return s.Store.Promote(ctx, namespace, name, id)
+}
+
+// testResponder records the response of a handler
+type testResponder struct {
+	code int
+	err  error
+}
+
+func (r *testResponder) Object(statusCode int, obj runtime.Object) { r.code = statusCode }
+
+func (r *testResponder) Error(err error) {
+	r.err = err
+	r.code = http.StatusInternalServerError
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		r.code = int(status.Status().Code)
+	}
+}
+
+func TestContentHandlerEvents(t *testing.T) {
+	data := []byte("hello")
+	storeErr := errors.New("disk full")
+	otherDigest := sha256.Sum256([]byte("other"))
+	stored := &cdn.File{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "index.txt", ResourceVersion: "1"},
+		Spec:       cdn.FileSpec{Size: 3, ContentType: "text/plain"},
+		Status:     cdn.FileStatus{Uploaded: true, Checksum: sha256Hex([]byte("old")), ContentGeneration: 1},
+	}
+
+	testCases := []struct {
+		desc       string
+		existing   *cdn.File
+		method     string
+		digest     string
+		stageErr   error
+		promoteErr error
+		wantCode   int
+		wantEvents []string
+		// wantStatus is the status of the File afterwards, without its
+		// LastUpload
+		wantStatus cdn.FileStatus
+	}{
+		{
+			desc:       "upload creates the File",
+			method:     http.MethodPut,
+			wantCode:   http.StatusCreated,
+			wantEvents: []string{"Normal Uploaded Uploaded 5 bytes of text/plain with checksum " + sha256Hex(data)},
+			wantStatus: cdn.FileStatus{Uploaded: true, Checksum: sha256Hex(data), ContentGeneration: 1},
+		},
+		{
+			desc:       "upload replaces the content",
+			existing:   stored,
+			method:     http.MethodPut,
+			wantCode:   http.StatusCreated,
+			wantEvents: []string{"Normal Uploaded Uploaded 5 bytes of text/plain with checksum " + sha256Hex(data)},
+			wantStatus: cdn.FileStatus{Uploaded: true, Checksum: sha256Hex(data), ContentGeneration: 2},
+		},
+		{
+			desc:       "checksum mismatch",
+			existing:   stored,
+			method:     http.MethodPut,
+			digest:     "sha-256=:" + base64.StdEncoding.EncodeToString(otherDigest[:]) + ":",
+			wantCode:   http.StatusBadRequest,
+			wantEvents: []string{"Warning ChecksumMismatch Rejected upload of 5 bytes with checksum " + sha256Hex(data) + ", expected " + hex.EncodeToString(otherDigest[:])},
+			wantStatus: stored.Status,
+		},
+		{
+			desc:       "promote fails on create",
+			method:     http.MethodPut,
+			promoteErr: storeErr,
+			wantCode:   http.StatusInternalServerError,
+			wantEvents: []string{"Warning UploadFailed Internal error occurred: failed to store content: disk full"},
+			wantStatus: cdn.FileStatus{Checksum: sha256Hex(data), ContentGeneration: 1, Error: "Internal error occurred: failed to store content: disk full"},
+		},
+		{
+			desc:       "promote fails on update",
+			existing:   stored,
+			method:     http.MethodPut,
+			promoteErr: storeErr,
+			wantCode:   http.StatusInternalServerError,
+			wantEvents: []string{"Warning UploadFailed Internal error occurred: failed to store content: disk full"},
+			wantStatus: cdn.FileStatus{Checksum: sha256Hex(data), ContentGeneration: 2, Error: "Internal error occurred: failed to store content: disk full"},
+		},
+		{
+			desc:       "stage fails on update",
+			existing:   stored,
+			method:     http.MethodPut,
+			stageErr:   storeErr,
+			wantCode:   http.StatusInternalServerError,
+			wantEvents: []string{"Warning UploadFailed Internal error occurred: failed to store content: disk full"},
+			wantStatus: stored.Status,
+		},
+		{
+			desc:       "download of mismatching content",
+			existing:   stored,
+			method:     http.MethodGet,
+			wantCode:   http.StatusOK,
+			wantEvents: []string{"Warning ChecksumMismatch Stored content has checksum " + sha256Hex(data) + ", expected " + stored.Status.Checksum},
+			wantStatus: stored.Status,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := request.WithNamespace(request.WithUser(context.Background(), &user.DefaultInfo{Name: "alice"}), "default")
+			storage := &testFileStorage{files: map[string]*cdn.File{}}
+			contentStore := &failingStore{Store: content.NewMemoryStore(), stageErr: tc.stageErr, promoteErr: tc.promoteErr}
+			if tc.existing != nil {
+				storage.files[tc.existing.Name] = tc.existing.DeepCopy()
+				// The stored content does not have the checksum of the
+				// File, so downloads detect the mismatch
+				require.NoError(t, contentStore.Put(ctx, "default", tc.existing.Name, &content.Object{Data: data, ContentType: "text/plain", Checksum: sha256Hex(data)}))
+			}
+			recorder := record.NewFakeRecorder(10)
+			responder := &testResponder{}
+			handler := &contentHandler{
+				ctx:          ctx,
+				store:        storage,
+				status:       testStatusStorage{storage},
+				contentStore: contentStore,
+				recorder:     recorder,
+				name:         "index.txt",
+				options:      &cdn.FileContent{},
+				responder:    responder,
+			}
+
+			req := httptest.NewRequest(tc.method, "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/default/files/index.txt/content", bytes.NewReader(data))
+			req.Header.Set("Content-Type", "text/plain")
+			if tc.digest != "" {
+				req.Header.Set("Content-Digest", tc.digest)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			code := responder.code
+			if code == 0 {
+				code = w.Code
+			}
+			assert.Equal(t, tc.wantCode, code, "error: %v", responder.err)
+			close(recorder.Events)
+			var recorded []string
+			for event := range recorder.Events {
+				recorded = append(recorded, event)
+			}
+			assert.Equal(t, tc.wantEvents, recorded)
+
+			file := storage.files["index.txt"]
+			require.NotNil(t, file)
+			status := file.Status
+			status.LastUpload = nil
+			assert.Equal(t, tc.wantStatus, status)
+		})
+	}
+}
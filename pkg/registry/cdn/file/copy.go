@@ -0,0 +1,299 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/registry"
+)
+
+// CopyREST implements rest.Connecter for copying the content of a File to
+// another File, possibly in another namespace, without it leaving the server
+type CopyREST struct {
+	store        *registry.REST
+	status       *StatusREST
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
+	externalHost string
+}
+
+// NewCopyREST creates a new CopyREST. authorizer checks the permissions on
+// the copied and the destination File; if nil, they are not checked.
+func NewCopyREST(store *registry.REST, status *StatusREST, contentStore content.Store, recorder record.EventRecorder, authorizer authorizer.Authorizer, externalHost string) *CopyREST {
+	return &CopyREST{
+		store:        store,
+		status:       status,
+		contentStore: contentStore,
+		recorder:     recorder,
+		authorizer:   authorizer,
+		externalHost: externalHost,
+	}
+}
+
+var _ rest.Connecter = &CopyREST{}
+var _ rest.StorageMetadata = &CopyREST{}
+
+// New returns an empty object that can be used with Create and Update
+func (r *CopyREST) New() runtime.Object {
+	return &cdn.FileContent{}
+}
+
+// Destroy cleans up resources on shutdown
+func (r *CopyREST) Destroy() {}
+
+// Connect returns an http.Handler that copies the content of the named File
+func (r *CopyREST) Connect(ctx context.Context, name string, options runtime.Object, responder rest.Responder) (http.Handler, error) {
+	opts, ok := options.(*cdn.FileCopyOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options object: %#v", options)
+	}
+
+	return &copyHandler{
+		ctx:          ctx,
+		store:        r.store,
+		status:       r.status,
+		contentStore: r.contentStore,
+		recorder:     r.recorder,
+		authorizer:   r.authorizer,
+		name:         name,
+		options:      opts,
+		responder:    responder,
+		externalHost: r.externalHost,
+	}, nil
+}
+
+// NewConnectOptions returns an empty options object for the Connect method
+func (r *CopyREST) NewConnectOptions() (runtime.Object, bool, string) {
+	return &cdn.FileCopyOptions{}, false, ""
+}
+
+// ConnectMethods returns the list of HTTP methods handled by Connect
+func (r *CopyREST) ConnectMethods() []string {
+	return []string{"POST"}
+}
+
+// ProducesMIMETypes returns a list of MIME types the verb can respond with
+func (r *CopyREST) ProducesMIMETypes(verb string) []string {
+	return nil
+}
+
+// ProducesObject returns the object the verb responds with
+func (r *CopyREST) ProducesObject(verb string) interface{} {
+	return &cdn.FileContent{}
+}
+
+// copyStorage is the File storage copies are read from and written to
+type copyStorage interface {
+	fileStorage
+	rest.GracefulDeleter
+}
+
+// copyHandler handles HTTP requests copying the content of a File
+type copyHandler struct {
+	ctx          context.Context
+	store        copyStorage
+	status       rest.Updater
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
+	name         string
+	options      *cdn.FileCopyOptions
+	responder    rest.Responder
+	externalHost string
+}
+
+// ServeHTTP handles POST requests copying or moving the content of a File.
+//
+// The generic authorization filter only checks "create files/copy" on the
+// copied File, so reading its content requires "get files/content", moving it
+// "delete files", and writing the destination "update files/content" and the
+// permissions of an upload in its namespace.
+func (h *copyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	recorder, responder := instrumentRequest(w, req, h.responder, content.OperationCopy)
+	defer recorder.done(h.ctx)
+	w, h.responder = recorder, responder
+
+	if req.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	namespace := request.NamespaceValue(h.ctx)
+	destNamespace, destName, err := copyDestination(namespace, h.name, h.options)
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+	if err := Authorize(h.ctx, h.authorizer, "get", "content", h.name); err != nil {
+		h.responder.Error(err)
+		return
+	}
+	if h.options.Move {
+		if err := Authorize(h.ctx, h.authorizer, "delete", "", h.name); err != nil {
+			h.responder.Error(err)
+			return
+		}
+	}
+	destCtx := request.WithNamespace(h.ctx, destNamespace)
+	if err := Authorize(destCtx, h.authorizer, "update", "content", destName); err != nil {
+		h.responder.Error(err)
+		return
+	}
+
+	obj, err := h.store.Get(h.ctx, h.name, &metav1.GetOptions{})
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+	file, ok := obj.(*cdn.File)
+	if !ok {
+		h.responder.Error(fmt.Errorf("object is not a File"))
+		return
+	}
+	entry, err := h.contentStore.Get(h.ctx, namespace, h.name)
+	if err != nil {
+		if content.IsNotFound(err) {
+			h.responder.Error(apierrors.NewNotFound(cdn.Resource("file"), h.name))
+			return
+		}
+		h.responder.Error(apierrors.NewInternalError(fmt.Errorf("failed to read content: %w", err)))
+		return
+	}
+	if file.Status.Checksum != "" && entry.Checksum != file.Status.Checksum {
+		// Never spread content that no longer matches its File
+		h.responder.Error(apierrors.NewConflict(cdn.Resource("files"), h.name,
+			fmt.Errorf("stored content has checksum %s, expected %s", entry.Checksum, file.Status.Checksum)))
+		return
+	}
+
+	fw := &fileWrite{
+		name:        destName,
+		url:         buildContentURL(req, h.externalHost, destNamespace, destName),
+		data:        entry.Data,
+		contentType: file.Spec.ContentType,
+		checksum:    entry.Checksum,
+		upload:      newFileUpload(h.ctx, req),
+		dryRun:      h.options.Check,
+	}
+	if h.options.Move {
+		// A moved File keeps its metadata
+		fw.labels = file.Labels
+		fw.annotations = file.Annotations
+	}
+	writer := &fileWriter{store: h.store, status: h.status, contentStore: h.contentStore, recorder: h.recorder, authorizer: h.authorizer}
+	result, err := writer.write(destCtx, fw)
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+
+	if h.options.Move && !h.options.Check {
+		if err := h.deleteSource(file); err != nil {
+			if result == cdn.FileArchiveEntryCreated {
+				h.rollback(destCtx, destName)
+			}
+			h.responder.Error(err)
+			return
+		}
+	}
+	auditUpload(h.ctx, fw.upload, int64(len(entry.Data)),
+		AuditAnnotationContentType, fw.contentType,
+		AuditAnnotationResult, string(result),
+		AuditAnnotationCopySource, namespace+"/"+h.name,
+	)
+
+	verb := "copied"
+	if h.options.Move {
+		verb = "moved"
+	}
+	code := http.StatusOK
+	if result == cdn.FileArchiveEntryCreated {
+		code = http.StatusCreated
+	}
+	message := fmt.Sprintf("file %s/%s %s to %s/%s (%d bytes, %s)", namespace, h.name, verb, destNamespace, destName, len(entry.Data), result)
+	if h.options.Check {
+		message += " (dry run)"
+	}
+	h.responder.Object(code, &cdn.FileContent{
+		Status: metav1.Status{
+			Status:  metav1.StatusSuccess,
+			Message: message,
+			Details: &metav1.StatusDetails{
+				Name: destName,
+				Kind: "File",
+			},
+			Code: int32(code),
+		},
+	})
+}
+
+// deleteSource deletes the moved File unless it changed since it was read.
+// The File storage removes its content.
+func (h *copyHandler) deleteSource(file *cdn.File) error {
+	_, _, err := h.store.Delete(h.ctx, file.Name, rest.ValidateAllObjectFunc, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			UID:             &file.UID,
+			ResourceVersion: &file.ResourceVersion,
+		},
+	})
+	return err
+}
+
+// rollback deletes the destination File created by a move that could not
+// delete the moved File, so that a failed move leaves a single copy
+func (h *copyHandler) rollback(ctx context.Context, name string) {
+	if _, _, err := h.store.Delete(ctx, name, rest.ValidateAllObjectFunc, &metav1.DeleteOptions{}); err != nil {
+		klog.FromContext(ctx).Error(err, "Failed to roll back move", "namespace", request.NamespaceValue(ctx), "name", name)
+	}
+}
+
+// copyDestination returns the namespace and name of the File the content of
+// the named File in namespace is copied to. The destination defaults to the
+// namespace of the copied File and must differ from it.
+func copyDestination(namespace, name string, opts *cdn.FileCopyOptions) (string, string, error) {
+	destNamespace := opts.DestinationNamespace
+	if destNamespace == "" {
+		destNamespace = namespace
+	}
+
+	var errs field.ErrorList
+	if opts.Destination == "" {
+		errs = append(errs, field.Required(field.NewPath("destination"), ""))
+	} else if destNamespace == namespace && opts.Destination == name {
+		errs = append(errs, field.Invalid(field.NewPath("destination"), opts.Destination, "must differ from the copied File"))
+	}
+	if len(errs) > 0 {
+		return "", "", apierrors.NewInvalid(cdn.Kind("FileCopyOptions"), name, errs)
+	}
+	return destNamespace, opts.Destination, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+func TestCopyDestination(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          cdn.FileCopyOptions
+		wantNamespace string
+		wantName      string
+		wantErr       bool
+	}{
+		{name: "same namespace", opts: cdn.FileCopyOptions{Destination: "copy.png"}, wantNamespace: "default", wantName: "copy.png"},
+		{name: "other namespace", opts: cdn.FileCopyOptions{Destination: "logo.png", DestinationNamespace: "web"}, wantNamespace: "web", wantName: "logo.png"},
+		{name: "explicit namespace", opts: cdn.FileCopyOptions{Destination: "copy.png", DestinationNamespace: "default"}, wantNamespace: "default", wantName: "copy.png"},
+		{name: "no destination", opts: cdn.FileCopyOptions{DestinationNamespace: "web"}, wantErr: true},
+		{name: "onto itself", opts: cdn.FileCopyOptions{Destination: "logo.png"}, wantErr: true},
+		{name: "onto itself explicitly", opts: cdn.FileCopyOptions{Destination: "logo.png", DestinationNamespace: "default"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, name, err := copyDestination("default", "logo.png", &tt.opts)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, apierrors.IsInvalid(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNamespace, namespace)
+			assert.Equal(t, tt.wantName, name)
+		})
+	}
+}
+
+func TestCopyAuthorizesDestinationContent(t *testing.T) {
+	data := []byte("hello")
+	testCases := []struct {
+		desc     string
+		options  cdn.FileCopyOptions
+		denied   authorizer.AttributesRecord
+		wantCode int
+	}{
+		{
+			desc:     "copy to another namespace",
+			options:  cdn.FileCopyOptions{Destination: "copy.txt", DestinationNamespace: "web"},
+			wantCode: http.StatusCreated,
+		},
+		{
+			desc:     "no content permission in the destination namespace",
+			options:  cdn.FileCopyOptions{Destination: "copy.txt", DestinationNamespace: "web"},
+			denied:   authorizer.AttributesRecord{Verb: "update", Namespace: "web", Subresource: "content"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			desc:     "no content permission in the same namespace",
+			options:  cdn.FileCopyOptions{Destination: "copy.txt"},
+			denied:   authorizer.AttributesRecord{Verb: "update", Namespace: "default", Subresource: "content"},
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := request.WithNamespace(request.WithUser(context.Background(), &user.DefaultInfo{Name: "alice"}), "default")
+			// alice may do everything but what the test case denies, like
+			// an editor who may update Files but not their content
+			authz := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+				if a.GetVerb() == tc.denied.Verb && a.GetNamespace() == tc.denied.Namespace && a.GetSubresource() == tc.denied.Subresource {
+					return authorizer.DecisionNoOpinion, "not allowed", nil
+				}
+				return authorizer.DecisionAllow, "", nil
+			})
+			storage := &testFileStorage{files: map[string]*cdn.File{
+				"index.txt": {
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "index.txt"},
+					Spec:       cdn.FileSpec{Size: int64(len(data)), ContentType: "text/plain"},
+					Status:     cdn.FileStatus{Uploaded: true, Checksum: sha256Hex(data)},
+				},
+			}}
+			contentStore := content.NewMemoryStore()
+			require.NoError(t, contentStore.Put(ctx, "default", "index.txt", &content.Object{Data: data, ContentType: "text/plain", Checksum: sha256Hex(data)}))
+			responder := &testResponder{}
+			handler := &copyHandler{
+				ctx:          ctx,
+				store:        storage,
+				status:       testStatusStorage{storage},
+				contentStore: contentStore,
+				recorder:     record.NewFakeRecorder(10),
+				authorizer:   authz,
+				name:         "index.txt",
+				options:      &tc.options,
+				responder:    responder,
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/apis/cdn.k8s.toms.place/v1alpha1/namespaces/default/files/index.txt/copy", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.wantCode, responder.code, "error: %v", responder.err)
+			_, copied := storage.files["copy.txt"]
+			assert.Equal(t, tc.wantCode == http.StatusCreated, copied)
+		})
+	}
+}
@@ -17,15 +17,25 @@ limitations under the License.
 package file
 
 import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/registry/generic"
 	genericregistry "k8s.io/apiserver/pkg/registry/generic/registry"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/apiserver/pkg/util/dryrun"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/structured-merge-diff/v6/fieldpath"
+
 	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
 	"k8s.toms.place/apiserver/pkg/registry"
 )
 
 // NewREST returns a RESTStorage object that will work against API services.
-func NewREST(scheme *runtime.Scheme, optsGetter generic.RESTOptionsGetter) (*registry.REST, error) {
+// The content of deleted Files is removed from contentStore.
+func NewREST(scheme *runtime.Scheme, optsGetter generic.RESTOptionsGetter, contentStore content.Store) (*registry.REST, error) {
 	strategy := NewStrategy(scheme)
 
 	store := &genericregistry.Store{
@@ -35,9 +45,21 @@ func NewREST(scheme *runtime.Scheme, optsGetter generic.RESTOptionsGetter) (*reg
 		DefaultQualifiedResource:  cdn.Resource("files"),
 		SingularQualifiedResource: cdn.Resource("file"),
 
-		CreateStrategy: strategy,
-		UpdateStrategy: strategy,
-		DeleteStrategy: strategy,
+		CreateStrategy:      strategy,
+		UpdateStrategy:      strategy,
+		DeleteStrategy:      strategy,
+		ResetFieldsStrategy: strategy,
+
+		AfterDelete: func(obj runtime.Object, options *metav1.DeleteOptions) {
+			// The hook also runs for dry-run deletes, which must keep the content
+			if dryrun.IsDryRun(options.DryRun) {
+				return
+			}
+			file := obj.(*cdn.File)
+			if err := contentStore.Delete(context.Background(), file.Namespace, file.Name); err != nil {
+				klog.ErrorS(err, "Failed to delete content of deleted File", "namespace", file.Namespace, "name", file.Name)
+			}
+		},
 
 		TableConvertor: fileTableConvertor{},
 	}
@@ -47,3 +69,49 @@ func NewREST(scheme *runtime.Scheme, optsGetter generic.RESTOptionsGetter) (*reg
 	}
 	return &registry.REST{Store: store}, nil
 }
+
+// StatusREST implements the REST endpoint for changing the status of a File.
+type StatusREST struct {
+	store *genericregistry.Store
+}
+
+// NewStatusREST returns the status subresource storage sharing the given
+// File storage.
+func NewStatusREST(scheme *runtime.Scheme, fileStorage *registry.REST) *StatusREST {
+	statusStrategy := NewStatusStrategy(NewStrategy(scheme))
+
+	statusStore := *fileStorage.Store
+	statusStore.UpdateStrategy = statusStrategy
+	statusStore.ResetFieldsStrategy = statusStrategy
+	return &StatusREST{store: &statusStore}
+}
+
+var _ rest.Patcher = &StatusREST{}
+
+// New creates a new File object.
+func (r *StatusREST) New() runtime.Object {
+	return &cdn.File{}
+}
+
+// Destroy cleans up resources on shutdown.
+func (r *StatusREST) Destroy() {
+	// Given that status store is a copy of the file store, it shares the
+	// underlying storage and is destroyed with it.
+}
+
+// Get retrieves the object from the storage. It is required to support Patch.
+func (r *StatusREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	return r.store.Get(ctx, name, options)
+}
+
+// Update alters the status subset of an object.
+func (r *StatusREST) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	// We are explicitly setting forceAllowCreate to false in the call to the underlying storage because
+	// subresources should never allow create on update.
+	return r.store.Update(ctx, name, objInfo, createValidation, updateValidation, false, options)
+}
+
+// GetResetFields implements rest.ResetFieldsStrategy
+func (r *StatusREST) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return r.store.GetResetFields()
+}
@@ -0,0 +1,491 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/events"
+	"k8s.toms.place/apiserver/pkg/registry"
+)
+
+// FsckPath is the path of the endpoint checking Files against their content.
+// GET reports the inconsistencies, POST also repairs them.
+const FsckPath = "/cdn/fsck"
+
+// originFetchTimeout bounds a repair's fetch of content from its origin
+const originFetchTimeout = time.Minute
+
+// fsckChunkSize is the number of Files checked per list request
+const fsckChunkSize = 500
+
+// FsckProblem is a kind of inconsistency between a File and its content.
+type FsckProblem string
+
+const (
+	// FsckMissingContent is a File marked uploaded without stored content.
+	FsckMissingContent FsckProblem = "MissingContent"
+	// FsckUnreadableContent is stored content that cannot be read, such as
+	// content that cannot be decrypted.
+	FsckUnreadableContent FsckProblem = "UnreadableContent"
+	// FsckCorruptContent is stored content that does not match its own
+	// checksum.
+	FsckCorruptContent FsckProblem = "CorruptContent"
+	// FsckChecksumMismatch is stored content other than status.checksum.
+	FsckChecksumMismatch FsckProblem = "ChecksumMismatch"
+	// FsckSizeMismatch is stored content whose size is not spec.size.
+	FsckSizeMismatch FsckProblem = "SizeMismatch"
+	// FsckContentTypeMismatch is a spec.contentType that is invalid or
+	// contradicts the content.
+	FsckContentTypeMismatch FsckProblem = "ContentTypeMismatch"
+	// FsckOrphanedContent is stored content without a File.
+	FsckOrphanedContent FsckProblem = "OrphanedContent"
+)
+
+// FsckFinding is an inconsistency found for one File.
+type FsckFinding struct {
+	Problem   FsckProblem `json:"problem"`
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	Message   string      `json:"message"`
+	// Repair describes how the finding was repaired.
+	Repair string `json:"repair,omitempty"`
+	// RepairError is why the repair failed.
+	RepairError string `json:"repairError,omitempty"`
+}
+
+// FsckReport is the result of a consistency check.
+type FsckReport struct {
+	// Namespace is the checked namespace, or empty for all namespaces.
+	Namespace string `json:"namespace,omitempty"`
+	// Repair is true if the findings were repaired.
+	Repair bool `json:"repair"`
+	// Refetch is true if content was fetched again from allowed origins.
+	Refetch bool `json:"refetch,omitempty"`
+	// Files is the number of checked Files.
+	Files int `json:"files"`
+	// Objects is the number of checked content objects.
+	Objects  int           `json:"objects"`
+	Findings []FsckFinding `json:"findings"`
+}
+
+// Fsck checks Files against the content store, and optionally repairs the
+// inconsistencies: the File's spec and status are corrected to describe the
+// stored content, Files without readable content are marked not uploaded, and
+// orphaned content is deleted. If asked to, content is instead fetched again
+// from spec.resourceLocation where it is one of the allowed origins; the
+// field is set by the File's editors, so other locations are never fetched.
+type Fsck struct {
+	store        *registry.REST
+	status       *StatusREST
+	contentStore content.Store
+	recorder     record.EventRecorder
+	origins      []string
+	client       *http.Client
+}
+
+// NewFsck returns a Fsck for the Files of store and their content in
+// contentStore. Repairs of the status are written through status. Content is
+// only fetched again from origins, the "<scheme>://<host>" prefixes of the
+// allowed resource locations.
+func NewFsck(store *registry.REST, status *StatusREST, contentStore content.Store, recorder record.EventRecorder, origins []string) *Fsck {
+	f := &Fsck{
+		store:        store,
+		status:       status,
+		contentStore: contentStore,
+		recorder:     recorder,
+		origins:      origins,
+	}
+	f.client = &http.Client{
+		Timeout: originFetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !f.allowedOrigin(req.URL.String()) {
+				return fmt.Errorf("redirect to %s is not an allowed origin", req.URL.Redacted())
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+// ValidateOrigin returns an error if origin is not a "<scheme>://<host>"
+// origin content may be fetched again from
+func ValidateOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" || u.User != nil {
+		return fmt.Errorf("origin %q must be http(s)://<host>[:<port>]", origin)
+	}
+	return nil
+}
+
+// allowedOrigin returns whether location is on one of the allowed origins
+func (f *Fsck) allowedOrigin(location string) bool {
+	u, err := url.Parse(location)
+	if err != nil || u.User != nil {
+		return false
+	}
+	for _, origin := range f.origins {
+		allowed, err := url.Parse(origin)
+		if err == nil && strings.EqualFold(u.Scheme, allowed.Scheme) && strings.EqualFold(u.Host, allowed.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP serves FsckPath. The namespace query parameter restricts the
+// check to one namespace; refetch=true makes a repair fetch content again
+// from the allowed origins.
+func (f *Fsck) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var repair bool
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		repair = true
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	refetch := req.URL.Query().Get("refetch") == "true"
+	if refetch && !repair {
+		http.Error(w, "refetch requires a repair", http.StatusBadRequest)
+		return
+	}
+
+	ctx := req.Context()
+	report, err := f.Check(ctx, req.URL.Query().Get("namespace"), repair, refetch, newFileUpload(ctx, req))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Check checks the Files of namespace, or of all namespaces if it is empty,
+// and repairs the findings if repair is true. If refetch is true, content is
+// fetched again from allowed origins, attributed to upload.
+func (f *Fsck) Check(ctx context.Context, namespace string, repair, refetch bool, upload *cdn.FileUpload) (*FsckReport, error) {
+	report := &FsckReport{Namespace: namespace, Repair: repair, Refetch: refetch, Findings: []FsckFinding{}}
+
+	checked := map[types.NamespacedName]bool{}
+	options := &metainternalversion.ListOptions{Limit: fsckChunkSize}
+	for {
+		obj, err := f.store.List(request.WithNamespace(ctx, namespace), options)
+		if err != nil {
+			return nil, err
+		}
+		list := obj.(*cdn.FileList)
+		for i := range list.Items {
+			file := &list.Items[i]
+			checked[types.NamespacedName{Namespace: file.Namespace, Name: file.Name}] = true
+			report.Files++
+
+			stored, err := readStoredContent(ctx, f.contentStore, file.Namespace, file.Name)
+			if stored != nil || err != nil {
+				report.Objects++
+			}
+			findings := checkFile(file, stored, err)
+			if repair && len(findings) > 0 {
+				description, err := f.repairFile(ctx, file, stored, findings, refetch, upload)
+				for i := range findings {
+					findings[i].Repair = description
+					if err != nil {
+						findings[i].RepairError = err.Error()
+					}
+				}
+			}
+			report.Findings = append(report.Findings, findings...)
+		}
+		if list.Continue == "" {
+			break
+		}
+		options.Continue = list.Continue
+	}
+
+	keys, err := f.contentStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		if checked[key] || (namespace != "" && key.Namespace != namespace) {
+			continue
+		}
+		report.Objects++
+		// The File may have been created since the Files were listed
+		_, err := f.store.Get(request.WithNamespace(ctx, key.Namespace), key.Name, &metav1.GetOptions{})
+		if !apierrors.IsNotFound(err) {
+			continue
+		}
+		finding := FsckFinding{
+			Problem:   FsckOrphanedContent,
+			Namespace: key.Namespace,
+			Name:      key.Name,
+			Message:   "content is stored but there is no File",
+		}
+		if repair {
+			finding.Repair = "deleted the content"
+			if err := f.contentStore.Delete(ctx, key.Namespace, key.Name); err != nil {
+				finding.RepairError = err.Error()
+			}
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+
+	return report, nil
+}
+
+// storedContent is what a check read of the stored content of a File
+type storedContent struct {
+	// checksum is the checksum the content was stored with.
+	checksum string
+	// digest is the checksum of the content as read.
+	digest string
+	// size is the number of bytes read.
+	size int64
+	// head is the beginning of the content, enough to sniff its type.
+	head []byte
+}
+
+// readStoredContent stats the named content and reads it as a stream to
+// verify its checksum. It returns nil if there is no content.
+func readStoredContent(ctx context.Context, store content.Store, namespace, name string) (*storedContent, error) {
+	info, err := store.Stat(ctx, namespace, name)
+	if content.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r, err := content.Open(ctx, store, namespace, name)
+	if content.IsNotFound(err) {
+		// Deleted since it was stat'ed
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	digest := sha256.New()
+	head := &prefixWriter{limit: 512}
+	size, err := io.Copy(io.MultiWriter(digest, head), r)
+	if err != nil {
+		return nil, err
+	}
+	return &storedContent{
+		checksum: info.Checksum,
+		digest:   hex.EncodeToString(digest.Sum(nil)),
+		size:     size,
+		head:     head.data,
+	}, nil
+}
+
+// prefixWriter keeps the first limit bytes written to it
+type prefixWriter struct {
+	limit int
+	data  []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if remaining := w.limit - len(w.data); remaining > 0 {
+		w.data = append(w.data, p[:min(remaining, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// checkFile returns the findings for file, whose stored content is stored, or
+// nil if it has none, or could not be read because of readErr
+func checkFile(file *cdn.File, stored *storedContent, readErr error) []FsckFinding {
+	var findings []FsckFinding
+	add := func(problem FsckProblem, format string, args ...interface{}) {
+		findings = append(findings, FsckFinding{
+			Problem:   problem,
+			Namespace: file.Namespace,
+			Name:      file.Name,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	switch {
+	case readErr != nil:
+		add(FsckUnreadableContent, "content cannot be read: %v", readErr)
+		return findings
+	case stored == nil:
+		if file.Status.Uploaded {
+			add(FsckMissingContent, "File is marked uploaded but has no content")
+		}
+		return findings
+	}
+
+	if stored.digest != stored.checksum {
+		add(FsckCorruptContent, "content has checksum %s but was stored with checksum %s", stored.digest, stored.checksum)
+		return findings
+	}
+	if stored.checksum != file.Status.Checksum {
+		add(FsckChecksumMismatch, "content has checksum %s, status.checksum is %q", stored.checksum, file.Status.Checksum)
+	}
+	if stored.size != file.Spec.Size {
+		add(FsckSizeMismatch, "content has %d bytes, spec.size is %d", stored.size, file.Spec.Size)
+	}
+	if contentType, ok := contentTypeMismatch(file, stored.head); ok {
+		add(FsckContentTypeMismatch, "content looks like %s, spec.contentType is %q", contentType, file.Spec.ContentType)
+	}
+	return findings
+}
+
+// contentTypeMismatch returns the content type of data and true if the
+// File's spec.contentType is invalid or of a different top-level type than
+// the sniffed type of data. Sniffed text types are not trusted, as the sniffer
+// cannot tell e.g. CSS or SVG from plain text.
+func contentTypeMismatch(file *cdn.File, data []byte) (string, bool) {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+
+	if _, err := normalizeContentType(file.Spec.ContentType); err != nil || file.Spec.ContentType == "" {
+		if byExtension := mime.TypeByExtension(path.Ext(file.Name)); byExtension != "" {
+			return byExtension, true
+		}
+		return sniffed, true
+	}
+	specified, _, _ := mime.ParseMediaType(file.Spec.ContentType)
+	if specified == "application/octet-stream" || sniffed == "application/octet-stream" || strings.HasPrefix(sniffed, "text/") {
+		return "", false
+	}
+	topLevel := func(mediaType string) string {
+		t, _, _ := strings.Cut(mediaType, "/")
+		return t
+	}
+	if topLevel(specified) != topLevel(sniffed) {
+		return sniffed, true
+	}
+	return "", false
+}
+
+// repairFile repairs the findings of file, whose stored content is stored, and
+// returns a description of the repair. If refetch is true, content from an
+// allowed origin is fetched again.
+func (f *Fsck) repairFile(ctx context.Context, file *cdn.File, stored *storedContent, findings []FsckFinding, refetch bool, upload *cdn.FileUpload) (string, error) {
+	problems := map[FsckProblem]bool{}
+	for _, finding := range findings {
+		problems[finding.Problem] = true
+	}
+	unusable := problems[FsckMissingContent] || problems[FsckUnreadableContent] || problems[FsckCorruptContent]
+
+	if (unusable || problems[FsckChecksumMismatch]) && refetch && f.allowedOrigin(file.Spec.ResourceLocation) {
+		description := fmt.Sprintf("fetched the content again from %s", file.Spec.ResourceLocation)
+		return description, f.refetch(ctx, file, upload)
+	}
+
+	updated := file.DeepCopy()
+	var repairs []string
+	switch {
+	case unusable:
+		updated.Status.Uploaded = false
+		updated.Status.Error = findings[0].Message
+		repairs = append(repairs, "marked the File not uploaded")
+	case problems[FsckChecksumMismatch]:
+		updated.Status.Uploaded = true
+		updated.Status.Error = ""
+		updated.Status.Checksum = stored.checksum
+		updated.Status.ContentGeneration++
+		repairs = append(repairs, "set status.checksum to the stored content's")
+	}
+	if !unusable && problems[FsckSizeMismatch] {
+		updated.Spec.Size = stored.size
+		repairs = append(repairs, "set spec.size to the stored content's")
+	}
+	if !unusable && problems[FsckContentTypeMismatch] {
+		updated.Spec.ContentType, _ = contentTypeMismatch(file, stored.head)
+		repairs = append(repairs, fmt.Sprintf("set spec.contentType to %s", updated.Spec.ContentType))
+	}
+
+	description := strings.Join(repairs, ", ")
+	ctx = request.WithNamespace(ctx, file.Namespace)
+	if _, err := commitFile(ctx, f.store, f.status, file, updated); err != nil {
+		return description, err
+	}
+	f.recorder.Eventf(events.FileReference(file), corev1.EventTypeNormal, events.ReasonRepaired, "Consistency check %s", description)
+	return description, nil
+}
+
+// refetch fetches the content of file from its resource location and uploads
+// it as upload
+func (f *Fsck) refetch(ctx context.Context, file *cdn.File, upload *cdn.FileUpload) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.Spec.ResourceLocation, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		f.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonOriginFetchFailed, "Failed to fetch content from origin: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("origin returned %s", resp.Status)
+		f.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonOriginFetchFailed, "Failed to fetch content from origin: %v", err)
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, DefaultArchiveLimits.MaxEntryBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > DefaultArchiveLimits.MaxEntryBytes {
+		return fmt.Errorf("origin content exceeds %d bytes", DefaultArchiveLimits.MaxEntryBytes)
+	}
+
+	contentType := file.Spec.ContentType
+	if originType, err := normalizeContentType(resp.Header.Get("Content-Type")); err == nil && resp.Header.Get("Content-Type") != "" {
+		contentType = originType
+	}
+	writer := &fileWriter{store: f.store, status: f.status, contentStore: f.contentStore, recorder: f.recorder}
+	_, err = writer.write(request.WithNamespace(ctx, file.Namespace), &fileWrite{
+		name:        file.Name,
+		url:         file.Spec.URL,
+		data:        data,
+		contentType: contentType,
+		upload:      upload,
+	})
+	return err
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+func TestCheckFile(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000000000")
+	stored := func(data []byte) *storedContent {
+		return &storedContent{checksum: sha256Hex(data), digest: sha256Hex(data), size: int64(len(data)), head: data}
+	}
+	file := func(data []byte, contentType string) *cdn.File {
+		return &cdn.File{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "logo.png"},
+			Spec:       cdn.FileSpec{Size: int64(len(data)), ContentType: contentType},
+			Status:     cdn.FileStatus{Uploaded: true, Checksum: sha256Hex(data)},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		file    *cdn.File
+		stored  *storedContent
+		readErr error
+		want    []FsckProblem
+	}{
+		{name: "consistent", file: file(png, "image/png"), stored: stored(png)},
+		{name: "not uploaded without content", file: &cdn.File{}},
+		{name: "uploaded without content", file: file(png, "image/png"), want: []FsckProblem{FsckMissingContent}},
+		{name: "unreadable", file: file(png, "image/png"), readErr: errors.New("cannot decrypt"), want: []FsckProblem{FsckUnreadableContent}},
+		{
+			name:   "corrupt",
+			file:   file(png, "image/png"),
+			stored: &storedContent{checksum: sha256Hex(png), digest: sha256Hex([]byte("garbage")), size: 7, head: []byte("garbage")},
+			want:   []FsckProblem{FsckCorruptContent},
+		},
+		{
+			name:   "other content",
+			file:   file(png, "image/png"),
+			stored: stored(append([]byte{}, png[:12]...)),
+			want:   []FsckProblem{FsckChecksumMismatch, FsckSizeMismatch},
+		},
+		{name: "wrong content type", file: file(png, "text/html"), stored: stored(png), want: []FsckProblem{FsckContentTypeMismatch}},
+		{name: "invalid content type", file: file(png, "not a type"), stored: stored(png), want: []FsckProblem{FsckContentTypeMismatch}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []FsckProblem
+			for _, finding := range checkFile(tt.file, tt.stored, tt.readErr) {
+				got = append(got, finding.Problem)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestContentTypeMismatch(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000000000")
+
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		data        []byte
+		want        string
+		wantOK      bool
+	}{
+		{name: "matching", fileName: "logo.png", contentType: "image/png", data: png},
+		{name: "same top-level type", fileName: "logo.png", contentType: "image/webp", data: png},
+		{name: "octet-stream", fileName: "logo.png", contentType: "application/octet-stream", data: png},
+		{name: "sniffed text", fileName: "style.css", contentType: "text/css", data: []byte("body { color: red }")},
+		{name: "sniffed text for json", fileName: "data.json", contentType: "application/json", data: []byte(`{"a": 1}`)},
+		{name: "contradicting", fileName: "logo.png", contentType: "text/html", data: png, want: "image/png", wantOK: true},
+		{name: "missing by extension", fileName: "logo.png", data: []byte("not sniffable"), want: "image/png", wantOK: true},
+		{name: "invalid sniffed", fileName: "logo", contentType: "not a type", data: png, want: "image/png", wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &cdn.File{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.fileName},
+				Spec:       cdn.FileSpec{ContentType: tt.contentType},
+			}
+			got, ok := contentTypeMismatch(file, tt.data)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadStoredContent(t *testing.T) {
+	ctx := context.Background()
+	data := []byte(strings.Repeat("x", 1000))
+	store := content.NewMemoryStore()
+	require.NoError(t, store.Put(ctx, "default", "big.txt", &content.Object{Data: data, Checksum: sha256Hex(data)}))
+
+	stored, err := readStoredContent(ctx, store, "default", "big.txt")
+	require.NoError(t, err)
+	assert.Equal(t, &storedContent{checksum: sha256Hex(data), digest: sha256Hex(data), size: 1000, head: data[:512]}, stored)
+
+	stored, err = readStoredContent(ctx, store, "default", "missing.txt")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestFsckAllowedOrigin(t *testing.T) {
+	f := NewFsck(nil, nil, nil, nil, []string{"https://assets.example.com", "http://origin.internal:8080/"})
+
+	tests := []struct {
+		location string
+		want     bool
+	}{
+		{location: "https://assets.example.com/logo.png", want: true},
+		{location: "HTTPS://Assets.Example.com/logo.png", want: true},
+		{location: "http://origin.internal:8080/a/b", want: true},
+		{location: "http://assets.example.com/logo.png"},
+		{location: "https://assets.example.com:8443/logo.png"},
+		{location: "http://origin.internal/a/b"},
+		{location: "http://169.254.169.254/latest/meta-data/"},
+		{location: "https://user@assets.example.com/logo.png"},
+		{location: "file:///etc/passwd"},
+		{location: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			assert.Equal(t, tt.want, f.allowedOrigin(tt.location))
+		})
+	}
+
+	assert.False(t, NewFsck(nil, nil, nil, nil, nil).allowedOrigin("https://assets.example.com/logo.png"))
+}
+
+func TestValidateOrigin(t *testing.T) {
+	for _, origin := range []string{"https://assets.example.com", "http://origin.internal:8080/"} {
+		assert.NoError(t, ValidateOrigin(origin), origin)
+	}
+	for _, origin := range []string{"assets.example.com", "ftp://assets.example.com", "https://assets.example.com/static", "https://user@assets.example.com", "https://"} {
+		assert.Error(t, ValidateOrigin(origin), origin)
+	}
+}
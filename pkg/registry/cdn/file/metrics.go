@@ -0,0 +1,132 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+// requestRecorder records the status code and transferred bytes of a content
+// request for metrics
+type requestRecorder struct {
+	http.ResponseWriter
+	body      *countingReader
+	operation string
+	start     time.Time
+	code      int
+	written   int64
+}
+
+// instrumentRequest wraps w, the body of req and responder to record the
+// request as operation once the returned recorder's done method is called
+func instrumentRequest(w http.ResponseWriter, req *http.Request, responder rest.Responder, operation string) (*requestRecorder, rest.Responder) {
+	r := &requestRecorder{
+		ResponseWriter: w,
+		body:           &countingReader{ReadCloser: req.Body},
+		operation:      operation,
+		start:          time.Now(),
+	}
+	req.Body = r.body
+	return r, &recordingResponder{Responder: responder, recorder: r}
+}
+
+func (r *requestRecorder) setCode(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *requestRecorder) WriteHeader(code int) {
+	r.setCode(code)
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *requestRecorder) Write(b []byte) (int, error) {
+	r.setCode(http.StatusOK)
+	n, err := r.ResponseWriter.Write(b)
+	r.written += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher for streamed responses
+func (r *requestRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (r *requestRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// done records the request in the content metrics. Uploads count the bytes
+// read from the request, all other operations the bytes written.
+func (r *requestRecorder) done(ctx context.Context) {
+	bytes := r.written
+	if r.operation == content.OperationUpload || r.operation == content.OperationArchiveUpload {
+		bytes = r.body.read
+	}
+	code := r.code
+	if code == 0 {
+		code = http.StatusOK
+	}
+	content.RecordRequest(request.NamespaceValue(ctx), r.operation, code, bytes, time.Since(r.start))
+}
+
+// recordingResponder records the status code of responses written through a
+// rest.Responder, which bypasses the wrapped http.ResponseWriter
+type recordingResponder struct {
+	rest.Responder
+	recorder *requestRecorder
+}
+
+func (r *recordingResponder) Object(statusCode int, obj runtime.Object) {
+	r.recorder.setCode(statusCode)
+	r.Responder.Object(statusCode, obj)
+}
+
+func (r *recordingResponder) Error(err error) {
+	code := http.StatusInternalServerError
+	if status, ok := err.(apierrors.APIStatus); ok {
+		code = int(status.Status().Code)
+	}
+	r.recorder.setCode(code)
+	r.Responder.Error(err)
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
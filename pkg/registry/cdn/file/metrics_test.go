@@ -0,0 +1,99 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+type fakeResponder struct{}
+
+func (fakeResponder) Object(statusCode int, obj runtime.Object) {}
+func (fakeResponder) Error(err error)                           {}
+
+func TestRequestRecorder(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		operation string
+		serve     func(w http.ResponseWriter, req *http.Request, responder *recordingResponder)
+		wantCode  int
+		wantBytes int64
+	}{
+		{
+			desc:      "download",
+			operation: content.OperationDownload,
+			serve: func(w http.ResponseWriter, req *http.Request, responder *recordingResponder) {
+				w.Write([]byte("hello"))
+			},
+			wantCode:  http.StatusOK,
+			wantBytes: 5,
+		},
+		{
+			desc:      "upload",
+			operation: content.OperationUpload,
+			serve: func(w http.ResponseWriter, req *http.Request, responder *recordingResponder) {
+				io.ReadAll(req.Body)
+				responder.Object(http.StatusCreated, &cdn.FileContent{})
+			},
+			wantCode:  http.StatusCreated,
+			wantBytes: 11,
+		},
+		{
+			desc:      "responder error",
+			operation: content.OperationDownload,
+			serve: func(w http.ResponseWriter, req *http.Request, responder *recordingResponder) {
+				responder.Error(apierrors.NewNotFound(cdn.Resource("files"), "a"))
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			desc:      "plain error",
+			operation: content.OperationUpload,
+			serve: func(w http.ResponseWriter, req *http.Request, responder *recordingResponder) {
+				http.Error(w, "bad", http.StatusBadRequest)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader("hello world"))
+			recorder, responder := instrumentRequest(httptest.NewRecorder(), req, fakeResponder{}, tc.operation)
+			tc.serve(recorder, req, responder.(*recordingResponder))
+
+			assert.Equal(t, tc.wantCode, recorder.code)
+			bytes := recorder.written
+			if tc.operation == content.OperationUpload {
+				bytes = recorder.body.read
+			}
+			assert.Equal(t, tc.wantBytes, bytes)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/apis/cdn/validation"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/content/signedurl"
+	"k8s.toms.place/apiserver/pkg/events"
+	"k8s.toms.place/apiserver/pkg/registry"
+)
+
+// SignedContentPath is the path prefix of signed URLs, which serve the content
+// of a File at SignedContentPath + "<namespace>/<name>" without credentials.
+const SignedContentPath = "/cdn/signed/"
+
+// SignedURLREST implements the signedurl subresource of a File, which signs a
+// URL for its content
+type SignedURLREST struct {
+	store        *registry.REST
+	signer       *signedurl.Signer
+	authorizer   authorizer.Authorizer
+	externalHost string
+	now          func() time.Time
+}
+
+// NewSignedURLREST creates a new SignedURLREST. Signing requires "get
+// files/content" on the File, checked with authorizer; if nil, it is not
+// checked. Signed URLs point at externalHost; without it no URL is signed, as
+// the Kubernetes API server does not proxy SignedContentPath.
+func NewSignedURLREST(store *registry.REST, signer *signedurl.Signer, authorizer authorizer.Authorizer, externalHost string) *SignedURLREST {
+	return &SignedURLREST{
+		store:        store,
+		signer:       signer,
+		authorizer:   authorizer,
+		externalHost: externalHost,
+		now:          time.Now,
+	}
+}
+
+var _ rest.NamedCreater = &SignedURLREST{}
+
+// New returns an empty FileSignedURL
+func (r *SignedURLREST) New() runtime.Object {
+	return &cdn.FileSignedURL{}
+}
+
+// Destroy cleans up resources on shutdown
+func (r *SignedURLREST) Destroy() {}
+
+// Create signs a URL for the content of the named File
+func (r *SignedURLREST) Create(ctx context.Context, name string, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	req, ok := obj.(*cdn.FileSignedURL)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("not a FileSignedURL: %T", obj))
+	}
+	if errs := validation.ValidateFileSignedURL(req); len(errs) > 0 {
+		return nil, apierrors.NewInvalid(cdn.Kind("FileSignedURL"), name, errs)
+	}
+	if createValidation != nil {
+		if err := createValidation(ctx, obj.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+	if r.externalHost == "" {
+		return nil, apierrors.NewServiceUnavailable("signing URLs requires the server to be started with --external-host")
+	}
+	// A signed URL hands out the content, so only its readers may sign one
+	if err := Authorize(ctx, r.authorizer, "get", "content", name); err != nil {
+		return nil, err
+	}
+
+	fileObj, err := r.store.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	file := fileObj.(*cdn.File)
+
+	expires := r.now().Add(time.Duration(*req.Spec.ExpirationSeconds) * time.Second).Truncate(time.Second)
+	query := r.signer.Sign(file.Namespace, file.Name, file.UID, expires)
+	u := "https://" + r.externalHost + SignedContentPath + file.Namespace + "/" + file.Name + "?" + query.Encode()
+
+	return &cdn.FileSignedURL{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              file.Name,
+			Namespace:         file.Namespace,
+			UID:               file.UID,
+			CreationTimestamp: file.CreationTimestamp,
+		},
+		Spec: req.Spec,
+		Status: cdn.FileSignedURLStatus{
+			URL:                 u,
+			ExpirationTimestamp: metav1.NewTime(expires),
+		},
+	}, nil
+}
+
+// SignedContent serves the content of Files at signed URLs. The requests are
+// neither authenticated nor authorized: the signature is the credential.
+type SignedContent struct {
+	store        *registry.REST
+	contentStore content.Store
+	signer       *signedurl.Signer
+	recorder     record.EventRecorder
+	now          func() time.Time
+}
+
+// NewSignedContent returns a SignedContent for the Files of store whose URLs
+// were signed by signer.
+func NewSignedContent(store *registry.REST, contentStore content.Store, signer *signedurl.Signer, recorder record.EventRecorder) *SignedContent {
+	return &SignedContent{
+		store:        store,
+		contentStore: contentStore,
+		signer:       signer,
+		recorder:     recorder,
+		now:          time.Now,
+	}
+}
+
+// ServeHTTP serves GET and HEAD requests for SignedContentPath. Files that do
+// not exist are reported like invalid signatures, so the endpoint does not
+// reveal which Files exist.
+func (s *SignedContent) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, SignedContentPath), "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, req)
+		return
+	}
+	ctx := request.WithNamespace(req.Context(), namespace)
+	recorder, _ := instrumentRequest(w, req, nil, content.OperationSignedDownload)
+	defer recorder.done(ctx)
+	w = recorder
+
+	obj, err := s.store.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil || s.signer.Verify(namespace, name, obj.(*cdn.File).UID, req.URL.Query(), s.now()) != nil {
+		http.Error(w, signedurl.ErrInvalid.Error(), http.StatusForbidden)
+		return
+	}
+	file := obj.(*cdn.File)
+
+	entry, err := s.contentStore.Get(ctx, namespace, name)
+	if content.IsNotFound(err) {
+		http.NotFound(w, req)
+		return
+	}
+	if err != nil {
+		s.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonOriginFetchFailed, "Failed to read content: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The File spec is authoritative for the content type
+	served := *entry
+	served.ContentType = file.Spec.ContentType
+	content.ServeObject(w, req, &served, content.ETag(entry.Checksum, file.Status.ContentGeneration))
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/utils/ptr"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content/signedurl"
+)
+
+func TestSignedURLRESTCreate(t *testing.T) {
+	ctx := request.WithNamespace(context.Background(), "web")
+	ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "jane"})
+	deny := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionNoOpinion, "not allowed", nil
+	})
+	r := NewSignedURLREST(nil, signedurl.NewRandomSigner(), deny, "cdn.example.com")
+	withoutHost := NewSignedURLREST(nil, signedurl.NewRandomSigner(), nil, "")
+
+	testCases := []struct {
+		desc       string
+		rest       *SignedURLREST
+		expiration *int64
+		check      func(error) bool
+	}{
+		{desc: "too short", expiration: ptr.To[int64](59), check: apierrors.IsInvalid},
+		{desc: "too long", expiration: ptr.To[int64](7*24*60*60 + 1), check: apierrors.IsInvalid},
+		{desc: "not defaulted", check: apierrors.IsInvalid},
+		// The File is not read unless the user may get its content
+		{desc: "forbidden", expiration: ptr.To[int64](60), check: apierrors.IsForbidden},
+		// The signed path is not reachable through the Kubernetes API server
+		{desc: "no external host", rest: withoutHost, expiration: ptr.To[int64](60), check: apierrors.IsServiceUnavailable},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := r
+			if tc.rest != nil {
+				s = tc.rest
+			}
+			obj := &cdn.FileSignedURL{Spec: cdn.FileSignedURLSpec{ExpirationSeconds: tc.expiration}}
+			_, err := s.Create(ctx, "index.html", obj, nil, &metav1.CreateOptions{})
+			assert.True(t, tc.check(err), "unexpected error %v", err)
+		})
+	}
+}
+
+func TestSignedContentRejectsRequests(t *testing.T) {
+	s := NewSignedContent(nil, nil, signedurl.NewRandomSigner(), nil)
+
+	testCases := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{method: http.MethodPut, path: SignedContentPath + "web/index.html", code: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: SignedContentPath + "web", code: http.StatusNotFound},
+		{method: http.MethodGet, path: SignedContentPath + "web/", code: http.StatusNotFound},
+		{method: http.MethodGet, path: SignedContentPath + "/index.html", code: http.StatusNotFound},
+		{method: http.MethodGet, path: SignedContentPath + "web/docs/index.html", code: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			assert.Equal(t, tc.code, w.Code)
+		})
+	}
+}
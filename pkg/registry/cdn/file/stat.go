@@ -0,0 +1,99 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/registry"
+)
+
+// StatREST implements the stat subresource of a File, which describes its
+// stored content without reading it
+type StatREST struct {
+	store        *registry.REST
+	contentStore content.Store
+}
+
+// NewStatREST creates a new StatREST.
+func NewStatREST(store *registry.REST, contentStore content.Store) *StatREST {
+	return &StatREST{store: store, contentStore: contentStore}
+}
+
+var _ rest.Getter = &StatREST{}
+
+// New returns an empty FileStat
+func (r *StatREST) New() runtime.Object {
+	return &cdn.FileStat{}
+}
+
+// Destroy cleans up resources on shutdown
+func (r *StatREST) Destroy() {}
+
+// Get describes the content stored for the named File
+func (r *StatREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	obj, err := r.store.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	file := obj.(*cdn.File)
+
+	info, err := r.contentStore.Stat(ctx, file.Namespace, file.Name)
+	if err != nil && !content.IsNotFound(err) {
+		return nil, apierrors.NewInternalError(err)
+	}
+	return newFileStat(file, info), nil
+}
+
+// newFileStat describes the content info of file, which is nil if no content
+// is stored
+func newFileStat(file *cdn.File, info *content.ObjectInfo) *cdn.FileStat {
+	stat := &cdn.FileStat{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              file.Name,
+			Namespace:         file.Namespace,
+			UID:               file.UID,
+			ResourceVersion:   file.ResourceVersion,
+			CreationTimestamp: file.CreationTimestamp,
+		},
+	}
+	if info == nil {
+		return stat
+	}
+	stat.Stored = true
+	stat.Size = info.Size
+	stat.ContentType = info.ContentType
+	stat.Checksum = info.Checksum
+	stat.Versions = info.Versions
+	stat.Cached = info.Cached
+	if !info.Modified.IsZero() {
+		modified := metav1.NewTime(info.Modified)
+		stat.Modified = &modified
+	}
+	if !info.Accessed.IsZero() {
+		accessed := metav1.NewTime(info.Accessed)
+		stat.LastAccess = &accessed
+	}
+	return stat
+}
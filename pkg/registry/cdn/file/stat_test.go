@@ -0,0 +1,61 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+)
+
+func TestNewFileStat(t *testing.T) {
+	file := &cdn.File{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "logo.png", UID: "uid", ResourceVersion: "7"}}
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		info *content.ObjectInfo
+		want cdn.FileStat
+	}{
+		{
+			name: "no content",
+			want: cdn.FileStat{},
+		},
+		{
+			name: "never read",
+			info: &content.ObjectInfo{Size: 3, ContentType: "image/png", Checksum: "abc", Versions: 2, Modified: modified},
+			want: cdn.FileStat{Stored: true, Size: 3, ContentType: "image/png", Checksum: "abc", Versions: 2, Modified: &metav1.Time{Time: modified}},
+		},
+		{
+			name: "cached",
+			info: &content.ObjectInfo{Size: 3, Checksum: "abc", Versions: 1, Modified: modified, Accessed: modified.Add(time.Hour), Cached: true},
+			want: cdn.FileStat{Stored: true, Size: 3, Checksum: "abc", Versions: 1, Modified: &metav1.Time{Time: modified}, LastAccess: &metav1.Time{Time: modified.Add(time.Hour)}, Cached: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.ObjectMeta = metav1.ObjectMeta{Namespace: "default", Name: "logo.png", UID: "uid", ResourceVersion: "7"}
+			assert.Equal(t, &tt.want, newFileStat(file, tt.info))
+		})
+	}
+}
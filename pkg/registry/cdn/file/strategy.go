@@ -19,6 +19,7 @@ package file
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -27,7 +28,10 @@ import (
 	"k8s.io/apiserver/pkg/registry/generic"
 	"k8s.io/apiserver/pkg/storage"
 	"k8s.io/apiserver/pkg/storage/names"
+	"sigs.k8s.io/structured-merge-diff/v6/fieldpath"
+
 	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
 	"k8s.toms.place/apiserver/pkg/apis/cdn/validation"
 )
 
@@ -57,7 +61,18 @@ func MatchFile(label labels.Selector, field fields.Selector) storage.SelectionPr
 
 // SelectableFields returns a field set that represents the object.
 func SelectableFields(obj *cdn.File) fields.Set {
-	return generic.ObjectMetaFieldsSet(&obj.ObjectMeta, true)
+	fileSpecificFieldsSet := fields.Set{
+		"spec.url":         obj.Spec.URL,
+		"spec.size":        strconv.FormatInt(obj.Spec.Size, 10),
+		"spec.contentType": obj.Spec.ContentType,
+		"status.uploaded":  strconv.FormatBool(obj.Status.Uploaded),
+		"status.checksum":  obj.Status.Checksum,
+
+		cdnv1alpha1.FieldMediaType:    cdnv1alpha1.MediaType(obj.Spec.ContentType),
+		cdnv1alpha1.FieldTopLevelType: cdnv1alpha1.TopLevelType(obj.Spec.ContentType),
+		cdnv1alpha1.FieldSizeClass:    strconv.Itoa(cdnv1alpha1.SizeClass(obj.Spec.Size)),
+	}
+	return generic.MergeFieldsSets(generic.ObjectMetaFieldsSet(&obj.ObjectMeta, true), fileSpecificFieldsSet)
 }
 
 type fileStrategy struct {
@@ -69,10 +84,29 @@ func (fileStrategy) NamespaceScoped() bool {
 	return true
 }
 
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (fileStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		"cdn.k8s.toms.place/v1alpha1": fieldpath.NewSet(
+			fieldpath.MakePathOrDie("status"),
+		),
+	}
+}
+
+// PrepareForCreate clears the status of a new File. Only uploads set it,
+// through the status subresource.
 func (fileStrategy) PrepareForCreate(ctx context.Context, obj runtime.Object) {
+	file := obj.(*cdn.File)
+	file.Status = cdn.FileStatus{}
 }
 
+// PrepareForUpdate keeps the status of the File, which only changes through
+// the status subresource.
 func (fileStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	newFile := obj.(*cdn.File)
+	oldFile := old.(*cdn.File)
+	newFile.Status = oldFile.Status
 }
 
 func (fileStrategy) Validate(ctx context.Context, obj runtime.Object) field.ErrorList {
@@ -103,3 +137,39 @@ func (fileStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object)
 func (fileStrategy) WarningsOnUpdate(ctx context.Context, obj, old runtime.Object) []string {
 	return nil
 }
+
+type fileStatusStrategy struct {
+	fileStrategy
+}
+
+// NewStatusStrategy creates a strategy for updating the status subresource.
+func NewStatusStrategy(strategy fileStrategy) fileStatusStrategy {
+	return fileStatusStrategy{strategy}
+}
+
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (fileStatusStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		"cdn.k8s.toms.place/v1alpha1": fieldpath.NewSet(
+			fieldpath.MakePathOrDie("spec"),
+		),
+	}
+}
+
+func (fileStatusStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	newFile := obj.(*cdn.File)
+	oldFile := old.(*cdn.File)
+	newFile.Spec = oldFile.Spec
+	newFile.Labels = oldFile.Labels
+	newFile.Annotations = oldFile.Annotations
+}
+
+func (fileStatusStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object) field.ErrorList {
+	return validation.ValidateFileStatusUpdate(obj.(*cdn.File), old.(*cdn.File))
+}
+
+// WarningsOnUpdate returns warnings for the given update.
+func (fileStatusStrategy) WarningsOnUpdate(ctx context.Context, obj, old runtime.Object) []string {
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+)
+
+func TestMatchFile(t *testing.T) {
+	file := &cdn.File{
+		ObjectMeta: metav1.ObjectMeta{Name: "index.html", Namespace: "web"},
+		Spec: cdn.FileSpec{
+			URL:         "https://cdn.example.com/index.html",
+			Size:        42,
+			ContentType: "text/html; charset=utf-8",
+		},
+		Status: cdn.FileStatus{
+			Uploaded: true,
+			Checksum: "abc123",
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		selector string
+		matches  bool
+	}{
+		{desc: "name", selector: "metadata.name=index.html", matches: true},
+		{desc: "content type", selector: `spec.contentType=text/html; charset\=utf-8`, matches: true},
+		{desc: "content type without parameters", selector: "spec.contentType=text/html", matches: false},
+		{desc: "media type", selector: "spec.mediaType=text/html", matches: true},
+		{desc: "top-level type", selector: "spec.topLevelType=text", matches: true},
+		{desc: "other top-level type", selector: "spec.topLevelType=image", matches: false},
+		{desc: "size class", selector: "spec.sizeClass=6", matches: true},
+		{desc: "excluded size classes", selector: "spec.sizeClass!=0,spec.sizeClass!=1,spec.sizeClass!=5", matches: true},
+		{desc: "excluded own size class", selector: "spec.sizeClass!=5,spec.sizeClass!=6", matches: false},
+		{desc: "other content type", selector: "spec.contentType=image/png", matches: false},
+		{desc: "uploaded", selector: "status.uploaded=true", matches: true},
+		{desc: "not uploaded", selector: "status.uploaded=false", matches: false},
+		{desc: "size", selector: "spec.size=42", matches: true},
+		{desc: "checksum", selector: "status.checksum=abc123", matches: true},
+		{desc: "url and uploaded", selector: "spec.url=https://cdn.example.com/index.html,status.uploaded!=false", matches: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			selector, err := fields.ParseSelector(tc.selector)
+			assert.NoError(t, err)
+
+			predicate := MatchFile(labels.Everything(), selector)
+			matches, err := predicate.Matches(file)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.matches, matches)
+		})
+	}
+}
+
+func TestStrategyStatus(t *testing.T) {
+	ctx := context.Background()
+	old := &cdn.File{
+		ObjectMeta: metav1.ObjectMeta{Name: "index.html", Labels: map[string]string{"app": "web"}},
+		Spec:       cdn.FileSpec{URL: "/index.html", Size: 42},
+		Status:     cdn.FileStatus{Uploaded: true, Checksum: "abc123", ContentGeneration: 3},
+	}
+	forged := cdn.FileStatus{Uploaded: true, Checksum: "forged", ContentGeneration: 9}
+
+	created := old.DeepCopy()
+	created.Status = forged
+	NewStrategy(nil).PrepareForCreate(ctx, created)
+	assert.Equal(t, cdn.FileStatus{}, created.Status, "create resets the status")
+
+	updated := old.DeepCopy()
+	updated.Spec.Size = 43
+	updated.Status = forged
+	NewStrategy(nil).PrepareForUpdate(ctx, updated, old)
+	assert.Equal(t, old.Status, updated.Status, "update keeps the status")
+	assert.Equal(t, int64(43), updated.Spec.Size)
+
+	status := old.DeepCopy()
+	status.Labels = map[string]string{"app": "other"}
+	status.Spec.Size = 43
+	status.Status = forged
+	NewStatusStrategy(NewStrategy(nil)).PrepareForUpdate(ctx, status, old)
+	assert.Equal(t, forged, status.Status, "status update changes the status")
+	assert.Equal(t, old.Spec, status.Spec, "status update keeps the spec")
+	assert.Equal(t, old.Labels, status.Labels, "status update keeps the labels")
+}
+
+func TestStatusStrategyValidateUpdate(t *testing.T) {
+	checksum := sha256Hex([]byte("hello"))
+	old := &cdn.File{
+		ObjectMeta: metav1.ObjectMeta{Name: "index.html"},
+		Status:     cdn.FileStatus{Uploaded: true, Checksum: checksum, ContentGeneration: 3},
+	}
+
+	testCases := []struct {
+		desc    string
+		status  cdn.FileStatus
+		wantErr string
+	}{
+		{desc: "new content", status: cdn.FileStatus{Uploaded: true, Checksum: sha256Hex([]byte("world")), ContentGeneration: 4}},
+		{desc: "unchanged", status: old.Status},
+		{desc: "failed upload", status: cdn.FileStatus{Checksum: checksum, ContentGeneration: 4, Error: "disk full"}},
+		{desc: "not uploaded", status: cdn.FileStatus{ContentGeneration: 3}},
+		{desc: "malformed checksum", status: cdn.FileStatus{Uploaded: true, Checksum: "abc123", ContentGeneration: 3}, wantErr: "status.checksum"},
+		{desc: "uppercase checksum", status: cdn.FileStatus{Uploaded: true, Checksum: strings.ToUpper(checksum), ContentGeneration: 3}, wantErr: "status.checksum"},
+		{desc: "uploaded without checksum", status: cdn.FileStatus{Uploaded: true, ContentGeneration: 3}, wantErr: "status.checksum"},
+		{desc: "decreasing content generation", status: cdn.FileStatus{Uploaded: true, Checksum: checksum, ContentGeneration: 2}, wantErr: "status.contentGeneration"},
+		{desc: "negative content generation", status: cdn.FileStatus{Uploaded: true, Checksum: checksum, ContentGeneration: -1}, wantErr: "status.contentGeneration"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			updated := old.DeepCopy()
+			updated.Status = tc.status
+			errs := NewStatusStrategy(NewStrategy(nil)).ValidateUpdate(context.Background(), updated, old)
+			if tc.wantErr == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			if assert.NotEmpty(t, errs) {
+				assert.Equal(t, tc.wantErr, errs[0].Field)
+			}
+		})
+	}
+}
@@ -42,6 +42,7 @@ func (fileTableConvertor) ConvertToTable(ctx context.Context, object runtime.Obj
 		// Wide columns (Priority: 1 means only shown with -o wide)
 		{Name: "Content-Type", Type: "string", Priority: 1, Description: "MIME type of the file"},
 		{Name: "Uploaded", Type: "boolean", Priority: 1, Description: "Whether the file has been uploaded"},
+		{Name: "Uploaded-By", Type: "string", Priority: 1, Description: "User that last uploaded the content"},
 	}
 
 	switch obj := object.(type) {
@@ -69,10 +70,19 @@ func fileToRow(file *cdn.File) metav1.TableRow {
 			// Wide columns (kubectl filters based on Priority)
 			file.Spec.ContentType,
 			file.Status.Uploaded,
+			uploadedBy(file),
 		},
 	}
 }
 
+// uploadedBy returns the user that last uploaded the content of file
+func uploadedBy(file *cdn.File) string {
+	if file.Status.LastUpload == nil || file.Status.LastUpload.User == "" {
+		return "<none>"
+	}
+	return file.Status.LastUpload.User
+}
+
 // translateTimestampSince returns the elapsed time since timestamp in
 // human-readable approximation.
 func translateTimestampSince(timestamp metav1.Time) string {
@@ -0,0 +1,158 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purge
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/registry/generic"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/apiserver/pkg/storage/names"
+	"sigs.k8s.io/structured-merge-diff/v6/fieldpath"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/apis/cdn/validation"
+)
+
+// NewStrategy creates and returns a purgeStrategy instance
+func NewStrategy(typer runtime.ObjectTyper) purgeStrategy {
+	return purgeStrategy{typer, names.SimpleNameGenerator}
+}
+
+// GetAttrs returns labels.Set, fields.Set, and error in case the given runtime.Object is not a Purge
+func GetAttrs(obj runtime.Object) (labels.Set, fields.Set, error) {
+	purge, ok := obj.(*cdn.Purge)
+	if !ok {
+		return nil, nil, fmt.Errorf("given object is not a Purge")
+	}
+	return labels.Set(purge.ObjectMeta.Labels), SelectableFields(purge), nil
+}
+
+// MatchPurge is the filter used by the generic etcd backend to watch events
+// from etcd to clients of the apiserver only interested in specific labels/fields.
+func MatchPurge(label labels.Selector, field fields.Selector) storage.SelectionPredicate {
+	return storage.SelectionPredicate{
+		Label:    label,
+		Field:    field,
+		GetAttrs: GetAttrs,
+	}
+}
+
+// SelectableFields returns a field set that represents the object.
+func SelectableFields(obj *cdn.Purge) fields.Set {
+	return generic.ObjectMetaFieldsSet(&obj.ObjectMeta, true)
+}
+
+type purgeStrategy struct {
+	runtime.ObjectTyper
+	names.NameGenerator
+}
+
+func (purgeStrategy) NamespaceScoped() bool {
+	return true
+}
+
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (purgeStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		"cdn.k8s.toms.place/v1alpha1": fieldpath.NewSet(
+			fieldpath.MakePathOrDie("status"),
+		),
+	}
+}
+
+func (purgeStrategy) PrepareForCreate(ctx context.Context, obj runtime.Object) {
+	purge := obj.(*cdn.Purge)
+	purge.Status = cdn.PurgeStatus{}
+	purge.Generation = 1
+}
+
+func (purgeStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	newPurge := obj.(*cdn.Purge)
+	oldPurge := old.(*cdn.Purge)
+	newPurge.Status = oldPurge.Status
+}
+
+func (purgeStrategy) Validate(ctx context.Context, obj runtime.Object) field.ErrorList {
+	purge := obj.(*cdn.Purge)
+	return validation.ValidatePurge(purge)
+}
+
+// WarningsOnCreate returns warnings for the creation of the given object.
+func (purgeStrategy) WarningsOnCreate(ctx context.Context, obj runtime.Object) []string { return nil }
+
+func (purgeStrategy) AllowCreateOnUpdate() bool {
+	return false
+}
+
+func (purgeStrategy) AllowUnconditionalUpdate() bool {
+	return false
+}
+
+func (purgeStrategy) Canonicalize(obj runtime.Object) {
+}
+
+func (purgeStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object) field.ErrorList {
+	return validation.ValidatePurgeUpdate(obj.(*cdn.Purge), old.(*cdn.Purge))
+}
+
+// WarningsOnUpdate returns warnings for the given update.
+func (purgeStrategy) WarningsOnUpdate(ctx context.Context, obj, old runtime.Object) []string {
+	return nil
+}
+
+type purgeStatusStrategy struct {
+	purgeStrategy
+}
+
+// NewStatusStrategy creates a strategy for updating the status subresource.
+func NewStatusStrategy(strategy purgeStrategy) purgeStatusStrategy {
+	return purgeStatusStrategy{strategy}
+}
+
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (purgeStatusStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		"cdn.k8s.toms.place/v1alpha1": fieldpath.NewSet(
+			fieldpath.MakePathOrDie("spec"),
+		),
+	}
+}
+
+func (purgeStatusStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	newPurge := obj.(*cdn.Purge)
+	oldPurge := old.(*cdn.Purge)
+	newPurge.Spec = oldPurge.Spec
+	newPurge.Labels = oldPurge.Labels
+	newPurge.Annotations = oldPurge.Annotations
+}
+
+func (purgeStatusStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object) field.ErrorList {
+	return nil
+}
+
+// WarningsOnUpdate returns warnings for the given update.
+func (purgeStatusStrategy) WarningsOnUpdate(ctx context.Context, obj, old runtime.Object) []string {
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package purge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+)
+
+type purgeTableConvertor struct{}
+
+var _ rest.TableConvertor = purgeTableConvertor{}
+
+func (purgeTableConvertor) ConvertToTable(ctx context.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	var table metav1.Table
+
+	table.ColumnDefinitions = []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Files", Type: "integer", Description: "Number of purged Files"},
+		{Name: "Webhooks", Type: "string", Description: "Notified downstream webhooks out of all configured"},
+		{Name: "Completed", Type: "string", Description: "Time since the purge completed"},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+	}
+
+	switch obj := object.(type) {
+	case *cdn.PurgeList:
+		table.ResourceVersion = obj.ResourceVersion
+		table.Continue = obj.Continue
+		for i := range obj.Items {
+			table.Rows = append(table.Rows, purgeToRow(&obj.Items[i]))
+		}
+	case *cdn.Purge:
+		table.ResourceVersion = obj.ResourceVersion
+		table.Rows = append(table.Rows, purgeToRow(obj))
+	}
+
+	return &table, nil
+}
+
+func purgeToRow(purge *cdn.Purge) metav1.TableRow {
+	age := "<unknown>"
+	if !purge.CreationTimestamp.IsZero() {
+		age = duration.HumanDuration(time.Since(purge.CreationTimestamp.Time))
+	}
+	completed := "<pending>"
+	if purge.Status.CompletionTime != nil {
+		completed = duration.HumanDuration(time.Since(purge.Status.CompletionTime.Time))
+	}
+	notified := 0
+	for _, webhook := range purge.Status.Webhooks {
+		if webhook.Error == "" {
+			notified++
+		}
+	}
+	return metav1.TableRow{
+		Object: runtime.RawExtension{Object: purge},
+		Cells: []interface{}{
+			purge.Name,
+			len(purge.Status.Files),
+			fmt.Sprintf("%d/%d", notified, len(purge.Status.Webhooks)),
+			completed,
+			age,
+		},
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+// FilePath returns the URL path a File selected by a Site is served at.
+func FilePath(name string, annotations map[string]string) string {
+	if p, ok := annotations[cdnv1alpha1.AnnotationPath]; ok && strings.HasPrefix(p, "/") {
+		return path.Clean(p)
+	}
+	return "/" + name
+}
+
+// Router resolves URL paths to File names for a Site.
+type Router struct {
+	routes        map[string]string
+	indexDocument string
+	notFound      string
+	spaFallback   bool
+}
+
+// NewRouter returns an empty Router for a Site with the given documents.
+func NewRouter(indexDocument, notFoundDocument string, spaFallback bool) *Router {
+	return &Router{
+		routes:        map[string]string{},
+		indexDocument: indexDocument,
+		notFound:      notFoundDocument,
+		spaFallback:   spaFallback,
+	}
+}
+
+// Add routes p to the named File, replacing any previous route for p.
+func (r *Router) Add(p, name string) {
+	r.routes[path.Clean(p)] = name
+}
+
+// Files returns the names of all Files reachable through the router.
+func (r *Router) Files() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, name := range r.routes {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Lookup returns the File routed at exactly p, trying the index document
+// for directory-style paths.
+func (r *Router) Lookup(p string) (string, bool) {
+	if !strings.HasSuffix(p, "/") {
+		if name, ok := r.routes[path.Clean("/"+p)]; ok {
+			return name, true
+		}
+	}
+	name, ok := r.routes[path.Join("/", p, r.indexDocument)]
+	return name, ok
+}
+
+// Resolve returns the File to serve for p and the status code to serve it
+// with, applying the SPA fallback and the not found document.
+func (r *Router) Resolve(p string) (string, int, bool) {
+	if name, ok := r.Lookup(p); ok {
+		return name, http.StatusOK, true
+	}
+	return r.Fallback()
+}
+
+// Fallback returns the File served when a path cannot be resolved.
+func (r *Router) Fallback() (string, int, bool) {
+	if r.spaFallback {
+		if name, ok := r.Lookup("/"); ok {
+			return name, http.StatusOK, true
+		}
+	}
+	if r.notFound != "" {
+		if name, ok := r.Lookup(r.notFound); ok {
+			return name, http.StatusNotFound, true
+		}
+	}
+	return "", http.StatusNotFound, false
+}
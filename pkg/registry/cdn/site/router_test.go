@@ -0,0 +1,68 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterResolve(t *testing.T) {
+	newRouter := func(notFound string, spa bool) *Router {
+		r := NewRouter("index.html", notFound, spa)
+		r.Add("/index.html", "home")
+		r.Add("/docs/index.html", "docs")
+		r.Add("/docs/getting-started", "getting-started")
+		r.Add("/404.html", "not-found")
+		return r
+	}
+
+	testCases := []struct {
+		desc     string
+		router   *Router
+		path     string
+		wantFile string
+		wantCode int
+		wantOK   bool
+	}{
+		{desc: "root index", router: newRouter("", false), path: "/", wantFile: "home", wantCode: http.StatusOK, wantOK: true},
+		{desc: "exact path", router: newRouter("", false), path: "/docs/getting-started", wantFile: "getting-started", wantCode: http.StatusOK, wantOK: true},
+		{desc: "directory without slash", router: newRouter("", false), path: "/docs", wantFile: "docs", wantCode: http.StatusOK, wantOK: true},
+		{desc: "directory with slash", router: newRouter("", false), path: "/docs/", wantFile: "docs", wantCode: http.StatusOK, wantOK: true},
+		{desc: "unclean path", router: newRouter("", false), path: "/docs/../docs/getting-started", wantFile: "getting-started", wantCode: http.StatusOK, wantOK: true},
+		{desc: "not found", router: newRouter("", false), path: "/missing", wantCode: http.StatusNotFound},
+		{desc: "not found document", router: newRouter("/404.html", false), path: "/missing", wantFile: "not-found", wantCode: http.StatusNotFound, wantOK: true},
+		{desc: "spa fallback", router: newRouter("/404.html", true), path: "/app/route", wantFile: "home", wantCode: http.StatusOK, wantOK: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			file, code, ok := tc.router.Resolve(tc.path)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantCode, code)
+			assert.Equal(t, tc.wantFile, file)
+		})
+	}
+}
+
+func TestFilePath(t *testing.T) {
+	assert.Equal(t, "/index.html", FilePath("index.html", nil))
+	assert.Equal(t, "/docs/getting-started", FilePath("docs-start", map[string]string{"cdn.k8s.toms.place/path": "/docs/getting-started"}))
+	assert.Equal(t, "/style.css", FilePath("style.css", map[string]string{"cdn.k8s.toms.place/path": "relative"}))
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	cdninformers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions/cdn/v1alpha1"
+	cdnlisters "k8s.toms.place/apiserver/pkg/generated/listers/cdn/v1alpha1"
+)
+
+// RouterCache keeps the Router of each served Site, built from the Files in
+// the File informer's cache. A Router is rebuilt once its Site changes or a
+// File of its namespace is added, deleted or relabelled.
+type RouterCache struct {
+	files  cdnlisters.FileLister
+	synced cache.InformerSynced
+
+	lock sync.Mutex
+	// generations counts the File changes per namespace
+	generations map[string]uint64
+	routers     map[types.NamespacedName]*cachedRouter
+}
+
+type cachedRouter struct {
+	siteVersion string
+	generation  uint64
+	router      *Router
+}
+
+// NewRouterCache returns a RouterCache watching fileInformer, or nil if
+// fileInformer is nil.
+func NewRouterCache(fileInformer cdninformers.FileInformer) (*RouterCache, error) {
+	if fileInformer == nil {
+		return nil, nil
+	}
+	c := &RouterCache{
+		files:       fileInformer.Lister(),
+		synced:      fileInformer.Informer().HasSynced,
+		generations: map[string]uint64{},
+		routers:     map[types.NamespacedName]*cachedRouter{},
+	}
+	if _, err := fileInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.fileChanged,
+		UpdateFunc: func(old, obj interface{}) {
+			if routesChanged(old, obj) {
+				c.fileChanged(obj)
+			}
+		},
+		DeleteFunc: c.fileChanged,
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Router returns the Router of site. ok is false if the cache cannot build
+// it because it is nil or the informer has not synced yet.
+func (c *RouterCache) Router(site *cdn.Site) (router *Router, ok bool, err error) {
+	if c == nil || !c.synced() {
+		return nil, false, nil
+	}
+	key := types.NamespacedName{Namespace: site.Namespace, Name: site.Name}
+
+	c.lock.Lock()
+	generation := c.generations[site.Namespace]
+	cached, found := c.routers[key]
+	c.lock.Unlock()
+	if found && cached.siteVersion == site.ResourceVersion && cached.generation == generation {
+		return cached.router, true, nil
+	}
+
+	// Files changed while building bump the generation, so the Router is
+	// rebuilt on the next request
+	router, err = newSiteRouter(site, func(selector labels.Selector) ([]metav1.ObjectMeta, error) {
+		files, err := c.files.Files(site.Namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		metas := make([]metav1.ObjectMeta, 0, len(files))
+		for _, file := range files {
+			metas = append(metas, file.ObjectMeta)
+		}
+		return metas, nil
+	})
+	if err != nil {
+		return nil, true, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.routers[key] = &cachedRouter{siteVersion: site.ResourceVersion, generation: generation, router: router}
+	return router, true, nil
+}
+
+// fileChanged invalidates the Routers of the namespace of obj. Routers of
+// deleted Sites in the namespace are dropped with them.
+func (c *RouterCache) fileChanged(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	file, ok := obj.(*cdnv1alpha1.File)
+	if !ok {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generations[file.Namespace]++
+	for key := range c.routers {
+		if key.Namespace == file.Namespace {
+			delete(c.routers, key)
+		}
+	}
+}
+
+// routesChanged returns whether a File update may change the routes of Sites,
+// which only depend on its name, labels and path annotation
+func routesChanged(old, obj interface{}) bool {
+	oldFile, ok := old.(*cdnv1alpha1.File)
+	if !ok {
+		return true
+	}
+	file, ok := obj.(*cdnv1alpha1.File)
+	if !ok {
+		return true
+	}
+	return !labels.Equals(oldFile.Labels, file.Labels) ||
+		oldFile.Annotations[cdnv1alpha1.AnnotationPath] != file.Annotations[cdnv1alpha1.AnnotationPath]
+}
+
+// newSiteRouter builds the Router of site from the Files list returns for its
+// selector and its explicit routes, which take precedence.
+func newSiteRouter(site *cdn.Site, list func(labels.Selector) ([]metav1.ObjectMeta, error)) (*Router, error) {
+	router := NewRouter(site.Spec.IndexDocument, site.Spec.NotFoundDocument, site.Spec.SPAFallback)
+
+	if site.Spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(site.Spec.Selector)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(err.Error())
+		}
+		files, err := list(selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			router.Add(FilePath(file.Name, file.Annotations), file.Name)
+		}
+	}
+	for _, route := range site.Spec.Routes {
+		router.Add(route.Path, route.File)
+	}
+	return router, nil
+}
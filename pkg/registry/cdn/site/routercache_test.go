@@ -0,0 +1,98 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/fake"
+	informers "k8s.toms.place/apiserver/pkg/generated/informers/externalversions"
+)
+
+func TestRouterCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	file := func(name string, labels map[string]string) *cdnv1alpha1.File {
+		return &cdnv1alpha1.File{ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: name, Labels: labels}}
+	}
+	client := fake.NewSimpleClientset(file("index.html", map[string]string{"app": "web"}))
+	factory := informers.NewSharedInformerFactory(client, 0)
+	routers, err := NewRouterCache(factory.Cdn().V1alpha1().Files())
+	require.NoError(t, err)
+
+	site := &cdn.Site{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "www", ResourceVersion: "1"},
+		Spec: cdn.SiteSpec{
+			IndexDocument: "index.html",
+			Selector:      &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+
+	// Until the informer synced, the Router is not cached
+	_, ok, err := routers.Router(site)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	router, ok, err := routers.Router(site)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []string{"index.html"}, router.Files())
+	cached, _, _ := routers.Router(site)
+	assert.Same(t, router, cached)
+
+	// Status updates don't change the routes
+	updated := file("index.html", map[string]string{"app": "web"})
+	updated.Status.Checksum = "abc"
+	_, err = client.CdnV1alpha1().Files("web").UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	// Files of other namespaces don't either
+	_, err = client.CdnV1alpha1().Files("other").Create(ctx, &cdnv1alpha1.File{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "a", Labels: map[string]string{"app": "web"}}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// New Files of the namespace are routed once the informer saw them
+	_, err = client.CdnV1alpha1().Files("web").Create(ctx, file("about.html", map[string]string{"app": "web"}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		router, _, _ := routers.Router(site)
+		name, code, ok := router.Resolve("/about.html")
+		return ok && name == "about.html" && code == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A changed Site gets a new Router
+	router, _, _ = routers.Router(site)
+	site = site.DeepCopy()
+	site.ResourceVersion = "2"
+	site.Spec.Routes = []cdn.SiteRoute{{Path: "/about", File: "about.html"}}
+	changed, _, err := routers.Router(site)
+	require.NoError(t, err)
+	assert.NotSame(t, router, changed)
+	name, _, _ := changed.Resolve("/about")
+	assert.Equal(t, "about.html", name)
+}
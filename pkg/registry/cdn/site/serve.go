@@ -0,0 +1,249 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/content"
+	"k8s.toms.place/apiserver/pkg/events"
+	"k8s.toms.place/apiserver/pkg/registry"
+	filestorage "k8s.toms.place/apiserver/pkg/registry/cdn/file"
+)
+
+// ServeREST implements rest.Connecter for serving a Site
+type ServeREST struct {
+	siteStore    *registry.REST
+	fileStore    *registry.REST
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
+	routers      *RouterCache
+}
+
+// NewServeREST creates a new ServeREST. authorizer checks that the user may
+// get the content of every served File; if nil, it is not checked. Routers
+// are taken from routers; if nil, or until its informer synced, every request
+// lists the Files of the Site.
+func NewServeREST(siteStore, fileStore *registry.REST, contentStore content.Store, recorder record.EventRecorder, authorizer authorizer.Authorizer, routers *RouterCache) *ServeREST {
+	return &ServeREST{
+		siteStore:    siteStore,
+		fileStore:    fileStore,
+		contentStore: contentStore,
+		recorder:     recorder,
+		authorizer:   authorizer,
+		routers:      routers,
+	}
+}
+
+var _ rest.Connecter = &ServeREST{}
+var _ rest.StorageMetadata = &ServeREST{}
+
+// New returns an empty object that can be used with Create and Update
+func (r *ServeREST) New() runtime.Object {
+	return &cdn.SiteServeOptions{}
+}
+
+// Destroy cleans up resources on shutdown
+func (r *ServeREST) Destroy() {}
+
+// Connect returns an http.Handler that serves the Site
+func (r *ServeREST) Connect(ctx context.Context, name string, options runtime.Object, responder rest.Responder) (http.Handler, error) {
+	opts, ok := options.(*cdn.SiteServeOptions)
+	if !ok {
+		return nil, fmt.Errorf("invalid options object: %#v", options)
+	}
+
+	return &serveHandler{
+		ctx:          ctx,
+		siteStore:    r.siteStore,
+		fileStore:    r.fileStore,
+		contentStore: r.contentStore,
+		recorder:     r.recorder,
+		authorizer:   r.authorizer,
+		routers:      r.routers,
+		name:         name,
+		options:      opts,
+		responder:    responder,
+	}, nil
+}
+
+// NewConnectOptions returns an empty options object for the Connect method
+func (r *ServeREST) NewConnectOptions() (runtime.Object, bool, string) {
+	return &cdn.SiteServeOptions{}, true, "path"
+}
+
+// ConnectMethods returns the list of HTTP methods handled by Connect
+func (r *ServeREST) ConnectMethods() []string {
+	return []string{"GET", "HEAD"}
+}
+
+// ProducesMIMETypes returns a list of MIME types the verb can respond with
+func (r *ServeREST) ProducesMIMETypes(verb string) []string {
+	return []string{"*/*"}
+}
+
+// ProducesObject returns the object the verb responds with
+func (r *ServeREST) ProducesObject(verb string) interface{} {
+	return nil
+}
+
+// serveHandler resolves a request path through a Site and streams the File content
+type serveHandler struct {
+	ctx          context.Context
+	siteStore    *registry.REST
+	fileStore    *registry.REST
+	contentStore content.Store
+	recorder     record.EventRecorder
+	authorizer   authorizer.Authorizer
+	routers      *RouterCache
+	name         string
+	options      *cdn.SiteServeOptions
+	responder    rest.Responder
+}
+
+// ServeHTTP handles GET and HEAD requests for a path of the Site
+func (h *serveHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var headOnly bool
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodHead:
+		headOnly = true
+	default:
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	obj, err := h.siteStore.Get(h.ctx, h.name, &metav1.GetOptions{})
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+	site, ok := obj.(*cdn.Site)
+	if !ok {
+		h.responder.Error(fmt.Errorf("object is not a Site"))
+		return
+	}
+
+	router, err := h.router(site)
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+
+	p := path.Clean("/" + h.options.Path)
+
+	fileName, code, ok := router.Resolve(p)
+	if !ok {
+		h.responder.Error(apierrors.NewNotFound(cdn.Resource("sites/serve"), p))
+		return
+	}
+	file, entry, err := h.load(fileName)
+	if apierrors.IsNotFound(err) && code == http.StatusOK {
+		// The route exists but its File does not have content yet
+		if fileName, code, ok = router.Fallback(); ok {
+			file, entry, err = h.load(fileName)
+		}
+	}
+	if err != nil {
+		h.responder.Error(err)
+		return
+	}
+
+	for _, rule := range site.Spec.Headers {
+		if strings.HasPrefix(p, rule.PathPrefix) {
+			for _, header := range rule.Headers {
+				w.Header().Set(header.Name, header.Value)
+			}
+		}
+	}
+
+	// The File spec is authoritative for the content type
+	served := *entry
+	served.ContentType = file.Spec.ContentType
+	etag := content.ETag(entry.Checksum, file.Status.ContentGeneration)
+	if code == http.StatusOK {
+		// Answers conditional and Range requests
+		content.ServeObject(w, req, &served, etag)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	content.WriteObject(w, &served, code, headOnly)
+}
+
+// router returns the Router for the Site from the Files matched by its
+// selector and its explicit routes, which take precedence.
+func (h *serveHandler) router(site *cdn.Site) (*Router, error) {
+	if router, ok, err := h.routers.Router(site); ok {
+		return router, err
+	}
+	return newSiteRouter(site, func(selector labels.Selector) ([]metav1.ObjectMeta, error) {
+		obj, err := h.fileStore.List(h.ctx, &metainternalversion.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		files := obj.(*cdn.FileList).Items
+		metas := make([]metav1.ObjectMeta, 0, len(files))
+		for _, file := range files {
+			metas = append(metas, file.ObjectMeta)
+		}
+		return metas, nil
+	})
+}
+
+// load returns the File and its stored content, or a NotFound error. Serving
+// a File requires "get files/content" on it, like downloading it, whether it
+// was selected or routed explicitly.
+func (h *serveHandler) load(name string) (*cdn.File, *content.Object, error) {
+	if err := filestorage.Authorize(h.ctx, h.authorizer, "get", "content", name); err != nil {
+		return nil, nil, err
+	}
+	obj, err := h.fileStore.Get(h.ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	file, ok := obj.(*cdn.File)
+	if !ok {
+		return nil, nil, fmt.Errorf("object is not a File")
+	}
+
+	entry, err := h.contentStore.Get(h.ctx, request.NamespaceValue(h.ctx), name)
+	if content.IsNotFound(err) {
+		return nil, nil, apierrors.NewNotFound(cdn.Resource("file"), name)
+	}
+	if err != nil {
+		h.recorder.Eventf(events.FileReference(file), corev1.EventTypeWarning, events.ReasonOriginFetchFailed, "Failed to read content: %v", err)
+		return nil, nil, err
+	}
+	return file, entry, nil
+}
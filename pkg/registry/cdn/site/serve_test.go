@@ -0,0 +1,53 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestServeHandlerLoadAuthorizes(t *testing.T) {
+	ctx := request.WithNamespace(context.Background(), "web")
+	ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "jane"})
+
+	var got []authorizer.Attributes
+	authz := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		got = append(got, a)
+		return authorizer.DecisionNoOpinion, "not allowed", nil
+	})
+
+	// The File is not read when the user may not get its content
+	h := &serveHandler{ctx: ctx, authorizer: authz}
+	_, _, err := h.load("secret")
+	assert.True(t, apierrors.IsForbidden(err), "expected Forbidden, got %v", err)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "get", got[0].GetVerb())
+	assert.Equal(t, "web", got[0].GetNamespace())
+	assert.Equal(t, "files", got[0].GetResource())
+	assert.Equal(t, "content", got[0].GetSubresource())
+	assert.Equal(t, "secret", got[0].GetName())
+}
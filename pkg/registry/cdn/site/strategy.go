@@ -0,0 +1,164 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"context"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/registry/generic"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/apiserver/pkg/storage/names"
+	"sigs.k8s.io/structured-merge-diff/v6/fieldpath"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+	"k8s.toms.place/apiserver/pkg/apis/cdn/validation"
+)
+
+// NewStrategy creates and returns a siteStrategy instance
+func NewStrategy(typer runtime.ObjectTyper) siteStrategy {
+	return siteStrategy{typer, names.SimpleNameGenerator}
+}
+
+// GetAttrs returns labels.Set, fields.Set, and error in case the given runtime.Object is not a Site
+func GetAttrs(obj runtime.Object) (labels.Set, fields.Set, error) {
+	site, ok := obj.(*cdn.Site)
+	if !ok {
+		return nil, nil, fmt.Errorf("given object is not a Site")
+	}
+	return labels.Set(site.ObjectMeta.Labels), SelectableFields(site), nil
+}
+
+// MatchSite is the filter used by the generic etcd backend to watch events
+// from etcd to clients of the apiserver only interested in specific labels/fields.
+func MatchSite(label labels.Selector, field fields.Selector) storage.SelectionPredicate {
+	return storage.SelectionPredicate{
+		Label:    label,
+		Field:    field,
+		GetAttrs: GetAttrs,
+	}
+}
+
+// SelectableFields returns a field set that represents the object.
+func SelectableFields(obj *cdn.Site) fields.Set {
+	return generic.ObjectMetaFieldsSet(&obj.ObjectMeta, true)
+}
+
+type siteStrategy struct {
+	runtime.ObjectTyper
+	names.NameGenerator
+}
+
+func (siteStrategy) NamespaceScoped() bool {
+	return true
+}
+
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (siteStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		"cdn.k8s.toms.place/v1alpha1": fieldpath.NewSet(
+			fieldpath.MakePathOrDie("status"),
+		),
+	}
+}
+
+func (siteStrategy) PrepareForCreate(ctx context.Context, obj runtime.Object) {
+	site := obj.(*cdn.Site)
+	site.Status = cdn.SiteStatus{}
+	site.Generation = 1
+}
+
+func (siteStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	newSite := obj.(*cdn.Site)
+	oldSite := old.(*cdn.Site)
+	newSite.Status = oldSite.Status
+
+	if !apiequality.Semantic.DeepEqual(newSite.Spec, oldSite.Spec) {
+		newSite.Generation = oldSite.Generation + 1
+	}
+}
+
+func (siteStrategy) Validate(ctx context.Context, obj runtime.Object) field.ErrorList {
+	site := obj.(*cdn.Site)
+	return validation.ValidateSite(site)
+}
+
+// WarningsOnCreate returns warnings for the creation of the given object.
+func (siteStrategy) WarningsOnCreate(ctx context.Context, obj runtime.Object) []string { return nil }
+
+func (siteStrategy) AllowCreateOnUpdate() bool {
+	return false
+}
+
+func (siteStrategy) AllowUnconditionalUpdate() bool {
+	return false
+}
+
+func (siteStrategy) Canonicalize(obj runtime.Object) {
+}
+
+func (siteStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object) field.ErrorList {
+	site := obj.(*cdn.Site)
+	return validation.ValidateSite(site)
+}
+
+// WarningsOnUpdate returns warnings for the given update.
+func (siteStrategy) WarningsOnUpdate(ctx context.Context, obj, old runtime.Object) []string {
+	return nil
+}
+
+type siteStatusStrategy struct {
+	siteStrategy
+}
+
+// NewStatusStrategy creates a strategy for updating the status subresource.
+func NewStatusStrategy(strategy siteStrategy) siteStatusStrategy {
+	return siteStatusStrategy{strategy}
+}
+
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (siteStatusStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		"cdn.k8s.toms.place/v1alpha1": fieldpath.NewSet(
+			fieldpath.MakePathOrDie("spec"),
+		),
+	}
+}
+
+func (siteStatusStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	newSite := obj.(*cdn.Site)
+	oldSite := old.(*cdn.Site)
+	newSite.Spec = oldSite.Spec
+	newSite.Labels = oldSite.Labels
+	newSite.Annotations = oldSite.Annotations
+}
+
+func (siteStatusStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object) field.ErrorList {
+	return nil
+}
+
+// WarningsOnUpdate returns warnings for the given update.
+func (siteStatusStrategy) WarningsOnUpdate(ctx context.Context, obj, old runtime.Object) []string {
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package site
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"k8s.toms.place/apiserver/pkg/apis/cdn"
+)
+
+type siteTableConvertor struct{}
+
+var _ rest.TableConvertor = siteTableConvertor{}
+
+func (siteTableConvertor) ConvertToTable(ctx context.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	var table metav1.Table
+
+	table.ColumnDefinitions = []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+		{Name: "Files", Type: "integer", Description: "Number of Files served by the site"},
+		{Name: "Size", Type: "integer", Description: "Total size of the site in bytes"},
+		{Name: "Missing", Type: "integer", Description: "Number of referenced Files without content"},
+		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+		// Wide columns (Priority: 1 means only shown with -o wide)
+		{Name: "Index", Type: "string", Priority: 1, Description: "Index document of the site"},
+		{Name: "SPA", Type: "boolean", Priority: 1, Description: "Whether unmatched paths fall back to the index document"},
+	}
+
+	switch obj := object.(type) {
+	case *cdn.SiteList:
+		table.ResourceVersion = obj.ResourceVersion
+		table.Continue = obj.Continue
+		for i := range obj.Items {
+			table.Rows = append(table.Rows, siteToRow(&obj.Items[i]))
+		}
+	case *cdn.Site:
+		table.ResourceVersion = obj.ResourceVersion
+		table.Rows = append(table.Rows, siteToRow(obj))
+	}
+
+	return &table, nil
+}
+
+func siteToRow(site *cdn.Site) metav1.TableRow {
+	age := "<unknown>"
+	if !site.CreationTimestamp.IsZero() {
+		age = duration.HumanDuration(time.Since(site.CreationTimestamp.Time))
+	}
+	return metav1.TableRow{
+		Object: runtime.RawExtension{Object: site},
+		Cells: []interface{}{
+			site.Name,
+			site.Status.Files,
+			site.Status.TotalSize,
+			len(site.Status.MissingFiles),
+			age,
+			// Wide columns (kubectl filters based on Priority)
+			site.Spec.IndexDocument,
+			site.Spec.SPAFallback,
+		},
+	}
+}
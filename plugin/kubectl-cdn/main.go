@@ -20,12 +20,14 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
 
 	"k8s.toms.place/apiserver/plugin/kubectl-cdn/pkg/cmd"
 )
 
 func main() {
-	streams := cmd.IOStreams{
+	streams := genericiooptions.IOStreams{
 		In:     os.Stdin,
 		Out:    os.Stdout,
 		ErrOut: os.Stderr,
@@ -37,9 +39,27 @@ func main() {
 		Long:  "A kubectl plugin to upload and manage file content in the files.cdn.k8s.toms.place API",
 	}
 
-	rootCmd.AddCommand(cmd.NewCmdUpload(streams))
-	rootCmd.AddCommand(cmd.NewCmdGet(streams))
-	rootCmd.AddCommand(cmd.NewCmdList(streams))
+	// Connection flags shared by all commands, as in kubectl
+	configFlags := genericclioptions.NewConfigFlags(true)
+	configFlags.AddFlags(rootCmd.PersistentFlags())
+
+	rootCmd.AddCommand(cmd.NewCmdUpload(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdGet(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdList(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdBackup(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdRestore(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdFsck(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdSync(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdRemove(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdCopy(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdMove(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdWatch(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdTail(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdDescribe(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdEdit(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdServe(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdDiskUsage(configFlags, streams))
+	rootCmd.AddCommand(cmd.NewCmdFind(configFlags, streams))
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
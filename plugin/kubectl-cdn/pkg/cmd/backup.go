@@ -0,0 +1,408 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// BackupFormatVersion is the version of the backup archive format written by
+// the backup command. Restore refuses archives with a newer version.
+const BackupFormatVersion = 1
+
+const (
+	// backupManifestName is the archive entry holding the BackupManifest. It
+	// is written last, once all blobs are known.
+	backupManifestName = "backup.json"
+	// backupBlobPrefix is the directory of the content blobs, which are
+	// named after their SHA-256 checksum.
+	backupBlobPrefix = "blobs/sha256/"
+	// backupFetchAttempts bounds how often a File is re-read when its
+	// content changes while it is backed up
+	backupFetchAttempts = 3
+)
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	// Version of the archive format
+	Version int `json:"version"`
+	// ID identifies the backup; incremental backups refer to their base by ID
+	ID string `json:"id"`
+	// Created is when the backup was taken
+	Created metav1.Time `json:"created"`
+	// Base is the ID of the backup this one is incremental to. Blobs that
+	// are in the base chain are not repeated in this archive.
+	Base string `json:"base,omitempty"`
+	// Namespaces the backup was restricted to, or empty for all namespaces
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Files holds every backed up File, including those whose blob is in the
+	// base chain
+	Files []BackupFile `json:"files"`
+	// Blobs lists the checksums of the blobs stored in this archive
+	Blobs []string `json:"blobs"`
+}
+
+// BackupFile is one File in a backup
+type BackupFile struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Checksum is the SHA-256 checksum of the content, or empty if the File
+	// had no content
+	Checksum    string `json:"checksum,omitempty"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType,omitempty"`
+	// Object is the File as returned by the API server
+	Object *cdnv1alpha1.File `json:"object"`
+}
+
+// BackupOptions holds the options for the backup command
+type BackupOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Output archive path
+	OutputPath string
+	// Namespaces to back up; all namespaces if empty
+	Namespaces []string
+	// Path of a previous backup to take an incremental backup against
+	Incremental string
+	// Page size of File lists; 0 lists all Files at once
+	ChunkSize int64
+}
+
+// NewBackupOptions creates new BackupOptions with default values
+func NewBackupOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *BackupOptions {
+	return &BackupOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		ChunkSize:   defaultChunkSize,
+	}
+}
+
+// NewCmdBackup creates the backup command
+func NewCmdBackup(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewBackupOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "backup -o [archive]",
+		Short: "Back up Files and their content to an archive",
+		Long: `Back up File resources and their content to a tar.gz archive.
+
+The archive holds a versioned backup.json manifest with every File object and
+its checksum, and one blob per distinct content under blobs/sha256/. Files are
+listed at a single resource version, and each blob is verified against the
+checksum of the File it is stored for, so metadata and content in the archive
+always match.
+
+An incremental backup only stores the blobs that are not already in the given
+previous backup or its base chain. Restore it together with its base archives.
+
+Examples:
+  # Back up all namespaces
+  kubectl cdn backup -o backup-full.tgz
+
+  # Back up only some namespaces
+  kubectl cdn backup -n web -n docs -o backup-web.tgz
+
+  # Take an incremental backup against a previous one
+  kubectl cdn backup --incremental backup-full.tgz -o backup-1.tgz
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.OutputPath, "output", "o", "", "Path of the archive to write")
+	cmd.Flags().StringSliceVarP(&o.Namespaces, "namespace", "n", nil, "Namespaces to back up (default all namespaces)")
+	cmd.Flags().StringVar(&o.Incremental, "incremental", "", "Previous backup archive to take an incremental backup against")
+	addChunkSizeFlag(cmd, &o.ChunkSize)
+	cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+// Run executes the backup command
+func (o *BackupOptions) Run() error {
+	ctx := context.Background()
+
+	manifest := &BackupManifest{
+		Version:    BackupFormatVersion,
+		ID:         newBackupID(),
+		Created:    metav1.Now(),
+		Namespaces: o.Namespaces,
+		Files:      []BackupFile{},
+		Blobs:      []string{},
+	}
+
+	// Blobs in the base chain are not stored again
+	inBase := map[string]bool{}
+	if o.Incremental != "" {
+		base, err := readBackupManifest(o.Incremental)
+		if err != nil {
+			return err
+		}
+		manifest.Base = base.ID
+		for _, f := range base.Files {
+			if f.Checksum != "" {
+				inBase[f.Checksum] = true
+			}
+		}
+	}
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+
+	files, err := listBackupFiles(ctx, client, o.Namespaces, o.ChunkSize)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file next to the output, so a failed backup never
+	// leaves a truncated archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(o.OutputPath), "."+filepath.Base(o.OutputPath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", o.OutputPath, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+
+	stored := map[string]bool{}
+	var storedBytes int64
+	for i := range files {
+		file := &files[i]
+		entry := BackupFile{
+			Namespace: file.Namespace,
+			Name:      file.Name,
+		}
+
+		checksum := file.Status.Checksum
+		if checksum != "" && !inBase[checksum] && !stored[checksum] {
+			var data []byte
+			file, data, err = fetchBackupContent(ctx, client, file)
+			if err != nil {
+				return err
+			}
+			checksum = file.Status.Checksum
+			if !inBase[checksum] && !stored[checksum] {
+				if err := writeTarEntry(tw, backupBlobPrefix+checksum, data); err != nil {
+					return fmt.Errorf("failed to write archive: %w", err)
+				}
+				stored[checksum] = true
+				storedBytes += int64(len(data))
+				manifest.Blobs = append(manifest.Blobs, checksum)
+			}
+		}
+
+		// Typed clients drop the kind of listed objects; keep the archived
+		// Files self-describing
+		file.APIVersion = cdnv1alpha1.SchemeGroupVersion.String()
+		file.Kind = "File"
+		file.ManagedFields = nil
+		entry.Checksum = checksum
+		entry.Size = file.Spec.Size
+		entry.ContentType = file.Spec.ContentType
+		entry.Object = file
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeTarEntry(tw, backupManifestName, data); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", o.OutputPath, err)
+	}
+	if err := os.Rename(tmp.Name(), o.OutputPath); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", o.OutputPath, err)
+	}
+
+	kind := "full"
+	if manifest.Base != "" {
+		kind = "incremental"
+	}
+	fmt.Fprintf(o.ErrOut, "✓ Saved %s backup %s to %s (%d Files, %d blobs, %d bytes of content)\n",
+		kind, manifest.ID, o.OutputPath, len(manifest.Files), len(manifest.Blobs), storedBytes)
+
+	return nil
+}
+
+// newBackupID returns a sortable, unique backup ID
+func newBackupID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// listBackupFiles lists the Files of namespaces, or of all namespaces if
+// empty. Each namespace is listed in pages of chunkSize at a single resource
+// version.
+func listBackupFiles(ctx context.Context, client cdnclient.CdnV1alpha1Interface, namespaces []string, chunkSize int64) ([]cdnv1alpha1.File, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var files []cdnv1alpha1.File
+	for _, namespace := range namespaces {
+		err := listFiles(ctx, client.Files(namespace), metav1.ListOptions{}, chunkSize, func(file *cdnv1alpha1.File) error {
+			files = append(files, *file)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Namespace != files[j].Namespace {
+			return files[i].Namespace < files[j].Namespace
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// fetchBackupContent downloads the content of file and verifies it against
+// the File's checksum. If the content was replaced since the File was listed,
+// the File is read again, so the returned File always matches the content.
+func fetchBackupContent(ctx context.Context, client cdnclient.CdnV1alpha1Interface, file *cdnv1alpha1.File) (*cdnv1alpha1.File, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		data, err := contentRequest(client, http.MethodGet, file.Namespace, file.Name).Do(ctx).Raw()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get content of %s/%s: %w", file.Namespace, file.Name, err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) == file.Status.Checksum {
+			return file, data, nil
+		}
+		if attempt == backupFetchAttempts {
+			return nil, nil, fmt.Errorf("content of %s/%s changed during the backup %d times, giving up",
+				file.Namespace, file.Name, attempt)
+		}
+
+		current, err := client.Files(file.Namespace).Get(ctx, file.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get file %s/%s: %w", file.Namespace, file.Name, err)
+		}
+		file = current
+	}
+}
+
+// writeTarEntry writes data as a regular file named name
+func writeTarEntry(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// walkBackup calls fn for every entry of the backup archive at path
+func walkBackup(path string, fn func(hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup %s: %w", path, err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", path, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read backup %s: %w", path, err)
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// readBackupManifest reads and validates the manifest of the backup archive
+// at path
+func readBackupManifest(path string) (*BackupManifest, error) {
+	var manifest *BackupManifest
+	err := walkBackup(path, func(hdr *tar.Header, r io.Reader) error {
+		if hdr.Name != backupManifestName {
+			return nil
+		}
+		manifest = &BackupManifest{}
+		if err := json.NewDecoder(r).Decode(manifest); err != nil {
+			return fmt.Errorf("failed to read manifest of backup %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a backup: it has no %s", path, backupManifestName)
+	}
+	if manifest.Version < 1 || manifest.Version > BackupFormatVersion {
+		return nil, fmt.Errorf("backup %s has unsupported format version %d", path, manifest.Version)
+	}
+	return manifest, nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// cdnIgnoreFile is the file listing the paths sync ignores, relative to the
+// synchronised directory
+const cdnIgnoreFile = ".cdnignore"
+
+// cdnIgnore holds the patterns of a .cdnignore file. The syntax follows
+// .gitignore: blank lines and lines starting with # are skipped, ! negates a
+// pattern, a trailing / only matches directories, a pattern containing a / is
+// matched against the whole path relative to the directory and any other
+// pattern against the name at any depth. *, ? and [...] match within a path
+// segment and ** across segments. The last matching pattern decides.
+type cdnIgnore []cdnIgnorePattern
+
+// cdnIgnorePattern is one line of a .cdnignore file
+type cdnIgnorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// readCdnIgnore reads the .cdnignore file at path; a missing file ignores
+// nothing
+func readCdnIgnore(path string) (cdnIgnore, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ignore cdnIgnore
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p cdnIgnorePattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		re, err := globRegexp(line, anchored)
+		if err != nil {
+			return nil, err
+		}
+		p.re = re
+		ignore = append(ignore, p)
+	}
+	return ignore, scanner.Err()
+}
+
+// Match returns whether the slash-separated path relative to the synchronised
+// directory is ignored
+func (c cdnIgnore) Match(path string, isDir bool) bool {
+	ignored := false
+	for _, p := range c {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(path) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// globRegexp compiles a .cdnignore glob. Unanchored globs match at any depth.
+func globRegexp(glob string, anchored bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	testCases := []struct {
+		glob     string
+		anchored bool
+		path     string
+		want     bool
+	}{
+		{glob: "*.log", path: "build.log", want: true},
+		{glob: "*.log", path: "logs/build.log", want: true},
+		{glob: "*.log", path: "build.log.gz", want: false},
+		{glob: "*.log", path: "logs.log/index.html", want: false},
+		{glob: "docs/*.md", anchored: true, path: "docs/index.md", want: true},
+		{glob: "docs/*.md", anchored: true, path: "site/docs/index.md", want: false},
+		{glob: "docs/*.md", anchored: true, path: "docs/api/index.md", want: false},
+		{glob: "docs/**/*.md", anchored: true, path: "docs/index.md", want: true},
+		{glob: "docs/**/*.md", anchored: true, path: "docs/api/v1/index.md", want: true},
+		{glob: "**/tmp", anchored: true, path: "tmp", want: true},
+		{glob: "**/tmp", anchored: true, path: "a/b/tmp", want: true},
+		{glob: "assets/**", anchored: true, path: "assets/img/logo.png", want: true},
+		{glob: "assets/**", anchored: true, path: "assets", want: false},
+		{glob: "file?.txt", path: "file1.txt", want: true},
+		{glob: "file?.txt", path: "file10.txt", want: false},
+		{glob: "file?.txt", path: "file/.txt", want: false},
+		{glob: "[ab].css", path: "a.css", want: true},
+		{glob: "[ab].css", path: "c.css", want: false},
+		{glob: "[!ab].css", path: "c.css", want: true},
+		{glob: "[!ab].css", path: "a.css", want: false},
+		{glob: "[ab.css", path: "[ab.css", want: true},
+		{glob: "a+b.txt", path: "a+b.txt", want: true},
+		{glob: "a+b.txt", path: "aab.txt", want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.glob+" "+tc.path, func(t *testing.T) {
+			re, err := globRegexp(tc.glob, tc.anchored)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, re.MatchString(tc.path), "regexp %s", re)
+		})
+	}
+}
+
+func TestCdnIgnoreMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), cdnIgnoreFile)
+	require.NoError(t, os.WriteFile(path, []byte(`# build output
+*.log
+!keep.log
+
+build/
+/secret.txt
+docs/**/draft-*
+`), 0644))
+	ignore, err := readCdnIgnore(path)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "index.html", want: false},
+		{path: "debug.log", want: true},
+		{path: "logs/debug.log", want: true},
+		{path: "keep.log", want: false},
+		{path: "logs/keep.log", want: false},
+		{path: "build", isDir: true, want: true},
+		{path: "src/build", isDir: true, want: true},
+		{path: "build", want: false},
+		{path: "secret.txt", want: true},
+		{path: "public/secret.txt", want: false},
+		{path: "docs/draft-1.md", want: true},
+		{path: "docs/blog/draft-2.md", want: true},
+		{path: "docs/blog/post.md", want: false},
+		{path: "# build output", want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, ignore.Match(tc.path, tc.isDir))
+		})
+	}
+}
+
+func TestReadCdnIgnoreMissing(t *testing.T) {
+	ignore, err := readCdnIgnore(filepath.Join(t.TempDir(), cdnIgnoreFile))
+	require.NoError(t, err)
+	assert.False(t, ignore.Match("index.html", false))
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	cdnapi "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned"
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// newCdnClient returns a client for the CDN API configured by the kubectl
+// flags
+func newCdnClient(configFlags *genericclioptions.ConfigFlags) (cdnv1alpha1.CdnV1alpha1Interface, error) {
+	config, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
+	}
+	clientset, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return clientset.CdnV1alpha1(), nil
+}
+
+// newContentClient returns a client transferring the content of the Files
+// in namespace, configured by the kubectl flags
+func newContentClient(configFlags *genericclioptions.ConfigFlags, namespace string) (contentclient.FileInterface, error) {
+	client, err := newContentClients(configFlags)
+	if err != nil {
+		return nil, err
+	}
+	return client.Files(namespace), nil
+}
+
+// newContentClients returns a client transferring the content of Files in
+// any namespace, configured by the kubectl flags
+func newContentClients(configFlags *genericclioptions.ConfigFlags) (contentclient.Interface, error) {
+	config, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
+	}
+	client, err := contentclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return client, nil
+}
+
+// namespaceOf returns the namespace of the --namespace flag or, without it,
+// of the current kubeconfig context
+func namespaceOf(configFlags *genericclioptions.ConfigFlags) (string, error) {
+	namespace, _, err := configFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine namespace: %w", err)
+	}
+	return namespace, nil
+}
+
+// contentRequest returns a request for the content subresource of the File
+// name in namespace
+func contentRequest(client cdnv1alpha1.CdnV1alpha1Interface, verb, namespace, name string) *rest.Request {
+	return client.RESTClient().Verb(verb).
+		Namespace(namespace).
+		Resource("files").
+		Name(name).
+		SubResource("content")
+}
+
+// newContentHTTPClient returns an HTTP client authenticated like the CDN
+// client, for content requests that need the raw response
+func newContentHTTPClient(configFlags *genericclioptions.ConfigFlags) (*http.Client, error) {
+	config, err := configFlags.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
+	}
+	httpClient, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return httpClient, nil
+}
+
+// listFiles calls each for every File matching options, listing them in pages
+// of chunkSize, or all at once if it is 0
+func listFiles(ctx context.Context, files cdnv1alpha1.FileInterface, options metav1.ListOptions, chunkSize int64, each func(*cdnapi.File) error) error {
+	options.Limit = chunkSize
+	for {
+		list, err := files.List(ctx, options)
+		if err != nil {
+			return fmt.Errorf("failed to list Files: %w", err)
+		}
+		for i := range list.Items {
+			if err := each(&list.Items[i]); err != nil {
+				return err
+			}
+		}
+		if list.Continue == "" {
+			return nil
+		}
+		options.Continue = list.Continue
+	}
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/scheme"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// remotePrefix marks a cp argument as a File rather than a local path
+const remotePrefix = "cdn:"
+
+// copyPath is a local path or a File given as a cp or mv argument
+type copyPath struct {
+	// Local is the local path, or empty for a File
+	Local string
+	// Namespace and Name of the File. Name is empty if the argument only
+	// named a namespace, in which case the name of the other side is used.
+	Namespace string
+	Name      string
+}
+
+// isRemote returns whether p is a File
+func (p copyPath) isRemote() bool {
+	return p.Local == ""
+}
+
+func (p copyPath) String() string {
+	if !p.isRemote() {
+		return p.Local
+	}
+	return p.Namespace + "/" + p.Name
+}
+
+// parseRemotePath parses a File given as [namespace/][name]. The namespace
+// defaults to namespace.
+func parseRemotePath(arg, namespace string) (copyPath, error) {
+	p := copyPath{Namespace: namespace, Name: arg}
+	if ns, name, ok := strings.Cut(arg, "/"); ok {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return copyPath{}, fmt.Errorf("invalid namespace in %q: %s", arg, strings.Join(errs, "; "))
+		}
+		p.Namespace, p.Name = ns, name
+	}
+	if p.Name != "" {
+		if errs := validation.IsDNS1123Subdomain(p.Name); len(errs) > 0 {
+			return copyPath{}, fmt.Errorf("invalid File name in %q: %s", arg, strings.Join(errs, "; "))
+		}
+	}
+	return p, nil
+}
+
+// parseCopyPath parses a cp argument: a File if it starts with "cdn:",
+// otherwise a local path
+func parseCopyPath(arg, namespace string) (copyPath, error) {
+	remote, ok := strings.CutPrefix(arg, remotePrefix)
+	if !ok {
+		return copyPath{Local: arg}, nil
+	}
+	return parseRemotePath(remote, namespace)
+}
+
+// CopyOptions holds the options for the cp and mv commands
+type CopyOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Source and Destination of the copy
+	Source      copyPath
+	Destination copyPath
+	// Delete the source once it was copied
+	Move bool
+	// Content type override for uploads
+	ContentType string
+	// Only check the copy
+	DryRun bool
+}
+
+// NewCopyOptions creates new CopyOptions with default values
+func NewCopyOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *CopyOptions {
+	return &CopyOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+	}
+}
+
+// NewCmdCopy creates the cp command
+func NewCmdCopy(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewCopyOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "cp <source> <destination>",
+		Short: "Copy content between local files and Files",
+		Long: `Copy content between local files and File resources.
+
+Files are written as cdn:[namespace/]name, with the namespace defaulting to
+the current one; everything else is a local path. If the destination File
+has no name, or the local destination is a directory, the name of the source
+is used.
+
+Copies between two Files are made by the server, so the content never leaves
+the cluster. Copying to another namespace requires permission to read the
+source's content and to create or update the destination File there.
+
+With --dry-run nothing changes: copies between Files are checked by the
+server, including their authorization, uploads and downloads only check that
+the source exists.
+
+Examples:
+  # Upload a local file
+  kubectl cdn cp index.html cdn:my-index
+
+  # Download a File into the current directory
+  kubectl cdn cp cdn:my-index .
+
+  # Copy a File to another namespace on the server
+  kubectl cdn cp cdn:my-index cdn:production/my-index
+
+  # Check a copy without making it
+  kubectl cdn cp cdn:my-index cdn:production/ --dry-run
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(args, parseCopyPath); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&o.ContentType, "content-type", "", "Content-Type of uploaded files (auto-detected if not specified)")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "Only check the copy")
+
+	return cmd
+}
+
+// NewCmdMove creates the mv command
+func NewCmdMove(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewCopyOptions(configFlags, streams)
+	o.Move = true
+
+	cmd := &cobra.Command{
+		Use:   "mv <source> <destination>",
+		Short: "Rename a File or move it to another namespace",
+		Long: `Rename a File resource or move it to another namespace.
+
+Both arguments are Files given as [namespace/]name, optionally prefixed with
+cdn:. If the destination has no name, the File keeps its name. The server
+copies the content and metadata to the destination and then deletes the
+source, unless it changed in the meantime; a destination created by a failed
+move is deleted again. Moving requires permission to delete the source in
+addition to those of cp.
+
+With --dry-run the move is only checked by the server, including its
+authorization.
+
+Examples:
+  # Rename a File
+  kubectl cdn mv my-index index
+
+  # Move a File to another namespace
+  kubectl cdn mv my-index production/
+`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parse := func(arg, namespace string) (copyPath, error) {
+				return parseRemotePath(strings.TrimPrefix(arg, remotePrefix), namespace)
+			}
+			if err := o.Complete(args, parse); err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "Only check the move on the server")
+
+	return cmd
+}
+
+// Complete parses the source and destination arguments with parse
+func (o *CopyOptions) Complete(args []string, parse func(arg, namespace string) (copyPath, error)) error {
+	namespace, err := namespaceOf(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	if o.Source, err = parse(args[0], namespace); err != nil {
+		return err
+	}
+	if o.Destination, err = parse(args[1], namespace); err != nil {
+		return err
+	}
+
+	switch {
+	case !o.Source.isRemote() && !o.Destination.isRemote():
+		return fmt.Errorf("one of source and destination must be a File (%s[namespace/]name)", remotePrefix)
+	case o.Source.isRemote() && o.Source.Name == "":
+		return fmt.Errorf("the source File needs a name")
+	case o.Destination.isRemote() && o.Destination.Name == "":
+		if o.Source.isRemote() {
+			o.Destination.Name = o.Source.Name
+		} else {
+			o.Destination.Name = filepath.Base(o.Source.Local)
+		}
+	case !o.Destination.isRemote():
+		if info, err := os.Stat(o.Destination.Local); err == nil && info.IsDir() {
+			o.Destination.Local = filepath.Join(o.Destination.Local, o.Source.Name)
+		}
+	}
+	return nil
+}
+
+// Run executes the cp or mv command
+func (o *CopyOptions) Run() error {
+	ctx := context.Background()
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !o.Source.isRemote():
+		return o.upload(ctx, client)
+	case !o.Destination.isRemote():
+		return o.download(ctx, client)
+	default:
+		return o.copy(ctx, client)
+	}
+}
+
+// copy copies a File to another File on the server
+func (o *CopyOptions) copy(ctx context.Context, client cdnclient.CdnV1alpha1Interface) error {
+	options := &cdnv1alpha1.FileCopyOptions{
+		Destination:          o.Destination.Name,
+		DestinationNamespace: o.Destination.Namespace,
+		Move:                 o.Move,
+		Check:                o.DryRun,
+	}
+	result := &cdnv1alpha1.FileContent{}
+	err := client.RESTClient().Post().
+		Namespace(o.Source.Namespace).
+		Resource("files").
+		Name(o.Source.Name).
+		SubResource("copy").
+		VersionedParams(options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", o.Source, o.Destination, err)
+	}
+	fmt.Fprintf(o.ErrOut, "✓ %s\n", result.Status.Message)
+	return nil
+}
+
+// upload copies a local file to a File
+func (o *CopyOptions) upload(ctx context.Context, client cdnclient.CdnV1alpha1Interface) error {
+	data, err := os.ReadFile(o.Source.Local)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", o.Source.Local, err)
+	}
+	contentType := o.ContentType
+	if contentType == "" {
+		contentType = syncContentType(o.Source.Local)
+	}
+	if o.DryRun {
+		fmt.Fprintf(o.ErrOut, "✓ Would upload %s to %s (%d bytes, %s) (dry run)\n", o.Source, o.Destination, len(data), contentType)
+		return nil
+	}
+
+	sum := sha256.Sum256(data)
+	err = contentRequest(client, http.MethodPut, o.Destination.Namespace, o.Destination.Name).
+		SetHeader("Content-Type", contentType).
+		SetHeader("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":").
+		Body(data).
+		Do(ctx).
+		Error()
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to %s: %w", o.Source, o.Destination, err)
+	}
+	fmt.Fprintf(o.ErrOut, "✓ Uploaded %s to %s (%d bytes, %s)\n", o.Source, o.Destination, len(data), contentType)
+	return nil
+}
+
+// download copies a File to a local file
+func (o *CopyOptions) download(ctx context.Context, client cdnclient.CdnV1alpha1Interface) error {
+	if o.DryRun {
+		err := contentRequest(client, http.MethodHead, o.Source.Namespace, o.Source.Name).Do(ctx).Error()
+		if err != nil {
+			return fmt.Errorf("failed to get content of %s: %w", o.Source, err)
+		}
+		fmt.Fprintf(o.ErrOut, "✓ Would download %s to %s (dry run)\n", o.Source, o.Destination)
+		return nil
+	}
+
+	data, err := contentRequest(client, http.MethodGet, o.Source.Namespace, o.Source.Name).Do(ctx).Raw()
+	if err != nil {
+		return fmt.Errorf("failed to get content of %s: %w", o.Source, err)
+	}
+	if err := os.WriteFile(o.Destination.Local, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", o.Destination.Local, err)
+	}
+	fmt.Fprintf(o.ErrOut, "✓ Downloaded %s to %s (%d bytes)\n", o.Source, o.Destination, len(data))
+	return nil
+}
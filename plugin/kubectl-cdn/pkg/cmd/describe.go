@@ -0,0 +1,380 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF for image dimensions
+	_ "image/jpeg" // register JPEG for image dimensions
+	_ "image/png"  // register PNG for image dimensions
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+const (
+	// previewBytes is how much of a text File is fetched for its preview
+	previewBytes = 4 << 10
+	// imageConfigBytes is how much of an image is fetched to decode its
+	// dimensions
+	imageConfigBytes = 64 << 10
+)
+
+// DescribeOptions holds the options for the describe command
+type DescribeOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Names of the File resources
+	ResourceNames []string
+	// Namespace
+	Namespace string
+	// Number of lines of text Files to preview
+	PreviewLines int
+}
+
+// NewDescribeOptions creates new DescribeOptions with default values
+func NewDescribeOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *DescribeOptions {
+	return &DescribeOptions{
+		IOStreams:    streams,
+		ConfigFlags:  configFlags,
+		PreviewLines: 10,
+	}
+}
+
+// NewCmdDescribe creates the describe command
+func NewCmdDescribe(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewDescribeOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:     "describe [resource-name...]",
+		Aliases: []string{"stat"},
+		Short:   "Show details of Files and their stored content",
+		Long: `Show details of File resources together with their stored content.
+
+Besides the File's metadata, spec and status, the content section describes
+the content as stored by the replica serving the request, read from the stat
+subresource without downloading the content: its size, content type and
+checksum, how many versions were stored, when it was stored and last read, and
+whether it is cached. A checksum that differs from the File status is flagged.
+
+Text Files are previewed with their first lines, with control characters
+replaced, and images of a known format (PNG, JPEG, GIF) with their
+dimensions. Only the first bytes of the content are fetched for either, which
+counts as a read of the content.
+Recent Events of the File are listed last.
+
+Examples:
+  # Describe a File
+  kubectl cdn describe logo.png
+
+  # Describe several Files without a preview
+  kubectl cdn stat index.html app.js --preview-lines 0
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.ResourceNames = args
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().IntVar(&o.PreviewLines, "preview-lines", o.PreviewLines, "Number of lines of text Files to preview, 0 to disable previews")
+
+	return cmd
+}
+
+// Run executes the describe command
+func (o *DescribeOptions) Run() error {
+	ctx := context.Background()
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	files, err := newContentClient(o.ConfigFlags, o.Namespace)
+	if err != nil {
+		return err
+	}
+	config, err := o.ConfigFlags.ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build kubernetes config: %w", err)
+	}
+	coreClient, err := corev1client.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	for i, name := range o.ResourceNames {
+		if i > 0 {
+			fmt.Fprintln(o.Out)
+		}
+		if err := o.describe(ctx, client, files, coreClient, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describe prints the details of the named File
+func (o *DescribeOptions) describe(ctx context.Context, client cdnclient.CdnV1alpha1Interface, files contentclient.FileInterface, coreClient corev1client.CoreV1Interface, name string) error {
+	file, err := client.Files(o.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	stat, err := files.Stat(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to stat content: %w", err)
+	}
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", file.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", file.Namespace)
+	fmt.Fprintf(w, "Labels:\t%s\n", formatMap(file.Labels))
+	fmt.Fprintf(w, "Annotations:\t%s\n", formatMap(file.Annotations))
+	fmt.Fprintf(w, "Created:\t%s\n", formatTime(&file.CreationTimestamp))
+	fmt.Fprintf(w, "URL:\t%s\n", orNone(file.Spec.URL))
+	if file.Spec.ResourceLocation != "" {
+		fmt.Fprintf(w, "Resource Location:\t%s\n", file.Spec.ResourceLocation)
+	}
+	fmt.Fprintf(w, "Content Type:\t%s\n", orNone(file.Spec.ContentType))
+	fmt.Fprintf(w, "Size:\t%s\n", formatSize(file.Spec.Size))
+	fmt.Fprintf(w, "Status:\n")
+	fmt.Fprintf(w, "  Uploaded:\t%t\n", file.Status.Uploaded)
+	if file.Status.Error != "" {
+		fmt.Fprintf(w, "  Error:\t%s\n", file.Status.Error)
+	}
+	fmt.Fprintf(w, "  Checksum:\t%s\n", orNone(file.Status.Checksum))
+	fmt.Fprintf(w, "  Content Generation:\t%d\n", file.Status.ContentGeneration)
+	if upload := file.Status.LastUpload; upload != nil {
+		fmt.Fprintf(w, "  Last Upload:\t%s by %s from %s\n", formatTime(&upload.Time), orNone(upload.User), orNone(upload.SourceIP))
+	}
+
+	fmt.Fprintf(w, "Content:\n")
+	fmt.Fprintf(w, "  Stored:\t%t\n", stat.Stored)
+	if stat.Stored {
+		fmt.Fprintf(w, "  Size:\t%s\n", formatSize(stat.Size))
+		fmt.Fprintf(w, "  Content Type:\t%s\n", orNone(stat.ContentType))
+		checksum := stat.Checksum
+		switch file.Status.Checksum {
+		case stat.Checksum:
+		case "":
+			checksum += " (no checksum in status)"
+		default:
+			checksum += " (MISMATCH: status has " + file.Status.Checksum + ")"
+		}
+		fmt.Fprintf(w, "  Checksum:\t%s\n", checksum)
+		fmt.Fprintf(w, "  Versions:\t%d\n", stat.Versions)
+		fmt.Fprintf(w, "  Modified:\t%s\n", formatTime(stat.Modified))
+		fmt.Fprintf(w, "  Last Access:\t%s\n", formatTime(stat.LastAccess))
+		fmt.Fprintf(w, "  Cached:\t%t\n", stat.Cached)
+
+		if err := o.preview(ctx, w, files, stat); err != nil {
+			fmt.Fprintf(w, "Preview:\t<unavailable: %v>\n", err)
+		}
+	}
+	w.Flush()
+
+	return o.printEvents(ctx, coreClient, file)
+}
+
+// preview prints the first lines of text content or the dimensions of images
+func (o *DescribeOptions) preview(ctx context.Context, w io.Writer, files contentclient.FileInterface, stat *cdnv1alpha1.FileStat) error {
+	if o.PreviewLines <= 0 || stat.Size == 0 {
+		return nil
+	}
+
+	switch {
+	case isTextContentType(stat.ContentType):
+		part, err := files.ReadRange(ctx, stat.Name, 0, previewBytes)
+		if err != nil {
+			return err
+		}
+		lines, complete := firstLines(part.Data, o.PreviewLines)
+		fmt.Fprintf(w, "Preview:\n")
+		for _, line := range lines {
+			// Written without a tab, so the line is not aligned
+			fmt.Fprintf(w, "  | %s\n", sanitizeLine(line))
+		}
+		if !complete || int64(len(part.Data)) < part.Size {
+			fmt.Fprintf(w, "  | ...\n")
+		}
+	case strings.HasPrefix(stat.ContentType, "image/"):
+		part, err := files.ReadRange(ctx, stat.Name, 0, imageConfigBytes)
+		if err != nil {
+			return err
+		}
+		config, format, err := image.DecodeConfig(bytes.NewReader(part.Data))
+		if err != nil {
+			fmt.Fprintf(w, "  Dimensions:\t<unknown image format>\n")
+			return nil
+		}
+		fmt.Fprintf(w, "  Dimensions:\t%dx%d (%s)\n", config.Width, config.Height, format)
+	}
+	return nil
+}
+
+// printEvents prints the Events recorded for file. Events that cannot be
+// listed are reported as unavailable rather than failing the command.
+func (o *DescribeOptions) printEvents(ctx context.Context, coreClient corev1client.CoreV1Interface, file *cdnv1alpha1.File) error {
+	selector := fields.Set{
+		"involvedObject.kind": "File",
+		"involvedObject.name": file.Name,
+		"involvedObject.uid":  string(file.UID),
+	}.AsSelector().String()
+	events, err := coreClient.Events(file.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		fmt.Fprintf(o.Out, "Events:  <unavailable: %v>\n", err)
+		return nil
+	}
+	if len(events.Items) == 0 {
+		fmt.Fprintf(o.Out, "Events:  <none>\n")
+		return nil
+	}
+
+	items := events.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return eventTime(&items[i]).Before(eventTime(&items[j]))
+	})
+	fmt.Fprintf(o.Out, "Events:\n")
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  TYPE\tREASON\tAGE\tFROM\tMESSAGE\n")
+	for i := range items {
+		event := &items[i]
+		age := duration.HumanDuration(time.Since(eventTime(event)))
+		if event.Count > 1 {
+			age = fmt.Sprintf("%s (x%d)", age, event.Count)
+		}
+		from := event.Source.Component
+		if from == "" {
+			from = event.ReportingController
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", event.Type, event.Reason, age, from, sanitizeLine([]byte(strings.TrimSpace(event.Message))))
+	}
+	return w.Flush()
+}
+
+// eventTime returns when event last occurred
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// firstLines returns up to n lines of data without their line endings, and
+// whether they end where data does
+func firstLines(data []byte, n int) ([][]byte, bool) {
+	var lines [][]byte
+	for len(data) > 0 && len(lines) < n {
+		line, rest, found := bytes.Cut(data, []byte("\n"))
+		if !found && len(lines) > 0 {
+			// A line cut off by the end of the fetched bytes
+			return lines, false
+		}
+		lines = append(lines, bytes.TrimSuffix(line, []byte("\r")))
+		data = rest
+	}
+	return lines, len(data) == 0
+}
+
+// sanitizeLine makes line safe to print to a terminal: tabs are expanded
+// and other control characters and invalid UTF-8 are replaced
+func sanitizeLine(line []byte) string {
+	var b strings.Builder
+	for len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		line = line[size:]
+		switch {
+		case r == '\t':
+			b.WriteString("    ")
+		case r == utf8.RuneError && size <= 1, unicode.IsControl(r):
+			b.WriteRune(utf8.RuneError)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// formatMap formats labels or annotations as sorted key=value pairs
+func formatMap(m map[string]string) string {
+	if len(m) == 0 {
+		return "<none>"
+	}
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// formatTime formats t with its age, or <none> if it is unset
+func formatTime(t *metav1.Time) string {
+	if t == nil || t.IsZero() {
+		return "<none>"
+	}
+	return fmt.Sprintf("%s (%s ago)", t.UTC().Format(time.RFC3339), duration.HumanDuration(time.Since(t.Time)))
+}
+
+// formatSize formats a size in bytes, with a human readable unit if larger
+// than a KiB
+func formatSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	return fmt.Sprintf("%d bytes (%s)", n, formatBytes(n))
+}
+
+// orNone returns s, or <none> if it is empty
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
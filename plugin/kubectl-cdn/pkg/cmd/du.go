@@ -0,0 +1,212 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+// DiskUsageOptions holds the options for the du command
+type DiskUsageOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Namespace
+	Namespace string
+	// Sum up Files in all namespaces
+	AllNamespaces bool
+	// Label selector to filter on
+	Selector string
+	// Field selector to filter on
+	FieldSelector string
+	// What to group the Files by: namespace, content-type or label
+	By string
+	// Label key to group by with --by label
+	LabelKey string
+	// Print sizes in bytes
+	Bytes bool
+	// Page size of File lists; 0 lists all Files at once
+	ChunkSize int64
+}
+
+// NewDiskUsageOptions creates new DiskUsageOptions with default values
+func NewDiskUsageOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *DiskUsageOptions {
+	return &DiskUsageOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		By:          "namespace",
+		LabelKey:    cdnv1alpha1.LabelArchive,
+		ChunkSize:   defaultChunkSize,
+	}
+}
+
+// NewCmdDiskUsage creates the du command
+func NewCmdDiskUsage(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewDiskUsageOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "du",
+		Short: "Summarise the storage used by Files",
+		Long: `Summarise the storage used by File resources, grouped by namespace, content
+type or label.
+
+Each group lists its number of Files, their total size and its share of the
+total, largest first, followed by the total. Sizes are the sizes of the
+uploaded content in spec.size. Content types are grouped by their media type
+without parameters. With --by label, Files are grouped by the value of
+--label-key, which defaults to the archive label set by sync and archive
+uploads.
+
+Examples:
+  # Storage used per namespace in the cluster
+  kubectl cdn du -A
+
+  # Storage used per content type in a namespace
+  kubectl cdn du --by content-type -n my-namespace
+
+  # Storage used per synchronised site
+  kubectl cdn du --by label
+
+  # Storage used per team label, counting only uploaded Files
+  kubectl cdn du -A --by label --label-key team --field-selector status.uploaded=true
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch o.By {
+			case "namespace", "content-type", "label":
+			default:
+				return fmt.Errorf("unsupported --by %q, use namespace, content-type or label", o.By)
+			}
+			if o.By == "label" && o.LabelKey == "" {
+				return fmt.Errorf("--label-key is required with --by label")
+			}
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", false, "Sum up Files in all namespaces")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Label selector to filter on")
+	cmd.Flags().StringVar(&o.FieldSelector, "field-selector", "", "Field selector to filter on")
+	cmd.Flags().StringVar(&o.By, "by", o.By, "Group the Files by namespace, content-type or label")
+	cmd.Flags().StringVar(&o.LabelKey, "label-key", o.LabelKey, "Label to group the Files by with --by label")
+	cmd.Flags().BoolVar(&o.Bytes, "bytes", false, "Print sizes in bytes instead of human-readable units")
+	addChunkSizeFlag(cmd, &o.ChunkSize)
+
+	return cmd
+}
+
+// usage is the storage used by a group of Files
+type usage struct {
+	group string
+	files int
+	bytes int64
+}
+
+// Run executes the du command
+func (o *DiskUsageOptions) Run() error {
+	ctx := context.Background()
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	namespace := o.Namespace
+	if o.AllNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+
+	groups := map[string]*usage{}
+	total := &usage{group: "TOTAL"}
+	options := metav1.ListOptions{LabelSelector: o.Selector, FieldSelector: o.FieldSelector}
+	err = listFiles(ctx, client.Files(namespace), options, o.ChunkSize, func(file *cdnv1alpha1.File) error {
+		group := o.groupOf(file)
+		u, ok := groups[group]
+		if !ok {
+			u = &usage{group: group}
+			groups[group] = u
+		}
+		u.files++
+		u.bytes += file.Spec.Size
+		total.files++
+		total.bytes += file.Spec.Size
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	usages := make([]*usage, 0, len(groups))
+	for _, u := range groups {
+		usages = append(usages, u)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].bytes != usages[j].bytes {
+			return usages[i].bytes > usages[j].bytes
+		}
+		return usages[i].group < usages[j].group
+	})
+
+	header := map[string]string{"namespace": "NAMESPACE", "content-type": "CONTENT-TYPE", "label": "LABEL " + o.LabelKey}[o.By]
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tFILES\tSIZE\tSHARE\n", header)
+	for _, u := range append(usages, total) {
+		share := "100.0%"
+		if total.bytes > 0 {
+			share = fmt.Sprintf("%.1f%%", float64(u.bytes)*100/float64(total.bytes))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", u.group, u.files, o.formatSize(u.bytes), share)
+	}
+	return w.Flush()
+}
+
+// groupOf returns the group file is summed up in
+func (o *DiskUsageOptions) groupOf(file *cdnv1alpha1.File) string {
+	var group string
+	switch o.By {
+	case "namespace":
+		group = file.Namespace
+	case "content-type":
+		group = cdnv1alpha1.MediaType(file.Spec.ContentType)
+	case "label":
+		group = file.Labels[o.LabelKey]
+	}
+	return orNone(group)
+}
+
+// formatSize formats a total size for the table
+func (o *DiskUsageOptions) formatSize(n int64) string {
+	if o.Bytes {
+		return fmt.Sprint(n)
+	}
+	return formatBytes(n)
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"sigs.k8s.io/yaml"
+
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// errEditAborted is returned when the user gives up an edit
+var errEditAborted = errors.New("edit aborted")
+
+// EditOptions holds the options for the edit command
+type EditOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Name of the File resource
+	ResourceName string
+	// Namespace
+	Namespace string
+	// Validate JSON and YAML content before uploading it
+	Validate bool
+	// Retries of transfers failing with a transient error
+	Retries int
+
+	// answers reads the answers to prompts from In
+	answers *bufio.Reader
+}
+
+// NewEditOptions creates new EditOptions with default values
+func NewEditOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *EditOptions {
+	return &EditOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		Validate:    true,
+		Retries:     5,
+	}
+}
+
+// NewCmdEdit creates the edit command
+func NewCmdEdit(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewEditOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "edit [resource-name]",
+		Short: "Edit the content of a File in your editor",
+		Long: `Edit the content of a text File in your editor.
+
+The content is downloaded to a temporary file, named after the File so that
+editors recognise its type, and opened with the editor from the KUBE_EDITOR or
+EDITOR environment variable, or vi. When the editor exits, the content is
+uploaded again with the File's content type if it changed.
+
+Content of JSON and YAML Files is validated before it is uploaded; invalid
+content can be edited again or the edit aborted. Use --validate=false to
+upload it anyway.
+
+The upload is conditional on the content being unchanged since it was
+downloaded. If someone else changed it in the meantime, their changes can be
+merged with yours line by line, or the edit aborted. Lines both of you changed
+are left between conflict markers to be resolved in the editor. Aborted edits
+keep the temporary file, so nothing is lost.
+
+Examples:
+  # Edit a File
+  kubectl cdn edit index.html
+
+  # Edit a File with a different editor
+  KUBE_EDITOR="code --wait" kubectl cdn edit config.json
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.ResourceName = args[0]
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.Validate, "validate", o.Validate, "Validate JSON and YAML content before uploading it")
+	cmd.Flags().IntVar(&o.Retries, "retries", o.Retries, "Number of retries after transient errors")
+
+	return cmd
+}
+
+// editedContent is content of a File as downloaded for an edit
+type editedContent struct {
+	data        []byte
+	contentType string
+	// etag identifies the downloaded content in If-Match
+	etag string
+}
+
+// Run executes the edit command
+func (o *EditOptions) Run() error {
+	ctx := context.Background()
+	o.answers = bufio.NewReader(o.In)
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	files, err := newContentClient(o.ConfigFlags, o.Namespace)
+	if err != nil {
+		return err
+	}
+
+	original, err := o.fetch(ctx, client, files)
+	if err != nil {
+		return err
+	}
+	if !isTextContentType(original.contentType) {
+		return fmt.Errorf("cannot edit %s content of File %s, only text; use kubectl cdn get and upload instead", original.contentType, o.ResourceName)
+	}
+
+	f, err := os.CreateTemp("", "kubectl-cdn-edit-*-"+filepath.Base(o.ResourceName))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	path := f.Name()
+	_, err = f.Write(original.data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := o.edit(ctx, client, files, path, original); err != nil {
+		return fmt.Errorf("%w; your changes are kept in %s", err, path)
+	}
+	os.Remove(path)
+	return nil
+}
+
+// edit opens path in the editor until its content is valid and uploaded over
+// base, merging concurrent changes if the user asks to
+func (o *EditOptions) edit(ctx context.Context, client cdnclient.CdnV1alpha1Interface, files contentclient.FileInterface, path string, base *editedContent) error {
+	openEditor := true
+	for {
+		if openEditor {
+			if err := runEditor(path, o.IOStreams); err != nil {
+				return err
+			}
+		}
+		openEditor = true
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read temporary file: %w", err)
+		}
+		if bytes.Equal(data, base.data) {
+			fmt.Fprintln(o.ErrOut, "Edit cancelled, no changes made.")
+			return nil
+		}
+
+		if err := o.validate(data, base.contentType); err != nil {
+			fmt.Fprintf(o.ErrOut, "error: %v\n", err)
+			if answer, err := o.choose("Edit again or abort?", "edit", "abort"); err != nil || answer != "edit" {
+				return errors.Join(errEditAborted, err)
+			}
+			continue
+		}
+
+		err = o.upload(ctx, files, data, base)
+		if err == nil {
+			fmt.Fprintf(o.ErrOut, "✓ Edited %s/%s (%s)\n", o.Namespace, o.ResourceName, formatBytes(int64(len(data))))
+			return nil
+		}
+		if !apierrors.IsConflict(err) {
+			return fmt.Errorf("failed to upload content: %w", err)
+		}
+
+		current, err := o.fetch(ctx, client, files)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(o.ErrOut, "File %s was changed by someone else while you were editing it.\n", o.ResourceName)
+		if answer, err := o.choose("Merge their changes with yours or abort?", "merge", "abort"); err != nil || answer != "merge" {
+			return errors.Join(errEditAborted, err)
+		}
+		merged, conflicts := merge3(base.data, data, current.data)
+		if bytes.Equal(merged, current.data) {
+			fmt.Fprintf(o.ErrOut, "✓ File %s already has your changes\n", o.ResourceName)
+			return nil
+		}
+		if err := os.WriteFile(path, merged, 0600); err != nil {
+			return fmt.Errorf("failed to write temporary file: %w", err)
+		}
+		if conflicts {
+			fmt.Fprintln(o.ErrOut, "Both of you changed the same lines; resolve the conflicts marked in the editor.")
+		}
+		// Uploads are now conditional on the current content, and merges
+		// without conflicts are uploaded right away
+		base, openEditor = current, conflicts
+	}
+}
+
+// fetch downloads the content of the File to edit
+func (o *EditOptions) fetch(ctx context.Context, client cdnclient.CdnV1alpha1Interface, files contentclient.FileInterface) (*editedContent, error) {
+	file, err := client.Files(o.Namespace).Get(ctx, o.ResourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !file.Status.Uploaded {
+		return nil, fmt.Errorf("File %s has no content to edit; use kubectl cdn upload instead", o.ResourceName)
+	}
+
+	var buf bytes.Buffer
+	info, err := files.Download(ctx, o.ResourceName, &buf, contentclient.DownloadOptions{
+		Backoff: transferBackoff(o.Retries),
+		OnRetry: logRetry(o.ErrOut, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download content: %w", err)
+	}
+	return &editedContent{data: buf.Bytes(), contentType: file.Spec.ContentType, etag: info.ETag}, nil
+}
+
+// upload replaces the content of the File with data if it still is base
+func (o *EditOptions) upload(ctx context.Context, files contentclient.FileInterface, data []byte, base *editedContent) error {
+	_, err := files.Upload(ctx, o.ResourceName, bytes.NewReader(data), contentclient.UploadOptions{
+		ContentType: base.contentType,
+		IfMatch:     base.etag,
+		Backoff:     transferBackoff(o.Retries),
+		OnRetry:     logRetry(o.ErrOut, nil),
+	})
+	return err
+}
+
+// validate checks that data is well-formed content of the type, if it is
+// JSON or YAML, and that no conflict markers are left
+func (o *EditOptions) validate(data []byte, contentType string) error {
+	if hasConflictMarkers(data) {
+		return fmt.Errorf("the content has unresolved conflict markers")
+	}
+	if !o.Validate {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return validateJSON(data)
+	case mediaType == "application/yaml" || mediaType == "application/x-yaml" ||
+		mediaType == "text/yaml" || strings.HasSuffix(mediaType, "+yaml"):
+		return validateYAML(data)
+	}
+	return nil
+}
+
+// validateJSON returns an error locating the first syntax error in data
+func validateJSON(data []byte) error {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+		return fmt.Errorf("invalid JSON on line %d: %v", line, err)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	return nil
+}
+
+// validateYAML returns an error for the first malformed document in data
+func validateYAML(data []byte) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for doc := 1; ; doc++ {
+		chunk, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid YAML: %v", err)
+		}
+		var v interface{}
+		if err := yaml.Unmarshal(chunk, &v); err != nil {
+			return fmt.Errorf("invalid YAML in document %d: %v", doc, err)
+		}
+	}
+}
+
+// choose asks question on ErrOut until one of the choices, or its first
+// letter, is read from In. Without an answer, it returns the last choice.
+func (o *EditOptions) choose(question string, choices ...string) (string, error) {
+	prompt := make([]string, len(choices))
+	for i, choice := range choices {
+		prompt[i] = "[" + choice[:1] + "]" + choice[1:]
+	}
+	for {
+		fmt.Fprintf(o.ErrOut, "%s %s: ", question, strings.Join(prompt, ", "))
+		answer, err := o.answers.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer == "" && err == io.EOF {
+			fmt.Fprintln(o.ErrOut)
+			return choices[len(choices)-1], nil
+		}
+		for _, choice := range choices {
+			if answer == choice || answer == choice[:1] {
+				return choice, nil
+			}
+		}
+	}
+}
+
+// runEditor opens path with the editor from KUBE_EDITOR or EDITOR, or vi, and
+// waits for it to exit. The editor is run by the shell, so it may have
+// arguments.
+func runEditor(path string, streams genericiooptions.IOStreams) error {
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = streams.In, streams.Out, streams.ErrOut
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+// maxSizeClass is the size class of the largest possible File size
+const maxSizeClass = 63
+
+// FindOptions holds the options for the find command
+type FindOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Namespace
+	Namespace string
+	// Find Files in all namespaces
+	AllNamespaces bool
+	// Label selector to filter on
+	Selector string
+	// Only Files larger than this size
+	LargerThan string
+	// Only Files smaller than this size
+	SmallerThan string
+	// Only Files last modified longer ago than this
+	OlderThan string
+	// Only Files last modified more recently than this
+	NewerThan string
+	// Media type pattern, such as image/*
+	Type string
+	// Only Files whose content was not uploaded
+	NotUploaded bool
+	// Output format; wide or empty for names
+	Output string
+	// Page size of File lists; 0 lists all Files at once
+	ChunkSize int64
+}
+
+// NewFindOptions creates new FindOptions with default values
+func NewFindOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *FindOptions {
+	return &FindOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		ChunkSize:   defaultChunkSize,
+	}
+}
+
+// NewCmdFind creates the find command
+func NewCmdFind(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewFindOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "find",
+		Short: "Find Files by size, age and type",
+		Long: `Find File resources by size, age, media type and upload state.
+
+The names of the matching Files are printed one per line, prefixed with their
+namespace with -A, so they can be piped to rm; -o wide prints a table instead.
+The number and total size of the Files found are printed to stderr.
+
+Sizes are bytes, or have a K, M, G or T suffix for powers of 1024. Ages are
+Go durations such as 12h, or have a d or w suffix for days and weeks; a File's
+age is the time since its last upload, or since it was created if it has none.
+--type matches the media type of the content type without its parameters,
+such as image/png or image/*.
+
+The type, upload state and approximate size are filtered by the server using
+the spec.mediaType, spec.topLevelType, status.uploaded and spec.sizeClass
+field selectors, so only candidates are listed; exact sizes and ages are
+checked locally.
+
+Examples:
+  # Find images larger than 10 MiB not changed for 30 days
+  kubectl cdn find --larger-than 10M --older-than 30d --type 'image/*'
+
+  # Delete Files whose content was never uploaded, in all namespaces
+  kubectl cdn find -A --not-uploaded --older-than 1d | xargs -r kubectl cdn rm -y
+
+  # Show the largest candidates as a table
+  kubectl cdn find --larger-than 100M -o wide
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.Output != "" && o.Output != "wide" {
+				return fmt.Errorf("unsupported output format %q, use wide", o.Output)
+			}
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", false, "Find Files in all namespaces")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Label selector to filter on")
+	cmd.Flags().StringVar(&o.LargerThan, "larger-than", "", "Only Files larger than this size, such as 10M")
+	cmd.Flags().StringVar(&o.SmallerThan, "smaller-than", "", "Only Files smaller than this size, such as 1K")
+	cmd.Flags().StringVar(&o.OlderThan, "older-than", "", "Only Files last uploaded longer ago than this, such as 30d")
+	cmd.Flags().StringVar(&o.NewerThan, "newer-than", "", "Only Files last uploaded more recently than this, such as 12h")
+	cmd.Flags().StringVar(&o.Type, "type", "", "Only Files of this media type, such as image/* or text/html")
+	cmd.Flags().BoolVar(&o.NotUploaded, "not-uploaded", false, "Only Files whose content was not uploaded")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "Output format: wide for a table instead of names")
+	addChunkSizeFlag(cmd, &o.ChunkSize)
+
+	return cmd
+}
+
+// fileFilter is the local part of the find criteria
+type fileFilter struct {
+	// minSize and maxSize are inclusive bounds of the size, maxSize -1 if
+	// unbounded
+	minSize, maxSize int64
+	// modifiedBefore and modifiedAfter bound the last modification
+	modifiedBefore, modifiedAfter time.Time
+	// typePattern is matched against the media type if it has wildcards
+	// the server cannot express
+	typePattern string
+}
+
+// Run executes the find command
+func (o *FindOptions) Run() error {
+	ctx := context.Background()
+
+	filter, selector, err := o.criteria(time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	namespace := o.Namespace
+	if o.AllNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+
+	var found []*cdnv1alpha1.File
+	var total int64
+	options := metav1.ListOptions{LabelSelector: o.Selector, FieldSelector: selector.String()}
+	err = listFiles(ctx, client.Files(namespace), options, o.ChunkSize, func(file *cdnv1alpha1.File) error {
+		if filter.matches(file) {
+			found = append(found, file)
+			total += file.Spec.Size
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if o.Output == "wide" {
+		o.printTable(found)
+	} else {
+		for _, file := range found {
+			if o.AllNamespaces {
+				fmt.Fprintf(o.Out, "%s/%s\n", file.Namespace, file.Name)
+			} else {
+				fmt.Fprintln(o.Out, file.Name)
+			}
+		}
+	}
+	fmt.Fprintf(o.ErrOut, "Found %d File(s), %s in total\n", len(found), formatBytes(total))
+	return nil
+}
+
+// criteria returns the local filter and the server-side field selector of
+// the options, with ages relative to now
+func (o *FindOptions) criteria(now time.Time) (*fileFilter, fields.Selector, error) {
+	filter := &fileFilter{maxSize: -1}
+	var selectors []fields.Selector
+
+	if o.LargerThan != "" {
+		size, err := parseSize(o.LargerThan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --larger-than: %w", err)
+		}
+		filter.minSize = size + 1
+	}
+	if o.SmallerThan != "" {
+		size, err := parseSize(o.SmallerThan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --smaller-than: %w", err)
+		}
+		if size == 0 {
+			return nil, nil, fmt.Errorf("invalid --smaller-than: no File is smaller than 0 bytes")
+		}
+		filter.maxSize = size - 1
+	}
+	if filter.maxSize >= 0 && filter.minSize > filter.maxSize {
+		return nil, nil, fmt.Errorf("no File is larger than %s and smaller than %s", o.LargerThan, o.SmallerThan)
+	}
+	selectors = append(selectors, sizeClassSelector(filter.minSize, filter.maxSize)...)
+
+	if o.OlderThan != "" {
+		age, err := parseAge(o.OlderThan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --older-than: %w", err)
+		}
+		filter.modifiedBefore = now.Add(-age)
+	}
+	if o.NewerThan != "" {
+		age, err := parseAge(o.NewerThan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --newer-than: %w", err)
+		}
+		filter.modifiedAfter = now.Add(-age)
+	}
+
+	if o.Type != "" {
+		typeSelector, pattern, err := mediaTypeSelector(o.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		if typeSelector != nil {
+			selectors = append(selectors, typeSelector)
+		}
+		filter.typePattern = pattern
+	}
+
+	if o.NotUploaded {
+		selectors = append(selectors, fields.OneTermEqualSelector("status.uploaded", "false"))
+	}
+	return filter, fields.AndSelectors(selectors...), nil
+}
+
+// matches returns whether file meets the local criteria
+func (f *fileFilter) matches(file *cdnv1alpha1.File) bool {
+	if file.Spec.Size < f.minSize || (f.maxSize >= 0 && file.Spec.Size > f.maxSize) {
+		return false
+	}
+	modified := fileModified(file)
+	if !f.modifiedBefore.IsZero() && !modified.Before(f.modifiedBefore) {
+		return false
+	}
+	if !f.modifiedAfter.IsZero() && !modified.After(f.modifiedAfter) {
+		return false
+	}
+	if f.typePattern != "" {
+		if ok, _ := path.Match(f.typePattern, cdnv1alpha1.MediaType(file.Spec.ContentType)); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// printTable prints the Files found as a table
+func (o *FindOptions) printTable(found []*cdnv1alpha1.File) {
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	var columns []string
+	if o.AllNamespaces {
+		columns = append(columns, "NAMESPACE")
+	}
+	columns = append(columns, "NAME", "SIZE", "CONTENT-TYPE", "UPLOADED", "AGE")
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	for _, file := range found {
+		var row []string
+		if o.AllNamespaces {
+			row = append(row, file.Namespace)
+		}
+		row = append(row, file.Name, formatBytes(file.Spec.Size), orNone(file.Spec.ContentType),
+			strconv.FormatBool(file.Status.Uploaded), duration.HumanDuration(time.Since(fileModified(file))))
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// fileModified returns when the content of file was last uploaded, or when
+// it was created if it has not been
+func fileModified(file *cdnv1alpha1.File) time.Time {
+	if file.Status.LastUpload != nil && !file.Status.LastUpload.Time.IsZero() {
+		return file.Status.LastUpload.Time.Time
+	}
+	return file.CreationTimestamp.Time
+}
+
+// sizeClassSelector returns the field selectors excluding the size classes
+// that hold no size from minSize to maxSize, or up from minSize if maxSize
+// is negative
+func sizeClassSelector(minSize, maxSize int64) []fields.Selector {
+	var selectors []fields.Selector
+	for class := 0; class < cdnv1alpha1.SizeClass(minSize); class++ {
+		selectors = append(selectors, fields.OneTermNotEqualSelector(cdnv1alpha1.FieldSizeClass, strconv.Itoa(class)))
+	}
+	if maxSize >= 0 {
+		for class := cdnv1alpha1.SizeClass(maxSize) + 1; class <= maxSizeClass; class++ {
+			selectors = append(selectors, fields.OneTermNotEqualSelector(cdnv1alpha1.FieldSizeClass, strconv.Itoa(class)))
+		}
+	}
+	return selectors
+}
+
+// mediaTypeSelector returns the field selector for a media type pattern, or
+// nil if it matches every type, and the pattern if the selector is only
+// approximate and it has to be matched locally
+func mediaTypeSelector(pattern string) (fields.Selector, string, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, "", fmt.Errorf("invalid --type %q: %w", pattern, err)
+	}
+	topLevelType, subtype, ok := strings.Cut(pattern, "/")
+	switch {
+	case pattern == "*" || pattern == "*/*":
+		return nil, "", nil
+	case !ok:
+		return nil, "", fmt.Errorf("invalid --type %q: expected a media type such as image/png or image/*", pattern)
+	case !strings.ContainsAny(pattern, `*?[\`):
+		return fields.OneTermEqualSelector(cdnv1alpha1.FieldMediaType, pattern), "", nil
+	case subtype == "*" && !strings.ContainsAny(topLevelType, `*?[\`):
+		return fields.OneTermEqualSelector(cdnv1alpha1.FieldTopLevelType, topLevelType), "", nil
+	case !strings.ContainsAny(topLevelType, `*?[\`):
+		return fields.OneTermEqualSelector(cdnv1alpha1.FieldTopLevelType, topLevelType), pattern, nil
+	default:
+		return nil, pattern, nil
+	}
+}
+
+// parseSize parses a size in bytes with an optional K, M, G or T suffix for
+// powers of 1024, which may be followed by B or iB
+func parseSize(s string) (int64, error) {
+	number := strings.TrimSpace(s)
+	upper := strings.ToUpper(number)
+	upper = strings.TrimSuffix(upper, "B")
+	upper = strings.TrimSuffix(upper, "I")
+	shift := 0
+	if i := len(upper) - 1; i >= 0 {
+		if unit := strings.IndexByte("KMGT", upper[i]); unit >= 0 {
+			shift = 10 * (unit + 1)
+			upper = upper[:i]
+		}
+	}
+	if upper == "" {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	value, err := strconv.ParseFloat(upper, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	size := value * float64(int64(1)<<shift)
+	if size >= 1<<63 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(size), nil
+}
+
+// parseAge parses a Go duration, or a number of days or weeks with a d or w
+// suffix
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(s, suffix); ok {
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil || value < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(value * float64(unit)), nil
+		}
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return age, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+)
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "512", want: 512},
+		{in: " 10 ", want: 10},
+		{in: "1K", want: 1 << 10},
+		{in: "1k", want: 1 << 10},
+		{in: "1KB", want: 1 << 10},
+		{in: "1KiB", want: 1 << 10},
+		{in: "1.5M", want: 3 << 19},
+		{in: "2G", want: 2 << 30},
+		{in: "1T", want: 1 << 40},
+		{in: "100B", want: 100},
+		{in: "+10M", want: 10 << 20},
+		{in: "-1K", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "K", wantErr: true},
+		{in: "10X", wantErr: true},
+		{in: "1P", wantErr: true},
+		{in: "ten", wantErr: true},
+		{in: "9000000T", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			size, err := parseSize(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, size)
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "90m", want: 90 * time.Minute},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "1w", want: 7 * 24 * time.Hour},
+		{in: " 3d ", want: 72 * time.Hour},
+		{in: "+2d", want: 48 * time.Hour},
+		{in: "0s", want: 0},
+		{in: "-1d", wantErr: true},
+		{in: "-1h", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "1y", wantErr: true},
+		{in: "10", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			age, err := parseAge(tc.in)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, age)
+		})
+	}
+}
+
+// sizeClasses returns the size classes from first to last
+func sizeClasses(first, last int) []string {
+	var classes []string
+	for class := first; class <= last; class++ {
+		classes = append(classes, strconv.Itoa(class))
+	}
+	return classes
+}
+
+func TestSizeClassSelector(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		minSize int64
+		maxSize int64
+		// wantExcluded are the size classes selected against
+		wantExcluded []string
+	}{
+		{desc: "unbounded", minSize: 0, maxSize: -1},
+		{desc: "at least one byte", minSize: 1, maxSize: -1, wantExcluded: []string{"0"}},
+		{desc: "larger than 1K", minSize: 1025, maxSize: -1, wantExcluded: sizeClasses(0, 10)},
+		{desc: "empty", minSize: 0, maxSize: 0, wantExcluded: sizeClasses(1, maxSizeClass)},
+		{desc: "smaller than 1K", minSize: 0, maxSize: 1023, wantExcluded: sizeClasses(11, maxSizeClass)},
+		{desc: "one size class", minSize: 1024, maxSize: 2047, wantExcluded: append(sizeClasses(0, 10), sizeClasses(12, maxSizeClass)...)},
+		{desc: "largest size", minSize: 1<<63 - 1, maxSize: -1, wantExcluded: sizeClasses(0, maxSizeClass-1)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var excluded []string
+			for _, selector := range sizeClassSelector(tc.minSize, tc.maxSize) {
+				requirements := selector.Requirements()
+				require.Len(t, requirements, 1)
+				assert.Equal(t, cdnv1alpha1.FieldSizeClass, requirements[0].Field)
+				excluded = append(excluded, requirements[0].Value)
+			}
+			assert.Equal(t, tc.wantExcluded, excluded)
+		})
+	}
+}
+
+func TestFindCriteriaSize(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		largerThan  string
+		smallerThan string
+		wantMin     int64
+		wantMax     int64
+		wantErr     bool
+		// matches maps File sizes to whether they are found
+		matches map[int64]bool
+	}{
+		{desc: "no bounds", wantMax: -1, matches: map[int64]bool{0: true, 1 << 40: true}},
+		{desc: "larger than", largerThan: "1K", wantMin: 1025, wantMax: -1, matches: map[int64]bool{1024: false, 1025: true}},
+		{desc: "smaller than", smallerThan: "1K", wantMax: 1023, matches: map[int64]bool{1023: true, 1024: false}},
+		{desc: "between", largerThan: "1K", smallerThan: "1M", wantMin: 1025, wantMax: 1<<20 - 1, matches: map[int64]bool{1024: false, 4096: true, 1 << 20: false}},
+		{desc: "empty range", largerThan: "1M", smallerThan: "1K", wantErr: true},
+		{desc: "smaller than nothing", smallerThan: "0", wantErr: true},
+		{desc: "invalid size", largerThan: "big", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			o := &FindOptions{LargerThan: tc.largerThan, SmallerThan: tc.smallerThan}
+			filter, _, err := o.criteria(time.Now())
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantMin, filter.minSize)
+			assert.Equal(t, tc.wantMax, filter.maxSize)
+			for size, want := range tc.matches {
+				file := &cdnv1alpha1.File{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Now()}, Spec: cdnv1alpha1.FileSpec{Size: size}}
+				assert.Equal(t, want, filter.matches(file), "size %d", size)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+)
+
+// fsckPath is the server's consistency check endpoint
+const fsckPath = "/cdn/fsck"
+
+// FsckOptions holds the options for the fsck command
+type FsckOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Namespace
+	Namespace string
+	// All namespaces
+	AllNamespaces bool
+	// Repair the findings
+	Repair bool
+	// Fetch content again from the allowed origins while repairing
+	Refetch bool
+	// Output format; json or empty for a table
+	Output string
+}
+
+// FsckReport represents the response of the consistency check endpoint
+type FsckReport struct {
+	Namespace string        `json:"namespace,omitempty"`
+	Repair    bool          `json:"repair"`
+	Refetch   bool          `json:"refetch,omitempty"`
+	Files     int           `json:"files"`
+	Objects   int           `json:"objects"`
+	Findings  []FsckFinding `json:"findings"`
+}
+
+// FsckFinding represents an inconsistency found by the consistency check
+type FsckFinding struct {
+	Problem     string `json:"problem"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Message     string `json:"message"`
+	Repair      string `json:"repair,omitempty"`
+	RepairError string `json:"repairError,omitempty"`
+}
+
+// NewFsckOptions creates new FsckOptions with default values
+func NewFsckOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *FsckOptions {
+	return &FsckOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+	}
+}
+
+// NewCmdFsck creates the fsck command
+func NewCmdFsck(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewFsckOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "fsck",
+		Short: "Check Files against their stored content",
+		Long: `Check File resources against the content stored by the server.
+
+The server reports Files marked uploaded without content, content that cannot
+be read or does not match its checksum, content other than status.checksum,
+sizes other than spec.size, content types that are invalid or contradict the
+content, and content without a File.
+
+With --repair the server also fixes the findings: Files are updated to
+describe their stored content or marked not uploaded, and content without a
+File is deleted. With --refetch the content of Files whose
+spec.resourceLocation is on an origin the server allows with
+--fsck-refetch-origins is fetched again instead.
+
+Examples:
+  # Check the Files in the default namespace
+  kubectl cdn fsck
+
+  # Check all namespaces
+  kubectl cdn fsck -A
+
+  # Repair the findings in a namespace
+  kubectl cdn fsck -n web --repair
+
+  # Repair the findings, fetching content again from allowed origins
+  kubectl cdn fsck -n web --repair --refetch
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Findings are reported as an error; the usage does not help
+			cmd.SilenceUsage = true
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			if o.Refetch && !o.Repair {
+				return fmt.Errorf("--refetch requires --repair")
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", false, "Check Files in all namespaces")
+	cmd.Flags().BoolVar(&o.Repair, "repair", false, "Repair the findings")
+	cmd.Flags().BoolVar(&o.Refetch, "refetch", false, "Fetch content again from the origins allowed by the server while repairing")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "Output format; one of: json")
+
+	return cmd
+}
+
+// Run executes the fsck command
+func (o *FsckOptions) Run() error {
+	if o.Output != "" && o.Output != "json" {
+		return fmt.Errorf("unsupported output format %q", o.Output)
+	}
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+
+	req := client.RESTClient().Get()
+	if o.Repair {
+		req = client.RESTClient().Post()
+	}
+	req = req.AbsPath(fsckPath)
+	if !o.AllNamespaces {
+		req = req.Param("namespace", o.Namespace)
+	}
+	if o.Refetch {
+		req = req.Param("refetch", "true")
+	}
+
+	rawBody, err := req.Do(context.Background()).Raw()
+	if err != nil {
+		return fmt.Errorf("failed to check files: %w", err)
+	}
+
+	var report FsckReport
+	if err := json.Unmarshal(rawBody, &report); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if o.Output == "json" {
+		enc := json.NewEncoder(o.Out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if len(report.Findings) > 0 {
+		w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+		if o.Repair {
+			fmt.Fprintln(w, "PROBLEM\tNAMESPACE\tNAME\tMESSAGE\tREPAIR")
+		} else {
+			fmt.Fprintln(w, "PROBLEM\tNAMESPACE\tNAME\tMESSAGE")
+		}
+		for _, finding := range report.Findings {
+			if o.Repair {
+				repair := finding.Repair
+				if finding.RepairError != "" {
+					repair = fmt.Sprintf("failed: %s", finding.RepairError)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					finding.Problem, finding.Namespace, finding.Name, finding.Message, repair)
+			} else {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					finding.Problem, finding.Namespace, finding.Name, finding.Message)
+			}
+		}
+		w.Flush()
+	}
+
+	failed := 0
+	for _, finding := range report.Findings {
+		if finding.RepairError != "" {
+			failed++
+		}
+	}
+	switch {
+	case len(report.Findings) == 0:
+		fmt.Fprintf(o.ErrOut, "✓ Checked %d Files and %d content objects, no problems found\n", report.Files, report.Objects)
+	case o.Repair && failed == 0:
+		fmt.Fprintf(o.ErrOut, "✓ Checked %d Files and %d content objects, repaired %d problems\n", report.Files, report.Objects, len(report.Findings))
+	case o.Repair:
+		return fmt.Errorf("checked %d Files and %d content objects, failed to repair %d of %d problems", report.Files, report.Objects, failed, len(report.Findings))
+	default:
+		return fmt.Errorf("checked %d Files and %d content objects, found %d problems", report.Files, report.Objects, len(report.Findings))
+	}
+
+	return nil
+}
@@ -18,41 +18,64 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/scheme"
 )
 
+// defaultChunkSize is the default page size for listing Files, as in kubectl
+const defaultChunkSize = 500
+
+// addChunkSizeFlag adds the --chunk-size flag setting the page size of File
+// lists
+func addChunkSizeFlag(cmd *cobra.Command, chunkSize *int64) {
+	cmd.Flags().Int64Var(chunkSize, "chunk-size", *chunkSize, "Return large lists in chunks rather than all at once. Pass 0 to disable.")
+}
+
 // ListOptions holds the options for the list command
 type ListOptions struct {
-	IOStreams
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+	PrintFlags  *genericclioptions.PrintFlags
 
 	// Namespace
 	Namespace string
 	// All namespaces
 	AllNamespaces bool
-	// Kubeconfig path
-	KubeConfig string
-	// Context to use
-	Context string
+	// Label selector
+	Selector string
+	// Field selector
+	FieldSelector string
+	// Page size; 0 lists all Files at once
+	ChunkSize int64
+	// Omit the table header
+	NoHeaders bool
 }
 
 // NewListOptions creates new ListOptions with default values
-func NewListOptions(streams IOStreams) *ListOptions {
+func NewListOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *ListOptions {
 	return &ListOptions{
-		IOStreams: streams,
-		Namespace: "default",
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		PrintFlags:  genericclioptions.NewPrintFlags("").WithTypeSetter(scheme.Scheme),
+		ChunkSize:   defaultChunkSize,
 	}
 }
 
 // NewCmdList creates the list command
-func NewCmdList(streams IOStreams) *cobra.Command {
-	o := NewListOptions(streams)
+func NewCmdList(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewListOptions(configFlags, streams)
 
 	cmd := &cobra.Command{
 		Use:     "list",
@@ -60,7 +83,8 @@ func NewCmdList(streams IOStreams) *cobra.Command {
 		Short:   "List files in the CDN API",
 		Long: `List files from the files.cdn.k8s.toms.place API.
 
-This command lists all File resources in the specified namespace.
+This command lists all File resources in the specified namespace, or those
+matching a label or field selector. Files are listed in pages of --chunk-size.
 
 Examples:
   # List files in the default namespace
@@ -69,139 +93,141 @@ Examples:
   # List files in a specific namespace
   kubectl cdn list -n my-namespace
 
-  # List files in all namespaces
-  kubectl cdn list -A
+  # List files in all namespaces, with more columns
+  kubectl cdn list -A -o wide
+
+  # List the Files of an uploaded archive as YAML
+  kubectl cdn list -l cdn.k8s.toms.place/archive=web -o yaml
+
+  # Print the name and checksum of every File
+  kubectl cdn list -o jsonpath='{range .items[*]}{.metadata.name} {.status.checksum}{"\n"}{end}'
 `,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
 			return o.Run()
 		},
 	}
 
-	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "Namespace of the File resources")
+	o.PrintFlags.AddFlags(cmd)
+	cmd.Flags().Lookup("output").Usage = fmt.Sprintf("Output format. One of: (%s).", strings.Join(append(o.PrintFlags.AllowedFormats(), "wide"), ", "))
 	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", false, "List files in all namespaces")
-	cmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "Path to kubeconfig file")
-	cmd.Flags().StringVar(&o.Context, "context", "", "Kubernetes context to use")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Label selector to filter on")
+	cmd.Flags().StringVar(&o.FieldSelector, "field-selector", "", "Field selector to filter on")
+	addChunkSizeFlag(cmd, &o.ChunkSize)
+	cmd.Flags().BoolVar(&o.NoHeaders, "no-headers", false, "Don't print headers in the table output")
 
 	return cmd
 }
 
-// FileListResponse represents the API response for listing files
-type FileListResponse struct {
-	metav1.TypeMeta `json:",inline"`
-	metav1.ListMeta `json:"metadata"`
-	Items           []FileResponse `json:"items"`
-}
-
-// FileResponse represents a single file in the list
-type FileResponse struct {
-	metav1.TypeMeta   `json:",inline"`
-	metav1.ObjectMeta `json:"metadata"`
-	Spec              FileSpecResponse   `json:"spec"`
-	Status            FileStatusResponse `json:"status"`
-}
-
-// FileSpecResponse represents the spec of a file
-type FileSpecResponse struct {
-	URL         string `json:"url,omitempty"`
-	Size        int64  `json:"size,omitempty"`
-	ContentType string `json:"contentType,omitempty"`
-}
-
-// FileStatusResponse represents the status of a file
-type FileStatusResponse struct {
-	Uploaded bool   `json:"uploaded,omitempty"`
-	Error    string `json:"error,omitempty"`
-}
-
 // Run executes the list command
 func (o *ListOptions) Run() error {
-	// Build kubernetes client config
-	config, err := o.buildConfig()
-	if err != nil {
-		return fmt.Errorf("failed to build kubernetes config: %w", err)
+	output := *o.PrintFlags.OutputFormat
+	if output != "" && output != "wide" {
+		// Fail before listing if the format is unknown
+		if _, err := o.PrintFlags.ToPrinter(); err != nil {
+			return err
+		}
 	}
 
-	// Create REST client for the CDN API
-	cdnConfig := *config
-	cdnConfig.APIPath = "/apis"
-	cdnConfig.GroupVersion = &cdnGroupVersion
-	cdnConfig.NegotiatedSerializer = cdnCodec
-
-	client, err := rest.RESTClientFor(&cdnConfig)
+	client, err := newCdnClient(o.ConfigFlags)
 	if err != nil {
-		return fmt.Errorf("failed to create REST client: %w", err)
+		return err
 	}
 
-	// Build the URL
-	var url string
+	namespace := o.Namespace
 	if o.AllNamespaces {
-		url = "/apis/cdn.k8s.toms.place/v1alpha1/files"
-	} else {
-		url = fmt.Sprintf("/apis/cdn.k8s.toms.place/v1alpha1/namespaces/%s/files", o.Namespace)
+		namespace = metav1.NamespaceAll
 	}
 
-	result := client.Get().
-		AbsPath(url).
-		Do(context.Background())
-
-	if err := result.Error(); err != nil {
-		return fmt.Errorf("failed to list files: %w", err)
-	}
-
-	rawBody, err := result.Raw()
+	// List all pages into one list, so every output format sees all Files
+	list := &cdnv1alpha1.FileList{}
+	options := metav1.ListOptions{LabelSelector: o.Selector, FieldSelector: o.FieldSelector}
+	err = listFiles(context.Background(), client.Files(namespace), options, o.ChunkSize, func(file *cdnv1alpha1.File) error {
+		list.Items = append(list.Items, *file)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
 
-	var fileList FileListResponse
-	if err := json.Unmarshal(rawBody, &fileList); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+	if output != "" && output != "wide" {
+		printer, err := o.PrintFlags.ToPrinter()
+		if err != nil {
+			return err
+		}
+		// Decoded items lack their kind, which kubectl prints for each item
+		for i := range list.Items {
+			list.Items[i].APIVersion = cdnv1alpha1.SchemeGroupVersion.String()
+			list.Items[i].Kind = "File"
+		}
+		if output != "name" {
+			return printer.PrintObj(list, o.Out)
+		}
+		// The name printer does not print lists
+		for i := range list.Items {
+			if err := printer.PrintObj(&list.Items[i], o.Out); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
+	o.printTable(list, output == "wide")
+	return nil
+}
 
-	// Print table output
-	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
-	if o.AllNamespaces {
-		fmt.Fprintln(w, "NAMESPACE\tNAME\tSIZE\tCONTENT-TYPE\tUPLOADED")
-	} else {
-		fmt.Fprintln(w, "NAME\tSIZE\tCONTENT-TYPE\tUPLOADED")
+// printTable prints the Files as a table; wide adds the resource location,
+// checksum and age
+func (o *ListOptions) printTable(list *cdnv1alpha1.FileList, wide bool) {
+	if len(list.Items) == 0 {
+		if o.AllNamespaces {
+			fmt.Fprintln(o.ErrOut, "No resources found")
+		} else {
+			fmt.Fprintf(o.ErrOut, "No resources found in %s namespace.\n", o.Namespace)
+		}
+		return
 	}
 
-	for _, file := range fileList.Items {
-		uploaded := "false"
-		if file.Status.Uploaded {
-			uploaded = "true"
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	if !o.NoHeaders {
+		var columns []string
+		if o.AllNamespaces {
+			columns = append(columns, "NAMESPACE")
+		}
+		columns = append(columns, "NAME", "SIZE", "CONTENT-TYPE", "UPLOADED")
+		if wide {
+			columns = append(columns, "RESOURCE-LOCATION", "CHECKSUM", "AGE")
 		}
+		fmt.Fprintln(w, strings.Join(columns, "\t"))
+	}
 
+	for _, file := range list.Items {
 		contentType := file.Spec.ContentType
 		if contentType == "" {
 			contentType = "-"
 		}
 
+		var row []string
 		if o.AllNamespaces {
-			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
-				file.Namespace, file.Name, file.Spec.Size, contentType, uploaded)
-		} else {
-			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n",
-				file.Name, file.Spec.Size, contentType, uploaded)
+			row = append(row, file.Namespace)
+		}
+		row = append(row, file.Name, fmt.Sprint(file.Spec.Size), contentType, fmt.Sprint(file.Status.Uploaded))
+		if wide {
+			resourceLocation := file.Spec.ResourceLocation
+			if resourceLocation == "" {
+				resourceLocation = "-"
+			}
+			checksum := file.Status.Checksum
+			if checksum == "" {
+				checksum = "-"
+			}
+			row = append(row, resourceLocation, checksum, duration.HumanDuration(time.Since(file.CreationTimestamp.Time)))
 		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
 	}
 	w.Flush()
-
-	return nil
-}
-
-// buildConfig creates the kubernetes client config
-func (o *ListOptions) buildConfig() (*rest.Config, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if o.KubeConfig != "" {
-		loadingRules.ExplicitPath = o.KubeConfig
-	}
-
-	configOverrides := &clientcmd.ConfigOverrides{}
-	if o.Context != "" {
-		configOverrides.CurrentContext = o.Context
-	}
-
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	return kubeConfig.ClientConfig()
 }
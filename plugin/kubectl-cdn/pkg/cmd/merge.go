@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+)
+
+const (
+	// maxMergeCells bounds the table used to match the changed lines of two
+	// versions; larger changes are merged as one hunk
+	maxMergeCells = 4 << 20
+
+	conflictOurs   = "<<<<<<< edited\n"
+	conflictSep    = "=======\n"
+	conflictTheirs = ">>>>>>> current\n"
+)
+
+// merge3 merges the changes from base to ours and from base to theirs line by
+// line, like diff3. Lines changed differently on both sides are kept between
+// conflict markers. It returns the merged content and whether it has
+// conflicts.
+func merge3(base, ours, theirs []byte) ([]byte, bool) {
+	baseLines, ourLines, theirLines := splitLines(base), splitLines(ours), splitLines(theirs)
+	ourMatch := matchLines(baseLines, ourLines)
+	theirMatch := matchLines(baseLines, theirLines)
+
+	var merged bytes.Buffer
+	conflicts := false
+	i, a, b := 0, 0, 0
+	for i < len(baseLines) || a < len(ourLines) || b < len(theirLines) {
+		if i < len(baseLines) && ourMatch[i] == a && theirMatch[i] == b {
+			// Unchanged on both sides
+			merged.Write(baseLines[i])
+			i, a, b = i+1, a+1, b+1
+			continue
+		}
+
+		// The hunk ends at the next base line both sides kept
+		j, aEnd, bEnd := i, len(ourLines), len(theirLines)
+		for ; j < len(baseLines); j++ {
+			if ourMatch[j] >= 0 && theirMatch[j] >= 0 {
+				aEnd, bEnd = ourMatch[j], theirMatch[j]
+				break
+			}
+		}
+		baseHunk, ourHunk, theirHunk := baseLines[i:j], ourLines[a:aEnd], theirLines[b:bEnd]
+		switch {
+		case equalLines(ourHunk, baseHunk):
+			writeLines(&merged, theirHunk)
+		case equalLines(theirHunk, baseHunk), equalLines(ourHunk, theirHunk):
+			writeLines(&merged, ourHunk)
+		default:
+			conflicts = true
+			merged.WriteString(conflictOurs)
+			writeHunk(&merged, ourHunk)
+			merged.WriteString(conflictSep)
+			writeHunk(&merged, theirHunk)
+			merged.WriteString(conflictTheirs)
+		}
+		i, a, b = j, aEnd, bEnd
+	}
+	return merged.Bytes(), conflicts
+}
+
+// hasConflictMarkers returns whether data has a line starting a conflict left
+// by merge3
+func hasConflictMarkers(data []byte) bool {
+	for _, line := range splitLines(data) {
+		if bytes.Equal(line, []byte(conflictOurs)) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits data after each newline
+func splitLines(data []byte) [][]byte {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchLines returns, for each line of base, the index of the line of other
+// it is kept as in a longest common subsequence, or -1 if it was removed
+func matchLines(base, other [][]byte) []int {
+	match := make([]int, len(base))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// Lines shared at the start and end need no table
+	prefix := 0
+	for prefix < len(base) && prefix < len(other) && bytes.Equal(base[prefix], other[prefix]) {
+		match[prefix] = prefix
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(other)-prefix &&
+		bytes.Equal(base[len(base)-1-suffix], other[len(other)-1-suffix]) {
+		match[len(base)-1-suffix] = len(other) - 1 - suffix
+		suffix++
+	}
+	x, y := base[prefix:len(base)-suffix], other[prefix:len(other)-suffix]
+	if len(x) == 0 || len(y) == 0 || (len(x)+1)*(len(y)+1) > maxMergeCells {
+		return match
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:]
+	width := len(y) + 1
+	lcs := make([]int32, (len(x)+1)*width)
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if bytes.Equal(x[i], y[j]) {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(x) && j < len(y); {
+		switch {
+		case bytes.Equal(x[i], y[j]):
+			match[prefix+i] = prefix + j
+			i, j = i+1, j+1
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+func equalLines(x, y [][]byte) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !bytes.Equal(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLines(buf *bytes.Buffer, lines [][]byte) {
+	for _, line := range lines {
+		buf.Write(line)
+	}
+}
+
+// writeHunk writes the lines of one side of a conflict, ending the last one
+// so that the following marker starts a line
+func writeHunk(buf *bytes.Buffer, lines [][]byte) {
+	writeLines(buf, lines)
+	if len(lines) > 0 && !bytes.HasSuffix(lines[len(lines)-1], []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		base          string
+		ours          string
+		theirs        string
+		want          string
+		wantConflicts bool
+	}{
+		{
+			desc: "unchanged",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nb\nc\n",
+		},
+		{
+			desc: "only ours changed",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			desc: "only theirs changed",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nb\nC\n",
+			want: "a\nb\nC\n",
+		},
+		{
+			desc: "different lines changed",
+			base: "a\nb\nc\nd\n", ours: "A\nb\nc\nd\n", theirs: "a\nb\nc\nD\n",
+			want: "A\nb\nc\nD\n",
+		},
+		{
+			desc: "same change on both sides",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nB\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			desc: "insertion and deletion",
+			base: "a\nb\nc\n", ours: "a\nx\nb\nc\n", theirs: "a\nb\n",
+			want: "a\nx\nb\n",
+		},
+		{
+			desc: "appended on both sides",
+			base: "a\n", ours: "a\nb\n", theirs: "a\nc\n",
+			want:          "a\n<<<<<<< edited\nb\n=======\nc\n>>>>>>> current\n",
+			wantConflicts: true,
+		},
+		{
+			desc: "overlapping edits",
+			base: "a\nb\nc\n", ours: "a\nB1\nc\n", theirs: "a\nB2\nc\n",
+			want:          "a\n<<<<<<< edited\nB1\n=======\nB2\n>>>>>>> current\nc\n",
+			wantConflicts: true,
+		},
+		{
+			desc: "edit and deletion of the same line",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nc\n",
+			want:          "a\n<<<<<<< edited\nB\n=======\n>>>>>>> current\nc\n",
+			wantConflicts: true,
+		},
+		{
+			desc: "conflict without trailing newline",
+			base: "a\nb", ours: "a\nx", theirs: "a\ny",
+			want:          "a\n<<<<<<< edited\nx\n=======\ny\n>>>>>>> current\n",
+			wantConflicts: true,
+		},
+		{
+			desc: "empty base with the same content",
+			base: "", ours: "x\n", theirs: "x\n",
+			want: "x\n",
+		},
+		{
+			desc: "empty base with content on one side",
+			base: "", ours: "", theirs: "y\n",
+			want: "y\n",
+		},
+		{
+			desc: "empty base with different content",
+			base: "", ours: "x\n", theirs: "y\n",
+			want:          "<<<<<<< edited\nx\n=======\ny\n>>>>>>> current\n",
+			wantConflicts: true,
+		},
+		{
+			desc: "everything removed",
+			base: "a\nb\n", ours: "", theirs: "a\nb\n",
+			want: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			merged, conflicts := merge3([]byte(tc.base), []byte(tc.ours), []byte(tc.theirs))
+			assert.Equal(t, tc.want, string(merged))
+			assert.Equal(t, tc.wantConflicts, conflicts)
+			assert.Equal(t, tc.wantConflicts, hasConflictMarkers(merged))
+		})
+	}
+}
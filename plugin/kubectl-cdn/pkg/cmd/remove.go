@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// RemoveOptions holds the options for the rm command
+type RemoveOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Names of the Files to delete, optionally prefixed with their namespace
+	// and a slash
+	Names []string
+	// Namespace
+	Namespace string
+	// Label selector of the Files to delete
+	Selector string
+	// Name prefix of the Files to delete
+	Prefix string
+	// Delete without asking for confirmation
+	Yes bool
+	// Only check the deletion on the server
+	DryRun bool
+	// Page size of File lists; 0 lists all Files at once
+	ChunkSize int64
+}
+
+// NewRemoveOptions creates new RemoveOptions with default values
+func NewRemoveOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *RemoveOptions {
+	return &RemoveOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		ChunkSize:   defaultChunkSize,
+	}
+}
+
+// NewCmdRemove creates the rm command
+func NewCmdRemove(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewRemoveOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:     "rm [resource-name...]",
+		Aliases: []string{"delete"},
+		Short:   "Delete Files and their content",
+		Long: `Delete File resources and their content.
+
+The Files are selected by name, or by a label selector and/or name prefix.
+Names of Files in another namespace are prefixed with it and a slash, as
+printed by find -A.
+The selected Files are listed and deleted after confirmation; --yes skips the
+confirmation. With --dry-run the deletion is only checked by the server,
+including its authorization, and nothing is deleted.
+
+Examples:
+  # Delete a File
+  kubectl cdn rm my-index
+
+  # Delete all Files of an uploaded archive without confirmation
+  kubectl cdn rm -l cdn.k8s.toms.place/archive=web --yes
+
+  # Show which Files starting with "web-" would be deleted
+  kubectl cdn rm --prefix web- --dry-run
+
+  # Delete the Files found by find
+  kubectl cdn find -A --not-uploaded | xargs -r kubectl cdn rm -y
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Names = args
+			if len(o.Names) == 0 && o.Selector == "" && o.Prefix == "" {
+				return fmt.Errorf("specify the Files to delete by name, --selector or --prefix")
+			}
+			if len(o.Names) > 0 && (o.Selector != "" || o.Prefix != "") {
+				return fmt.Errorf("resource names cannot be combined with --selector or --prefix")
+			}
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Label selector of the Files to delete")
+	cmd.Flags().StringVar(&o.Prefix, "prefix", "", "Delete the Files whose name starts with this prefix")
+	addChunkSizeFlag(cmd, &o.ChunkSize)
+	cmd.Flags().BoolVarP(&o.Yes, "yes", "y", false, "Delete without asking for confirmation")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "Only check the deletion on the server")
+
+	return cmd
+}
+
+// Run executes the rm command
+func (o *RemoveOptions) Run() error {
+	ctx := context.Background()
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+
+	var refs []types.NamespacedName
+	for _, name := range o.Names {
+		ref, err := o.parseName(name)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		names, err := o.selectFiles(ctx, client)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Fprintf(o.ErrOut, "No Files found in %s\n", o.Namespace)
+			return nil
+		}
+		for _, name := range names {
+			refs = append(refs, types.NamespacedName{Namespace: o.Namespace, Name: name})
+		}
+	}
+
+	if !o.DryRun && !o.Yes {
+		for _, ref := range refs {
+			fmt.Fprintf(o.ErrOut, "  %s\n", ref)
+		}
+		ok, err := confirm(o.In, o.ErrOut, fmt.Sprintf("Delete %d File(s) and their content?", len(refs)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	options := metav1.DeleteOptions{}
+	suffix := ""
+	if o.DryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+		suffix = " (dry run)"
+	}
+	failed := 0
+	for _, ref := range refs {
+		if err := client.Files(ref.Namespace).Delete(ctx, ref.Name, options); err != nil {
+			fmt.Fprintf(o.ErrOut, "✗ %s: %v\n", ref, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(o.Out, "file/%s deleted%s\n", ref.Name, suffix)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d Files", failed, len(refs))
+	}
+	return nil
+}
+
+// parseName returns the File named by an argument, which is in the
+// namespace of the options unless it is prefixed with another one
+func (o *RemoveOptions) parseName(name string) (types.NamespacedName, error) {
+	namespace, fileName, ok := strings.Cut(name, "/")
+	if !ok {
+		return types.NamespacedName{Namespace: o.Namespace, Name: name}, nil
+	}
+	if namespace == "" || fileName == "" || strings.Contains(fileName, "/") {
+		return types.NamespacedName{}, fmt.Errorf("invalid File %q, expected a name or namespace/name", name)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: fileName}, nil
+}
+
+// selectFiles returns the names of the Files matching the selector and prefix
+func (o *RemoveOptions) selectFiles(ctx context.Context, client cdnclient.CdnV1alpha1Interface) ([]string, error) {
+	var names []string
+	err := listFiles(ctx, client.Files(o.Namespace), metav1.ListOptions{LabelSelector: o.Selector}, o.ChunkSize, func(file *cdnv1alpha1.File) error {
+		if strings.HasPrefix(file.Name, o.Prefix) {
+			names = append(names, file.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// confirm asks question on out and returns whether the answer read from in
+// is yes. Without an answer, it is no.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,349 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/util/retry"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// RestoreOptions holds the options for the restore command
+type RestoreOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Backup archives, from the full backup to the latest incremental one
+	Archives []string
+	// Namespaces to restore; all namespaces in the backup if empty
+	Namespaces []string
+	// Retries of uploads failing with a transient error
+	Retries int
+}
+
+// restoreChange is how restore changed the metadata of a File
+type restoreChange int
+
+const (
+	restoreNone restoreChange = iota
+	restoreCreated
+	restoreUpdated
+)
+
+// restoreSummary counts what a restore changed
+type restoreSummary struct {
+	created   int
+	updated   int
+	uploaded  int
+	unchanged int
+}
+
+// NewRestoreOptions creates new RestoreOptions with default values
+func NewRestoreOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *RestoreOptions {
+	return &RestoreOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		Retries:     5,
+	}
+}
+
+// NewCmdRestore creates the restore command
+func NewCmdRestore(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewRestoreOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "restore [archive...]",
+		Short: "Restore Files and their content from backup archives",
+		Long: `Restore File resources and their content from backup archives.
+
+Pass the full backup followed by its incremental backups in order; the Files
+of the last archive are restored, with their blobs taken from any archive of
+the chain. Every blob is verified against its SHA-256 checksum before it is
+uploaded, and the server verifies it again through the Content-Digest header.
+Uploads are retried after transient errors.
+
+Restore is idempotent: Files whose labels, annotations and content already
+match the backup are left untouched, so an interrupted restore can simply be
+run again.
+
+Examples:
+  # Restore a full backup
+  kubectl cdn restore backup-full.tgz
+
+  # Restore the state of an incremental backup
+  kubectl cdn restore backup-full.tgz backup-1.tgz backup-2.tgz
+
+  # Restore only some namespaces
+  kubectl cdn restore backup-full.tgz -n web
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Archives = args
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&o.Namespaces, "namespace", "n", nil, "Namespaces to restore (default all namespaces in the backup)")
+	cmd.Flags().IntVar(&o.Retries, "retries", o.Retries, "Number of retries of each upload after transient errors")
+
+	return cmd
+}
+
+// Run executes the restore command
+func (o *RestoreOptions) Run() error {
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	content, err := newContentClients(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	return o.restore(context.Background(), client, content)
+}
+
+// restore restores the Files of the archives through client, and their
+// content through content
+func (o *RestoreOptions) restore(ctx context.Context, client cdnclient.CdnV1alpha1Interface, content contentclient.Interface) error {
+	manifest, err := o.readChain()
+	if err != nil {
+		return err
+	}
+
+	namespaces := map[string]bool{}
+	for _, namespace := range o.Namespaces {
+		namespaces[namespace] = true
+	}
+
+	// Restore the metadata first, and collect the Files that need content
+	summary := &restoreSummary{}
+	pending := map[string][]BackupFile{}
+	for _, file := range manifest.Files {
+		if len(namespaces) > 0 && !namespaces[file.Namespace] {
+			continue
+		}
+		changed, upToDate, err := restoreFileMetadata(ctx, client, content, file)
+		if err != nil {
+			return err
+		}
+		switch {
+		case changed == restoreCreated:
+			summary.created++
+		case changed == restoreUpdated:
+			summary.updated++
+		case upToDate:
+			summary.unchanged++
+		}
+		if upToDate {
+			continue
+		}
+		pending[file.Checksum] = append(pending[file.Checksum], file)
+	}
+
+	// Then upload each blob that is needed, from whichever archive holds it
+	for _, archive := range o.Archives {
+		if len(pending) == 0 {
+			break
+		}
+		err := walkBackup(archive, func(hdr *tar.Header, r io.Reader) error {
+			checksum, ok := strings.CutPrefix(hdr.Name, backupBlobPrefix)
+			if !ok || len(pending[checksum]) == 0 {
+				return nil
+			}
+			blob, err := spoolBlob(r, checksum)
+			if err != nil {
+				return fmt.Errorf("backup %s: %w", archive, err)
+			}
+			defer os.Remove(blob.Name())
+			defer blob.Close()
+			for _, file := range pending[checksum] {
+				if err := o.restoreFileContent(ctx, content, file, blob); err != nil {
+					return err
+				}
+				summary.uploaded++
+			}
+			delete(pending, checksum)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(pending) > 0 {
+		var missing []string
+		for _, files := range pending {
+			for _, file := range files {
+				missing = append(missing, file.Namespace+"/"+file.Name)
+			}
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("the content of %d Files is not in the given backups: %s",
+			len(missing), strings.Join(missing, ", "))
+	}
+
+	fmt.Fprintf(o.ErrOut, "✓ Restored backup %s (%d Files created, %d updated, %d uploaded, %d unchanged)\n",
+		manifest.ID, summary.created, summary.updated, summary.uploaded, summary.unchanged)
+
+	return nil
+}
+
+// readChain reads the manifests of the archives, checks that each archive is
+// incremental to the one before it, and returns the last manifest
+func (o *RestoreOptions) readChain() (*BackupManifest, error) {
+	var previous *BackupManifest
+	for _, archive := range o.Archives {
+		manifest, err := readBackupManifest(archive)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case previous == nil && manifest.Base != "":
+			return nil, fmt.Errorf("backup %s is incremental to backup %s, pass the base archives first", archive, manifest.Base)
+		case previous != nil && manifest.Base != previous.ID:
+			return nil, fmt.Errorf("backup %s is not incremental to backup %s", archive, previous.ID)
+		}
+		previous = manifest
+	}
+	return previous, nil
+}
+
+// restoreFileMetadata creates the File or updates its labels, annotations and
+// resource location to match the backup. It returns how the File was changed
+// and whether its content already matches the backup.
+func restoreFileMetadata(ctx context.Context, client cdnclient.CdnV1alpha1Interface, content contentclient.Interface, file BackupFile) (restoreChange, bool, error) {
+	files := client.Files(file.Namespace)
+	resourceLocation := file.Object.Spec.ResourceLocation
+
+	changed := restoreNone
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := files.Get(ctx, file.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			obj := &cdnv1alpha1.File{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   file.Namespace,
+					Name:        file.Name,
+					Labels:      file.Object.Labels,
+					Annotations: file.Object.Annotations,
+				},
+				Spec: cdnv1alpha1.FileSpec{ResourceLocation: resourceLocation},
+			}
+			if _, err := files.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+			changed = restoreCreated
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if reflect.DeepEqual(current.Labels, file.Object.Labels) &&
+			reflect.DeepEqual(current.Annotations, file.Object.Annotations) &&
+			current.Spec.ResourceLocation == resourceLocation {
+			return nil
+		}
+
+		current.Labels = file.Object.Labels
+		current.Annotations = file.Object.Annotations
+		current.Spec.ResourceLocation = resourceLocation
+		if _, err := files.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		changed = restoreUpdated
+		return nil
+	})
+	if err != nil {
+		return changed, false, fmt.Errorf("failed to restore file %s/%s: %w", file.Namespace, file.Name, err)
+	}
+
+	if file.Checksum == "" {
+		return changed, true, nil
+	}
+	// The status may outlive the content if the content store was lost, so
+	// ask the content store itself
+	stat, err := content.Files(file.Namespace).Stat(ctx, file.Name)
+	if err != nil {
+		return changed, false, fmt.Errorf("failed to get content of %s/%s: %w", file.Namespace, file.Name, err)
+	}
+	return changed, stat.Stored && stat.Checksum == file.Checksum, nil
+}
+
+// spoolBlob copies the blob read from r to a temporary file, verifying it
+// against checksum, so that it can be uploaded and retried without holding
+// it in memory. The caller removes the file.
+func spoolBlob(r io.Reader, checksum string) (*os.File, error) {
+	blob, err := os.CreateTemp("", "kubectl-cdn-restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	digest := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(blob, digest), r); err != nil {
+		blob.Close()
+		os.Remove(blob.Name())
+		return nil, fmt.Errorf("failed to read blob %s: %w", checksum, err)
+	}
+	if hex.EncodeToString(digest.Sum(nil)) != checksum {
+		blob.Close()
+		os.Remove(blob.Name())
+		return nil, fmt.Errorf("backup is corrupt: blob %s does not match its checksum", checksum)
+	}
+	return blob, nil
+}
+
+// restoreFileContent uploads the verified blob as the content of file
+func (o *RestoreOptions) restoreFileContent(ctx context.Context, content contentclient.Interface, file BackupFile, blob *os.File) error {
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if _, err := blob.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", file.Checksum, err)
+	}
+
+	_, err := content.Files(file.Namespace).Upload(ctx, file.Name, blob, contentclient.UploadOptions{
+		ContentType: contentType,
+		Backoff:     transferBackoff(o.Retries),
+		OnRetry: func(err error, delay time.Duration, retry, retries int) {
+			fmt.Fprintf(o.ErrOut, "Retrying %s/%s in %s (%d/%d): %v\n", file.Namespace, file.Name, delay.Round(time.Millisecond), retry, retries, err)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload content of %s/%s: %w", file.Namespace, file.Name, err)
+	}
+	return nil
+}
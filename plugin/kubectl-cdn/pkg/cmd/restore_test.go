@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+	contentfake "k8s.toms.place/apiserver/pkg/cdnclient/fake"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/fake"
+)
+
+// writeTestBackup writes a backup archive of files with the given blobs to
+// a temporary directory and returns its path
+func writeTestBackup(t *testing.T, files []BackupFile, blobs map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "backup.tgz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	manifest := BackupManifest{Version: BackupFormatVersion, ID: "test", Files: files}
+	for checksum, data := range blobs {
+		require.NoError(t, writeTarEntry(tw, backupBlobPrefix+checksum, []byte(data)))
+		manifest.Blobs = append(manifest.Blobs, checksum)
+	}
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	require.NoError(t, writeTarEntry(tw, backupManifestName, data))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return path
+}
+
+func testBackupFile(name, checksum string) BackupFile {
+	return BackupFile{
+		Namespace:   "web",
+		Name:        name,
+		Checksum:    checksum,
+		ContentType: "text/plain",
+		Object: &cdnv1alpha1.File{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "web",
+				Name:      name,
+				Labels:    map[string]string{"app": "web"},
+			},
+		},
+	}
+}
+
+func TestRestoreIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("hello"))
+	checksum := hex.EncodeToString(sum[:])
+	archive := writeTestBackup(t, []BackupFile{
+		testBackupFile("a.txt", checksum),
+		testBackupFile("b.txt", checksum),
+		testBackupFile("empty.txt", ""),
+	}, map[string]string{checksum: "hello"})
+
+	clientset := fake.NewSimpleClientset()
+	content := contentfake.New(clientset)
+	var out bytes.Buffer
+	o := NewRestoreOptions(nil, genericiooptions.IOStreams{Out: &out, ErrOut: &out})
+	o.Archives = []string{archive}
+
+	require.NoError(t, o.restore(ctx, clientset.CdnV1alpha1(), content), out.String())
+	assert.Contains(t, out.String(), "(3 Files created, 0 updated, 2 uploaded, 0 unchanged)")
+	for _, name := range []string{"a.txt", "b.txt"} {
+		var data bytes.Buffer
+		_, err := content.Files("web").Download(ctx, name, &data, contentclient.DownloadOptions{})
+		require.NoError(t, err, name)
+		assert.Equal(t, "hello", data.String(), name)
+	}
+	file, err := clientset.CdnV1alpha1().Files("web").Get(ctx, "a.txt", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "web", file.Labels["app"])
+
+	// Running the restore again changes nothing
+	out.Reset()
+	require.NoError(t, o.restore(ctx, clientset.CdnV1alpha1(), content), out.String())
+	assert.Contains(t, out.String(), "(0 Files created, 0 updated, 0 uploaded, 3 unchanged)")
+	stat, err := content.Files("web").Stat(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, stat.Versions)
+}
+
+func TestRestoreRejectsCorruptBlob(t *testing.T) {
+	sum := sha256.Sum256([]byte("hello"))
+	checksum := hex.EncodeToString(sum[:])
+	archive := writeTestBackup(t, []BackupFile{testBackupFile("a.txt", checksum)}, map[string]string{checksum: "HELLO"})
+
+	clientset := fake.NewSimpleClientset()
+	content := contentfake.New(clientset)
+	var out bytes.Buffer
+	o := NewRestoreOptions(nil, genericiooptions.IOStreams{Out: &out, ErrOut: &out})
+	o.Archives = []string{archive}
+
+	err := o.restore(context.Background(), clientset.CdnV1alpha1(), content)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match its checksum")
+	stat, err := content.Files("web").Stat(context.Background(), "a.txt")
+	require.NoError(t, err)
+	assert.False(t, stat.Stored)
+}
@@ -0,0 +1,732 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+const (
+	// liveReloadPath is where browsers wait for reload notifications. It
+	// takes precedence over a File served at the same path.
+	liveReloadPath = "/.cdn-serve/reload"
+	// liveReloadScript is injected into served HTML to reload the page when
+	// notified
+	liveReloadScript = `<script>new EventSource("` + liveReloadPath + `").onmessage = function() { location.reload() }</script>`
+	// overlayPollInterval is how often the overlay directory is checked for
+	// changes
+	overlayPollInterval = time.Second
+	// routesRetryInterval is how long to wait before watching the Files again
+	// after an error
+	routesRetryInterval = 5 * time.Second
+)
+
+// ServeOptions holds the options for the serve command
+type ServeOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Namespace
+	Namespace string
+	// Prefix of the Files to serve, the value of their archive label
+	Prefix string
+	// Label selector of the Files to serve
+	Selector string
+	// Address to listen on
+	Address string
+	// Port to listen on
+	Port int
+	// Local directory served in front of the Files
+	Overlay string
+	// Index document of directory-style paths
+	IndexDocument string
+	// Reload pages in the browser when what they serve changes
+	LiveReload bool
+}
+
+// NewServeOptions creates new ServeOptions with default values
+func NewServeOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *ServeOptions {
+	return &ServeOptions{
+		IOStreams:     streams,
+		ConfigFlags:   configFlags,
+		Address:       "127.0.0.1",
+		Port:          8080,
+		IndexDocument: "index.html",
+		LiveReload:    true,
+	}
+}
+
+// NewCmdServe creates the serve command
+func NewCmdServe(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewServeOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Preview Files as a website on a local HTTP server",
+		Long: `Run a local HTTP server that serves the Files of a namespace as a website.
+
+Each File is served at the path in its cdn.k8s.toms.place/path annotation, as
+set by sync and archive uploads, or else at /{name}, like a Site selecting
+it. Directory-style paths serve their index document, and paths of a
+directory without the trailing slash are redirected to it. Requests are
+proxied to the content of the File with your kube credentials and displayed
+inline rather than downloaded. The Files are watched, so new and changed
+Files are served without restarting.
+
+With --prefix, only the Files uploaded by sync or as an archive with that
+prefix are served; -l selects the Files by any label selector instead.
+
+With --overlay, files in a local directory are served in front of the Files,
+so a site can be developed locally against the content in the cluster. Paths
+excluded by the directory's .cdnignore are not served from it.
+
+Unless disabled with --live-reload=false, HTML pages are reloaded in the
+browser when the served Files or the overlay directory change.
+
+The server listens on 127.0.0.1 by default, as anyone who can reach it reads
+the Files with your credentials. Stop it with Ctrl-C.
+
+Examples:
+  # Preview the Files synchronised with prefix web at http://127.0.0.1:8080
+  kubectl cdn serve --prefix web -n my-namespace
+
+  # Develop ./dist locally in front of the Files on another port
+  kubectl cdn serve --prefix web --overlay ./dist --port 3000
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.Prefix != "" && o.Selector != "" {
+				return fmt.Errorf("--prefix and --selector cannot be combined")
+			}
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Prefix, "prefix", "", "Only serve the Files with this archive label, as uploaded by sync")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Label selector of the Files to serve")
+	cmd.Flags().StringVar(&o.Address, "address", o.Address, "Address to listen on")
+	cmd.Flags().IntVar(&o.Port, "port", o.Port, "Port to listen on")
+	cmd.Flags().StringVar(&o.Overlay, "overlay", "", "Local directory served in front of the Files")
+	cmd.Flags().StringVar(&o.IndexDocument, "index", o.IndexDocument, "Index document of directory-style paths")
+	cmd.Flags().BoolVar(&o.LiveReload, "live-reload", o.LiveReload, "Reload pages in the browser when the served Files or the overlay change")
+
+	return cmd
+}
+
+// Run executes the serve command
+func (o *ServeOptions) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	httpClient, err := newContentHTTPClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+
+	selector := o.Selector
+	if o.Prefix != "" {
+		selector = labels.Set{cdnv1alpha1.LabelArchive: o.Prefix}.String()
+	}
+	if _, err := labels.Parse(selector); err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+
+	h := &serveHandler{
+		options:   o,
+		client:    client,
+		transport: httpClient.Transport,
+		routes:    newServeRoutes(),
+		reload:    newLiveReload(),
+	}
+	if o.Overlay != "" {
+		info, err := os.Stat(o.Overlay)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", o.Overlay)
+		}
+		h.ignore, err = readCdnIgnore(filepath.Join(o.Overlay, cdnIgnoreFile))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", cdnIgnoreFile, err)
+		}
+	}
+
+	// Serve the current Files from the start, then keep them up to date
+	files := client.Files(o.Namespace)
+	listOptions := metav1.ListOptions{LabelSelector: selector}
+	list, err := files.List(ctx, listOptions)
+	if err != nil {
+		return fmt.Errorf("failed to list Files: %w", err)
+	}
+	h.routes.reset(list.Items)
+	go h.watchRoutes(ctx, files, listOptions, list.ResourceVersion)
+	if o.Overlay != "" && o.LiveReload {
+		go h.pollOverlay(ctx)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(o.Address, strconv.Itoa(o.Port)))
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	from := fmt.Sprintf("%d Files of %s", len(list.Items), o.Namespace)
+	if selector != "" {
+		from += " matching " + selector
+	}
+	if o.Overlay != "" {
+		from += " with " + o.Overlay + " in front"
+	}
+	fmt.Fprintf(o.ErrOut, "✓ Serving %s at http://%s\n", from, listener.Addr())
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveHandler serves the Files and the overlay directory
+type serveHandler struct {
+	options   *ServeOptions
+	client    cdnclient.CdnV1alpha1Interface
+	transport http.RoundTripper
+	routes    *serveRoutes
+	reload    *liveReload
+	// ignore holds the .cdnignore patterns of the overlay directory
+	ignore cdnIgnore
+}
+
+// ServeHTTP serves GET and HEAD requests from the overlay or the Files
+func (h *serveHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == liveReloadPath && h.options.LiveReload {
+		h.reload.ServeHTTP(w, req)
+		return
+	}
+
+	logged := &loggingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	source := h.serve(logged, req)
+	fmt.Fprintf(h.options.ErrOut, "%s  %s %s  %d  %s\n", time.Now().Format("15:04:05"), req.Method, req.URL.Path, logged.status, source)
+}
+
+// serve writes the response to req and returns where it was served from
+func (h *serveHandler) serve(w http.ResponseWriter, req *http.Request) string {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return "-"
+	}
+
+	// Keep the trailing slash of directory-style paths
+	p := path.Clean("/" + req.URL.Path)
+	if strings.HasSuffix(req.URL.Path, "/") && p != "/" {
+		p += "/"
+	}
+
+	if h.options.Overlay != "" {
+		if file, redirect, ok := h.overlayLookup(p); ok {
+			if redirect {
+				redirectToDirectory(w, req, p)
+				return "redirect"
+			}
+			h.serveOverlay(w, req, file)
+			return "overlay " + file
+		}
+	}
+
+	name, redirect, ok := h.routes.lookup(p, h.options.IndexDocument)
+	switch {
+	case !ok:
+		http.Error(w, fmt.Sprintf("no File is served at %s", p), http.StatusNotFound)
+		return "-"
+	case redirect:
+		redirectToDirectory(w, req, p)
+		return "redirect"
+	}
+	h.proxyContent(w, req, name)
+	return "File " + name
+}
+
+// proxyContent proxies req to the content of the named File, to be displayed
+// inline
+func (h *serveHandler) proxyContent(w http.ResponseWriter, req *http.Request, name string) {
+	target := contentRequest(h.client, http.MethodGet, h.options.Namespace, name).URL()
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL = target
+			r.Out.Host = ""
+			// Only forward the headers the content subresource uses; the
+			// transport authenticates the request
+			r.Out.Header = http.Header{}
+			for _, header := range []string{"Accept", "Range", "If-Range", "If-None-Match", "If-Modified-Since"} {
+				if values := r.In.Header.Values(header); len(values) > 0 {
+					r.Out.Header[header] = values
+				}
+			}
+		},
+		Transport: h.transport,
+		ModifyResponse: func(resp *http.Response) error {
+			resp.Header.Del("Content-Disposition")
+			if resp.StatusCode >= http.StatusBadRequest {
+				return replaceStatusBody(resp)
+			}
+			if h.options.LiveReload && resp.StatusCode == http.StatusOK && resp.Request.Method == http.MethodGet &&
+				isHTMLContentType(resp.Header.Get("Content-Type")) {
+				return injectLiveReload(resp)
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			http.Error(w, fmt.Sprintf("failed to get the content of %s: %v", name, err), http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, req)
+}
+
+// overlayLookup returns the file of the overlay directory served at p, and
+// whether p should be redirected to its directory-style path
+func (h *serveHandler) overlayLookup(p string) (string, bool, bool) {
+	rel := strings.TrimPrefix(p, "/")
+	if strings.HasSuffix(p, "/") || rel == "" {
+		rel = path.Join(rel, h.options.IndexDocument)
+	}
+	if h.overlayIgnored(rel) {
+		return "", false, false
+	}
+
+	file := filepath.Join(h.options.Overlay, filepath.FromSlash(rel))
+	info, err := os.Stat(file)
+	switch {
+	case err != nil:
+		return "", false, false
+	case info.Mode().IsRegular():
+		return file, false, true
+	case info.IsDir() && !strings.HasSuffix(p, "/"):
+		// A directory with an index document is served at its
+		// directory-style path
+		index, err := os.Stat(filepath.Join(file, h.options.IndexDocument))
+		if err == nil && index.Mode().IsRegular() && !h.overlayIgnored(path.Join(rel, h.options.IndexDocument)) {
+			return file, true, true
+		}
+	}
+	return "", false, false
+}
+
+// overlayIgnored returns whether the slash-separated path relative to the
+// overlay directory, or one of its parent directories, is excluded by its
+// .cdnignore, so sync would not upload it
+func (h *serveHandler) overlayIgnored(rel string) bool {
+	if rel == cdnIgnoreFile {
+		return true
+	}
+	segments := strings.Split(rel, "/")
+	for i := range segments {
+		if h.ignore.Match(strings.Join(segments[:i+1], "/"), i < len(segments)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveOverlay serves a file of the overlay directory with the content type
+// sync would upload it with
+func (h *serveHandler) serveOverlay(w http.ResponseWriter, req *http.Request, file string) {
+	f, err := os.Open(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := syncContentType(file)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !h.options.LiveReload || !isHTMLContentType(contentType) {
+		http.ServeContent(w, req, file, info.ModTime(), f)
+		return
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, req, file, info.ModTime(), bytes.NewReader(withLiveReload(data)))
+}
+
+// watchRoutes keeps the served Files up to date until ctx is done. Errors are
+// reported and the Files listed again.
+func (h *serveHandler) watchRoutes(ctx context.Context, files cdnclient.FileInterface, options metav1.ListOptions, resourceVersion string) {
+	for {
+		err := watchFiles(ctx, files, options, resourceVersion, func(eventType watch.EventType, file *cdnv1alpha1.File) error {
+			h.routes.update(eventType, file)
+			h.reload.notify()
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if !apierrors.IsResourceExpired(err) && !apierrors.IsGone(err) {
+			fmt.Fprintf(h.options.ErrOut, "! Failed to watch Files, retrying: %v\n", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(routesRetryInterval):
+			}
+		}
+
+		list, err := files.List(ctx, options)
+		for err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			fmt.Fprintf(h.options.ErrOut, "! Failed to list Files, retrying: %v\n", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(routesRetryInterval):
+			}
+			list, err = files.List(ctx, options)
+		}
+		if h.routes.reset(list.Items) {
+			h.reload.notify()
+		}
+		resourceVersion = list.ResourceVersion
+	}
+}
+
+// pollOverlay notifies the browsers whenever a file of the overlay directory
+// changes, until ctx is done
+func (h *serveHandler) pollOverlay(ctx context.Context) {
+	last := h.overlaySignature()
+	ticker := time.NewTicker(overlayPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if signature := h.overlaySignature(); signature != last {
+			last = signature
+			h.reload.notify()
+		}
+	}
+}
+
+// overlaySignature returns a hash of the paths, sizes and modification times
+// of the files in the overlay directory that are not ignored
+func (h *serveHandler) overlaySignature() uint64 {
+	hash := fnv.New64a()
+	_ = filepath.WalkDir(h.options.Overlay, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(h.options.Overlay, p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if h.ignore.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00%d\x00", rel, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return hash.Sum64()
+}
+
+// serveRoutes maps URL paths to the served Files
+type serveRoutes struct {
+	lock  sync.RWMutex
+	files map[string]*cdnv1alpha1.File
+	paths map[string]string
+}
+
+func newServeRoutes() *serveRoutes {
+	return &serveRoutes{files: map[string]*cdnv1alpha1.File{}, paths: map[string]string{}}
+}
+
+// reset replaces the served Files and returns whether any of them changed
+func (r *serveRoutes) reset(files []cdnv1alpha1.File) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	changed := len(files) != len(r.files)
+	served := make(map[string]*cdnv1alpha1.File, len(files))
+	for i := range files {
+		file := &files[i]
+		if old, ok := r.files[file.Name]; !ok || old.ResourceVersion != file.ResourceVersion {
+			changed = true
+		}
+		served[file.Name] = file
+	}
+	r.files = served
+	r.rebuild()
+	return changed
+}
+
+// update applies a watch event of a served File
+func (r *serveRoutes) update(eventType watch.EventType, file *cdnv1alpha1.File) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if eventType == watch.Deleted {
+		delete(r.files, file.Name)
+	} else {
+		r.files[file.Name] = file
+	}
+	r.rebuild()
+}
+
+// rebuild maps the path of every File to its name. Of several Files at the
+// same path, the first by name is served. The lock must be held.
+func (r *serveRoutes) rebuild() {
+	names := make([]string, 0, len(r.files))
+	for name := range r.files {
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	r.paths = make(map[string]string, len(names))
+	for _, name := range names {
+		r.paths[filePath(r.files[name])] = name
+	}
+}
+
+// lookup returns the File served at p, like a Site: the index document for
+// directory-style paths, and a redirect for directories without the trailing
+// slash
+func (r *serveRoutes) lookup(p, indexDocument string) (string, bool, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if !strings.HasSuffix(p, "/") {
+		if name, ok := r.paths[p]; ok {
+			return name, false, true
+		}
+		_, ok := r.paths[path.Join(p, indexDocument)]
+		return "", ok, ok
+	}
+	name, ok := r.paths[path.Join(p, indexDocument)]
+	return name, false, ok
+}
+
+// filePath returns the URL path file is served at by a Site selecting it
+func filePath(file *cdnv1alpha1.File) string {
+	if p, ok := file.Annotations[cdnv1alpha1.AnnotationPath]; ok && strings.HasPrefix(p, "/") {
+		return path.Clean(p)
+	}
+	return "/" + file.Name
+}
+
+// redirectToDirectory redirects req to the directory-style path of p
+func redirectToDirectory(w http.ResponseWriter, req *http.Request, p string) {
+	target := p + "/"
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	http.Redirect(w, req, target, http.StatusMovedPermanently)
+}
+
+// replaceStatusBody replaces the Status object of an API error response with
+// its message as plain text
+func replaceStatusBody(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	status := &metav1.Status{}
+	if json.Unmarshal(data, status) == nil && status.Message != "" {
+		data = []byte(status.Message + "\n")
+		resp.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	setBody(resp, data)
+	return nil
+}
+
+// injectLiveReload adds the live reload script to an HTML response. The
+// ETag no longer matches the changed content and is removed.
+func injectLiveReload(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Header.Del("ETag")
+	resp.Header.Del("Accept-Ranges")
+	resp.Header.Set("Cache-Control", "no-cache")
+	setBody(resp, withLiveReload(data))
+	return nil
+}
+
+// setBody replaces the body of resp
+func setBody(resp *http.Response, data []byte) {
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(data)))
+}
+
+// withLiveReload returns html with the live reload script inserted before its
+// closing body tag, or appended if it has none
+func withLiveReload(html []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if i < 0 {
+		i = len(html)
+	}
+	injected := make([]byte, 0, len(html)+len(liveReloadScript))
+	injected = append(injected, html[:i]...)
+	injected = append(injected, liveReloadScript...)
+	return append(injected, html[i:]...)
+}
+
+// isHTMLContentType returns whether content of the type is an HTML page
+func isHTMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// liveReload notifies connected browsers to reload their page using
+// server-sent events
+type liveReload struct {
+	lock    sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newLiveReload() *liveReload {
+	return &liveReload{clients: map[chan struct{}]struct{}{}}
+}
+
+// notify asks every connected browser to reload
+func (l *liveReload) notify() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for client := range l.clients {
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams reload notifications until the browser disconnects
+func (l *liveReload) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	client := make(chan struct{}, 1)
+	l.lock.Lock()
+	l.clients[client] = struct{}{}
+	l.lock.Unlock()
+	defer func() {
+		l.lock.Lock()
+		delete(l.clients, client)
+		l.lock.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	controller := http.NewResponseController(w)
+	fmt.Fprint(w, ": connected\n\n")
+	if err := controller.Flush(); err != nil {
+		return
+	}
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-client:
+			fmt.Fprint(w, "data: reload\n\n")
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// loggingResponseWriter records the status code of a response
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *loggingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
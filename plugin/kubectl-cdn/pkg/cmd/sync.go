@@ -0,0 +1,484 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// SyncOptions holds the options for the sync command
+type SyncOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Directory to synchronise
+	Dir string
+	// Namespace
+	Namespace string
+	// Prefix of the File names and value of the archive label
+	Prefix string
+	// Delete Files of the prefix that no longer exist locally
+	Delete bool
+	// Only print the plan
+	DryRun bool
+	// Number of concurrent uploads
+	Concurrency int
+	// Page size of File lists; 0 lists all Files at once
+	ChunkSize int64
+	// Retries of uploads failing with a transient error
+	Retries int
+}
+
+// syncActionType is what sync does to a File
+type syncActionType string
+
+const (
+	syncCreate syncActionType = "+"
+	syncUpdate syncActionType = "~"
+	syncDelete syncActionType = "-"
+)
+
+// syncAction is a change to one File
+type syncAction struct {
+	Type syncActionType
+	// Name of the File
+	Name string
+	// Path relative to the synchronised directory; empty for deletions
+	Path string
+	Size int64
+	// Upload is false if only the File's metadata changes
+	Upload      bool
+	ContentType string
+	// Existing File, nil for creations
+	Existing *cdnv1alpha1.File
+}
+
+// NewSyncOptions creates new SyncOptions with default values
+func NewSyncOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *SyncOptions {
+	return &SyncOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		Concurrency: 4,
+		ChunkSize:   defaultChunkSize,
+		Retries:     5,
+	}
+}
+
+// NewCmdSync creates the sync command
+func NewCmdSync(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewSyncOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "sync [directory]",
+		Short: "Synchronise a local directory to Files",
+		Long: `Synchronise a local directory recursively to File resources.
+
+Every regular file below the directory becomes a File named {prefix}-{path},
+with the path lowercased and characters other than letters, digits, '.' and
+'-' replaced by '-', exactly like an uploaded archive of that name. The Files
+are labelled cdn.k8s.toms.place/archive={prefix} and annotated with their path
+in cdn.k8s.toms.place/path, so a Site selecting the label serves the
+directory. The prefix defaults to the directory's name.
+
+Only files whose SHA-256 checksum differs from the File's status.checksum are
+uploaded. Uploads are streamed from the files with their checksum and retried
+after transient errors. With --delete, Files labelled with the prefix whose path no longer
+exists locally are deleted. The plan is printed before anything changes; with
+--dry-run nothing changes.
+
+Paths matching the patterns of a .cdnignore file in the directory are skipped.
+It uses the .gitignore syntax: '#' comments, '!' negations, a trailing '/' for
+directories, '*', '?' and '[...]' within a path segment and '**' across
+segments.
+
+Examples:
+  # Synchronise ./dist as the Files web-*
+  kubectl cdn sync ./dist --prefix web
+
+  # Show what would change, including deletions
+  kubectl cdn sync ./dist --prefix web --delete --dry-run
+
+  # Synchronise and delete Files removed locally
+  kubectl cdn sync ./dist --prefix web --delete -n my-namespace
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Dir = args[0]
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Prefix, "prefix", "", "Prefix of the File names and value of the archive label (default: the directory name)")
+	cmd.Flags().BoolVar(&o.Delete, "delete", false, "Delete Files of the prefix that no longer exist locally")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "Only print the plan")
+	cmd.Flags().IntVar(&o.Concurrency, "concurrency", o.Concurrency, "Number of concurrent uploads")
+	cmd.Flags().IntVar(&o.Retries, "retries", o.Retries, "Number of retries of each upload after transient errors")
+	addChunkSizeFlag(cmd, &o.ChunkSize)
+
+	return cmd
+}
+
+// Run executes the sync command
+func (o *SyncOptions) Run() error {
+	ctx := context.Background()
+
+	if o.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if o.Prefix == "" {
+		abs, err := filepath.Abs(o.Dir)
+		if err != nil {
+			return err
+		}
+		o.Prefix = strings.ToLower(filepath.Base(abs))
+	}
+	if errs := validation.IsValidLabelValue(o.Prefix); len(errs) > 0 {
+		return fmt.Errorf("invalid prefix %q: %s", o.Prefix, strings.Join(errs, "; "))
+	}
+
+	local, err := o.walk()
+	if err != nil {
+		return err
+	}
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	contentClient, err := newContentClient(o.ConfigFlags, o.Namespace)
+	if err != nil {
+		return err
+	}
+
+	files, err := listBackupFiles(ctx, client, []string{o.Namespace}, o.ChunkSize)
+	if err != nil {
+		return err
+	}
+
+	actions, unchanged, err := o.plan(local, files)
+	if err != nil {
+		return err
+	}
+
+	counts := map[syncActionType]int{}
+	for _, action := range actions {
+		counts[action.Type]++
+		switch {
+		case action.Type == syncDelete:
+			fmt.Fprintf(o.Out, "%s %s\n", action.Type, action.Name)
+		case action.Upload:
+			fmt.Fprintf(o.Out, "%s %s (%s, %d bytes)\n", action.Type, action.Name, action.Path, action.Size)
+		default:
+			fmt.Fprintf(o.Out, "%s %s (%s, metadata only)\n", action.Type, action.Name, action.Path)
+		}
+	}
+	fmt.Fprintf(o.Out, "Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		counts[syncCreate], counts[syncUpdate], counts[syncDelete], unchanged)
+
+	if o.DryRun || len(actions) == 0 {
+		return nil
+	}
+
+	failed := o.apply(ctx, client, contentClient, actions)
+	if failed > 0 {
+		return fmt.Errorf("failed to sync %d of %d Files", failed, len(actions))
+	}
+	fmt.Fprintf(o.ErrOut, "✓ Synced %s to %s (%d created, %d updated, %d deleted, %d unchanged)\n",
+		o.Dir, o.Namespace, counts[syncCreate], counts[syncUpdate], counts[syncDelete], unchanged)
+	return nil
+}
+
+// syncFile is a regular file below the synchronised directory
+type syncFile struct {
+	// Path relative to the directory, slash-separated
+	Path     string
+	Size     int64
+	Checksum string
+}
+
+// walk returns the files below the directory that are not ignored
+func (o *SyncOptions) walk() ([]syncFile, error) {
+	ignore, err := readCdnIgnore(filepath.Join(o.Dir, cdnIgnoreFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", cdnIgnoreFile, err)
+	}
+
+	var files []syncFile
+	err = filepath.WalkDir(o.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(o.Dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		if rel == cdnIgnoreFile || ignore.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			fmt.Fprintf(o.ErrOut, "! Skipping %s: not a regular file\n", rel)
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		size, err := io.Copy(h, f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		files = append(files, syncFile{Path: rel, Size: size, Checksum: hex.EncodeToString(h.Sum(nil))})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// plan compares the local files with the Files of the namespace and returns
+// the actions to take, sorted by File name, and the number of unchanged Files
+func (o *SyncOptions) plan(local []syncFile, files []cdnv1alpha1.File) ([]syncAction, int, error) {
+	existing := map[string]*cdnv1alpha1.File{}
+	for i := range files {
+		existing[files[i].Name] = &files[i]
+	}
+
+	var actions []syncAction
+	unchanged := 0
+	paths := map[string]string{}
+	for _, file := range local {
+		name, err := syncFileName(o.Prefix, file.Path)
+		if err != nil {
+			return nil, 0, err
+		}
+		if other, ok := paths[name]; ok {
+			return nil, 0, fmt.Errorf("%s and %s both map to the File name %s", other, file.Path, name)
+		}
+		paths[name] = file.Path
+
+		action := syncAction{
+			Type:        syncCreate,
+			Name:        name,
+			Path:        file.Path,
+			Size:        file.Size,
+			Upload:      true,
+			ContentType: syncContentType(filepath.Join(o.Dir, filepath.FromSlash(file.Path))),
+		}
+		current, ok := existing[name]
+		if ok {
+			action.Type = syncUpdate
+			action.Existing = current
+			action.Upload = !current.Status.Uploaded || current.Status.Checksum != file.Checksum
+			if !action.Upload &&
+				current.Labels[cdnv1alpha1.LabelArchive] == o.Prefix &&
+				current.Annotations[cdnv1alpha1.AnnotationPath] == "/"+file.Path {
+				unchanged++
+				continue
+			}
+		}
+		actions = append(actions, action)
+	}
+
+	if o.Delete {
+		for _, file := range files {
+			if file.Labels[cdnv1alpha1.LabelArchive] != o.Prefix {
+				continue
+			}
+			if _, ok := paths[file.Name]; ok {
+				continue
+			}
+			actions = append(actions, syncAction{Type: syncDelete, Name: file.Name})
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Name < actions[j].Name })
+	return actions, unchanged, nil
+}
+
+// apply takes the actions with a bounded number of workers and returns the
+// number of failed actions
+func (o *SyncOptions) apply(ctx context.Context, client cdnclient.CdnV1alpha1Interface, content contentclient.FileInterface, actions []syncAction) int {
+	jobs := make(chan syncAction)
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+	)
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for action := range jobs {
+				if err := o.applyAction(ctx, client, content, action); err != nil {
+					mu.Lock()
+					failed++
+					fmt.Fprintf(o.ErrOut, "✗ %s: %v\n", action.Name, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, action := range actions {
+		jobs <- action
+	}
+	close(jobs)
+	wg.Wait()
+	return failed
+}
+
+// applyAction creates, updates or deletes the File of action
+func (o *SyncOptions) applyAction(ctx context.Context, client cdnclient.CdnV1alpha1Interface, content contentclient.FileInterface, action syncAction) error {
+	files := client.Files(o.Namespace)
+
+	if action.Type == syncDelete {
+		err := files.Delete(ctx, action.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	labels := map[string]string{cdnv1alpha1.LabelArchive: o.Prefix}
+	annotations := map[string]string{cdnv1alpha1.AnnotationPath: "/" + action.Path}
+	if action.Existing == nil {
+		_, err := files.Create(ctx, &cdnv1alpha1.File{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        action.Name,
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: cdnv1alpha1.FileSpec{ContentType: action.ContentType},
+		}, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	} else if action.Existing.Labels[cdnv1alpha1.LabelArchive] != o.Prefix ||
+		action.Existing.Annotations[cdnv1alpha1.AnnotationPath] != "/"+action.Path {
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": labels, "annotations": annotations},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := files.Patch(ctx, action.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return err
+		}
+	}
+
+	if !action.Upload {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(o.Dir, filepath.FromSlash(action.Path)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = content.Upload(ctx, action.Name, f, contentclient.UploadOptions{
+		ContentType: action.ContentType,
+		Backoff:     transferBackoff(o.Retries),
+		// Uploads run concurrently, so retries name their File
+		OnRetry: func(err error, delay time.Duration, retry, retries int) {
+			fmt.Fprintf(o.ErrOut, "Retrying %s in %s (%d/%d): %v\n", action.Name, delay.Round(time.Millisecond), retry, retries, err)
+		},
+	})
+	return err
+}
+
+// syncFileName maps a path to the name of its File, the same way the server
+// names the entries of an uploaded archive
+func syncFileName(prefix, p string) (string, error) {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteByte('-')
+	for _, r := range strings.ToLower(p) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+
+	name := strings.TrimRight(b.String(), "-.")
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", fmt.Errorf("cannot map %s to a File name %q: %s", p, name, strings.Join(errs, "; "))
+	}
+	return name, nil
+}
+
+// syncContentType returns the MIME type of the file at p from its extension,
+// falling back to sniffing its content
+func syncContentType(p string) string {
+	if contentType := mime.TypeByExtension(path.Ext(p)); contentType != "" {
+		return contentType
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return http.DetectContentType(head[:n])
+}
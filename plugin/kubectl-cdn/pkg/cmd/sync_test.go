@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+	contentfake "k8s.toms.place/apiserver/pkg/cdnclient/fake"
+	"k8s.toms.place/apiserver/pkg/generated/clientset/versioned/fake"
+)
+
+func TestSyncFileName(t *testing.T) {
+	testCases := []struct {
+		prefix  string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{prefix: "web", path: "index.html", want: "web-index.html"},
+		{prefix: "web", path: "css/site.css", want: "web-css-site.css"},
+		{prefix: "web", path: "Images/Logo.PNG", want: "web-images-logo.png"},
+		{prefix: "web", path: "my file_v2.txt", want: "web-my-file-v2.txt"},
+		{prefix: "web", path: "notes.", want: "web-notes"},
+		{prefix: "web", path: "dir/-", want: "web-dir"},
+		{prefix: "web", path: "ünïcode.txt", want: "web--n-code.txt"},
+		{prefix: "web", path: strings.Repeat("a", 260), wantErr: true},
+		{prefix: "Web", path: "index.html", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			name, err := syncFileName(tc.prefix, tc.path)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, name)
+		})
+	}
+}
+
+func TestSyncPlanNameCollision(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		paths   []string
+		wantErr string
+	}{
+		{desc: "distinct names", paths: []string{"a.txt", "b.txt", "dir/a.txt"}},
+		{desc: "separator and space", paths: []string{"a b.txt", "a-b.txt"}, wantErr: "a b.txt and a-b.txt both map to the File name web-a-b.txt"},
+		{desc: "directory and dash", paths: []string{"css/site.css", "css-site.css"}, wantErr: "css/site.css and css-site.css both map to the File name web-css-site.css"},
+		{desc: "case", paths: []string{"README.md", "readme.md"}, wantErr: "README.md and readme.md both map to the File name web-readme.md"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			o := &SyncOptions{Prefix: "web", Dir: t.TempDir()}
+			var local []syncFile
+			for _, p := range tc.paths {
+				local = append(local, syncFile{Path: p})
+			}
+			actions, _, err := o.plan(local, nil)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, actions, len(tc.paths))
+		})
+	}
+}
+
+func TestSyncPlanAndApply(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for p, data := range map[string]string{
+		"index.html":   "<html>new</html>",
+		"css/site.css": "body {}",
+		"about.html":   "about",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, p), []byte(data), 0o644))
+	}
+
+	clientset := fake.NewSimpleClientset()
+	content := contentfake.New(clientset)
+	files := content.Files("ns")
+	upload := func(name, data string, labels, annotations map[string]string) {
+		_, err := clientset.CdnV1alpha1().Files("ns").Create(ctx, &cdnv1alpha1.File{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, Labels: labels, Annotations: annotations},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+		_, err = files.Upload(ctx, name, strings.NewReader(data), contentclient.UploadOptions{ContentType: "text/plain"})
+		require.NoError(t, err)
+	}
+	synced := func(p string) map[string]string {
+		return map[string]string{cdnv1alpha1.AnnotationPath: p}
+	}
+	web := map[string]string{cdnv1alpha1.LabelArchive: "web"}
+	upload("web-index.html", "<html>old</html>", web, synced("/index.html"))
+	upload("web-about.html", "about", web, synced("/about.html"))
+	upload("web-old.html", "old", web, synced("/old.html"))
+	upload("other-old.html", "old", map[string]string{cdnv1alpha1.LabelArchive: "other"}, nil)
+
+	var out bytes.Buffer
+	o := &SyncOptions{
+		IOStreams:   genericiooptions.IOStreams{Out: &out, ErrOut: &out},
+		Dir:         dir,
+		Namespace:   "ns",
+		Prefix:      "web",
+		Delete:      true,
+		Concurrency: 2,
+	}
+	plan := func() ([]syncAction, int) {
+		local, err := o.walk()
+		require.NoError(t, err)
+		listed, err := listBackupFiles(ctx, clientset.CdnV1alpha1(), []string{"ns"}, 0)
+		require.NoError(t, err)
+		actions, unchanged, err := o.plan(local, listed)
+		require.NoError(t, err)
+		return actions, unchanged
+	}
+
+	actions, unchanged := plan()
+	assert.Equal(t, 1, unchanged)
+	var got []string
+	for _, action := range actions {
+		got = append(got, string(action.Type)+" "+action.Name)
+	}
+	assert.Equal(t, []string{"+ web-css-site.css", "~ web-index.html", "- web-old.html"}, got)
+
+	assert.Zero(t, o.apply(ctx, clientset.CdnV1alpha1(), files, actions), out.String())
+
+	for name, want := range map[string]string{"web-index.html": "<html>new</html>", "web-css-site.css": "body {}", "web-about.html": "about", "other-old.html": "old"} {
+		var data bytes.Buffer
+		_, err := files.Download(ctx, name, &data, contentclient.DownloadOptions{})
+		require.NoError(t, err, name)
+		assert.Equal(t, want, data.String(), name)
+	}
+	created, err := clientset.CdnV1alpha1().Files("ns").Get(ctx, "web-css-site.css", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "web", created.Labels[cdnv1alpha1.LabelArchive])
+	assert.Equal(t, "/css/site.css", created.Annotations[cdnv1alpha1.AnnotationPath])
+	_, err = files.Stat(ctx, "web-old.html")
+	assert.Error(t, err)
+
+	// Nothing is left to do once the directory is synced
+	actions, unchanged = plan()
+	assert.Empty(t, actions)
+	assert.Equal(t, 3, unchanged)
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+)
+
+const (
+	// tailWindow is how many bytes from the end are fetched to find the
+	// last lines of a File
+	tailWindow = 64 << 10
+	// tailOverlap is how many of the last bytes printed are fetched again to
+	// tell appended from replaced content
+	tailOverlap = 256
+)
+
+// TailOptions holds the options for the tail command
+type TailOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Name of the File resource
+	ResourceName string
+	// Namespace
+	Namespace string
+	// Number of lines to print
+	Lines int
+	// Follow changes of the content
+	Follow bool
+}
+
+// NewTailOptions creates new TailOptions with default values
+func NewTailOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *TailOptions {
+	return &TailOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		Lines:       10,
+	}
+}
+
+// NewCmdTail creates the tail command
+func NewCmdTail(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewTailOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "tail [resource-name]",
+		Short: "Print the last lines of a text File",
+		Long: `Print the last lines of the content of a text File resource.
+
+With -f, the File is watched and its content followed: when it grows and the
+bytes printed last are unchanged, only the appended bytes are fetched with a
+Range request and printed. When it is replaced, a note is printed to stderr
+followed by the last lines of the new content. Deleted Files are waited for
+until they are created again. Stop following with Ctrl-C.
+
+Only Files with a text content type, such as text/*, JSON, XML or YAML, can
+be tailed.
+
+Examples:
+  # Print the last 10 lines of a log
+  kubectl cdn tail build-log
+
+  # Follow a log as it is uploaded
+  kubectl cdn tail -f build-log --lines 50
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.ResourceName = args[0]
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().IntVar(&o.Lines, "lines", o.Lines, "Number of lines to print")
+	cmd.Flags().BoolVarP(&o.Follow, "follow", "f", false, "Follow changes of the content")
+
+	return cmd
+}
+
+// contentTail is the part of a File's content that was printed last
+type contentTail struct {
+	// offset is the size of the content printed up to
+	offset int64
+	// checksum of the content printed up to offset
+	checksum string
+	// last are the last bytes printed
+	last []byte
+}
+
+// Run executes the tail command
+func (o *TailOptions) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	content, err := newContentClient(o.ConfigFlags, o.Namespace)
+	if err != nil {
+		return err
+	}
+	files := client.Files(o.Namespace)
+
+	file, err := files.Get(ctx, o.ResourceName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	tail := &contentTail{}
+	if err := o.update(ctx, content, file, tail); err != nil {
+		return err
+	}
+	if !o.Follow {
+		return nil
+	}
+
+	options := metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", o.ResourceName).String()}
+	resourceVersion := file.ResourceVersion
+	for {
+		err := watchFiles(ctx, files, options, resourceVersion, func(eventType watch.EventType, file *cdnv1alpha1.File) error {
+			if eventType == watch.Deleted {
+				fmt.Fprintf(o.ErrOut, "==> %s/%s deleted <==\n", o.Namespace, o.ResourceName)
+				*tail = contentTail{}
+				return nil
+			}
+			return o.update(ctx, content, file, tail)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !apierrors.IsResourceExpired(err) && !apierrors.IsGone(err) {
+			return err
+		}
+
+		// Missed events are gone; continue from the current state
+		file, err := files.Get(ctx, o.ResourceName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			list, err := files.List(ctx, options)
+			if err != nil {
+				return err
+			}
+			*tail = contentTail{}
+			resourceVersion = list.ResourceVersion
+			continue
+		case err != nil:
+			return err
+		}
+		if err := o.update(ctx, content, file, tail); err != nil {
+			return err
+		}
+		resourceVersion = file.ResourceVersion
+	}
+}
+
+// update prints the content of file that is new since tail
+func (o *TailOptions) update(ctx context.Context, content contentclient.FileInterface, file *cdnv1alpha1.File, tail *contentTail) error {
+	if file.Status.Checksum == tail.checksum || !file.Status.Uploaded {
+		return nil
+	}
+	if !isTextContentType(file.Spec.ContentType) {
+		return fmt.Errorf("%s/%s is not a text File (content type %s)", file.Namespace, file.Name, file.Spec.ContentType)
+	}
+
+	if tail.offset > 0 && file.Spec.Size > tail.offset {
+		// Fetch the appended bytes, and the last printed ones to check that
+		// the content was appended to rather than replaced
+		start := tail.offset - int64(len(tail.last))
+		part, err := content.ReadRange(ctx, o.ResourceName, start, 0)
+		if err != nil {
+			return err
+		}
+		if part.Offset == start && bytes.HasPrefix(part.Data, tail.last) {
+			appended := part.Data[len(tail.last):]
+			if _, err := o.Out.Write(appended); err != nil {
+				return err
+			}
+			tail.advance(appended, start+int64(len(part.Data)), part.Size, file.Status.Checksum)
+			return nil
+		}
+	}
+	if tail.offset > 0 {
+		fmt.Fprintf(o.ErrOut, "==> %s/%s replaced <==\n", file.Namespace, file.Name)
+	}
+
+	part, err := content.ReadRange(ctx, o.ResourceName, -tailWindow, 0)
+	if err != nil {
+		return err
+	}
+	lines := lastLines(part.Data, o.Lines, part.Offset > 0)
+	if _, err := o.Out.Write(lines); err != nil {
+		return err
+	}
+	*tail = contentTail{}
+	tail.advance(part.Data, part.Offset+int64(len(part.Data)), part.Size, file.Status.Checksum)
+	return nil
+}
+
+// advance records that the content up to offset, ending in printed, was
+// printed. The checksum is only known to match if offset is the total size.
+func (t *contentTail) advance(printed []byte, offset, total int64, checksum string) {
+	t.last = append(t.last, printed...)
+	if len(t.last) > tailOverlap {
+		t.last = t.last[len(t.last)-tailOverlap:]
+	}
+	t.offset = offset
+	t.checksum = ""
+	if offset == total {
+		t.checksum = checksum
+	}
+}
+
+// lastLines returns the last n lines of data. If partial is set, data starts
+// within a line, which is never returned.
+func lastLines(data []byte, n int, partial bool) []byte {
+	if n <= 0 {
+		return nil
+	}
+	if partial {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		} else {
+			data = nil
+		}
+	}
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	start := end
+	for ; n > 0 && start >= 0; n-- {
+		start = bytes.LastIndexByte(data[:start], '\n')
+	}
+	if start < 0 {
+		return data
+	}
+	return data[start+1:]
+}
+
+// isTextContentType returns whether content of the type can be printed
+func isTextContentType(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || params["charset"] != "" ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/xml", "application/yaml",
+		"application/x-yaml", "application/javascript", "application/toml":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+)
+
+// transferBackoff returns the backoff between attempts of a transfer that
+// failed with a transient error, allowing retries of them
+func transferBackoff(retries int) *wait.Backoff {
+	backoff := contentclient.DefaultBackoff
+	backoff.Steps = retries
+	return &backoff
+}
+
+// logRetry returns a contentclient.RetryFunc logging retries to out, below
+// the progress bar of the transfer
+func logRetry(out io.Writer, bar *progressBar) contentclient.RetryFunc {
+	return func(err error, delay time.Duration, retry, retries int) {
+		bar.Done()
+		fmt.Fprintf(out, "Retrying in %s (%d/%d): %v\n", delay.Round(time.Millisecond), retry, retries, err)
+	}
+}
+
+// progressBar renders the progress of a transfer on a terminal
+type progressBar struct {
+	out   io.Writer
+	label string
+	// total is the size of the transfer, or -1 if it is unknown
+	total   int64
+	current int64
+	drawn   time.Time
+
+	lock sync.Mutex
+}
+
+// newProgressBar returns a progress bar for a transfer labelled label, or
+// nil if out is not a terminal
+func newProgressBar(out io.Writer, label string) *progressBar {
+	if !isTerminal(out) {
+		return nil
+	}
+	return &progressBar{out: out, label: label, total: -1}
+}
+
+// isTerminal returns whether out is a terminal
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// Progress records that current of total bytes were transferred; it is a
+// contentclient.ProgressFunc. A nil progressBar ignores all calls.
+func (p *progressBar) Progress(current, total int64) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.current, p.total = current, total
+	if time.Since(p.drawn) >= 100*time.Millisecond {
+		p.draw()
+	}
+}
+
+// Done draws the final state of the transfer and ends the line
+func (p *progressBar) Done() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.draw()
+	fmt.Fprintln(p.out)
+}
+
+func (p *progressBar) draw() {
+	const width = 30
+	p.drawn = time.Now()
+	if p.total <= 0 {
+		fmt.Fprintf(p.out, "\r%s %s", p.label, formatBytes(p.current))
+		return
+	}
+	filled := int(min(p.current, p.total) * width / p.total)
+	fmt.Fprintf(p.out, "\r%s [%s%s] %3d%% %s/%s", p.label,
+		strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		min(p.current, p.total)*100/p.total, formatBytes(p.current), formatBytes(p.total))
+}
+
+// formatBytes formats n bytes with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
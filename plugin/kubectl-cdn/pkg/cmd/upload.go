@@ -17,32 +17,27 @@ limitations under the License.
 package cmd
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
-)
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
 
-// IOStreams provides the standard streams for commands
-type IOStreams struct {
-	In     io.Reader
-	Out    io.Writer
-	ErrOut io.Writer
-}
+	contentclient "k8s.toms.place/apiserver/pkg/cdnclient"
+)
 
 // UploadOptions holds the options for the upload command
 type UploadOptions struct {
-	IOStreams
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
 
 	// File path to upload
 	FilePath string
@@ -52,26 +47,25 @@ type UploadOptions struct {
 	Namespace string
 	// Content type override
 	ContentType string
-	// Kubeconfig path
-	KubeConfig string
-	// Context to use
-	Context string
 	// Create the resource if it doesn't exist
 	Create bool
+	// Retries of uploads failing with a transient error
+	Retries int
 }
 
 // NewUploadOptions creates new UploadOptions with default values
-func NewUploadOptions(streams IOStreams) *UploadOptions {
+func NewUploadOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *UploadOptions {
 	return &UploadOptions{
-		IOStreams: streams,
-		Namespace: "default",
-		Create:    true,
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		Create:      true,
+		Retries:     5,
 	}
 }
 
 // NewCmdUpload creates the upload command
-func NewCmdUpload(streams IOStreams) *cobra.Command {
-	o := NewUploadOptions(streams)
+func NewCmdUpload(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewUploadOptions(configFlags, streams)
 
 	cmd := &cobra.Command{
 		Use:   "upload [file-path] [resource-name]",
@@ -84,7 +78,14 @@ CDN API server. If the File resource doesn't exist, it will be created.
 The resource name is optional - if not provided, it will be derived from the
 filename (e.g., "index.html" becomes "index.html" as the resource name).
 
-The content type is automatically detected from the file extension.
+The content type is automatically detected from the file extension. With a
+file path of "-", the content is read from stdin, its type is detected from
+the content, and the resource name is required.
+
+The file is streamed to the server with its SHA-256 digest, so corruption in
+transit is rejected, and uploaded again after transient errors. Content read
+from stdin is streamed once and verified against the checksum stored by the
+server. A progress bar is shown when stderr is a terminal.
 
 Examples:
   # Upload a file (resource name derived from filename)
@@ -98,129 +99,86 @@ Examples:
 
   # Upload to a specific namespace
   kubectl cdn upload style.css -n my-namespace
+
+  # Upload the output of a command
+  tar -cz dist | kubectl cdn upload - dist.tgz
 `,
 		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			o.FilePath = args[0]
 			if len(args) > 1 {
 				o.ResourceName = args[1]
+			} else if o.FilePath == "-" {
+				return fmt.Errorf("a resource name is required to upload from stdin")
 			} else {
 				// Derive resource name from filename
 				o.ResourceName = filepath.Base(o.FilePath)
 			}
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
 			return o.Run()
 		},
 	}
 
-	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "Namespace of the File resource")
 	cmd.Flags().StringVar(&o.ContentType, "content-type", "", "Content-Type for the file (auto-detected if not specified)")
-	cmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "Path to kubeconfig file")
-	cmd.Flags().StringVar(&o.Context, "context", "", "Kubernetes context to use")
 	cmd.Flags().BoolVar(&o.Create, "create", true, "Create the File resource if it doesn't exist")
+	cmd.Flags().IntVar(&o.Retries, "retries", o.Retries, "Number of retries after transient errors")
 
 	return cmd
 }
 
 // Run executes the upload command
 func (o *UploadOptions) Run() error {
-	// Read the file
-	fileData, err := os.ReadFile(o.FilePath)
+	ctx := context.Background()
+	files, err := newContentClient(o.ConfigFlags, o.Namespace)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", o.FilePath, err)
+		return err
 	}
 
-	// Determine content type
-	contentType := o.ContentType
-	if contentType == "" {
-		// Try to detect from file extension
-		ext := filepath.Ext(o.FilePath)
-		contentType = mime.TypeByExtension(ext)
+	in, source, contentType := o.In, "stdin", o.ContentType
+	if o.FilePath != "-" {
+		f, err := os.Open(o.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", o.FilePath, err)
+		}
+		defer f.Close()
+		in, source = f, o.FilePath
+
+		// Determine content type from the file extension; content read from
+		// stdin is detected instead
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(o.FilePath))
+		}
 		if contentType == "" {
 			contentType = "application/octet-stream"
 		}
 	}
 
-	// Build kubernetes client config
-	config, err := o.buildConfig()
-	if err != nil {
-		return fmt.Errorf("failed to build kubernetes config: %w", err)
-	}
-
-	// Create REST client for the CDN API
-	cdnConfig := *config
-	cdnConfig.APIPath = "/apis"
-	cdnConfig.GroupVersion = &cdnGroupVersion
-	cdnConfig.NegotiatedSerializer = cdnCodec
-
-	client, err := rest.RESTClientFor(&cdnConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create REST client: %w", err)
-	}
-
-	// Upload the content using PUT to the content subresource
-	url := fmt.Sprintf("/apis/cdn.k8s.toms.place/v1alpha1/namespaces/%s/files/%s/content",
-		o.Namespace, o.ResourceName)
-
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, "", bytes.NewReader(fileData))
+	bar := newProgressBar(o.ErrOut, o.ResourceName)
+	info, err := files.Upload(ctx, o.ResourceName, in, contentclient.UploadOptions{
+		ContentType: contentType,
+		Backoff:     transferBackoff(o.Retries),
+		Progress:    bar.Progress,
+		OnRetry:     logRetry(o.ErrOut, bar),
+	})
+	bar.Done()
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Content-Type", contentType)
-
-	result := client.Put().
-		AbsPath(url).
-		SetHeader("Content-Type", contentType).
-		Body(fileData).
-		Do(context.Background())
-
-	if err := result.Error(); err != nil {
 		return fmt.Errorf("failed to upload content: %w", err)
 	}
 
-	// Parse response
-	rawBody, err := result.Raw()
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var response struct {
-		Status metav1.Status `json:"status"`
-	}
-	if err := json.Unmarshal(rawBody, &response); err != nil {
-		// Try to print as string if not JSON
-		fmt.Fprintf(o.Out, "Upload completed: %s\n", string(rawBody))
-		return nil
-	}
-
-	if response.Status.Status == metav1.StatusSuccess {
-		fmt.Fprintf(o.Out, "✓ Successfully uploaded %s to %s/%s (%d bytes, %s)\n",
-			o.FilePath, o.Namespace, o.ResourceName, len(fileData), contentType)
-	} else {
-		fmt.Fprintf(o.Out, "Upload response: %s\n", response.Status.Message)
-	}
-
+	fmt.Fprintf(o.Out, "✓ Successfully uploaded %s to %s/%s (%d bytes, %s)\n",
+		source, o.Namespace, o.ResourceName, info.Size, info.ContentType)
 	return nil
 }
 
-// buildConfig creates the kubernetes client config
-func (o *UploadOptions) buildConfig() (*rest.Config, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if o.KubeConfig != "" {
-		loadingRules.ExplicitPath = o.KubeConfig
-	}
-
-	configOverrides := &clientcmd.ConfigOverrides{}
-	if o.Context != "" {
-		configOverrides.CurrentContext = o.Context
-	}
-
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	return kubeConfig.ClientConfig()
-}
-
 // GetOptions holds the options for the get command
 type GetOptions struct {
-	IOStreams
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
 
 	// Name of the File resource
 	ResourceName string
@@ -228,23 +186,28 @@ type GetOptions struct {
 	Namespace string
 	// Output file path (optional)
 	OutputPath string
-	// Kubeconfig path
-	KubeConfig string
-	// Context to use
-	Context string
+	// Label selector; downloads the matching Files as one archive
+	Selector string
+	// Field selector; downloads the matching Files as one archive
+	FieldSelector string
+	// All downloads every File of the namespace as one archive
+	All bool
+	// Retries of downloads failing with a transient error
+	Retries int
 }
 
 // NewGetOptions creates new GetOptions with default values
-func NewGetOptions(streams IOStreams) *GetOptions {
+func NewGetOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *GetOptions {
 	return &GetOptions{
-		IOStreams: streams,
-		Namespace: "default",
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+		Retries:     5,
 	}
 }
 
 // NewCmdGet creates the get command
-func NewCmdGet(streams IOStreams) *cobra.Command {
-	o := NewGetOptions(streams)
+func NewCmdGet(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewGetOptions(configFlags, streams)
 
 	cmd := &cobra.Command{
 		Use:   "get [resource-name]",
@@ -253,6 +216,18 @@ func NewCmdGet(streams IOStreams) *cobra.Command {
 
 This command retrieves the content of a File resource from the CDN API server.
 
+The content is streamed to stdout, or to the output file, and verified against
+its checksum. Downloads interrupted by transient errors are resumed where they
+stopped after a backoff. A download to a file is written to <file>.partial
+first, which a later run resumes. A progress bar is shown when stderr is a
+terminal.
+
+With a label or field selector, or --all, the matching Files of the namespace
+are downloaded as one archive including a manifest with each File's spec,
+status and checksum instead. The archive format is taken from the output file
+extension (.tgz, .tar.gz or .zip) and defaults to tar.gz. Archives are not
+written to a terminal.
+
 Examples:
   # Get file content and print to stdout
   kubectl cdn get my-index
@@ -260,85 +235,174 @@ Examples:
   # Save file content to a local file
   kubectl cdn get my-index -o index.html
 
+  # Pipe file content to another command
+  kubectl cdn get dist.tgz -o - | tar -xz
+
   # Get from a specific namespace
   kubectl cdn get my-styles -n my-namespace
+
+  # Download all Files of an uploaded archive as a tar.gz
+  kubectl cdn get --selector cdn.k8s.toms.place/archive=web -o archive.tgz
+
+  # Download all Files of a namespace as a zip
+  kubectl cdn get -n my-namespace --all -o files.zip
 `,
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			o.ResourceName = args[0]
+			if len(args) > 0 {
+				o.ResourceName = args[0]
+			}
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if o.isArchive() {
+				return o.RunArchive()
+			}
 			return o.Run()
 		},
 	}
 
-	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "Namespace of the File resource")
-	cmd.Flags().StringVarP(&o.OutputPath, "output", "o", "", "Output file path (default: stdout)")
-	cmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "Path to kubeconfig file")
-	cmd.Flags().StringVar(&o.Context, "context", "", "Kubernetes context to use")
+	cmd.Flags().StringVarP(&o.OutputPath, "output", "o", "", "Output file path, or - for stdout (default: stdout)")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Label selector of the Files to download as an archive")
+	cmd.Flags().StringVar(&o.FieldSelector, "field-selector", "", "Field selector of the Files to download as an archive")
+	cmd.Flags().BoolVar(&o.All, "all", false, "Download all Files of the namespace as an archive")
+	cmd.Flags().IntVar(&o.Retries, "retries", o.Retries, "Number of retries after transient errors")
 
 	return cmd
 }
 
+// Validate checks that either a resource name or an archive selection is given
+func (o *GetOptions) Validate() error {
+	switch {
+	case o.isArchive() && o.ResourceName != "":
+		return fmt.Errorf("a resource name cannot be combined with --selector, --field-selector or --all")
+	case !o.isArchive() && o.ResourceName == "":
+		return fmt.Errorf("a resource name is required, or --selector, --field-selector or --all to download an archive")
+	}
+	return nil
+}
+
 // Run executes the get command
 func (o *GetOptions) Run() error {
-	// Build kubernetes client config
-	config, err := o.buildConfig()
+	ctx := context.Background()
+	files, err := newContentClient(o.ConfigFlags, o.Namespace)
 	if err != nil {
-		return fmt.Errorf("failed to build kubernetes config: %w", err)
+		return err
 	}
 
-	// Create REST client for the CDN API
-	cdnConfig := *config
-	cdnConfig.APIPath = "/apis"
-	cdnConfig.GroupVersion = &cdnGroupVersion
-	cdnConfig.NegotiatedSerializer = cdnCodec
+	if o.OutputPath == "" || o.OutputPath == "-" {
+		_, err := o.download(ctx, files, o.Out, false)
+		return err
+	}
 
-	client, err := rest.RESTClientFor(&cdnConfig)
+	// Resume the partial file of an earlier download
+	partial := o.OutputPath + ".partial"
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to create REST client: %w", err)
+		return fmt.Errorf("failed to create file %s: %w", partial, err)
+	}
+	defer f.Close()
+	info, err := o.download(ctx, files, f, true)
+	if err != nil {
+		// The partial file is kept for the next run to resume, unless it
+		// holds nothing or content that cannot be trusted
+		if stat, statErr := f.Stat(); errors.Is(err, contentclient.ErrChecksumMismatch) || statErr == nil && stat.Size() == 0 {
+			os.Remove(partial)
+		}
+		return err
 	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", partial, err)
+	}
+	if err := os.Rename(partial, o.OutputPath); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", o.OutputPath, err)
+	}
+	fmt.Fprintf(o.ErrOut, "✓ Saved %d bytes to %s\n", info.Size, o.OutputPath)
 
-	// Get the content using GET from the content subresource
-	url := fmt.Sprintf("/apis/cdn.k8s.toms.place/v1alpha1/namespaces/%s/files/%s/content",
-		o.Namespace, o.ResourceName)
+	return nil
+}
 
-	result := client.Get().
-		AbsPath(url).
-		Do(context.Background())
+// download writes the content of the File to w, resuming the content already
+// in w if resume is set, and retries transient errors
+func (o *GetOptions) download(ctx context.Context, files contentclient.FileInterface, w io.Writer, resume bool) (*contentclient.ContentInfo, error) {
+	bar := newProgressBar(o.ErrOut, o.ResourceName)
+	info, err := files.Download(ctx, o.ResourceName, w, contentclient.DownloadOptions{
+		Backoff:  transferBackoff(o.Retries),
+		Resume:   resume,
+		Progress: bar.Progress,
+		OnRetry:  logRetry(o.ErrOut, bar),
+	})
+	bar.Done()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get content: %w", err)
+	}
+	return info, nil
+}
 
-	if err := result.Error(); err != nil {
-		return fmt.Errorf("failed to get content: %w", err)
+// isArchive returns whether the command downloads an archive of many Files
+func (o *GetOptions) isArchive() bool {
+	return o.All || o.Selector != "" || o.FieldSelector != ""
+}
+
+// archiveFormat returns the archive format for the output path
+func (o *GetOptions) archiveFormat() string {
+	if strings.HasSuffix(strings.ToLower(o.OutputPath), ".zip") {
+		return "zip"
 	}
+	return "tar.gz"
+}
 
-	rawBody, err := result.Raw()
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+// RunArchive downloads the selected Files as one archive
+func (o *GetOptions) RunArchive() error {
+	toStdout := o.OutputPath == "" || o.OutputPath == "-"
+	if toStdout && isTerminal(o.Out) {
+		return fmt.Errorf("refusing to write an archive to a terminal; use -o <file>, or redirect stdout")
 	}
 
-	// Output to file or stdout
-	if o.OutputPath != "" {
-		if err := os.WriteFile(o.OutputPath, rawBody, 0644); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", o.OutputPath, err)
-		}
-		fmt.Fprintf(o.ErrOut, "✓ Saved %d bytes to %s\n", len(rawBody), o.OutputPath)
-	} else {
-		io.Copy(o.Out, bytes.NewReader(rawBody))
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
 	}
 
-	return nil
-}
+	// The archive is named after the namespace
+	stream, err := client.RESTClient().Get().
+		Namespace(o.Namespace).
+		Resource("files").
+		Name(o.Namespace).
+		SubResource("archive").
+		Param("labelSelector", o.Selector).
+		Param("fieldSelector", o.FieldSelector).
+		Param("format", o.archiveFormat()).
+		Stream(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to download archive: %w", err)
+	}
+	defer stream.Close()
 
-// buildConfig creates the kubernetes client config
-func (o *GetOptions) buildConfig() (*rest.Config, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if o.KubeConfig != "" {
-		loadingRules.ExplicitPath = o.KubeConfig
+	if toStdout {
+		if _, err := io.Copy(o.Out, stream); err != nil {
+			return fmt.Errorf("failed to download archive: %w", err)
+		}
+		return nil
 	}
 
-	configOverrides := &clientcmd.ConfigOverrides{}
-	if o.Context != "" {
-		configOverrides.CurrentContext = o.Context
+	out, err := os.Create(o.OutputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", o.OutputPath, err)
 	}
+	n, err := io.Copy(out, stream)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file %s: %w", o.OutputPath, err)
+	}
+	fmt.Fprintf(o.ErrOut, "✓ Saved %d bytes to %s\n", n, o.OutputPath)
 
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-	return kubeConfig.ClientConfig()
+	return nil
 }
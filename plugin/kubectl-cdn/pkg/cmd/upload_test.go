@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		options     GetOptions
+		wantArchive bool
+		wantErr     bool
+	}{
+		{desc: "name", options: GetOptions{ResourceName: "index.html"}},
+		{desc: "nothing", wantErr: true},
+		{desc: "selector", options: GetOptions{Selector: "app=web"}, wantArchive: true},
+		{desc: "field selector", options: GetOptions{FieldSelector: "status.uploaded=true"}, wantArchive: true},
+		{desc: "all", options: GetOptions{All: true}, wantArchive: true},
+		{desc: "name and selector", options: GetOptions{ResourceName: "index.html", Selector: "app=web"}, wantArchive: true, wantErr: true},
+		{desc: "name and all", options: GetOptions{ResourceName: "index.html", All: true}, wantArchive: true, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.options.Validate()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantArchive, tc.options.isArchive())
+		})
+	}
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	cdnv1alpha1 "k8s.toms.place/apiserver/pkg/apis/cdn/v1alpha1"
+	cdnclient "k8s.toms.place/apiserver/pkg/generated/clientset/versioned/typed/cdn/v1alpha1"
+)
+
+// WatchOptions holds the options for the watch command
+type WatchOptions struct {
+	genericiooptions.IOStreams
+
+	ConfigFlags *genericclioptions.ConfigFlags
+
+	// Namespace
+	Namespace string
+	// All namespaces
+	AllNamespaces bool
+	// Label selector
+	Selector string
+	// Field selector
+	FieldSelector string
+	// Only print changes, not the existing Files
+	WatchOnly bool
+	// Output format; json or empty for one line per event
+	Output string
+}
+
+// NewWatchOptions creates new WatchOptions with default values
+func NewWatchOptions(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *WatchOptions {
+	return &WatchOptions{
+		IOStreams:   streams,
+		ConfigFlags: configFlags,
+	}
+}
+
+// NewCmdWatch creates the watch command
+func NewCmdWatch(configFlags *genericclioptions.ConfigFlags, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewWatchOptions(configFlags, streams)
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream changes of Files",
+		Long: `Stream ADDED, MODIFIED and DELETED events of File resources.
+
+The existing Files are printed as ADDED first, unless --watch-only is given.
+Modifications show what changed: the size, content type, checksum, uploader
+and upload state. The watch is resumed after disconnects; if the server no
+longer has the events it missed, the Files are listed again and the
+differences reported as events. Stop it with Ctrl-C.
+
+With -o json, every event is printed as a JSON object with its type and the
+File.
+
+Examples:
+  # Watch the Files of the current namespace
+  kubectl cdn watch
+
+  # Watch the Files of an uploaded archive in all namespaces
+  kubectl cdn watch -A -l cdn.k8s.toms.place/archive=web
+
+  # Only print changes, as JSON
+  kubectl cdn watch --watch-only -o json
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.Output != "" && o.Output != "json" {
+				return fmt.Errorf("unsupported output format %q: only json is supported", o.Output)
+			}
+			namespace, err := namespaceOf(o.ConfigFlags)
+			if err != nil {
+				return err
+			}
+			o.Namespace = namespace
+			cmd.SilenceUsage = true
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "A", false, "Watch Files in all namespaces")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Label selector to filter on")
+	cmd.Flags().StringVar(&o.FieldSelector, "field-selector", "", "Field selector to filter on")
+	cmd.Flags().BoolVar(&o.WatchOnly, "watch-only", false, "Only print changes, not the existing Files")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "Output format: json (default: one line per event)")
+
+	return cmd
+}
+
+// Run executes the watch command until it is interrupted
+func (o *WatchOptions) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := newCdnClient(o.ConfigFlags)
+	if err != nil {
+		return err
+	}
+	namespace := o.Namespace
+	if o.AllNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+	files := client.Files(namespace)
+	listOptions := metav1.ListOptions{LabelSelector: o.Selector, FieldSelector: o.FieldSelector}
+
+	state := map[string]*cdnv1alpha1.File{}
+	report := !o.WatchOnly
+	for {
+		list, err := files.List(ctx, listOptions)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to list Files: %w", err)
+		}
+		if err := o.resync(state, list.Items, report); err != nil {
+			return err
+		}
+		report = true
+
+		err = watchFiles(ctx, files, listOptions, list.ResourceVersion, func(eventType watch.EventType, file *cdnv1alpha1.File) error {
+			key := file.Namespace + "/" + file.Name
+			old := state[key]
+			if eventType == watch.Deleted {
+				delete(state, key)
+			} else {
+				state[key] = file
+			}
+			return o.printEvent(eventType, old, file)
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !apierrors.IsResourceExpired(err) && !apierrors.IsGone(err) {
+			return err
+		}
+	}
+}
+
+// resync replaces state with files, printing the differences as events if
+// report is set
+func (o *WatchOptions) resync(state map[string]*cdnv1alpha1.File, files []cdnv1alpha1.File, report bool) error {
+	seen := map[string]bool{}
+	for i := range files {
+		file := &files[i]
+		key := file.Namespace + "/" + file.Name
+		seen[key] = true
+		old, ok := state[key]
+		state[key] = file
+		if !report || (ok && old.ResourceVersion == file.ResourceVersion) {
+			continue
+		}
+		eventType := watch.Added
+		if ok {
+			eventType = watch.Modified
+		}
+		if err := o.printEvent(eventType, old, file); err != nil {
+			return err
+		}
+	}
+	for key, old := range state {
+		if seen[key] {
+			continue
+		}
+		delete(state, key)
+		if err := o.printEvent(watch.Deleted, old, old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printEvent prints an event of file, whose previous state was old
+func (o *WatchOptions) printEvent(eventType watch.EventType, old, file *cdnv1alpha1.File) error {
+	if o.Output == "json" {
+		file.Kind = "File"
+		file.APIVersion = cdnv1alpha1.SchemeGroupVersion.String()
+		return json.NewEncoder(o.Out).Encode(struct {
+			Type   watch.EventType   `json:"type"`
+			Object *cdnv1alpha1.File `json:"object"`
+		}{eventType, file})
+	}
+	line := fmt.Sprintf("%s  %-8s  %s/%s", time.Now().Format("15:04:05"), eventType, file.Namespace, file.Name)
+	if change := describeFileChange(eventType, old, file); change != "" {
+		line += "  " + change
+	}
+	_, err := fmt.Fprintln(o.Out, line)
+	return err
+}
+
+// describeFileChange describes the content of a File for ADDED events, and
+// how it changed from old for MODIFIED events
+func describeFileChange(eventType watch.EventType, old, file *cdnv1alpha1.File) string {
+	switch eventType {
+	case watch.Deleted:
+		return ""
+	case watch.Added:
+		parts := []string{formatBytes(file.Spec.Size), file.Spec.ContentType}
+		if file.Status.Checksum != "" {
+			parts = append(parts, "checksum "+shortChecksum(file.Status.Checksum))
+		}
+		if file.Status.LastUpload != nil {
+			parts = append(parts, "by "+file.Status.LastUpload.User)
+		}
+		if !file.Status.Uploaded {
+			parts = append(parts, "not uploaded")
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	if old == nil {
+		return describeFileChange(watch.Added, nil, file)
+	}
+	var changes []string
+	if old.Spec.Size != file.Spec.Size {
+		changes = append(changes, fmt.Sprintf("size %s → %s", formatBytes(old.Spec.Size), formatBytes(file.Spec.Size)))
+	}
+	if old.Spec.ContentType != file.Spec.ContentType {
+		changes = append(changes, fmt.Sprintf("content type %s → %s", old.Spec.ContentType, file.Spec.ContentType))
+	}
+	if old.Status.Checksum != file.Status.Checksum {
+		changes = append(changes, fmt.Sprintf("checksum %s → %s", shortChecksum(old.Status.Checksum), shortChecksum(file.Status.Checksum)))
+	}
+	if upload := file.Status.LastUpload; upload != nil && (old.Status.LastUpload == nil || !upload.Time.Equal(&old.Status.LastUpload.Time)) {
+		changes = append(changes, "uploaded by "+upload.User)
+	}
+	if old.Status.Uploaded != file.Status.Uploaded {
+		changes = append(changes, fmt.Sprintf("uploaded %t → %t", old.Status.Uploaded, file.Status.Uploaded))
+	}
+	if file.Status.Error != "" && old.Status.Error != file.Status.Error {
+		changes = append(changes, "error: "+file.Status.Error)
+	}
+	if len(changes) == 0 {
+		return "metadata changed"
+	}
+	return strings.Join(changes, ", ")
+}
+
+// shortChecksum abbreviates a checksum for display, or returns "-" for none
+func shortChecksum(checksum string) string {
+	if checksum == "" {
+		return "-"
+	}
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
+
+// watchFiles calls handle for every event of the Files matching options from
+// resourceVersion on. It reconnects when the server closes the watch and
+// returns the error of the watch once the events since the last one it
+// received are gone, or nil once ctx is done.
+func watchFiles(ctx context.Context, files cdnclient.FileInterface, options metav1.ListOptions, resourceVersion string, handle func(watch.EventType, *cdnv1alpha1.File) error) error {
+	w, err := watchtools.NewRetryWatcherWithContext(ctx, resourceVersion, &cache.ListWatch{
+		WatchFuncWithContext: func(ctx context.Context, watchOptions metav1.ListOptions) (watch.Interface, error) {
+			watchOptions.LabelSelector = options.LabelSelector
+			watchOptions.FieldSelector = options.FieldSelector
+			return files.Watch(ctx, watchOptions)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch Files: %w", err)
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				file, ok := event.Object.(*cdnv1alpha1.File)
+				if !ok {
+					continue
+				}
+				if err := handle(event.Type, file); err != nil {
+					return err
+				}
+			case watch.Error:
+				return watchError(event.Object)
+			}
+		}
+	}
+}
+
+// watchError returns the error reported by a watch error event
+func watchError(obj runtime.Object) error {
+	if status, ok := obj.(*metav1.Status); ok {
+		return apierrors.FromObject(status)
+	}
+	return fmt.Errorf("unexpected watch error: %v", obj)
+}